@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofrs/flock"
 	"github.com/tinygo-org/tinygo/compileopts"
@@ -65,6 +66,10 @@ type BuildResult struct {
 	// Map from path to package name. It is needed to attribute binary size to
 	// the right Go package.
 	PackagePathMap map[string]string
+
+	// ProgramCached is true when the linked and optimized program was loaded
+	// from the build cache, instead of being optimized again.
+	ProgramCached bool
 }
 
 // packageAction is the struct that is serialized to JSON and hashed, to work as
@@ -109,6 +114,17 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		fmt.Printf("WORK=%s\n", tmpdir)
 	}
 
+	// Load the size budget now, so that an invalid budget file is reported
+	// before doing all the work of building the program.
+	sizeBudget, err := config.SizeBudget()
+	if err != nil {
+		return BuildResult{}, err
+	}
+
+	if config.StackCheck() && config.Scheduler() != "tasks" {
+		return BuildResult{}, fmt.Errorf("-stack-check requires -scheduler=tasks, got -scheduler=%s", config.Scheduler())
+	}
+
 	// Look up the build cache directory, which is used to speed up incremental
 	// builds.
 	cacheDir := goenv.Get("GOCACHE")
@@ -216,6 +232,9 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		NeedsStackObjects:  config.NeedsStackObjects(),
 		Debug:              !config.Options.SkipDWARF, // emit DWARF except when -internal-nodwarf is passed
 		PanicStrategy:      config.PanicStrategy(),
+		FramePointers:      config.Profiling(),
+		StackCheck:         config.StackCheck(),
+		WasmCanonicalABI:   config.WasmCanonicalABI(),
 	}
 
 	// Load the target machine, which is the LLVM object that contains all
@@ -528,13 +547,65 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		}
 	}()
 	var stackSizeLoads []string
+	var programCachePath string // cached optimized program, if it can be cached
 	programJob := &compileJob{
 		description:  "link+optimize packages (LTO)",
 		dependencies: packageJobs,
 		run: func(*compileJob) error {
+			ctx := llvm.NewContext()
+
+			// Check whether this program was already linked and optimized
+			// in a previous build. If none of the packages (or the build
+			// configuration) changed, the result is the same.
+			if useProgramCache(config) {
+				action := programAction{
+					CompilerBuildID: string(compilerBuildID),
+					TinyGoVersion:   goenv.Version(),
+					LLVMVersion:     llvm.Version,
+					Config:          compilerConfig,
+					Target:          config.Target,
+					GC:              config.GC(),
+					OptLevel:        optLevel,
+					InterpTimeout:   config.Options.InterpTimeout,
+					GlobalValues:    globalValues,
+				}
+				for _, pkg := range lprogram.Sorted() {
+					action.Packages = append(action.Packages, packageActionIDJobs[pkg.ImportPath].result)
+				}
+				hash, err := action.hash()
+				if err != nil {
+					return err
+				}
+				programCachePath = filepath.Join(cacheDir, "program-"+hash+".bc")
+				unlock := lock(programCachePath + ".lock")
+				defer unlock()
+				if _, err := os.Stat(programCachePath); err == nil {
+					data, err := os.ReadFile(strings.TrimSuffix(programCachePath, ".bc") + ".json")
+					if err != nil {
+						return err
+					}
+					var info programCacheInfo
+					err = json.Unmarshal(data, &info)
+					if err != nil {
+						return fmt.Errorf("could not read cached program info: %w", err)
+					}
+					mod, err = ctx.ParseBitcodeFile(programCachePath)
+					if err != nil {
+						return fmt.Errorf("failed to load cached program: %w", err)
+					}
+					stackSizeLoads = info.StackSizeLoads
+					result.ProgramCached = true
+
+					// Mark the program as recently used, so that it isn't
+					// removed by trimProgramCache.
+					now := time.Now()
+					os.Chtimes(programCachePath, now, now)
+					return nil
+				}
+			}
+
 			// Load and link all the bitcode files. This does not yet optimize
 			// anything, it only links the bitcode files together.
-			ctx := llvm.NewContext()
 			mod = ctx.NewModule("main")
 			for _, pkgJob := range packageJobs {
 				pkgMod, err := ctx.ParseBitcodeFile(pkgJob.result)
@@ -618,6 +689,19 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			if config.AutomaticStackSize() {
 				stackSizeLoads = transform.CreateStackSizeLoads(mod, config)
 			}
+
+			// Store the optimized program in the cache, for the next build.
+			if programCachePath != "" {
+				err := writeProgramCache(programCachePath, mod, programCacheInfo{
+					StackSizeLoads: stackSizeLoads,
+				})
+				if err != nil {
+					return err
+				}
+				// Every change results in a new program in the cache, so
+				// remove the ones that are unlikely to be used again.
+				trimProgramCache(cacheDir, programCachePath, time.Now(), programCacheMaxAge, programCacheMaxSize)
+			}
 			return nil
 		},
 	}
@@ -667,7 +751,12 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		description:  "generate output file",
 		dependencies: []*compileJob{programJob},
 		result:       objfile,
-		run: func(*compileJob) error {
+		run: func(job *compileJob) error {
+			if programCachePath != "" {
+				// The cached program is already stored in the right format.
+				job.result = programCachePath
+				return nil
+			}
 			llvmBuf := llvm.WriteThinLTOBitcodeToMemoryBuffer(mod)
 			defer llvmBuf.Dispose()
 			return os.WriteFile(objfile, llvmBuf.Bytes(), 0666)
@@ -811,6 +900,11 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			}
 			ldflags = append(ldflags, "-mllvm", "-mcpu="+config.CPU())
 			ldflags = append(ldflags, "-mllvm", "-mattr="+config.Features()) // needed for MIPS softfloat
+			if strings.HasPrefix(config.Triple(), "wasm32-") && strings.Contains(config.Features(), "+exception-handling") {
+				// Panics are implemented as WebAssembly exceptions, which
+				// need to be enabled explicitly in the code generator.
+				ldflags = append(ldflags, "-mllvm", "-wasm-enable-eh", "-mllvm", "-exception-model=wasm")
+			}
 			if config.GOOS() == "windows" {
 				// Options for the MinGW wrapper for the lld COFF linker.
 				ldflags = append(ldflags,
@@ -849,11 +943,12 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 
 			var calculatedStacks []string
 			var stackSizes map[string]functionStackSize
-			if config.Options.PrintStacks || config.AutomaticStackSize() {
+			if config.Options.PrintStacks || config.Options.StackReport != "" || config.AutomaticStackSize() {
 				// Try to determine stack sizes at compile time.
 				// Don't do this by default as it usually doesn't work on
 				// unsupported architectures.
-				calculatedStacks, stackSizes, err = determineStackSizes(mod, result.Executable)
+				// Interrupts are only needed for the stack report.
+				calculatedStacks, stackSizes, err = determineStackSizes(mod, result.Executable, config.Options.StackReport != "")
 				if err != nil {
 					return err
 				}
@@ -889,6 +984,13 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 
 				var args []string
 
+				hasExceptions := strings.Contains(config.Features(), "+exception-handling")
+				if hasExceptions {
+					// Panics are thrown as WebAssembly exceptions, using the
+					// try/catch instructions that LLVM emits.
+					args = append(args, "--enable-exception-handling")
+				}
+
 				if config.Scheduler() == "asyncify" {
 					args = append(args, "--asyncify")
 				}
@@ -898,6 +1000,15 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 				args = append(args,
 					opt,
 					"-g",
+				)
+				if hasExceptions && (config.GOOS() == "wasip1" || config.GOOS() == "wasip2") {
+					// WASI runtimes like wasmtime only implement the
+					// standardized version of the proposal (with exnref),
+					// not the legacy instructions that LLVM emits. Browsers
+					// and Node.js support both.
+					args = append(args, "--translate-to-exnref")
+				}
+				args = append(args,
 					inputFile,
 					"--output", result.Binary,
 				)
@@ -976,8 +1087,9 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 				}
 			}
 
-			// Print code size if requested.
-			if config.Options.PrintSizes != "" {
+			// Print code size if requested, and check it against the size
+			// budget.
+			if config.Options.PrintSizes != "" || sizeBudget != nil {
 				sizes, err := loadProgramSize(result.Executable, result.PackagePathMap)
 				if err != nil {
 					return err
@@ -1005,6 +1117,26 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 						return err
 					}
 					fmt.Println("Wrote size report to", filename)
+				case "json":
+					err := writeSizeReportJSON(os.Stdout, sizes, pkgName)
+					if err != nil {
+						return err
+					}
+				case "csv":
+					err := writeSizeReportCSV(os.Stdout, sizes, pkgName)
+					if err != nil {
+						return err
+					}
+				default:
+					if name := strings.TrimPrefix(config.Options.PrintSizes, "why:"); name != config.Options.PrintSizes {
+						printSizeWhy(os.Stdout, mod, sizes, name)
+					}
+				}
+				if sizeBudget != nil {
+					err := checkSizeBudget(sizes, sizeBudget, config.Options.SizeBaseline)
+					if err != nil {
+						return err
+					}
 				}
 			}
 
@@ -1013,6 +1145,14 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 				printStacks(calculatedStacks, stackSizes)
 			}
 
+			// Print the whole-program stack report, if requested.
+			if config.Options.StackReport != "" {
+				err := writeStackReport(os.Stdout, config.Options.StackReport, result.Executable, calculatedStacks, stackSizes)
+				if err != nil {
+					return err
+				}
+			}
+
 			return nil
 		},
 	}
@@ -1264,19 +1404,24 @@ func makeGlobalsModule(ctx llvm.Context, globals map[string]map[string]string, m
 // functionStackSizes keeps stack size information about a single function
 // (usually a goroutine).
 type functionStackSize struct {
+	kind             string // "main", "goroutine" or "interrupt"
 	humanName        string
 	stackSize        uint64
 	stackSizeType    stacksize.SizeType
 	missingStackSize *stacksize.CallNode
+	path             []*stacksize.CallNode // call path that determines the stack size
 }
 
 // determineStackSizes tries to determine the stack sizes of all started
-// goroutines and of the reset vector. The LLVM module is necessary to find
-// functions that call a function pointer.
-func determineStackSizes(mod llvm.Module, executable string) ([]string, map[string]functionStackSize, error) {
+// goroutines, of the reset vector and (if includeInterrupts is set) of
+// interrupt handlers created with interrupt.New. The LLVM module is necessary
+// to find functions that call a function pointer.
+func determineStackSizes(mod llvm.Module, executable string, includeInterrupts bool) ([]string, map[string]functionStackSize, error) {
 	var callsIndirectFunction []string
 	gowrappers := []string{}
 	gowrapperNames := make(map[string]string)
+	var interrupts []string
+	interruptNames := make(map[string]string)
 	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
 		// Determine which functions call a function pointer.
 		for bb := fn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
@@ -1297,8 +1442,18 @@ func determineStackSizes(mod llvm.Module, executable string) ([]string, map[stri
 			gowrappers = append(gowrappers, fn.Name())
 			gowrapperNames[fn.Name()] = attr.GetStringValue()
 		}
+
+		// Get a list of functions that call interrupt handlers. Only
+		// externally visible functions are included: others (like a software
+		// interrupt vector) are usually inlined in the real interrupt handler.
+		attr = fn.GetStringAttributeAtIndex(-1, "tinygo-interrupt")
+		if includeInterrupts && !attr.IsNil() && fn.Linkage() == llvm.ExternalLinkage && !fn.IsDeclaration() {
+			interrupts = append(interrupts, fn.Name())
+			interruptNames[fn.Name()] = fn.Name() + " (" + attr.GetStringValue() + ")"
+		}
 	}
 	sort.Strings(gowrappers)
+	sort.Strings(interrupts)
 
 	// Load the ELF binary.
 	f, err := elf.Open(executable)
@@ -1339,10 +1494,12 @@ func determineStackSizes(mod llvm.Module, executable string) ([]string, map[stri
 		}
 		stackSize, stackSizeType, missingStackSize := funcs[0].StackSize()
 		sizes[resetFunction] = functionStackSize{
+			kind:             "main",
 			stackSize:        stackSize,
 			stackSizeType:    stackSizeType,
 			missingStackSize: missingStackSize,
 			humanName:        resetFunction,
+			path:             funcs[0].StackPath(),
 		}
 	}
 
@@ -1357,30 +1514,62 @@ func determineStackSizes(mod llvm.Module, executable string) ([]string, map[stri
 			humanName = name // fallback
 		}
 		stackSize, stackSizeType, missingStackSize := funcs[0].StackSize()
+		path := funcs[0].StackPath()
 		if baseStackSizeType != stacksize.Bounded {
 			// It was not possible to determine the stack size at compile time
 			// because tinygo_startTask does not have a fixed stack size. This
 			// can happen when using -opt=1.
 			stackSizeType = baseStackSizeType
 			missingStackSize = baseStackSizeFailedAt
+			path = functions["tinygo_startTask"][0].StackPath()
 		} else if stackSize < baseStackSize {
 			// This goroutine has a very small stack, but still needs to fit all
 			// registers to start and suspend the goroutine. Otherwise a stack
 			// overflow will occur even before the goroutine is started.
 			stackSize = baseStackSize
+			path = functions["tinygo_startTask"][0].StackPath()
 		}
 		sizes[name] = functionStackSize{
+			kind:             "goroutine",
 			stackSize:        stackSize,
 			stackSizeType:    stackSizeType,
 			missingStackSize: missingStackSize,
 			humanName:        humanName,
+			path:             path,
 		}
 	}
 
+	// Add all interrupt handlers. They run on the main stack (the stack of
+	// the reset handler), but also need some space on the stack that they
+	// interrupt.
+	linkedInterrupts := interrupts[:0]
+	for _, name := range interrupts {
+		funcs := functions[name]
+		if len(funcs) == 0 {
+			// The handler isn't referenced from the interrupt vector, so the
+			// linker removed it.
+			continue
+		}
+		if len(funcs) != 1 {
+			return nil, nil, fmt.Errorf("expected exactly one definition of %s in the callgraph, found %d", name, len(funcs))
+		}
+		linkedInterrupts = append(linkedInterrupts, name)
+		stackSize, stackSizeType, missingStackSize := funcs[0].StackSize()
+		sizes[name] = functionStackSize{
+			kind:             "interrupt",
+			stackSize:        stackSize,
+			stackSizeType:    stackSizeType,
+			missingStackSize: missingStackSize,
+			humanName:        interruptNames[name],
+			path:             funcs[0].StackPath(),
+		}
+	}
+
+	stacks := append(gowrappers, linkedInterrupts...)
 	if resetFunction != "" {
-		return append([]string{resetFunction}, gowrappers...), sizes, nil
+		stacks = append([]string{resetFunction}, stacks...)
 	}
-	return gowrappers, sizes, nil
+	return stacks, sizes, nil
 }
 
 // modifyStackSizes modifies the .tinygo_stacksizes section with the updated
@@ -1410,34 +1599,17 @@ func modifyStackSizes(executable string, stackSizeLoads []string, stackSizes map
 			stackSize := uint32(fn.stackSize)
 
 			// Add stack size used by interrupts.
-			switch fileHeader.Machine {
-			case elf.EM_ARM:
-				if stackSize%8 != 0 {
-					// If the stack isn't a multiple of 8, it means the leaf
-					// function with the biggest stack depth doesn't have an aligned
-					// stack. If the STKALIGN flag is set (which it is by default)
-					// the interrupt controller will forcibly align the stack before
-					// storing in-use registers. This will thus overwrite one word
-					// past the end of the stack (off-by-one).
-					stackSize += 4
-				}
-
-				// On Cortex-M (assumed here), this stack size is 8 words or 32
-				// bytes. This is only to store the registers that the interrupt
-				// may modify, the interrupt will switch to the interrupt stack
-				// (MSP).
-				// Some background:
-				// https://interrupt.memfault.com/blog/cortex-m-rtos-context-switching
-				stackSize += 32
-
-				// Adding 4 for the stack canary, and another 4 to keep the
-				// stack aligned. Even though the size may be automatically
-				// determined, stack overflow checking is still important as the
-				// stack size cannot be determined for all goroutines.
-				stackSize += 8
-			default:
-				return fmt.Errorf("unknown architecture: %s", fileHeader.Machine.String())
+			overhead, err := interruptStackOverhead(fileHeader.Machine, uint64(stackSize))
+			if err != nil {
+				return err
 			}
+			stackSize += uint32(overhead)
+
+			// Adding 4 for the stack canary, and another 4 to keep the
+			// stack aligned. Even though the size may be automatically
+			// determined, stack overflow checking is still important as the
+			// stack size cannot be determined for all goroutines.
+			stackSize += 8
 
 			// Finally write the stack size to the binary.
 			binary.LittleEndian.PutUint32(data[i*4:], stackSize)
@@ -1447,6 +1619,35 @@ func modifyStackSizes(executable string, stackSizeLoads []string, stackSizes map
 	return replaceElfSection(executable, ".tinygo_stacksizes", data)
 }
 
+// interruptStackOverhead returns how much stack space an interrupt needs on
+// the stack of the code that it interrupts, when that code uses at most
+// stackSize bytes of stack.
+func interruptStackOverhead(machine elf.Machine, stackSize uint64) (uint64, error) {
+	switch machine {
+	case elf.EM_ARM:
+		overhead := uint64(0)
+		if stackSize%8 != 0 {
+			// If the stack isn't a multiple of 8, it means the leaf function
+			// with the biggest stack depth doesn't have an aligned stack. If
+			// the STKALIGN flag is set (which it is by default) the interrupt
+			// controller will forcibly align the stack before storing in-use
+			// registers. This will thus overwrite one word past the end of the
+			// stack (off-by-one).
+			overhead += 4
+		}
+
+		// On Cortex-M (assumed here), this stack size is 8 words or 32 bytes.
+		// This is only to store the registers that the interrupt may modify,
+		// the interrupt will switch to the interrupt stack (MSP).
+		// Some background:
+		// https://interrupt.memfault.com/blog/cortex-m-rtos-context-switching
+		overhead += 32
+		return overhead, nil
+	default:
+		return 0, fmt.Errorf("unknown architecture: %s", machine.String())
+	}
+}
+
 // printStacks prints the maximum stack depth for functions that are started as
 // goroutines. Stack sizes cannot always be determined statically, in particular
 // recursive functions and functions that call interface methods or function
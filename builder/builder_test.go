@@ -33,6 +33,7 @@ func TestClangAttributes(t *testing.T) {
 		"k210",
 		"nintendoswitch",
 		"riscv-qemu",
+		"riscv64-qemu",
 		"tkey",
 		"wasip1",
 		"wasip2",
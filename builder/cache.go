@@ -0,0 +1,261 @@
+package builder
+
+// This file implements the whole-program build cache, and the functions behind
+// 'tinygo clean -cache' and 'tinygo cache stats'.
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/compiler"
+	"tinygo.org/x/go-llvm"
+)
+
+// programAction is the cache key for the linked and optimized program: when
+// none of these inputs changed, the result of the whole-program optimization
+// (including the interp pass) of a previous build can be reused. The interp pass
+// runs on the linked program, where package initializers can modify globals of
+// other packages, so its results are only reused for the program as a whole
+// and not per package.
+type programAction struct {
+	CompilerBuildID string
+	TinyGoVersion   string
+	LLVMVersion     string
+	Config          *compiler.Config
+	Target          *compileopts.TargetSpec
+	GC              string
+	OptLevel        string // LLVM optimization level (O0, O1, O2, Os, Oz)
+	InterpTimeout   time.Duration
+	Packages        []string                     // action ID of each package, in initialization order
+	GlobalValues    map[string]map[string]string // global values set by the compiler
+}
+
+// hash returns the cache key for this program action.
+func (action *programAction) hash() (string, error) {
+	buf, err := json.Marshal(action)
+	if err != nil {
+		return "", err // shouldn't happen
+	}
+	hash := sha512.Sum512_224(buf)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// programCacheInfo is stored next to a cached program (as a JSON file), and
+// contains the information that was computed while optimizing the program but
+// isn't stored in the bitcode itself.
+type programCacheInfo struct {
+	StackSizeLoads []string
+}
+
+// useProgramCache returns whether the linked and optimized program may be
+// stored in and loaded from the build cache. This isn't the case when the
+// optimization steps need to print something, or when the program contains
+// values set with -ldflags="-X ..." (which may be secrets that shouldn't be
+// stored in the cache).
+func useProgramCache(config *compileopts.Config) bool {
+	return !config.Options.PrintIR && !config.DumpSSA() && !config.VerifyIR() && config.Options.PrintAllocs == nil && len(config.Options.GlobalValues) == 0
+}
+
+// writeProgramCache stores the optimized program and the extra information
+// about it in the cache. The bitcode is written in the same format as the
+// object file that is passed to the linker, so that a cached program can be
+// linked directly.
+func writeProgramCache(path string, mod llvm.Module, info programCacheInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err // shouldn't happen
+	}
+	err = writeCacheFile(strings.TrimSuffix(path, ".bc")+".json", data)
+	if err != nil {
+		return err
+	}
+	// Write the bitcode last: the JSON file is only read when the bitcode file
+	// exists.
+	buf := llvm.WriteThinLTOBitcodeToMemoryBuffer(mod)
+	defer buf.Dispose()
+	return writeCacheFile(path, buf.Bytes())
+}
+
+// writeCacheFile writes a file to the cache directory. It writes to a temporary
+// file that is renamed to the destination file, to avoid race conditions with
+// other TinyGo invocations that might be writing the same file.
+func writeCacheFile(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Limits for the optimized programs in the build cache. They are large and
+// every change to a program results in a new one, so programs that weren't used
+// for a while are removed, as are the least recently used ones when the total
+// size gets too big.
+const (
+	programCacheMaxAge  = 5 * 24 * time.Hour // same as the Go build cache
+	programCacheMaxSize = 1 << 30            // 1GiB
+)
+
+// trimProgramCache removes the cached programs in dir that weren't used in the
+// last maxAge, and then the least recently used programs until the total size
+// is at most maxSize. The program at keep (which was just stored) is never
+// removed, nor are programs that are in use by other TinyGo invocations. Errors
+// are ignored: trimming the cache is only done on a best-effort basis.
+func trimProgramCache(dir, keep string, now time.Time, maxAge time.Duration, maxSize int64) {
+	paths, err := filepath.Glob(filepath.Join(dir, "program-*.bc"))
+	if err != nil {
+		return
+	}
+	type cachedProgram struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var programs []cachedProgram
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue // removed in the meantime
+		}
+		programs = append(programs, cachedProgram{path, info.Size(), info.ModTime()})
+	}
+
+	// The modification time is updated on every use, so sorting on it puts
+	// the most recently used programs first.
+	sort.Slice(programs, func(i, j int) bool {
+		return programs[i].modTime.After(programs[j].modTime)
+	})
+	var size int64
+	for _, program := range programs {
+		size += program.size
+		if program.path == keep || (now.Sub(program.modTime) <= maxAge && size <= maxSize) {
+			continue
+		}
+		// Don't wait for the lock: another invocation that holds it is
+		// reading this program, so it's in use.
+		lock := flock.New(program.path + ".lock")
+		if locked, err := lock.TryLock(); err != nil || !locked {
+			continue
+		}
+		if os.Remove(program.path) == nil {
+			os.Remove(strings.TrimSuffix(program.path, ".bc") + ".json")
+			size -= program.size
+		}
+		lock.Close()
+	}
+}
+
+// A category of files in the cache directory, as reported by ReadCacheStats.
+type CacheCategory struct {
+	Name  string // human readable name of this category
+	Files int    // number of files
+	Size  int64  // total size in bytes
+}
+
+// Categories of files in the cache directory. Files that are part of the build
+// cache (removed with 'tinygo clean -cache') are matched on their file name.
+// Everything else, notably the directories with compiled libraries, is part of
+// the "libraries" category.
+var cacheCategories = []struct {
+	name       string
+	prefix     string
+	buildCache bool
+}{
+	{"packages", "pkg-", true},
+	{"programs", "program-", true},
+	{"C objects", "obj-", true},
+	{"C dependencies", "dep-", true},
+	{"ThinLTO", "thinlto", true},
+	{"temporary files", "tmp-", true},
+	{"libraries", "", false},
+}
+
+// cacheCategory returns the index in cacheCategories for the given top-level
+// file or directory in the cache directory.
+func cacheCategory(name string) int {
+	for i, category := range cacheCategories {
+		if strings.HasPrefix(name, category.prefix) {
+			return i
+		}
+	}
+	panic("unreachable")
+}
+
+// ReadCacheStats returns the number of files and their total size for each
+// category of files in the given cache directory.
+func ReadCacheStats(dir string) ([]CacheCategory, error) {
+	stats := make([]CacheCategory, len(cacheCategories))
+	for i, category := range cacheCategories {
+		stats[i].Name = category.name
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".lock") {
+			continue
+		}
+		stat := &stats[cacheCategory(entry.Name())]
+		err := filepath.WalkDir(filepath.Join(dir, entry.Name()), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			stat.Files++
+			stat.Size += info.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// CleanBuildCache removes the build cache (compiled packages, optimized
+// programs and compiled C files) from the given cache directory, but keeps the
+// compiled libraries that are much slower to rebuild.
+func CleanBuildCache(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !cacheCategories[cacheCategory(entry.Name())].buildCache {
+			continue
+		}
+		err := os.RemoveAll(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package builder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+// Test that the cache statistics are counted in the right category, and that
+// cleaning the build cache keeps compiled libraries.
+func TestBuildCache(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{
+		"pkg-1234.bc":                   100,
+		"pkg-1234.bc.lock":              0,
+		"program-5678.bc":               200,
+		"program-5678.json":             10,
+		"obj-abcd.bc":                   30,
+		"thinlto/llvmcache-1":           40,
+		"compiler-rt-cortex-m4/lib.a":   500,
+		"compiler-rt-cortex-m4/include": 5,
+	}
+	for name, size := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0o777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, make([]byte, size), 0o666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkStats := func(expected map[string][2]int64) {
+		t.Helper()
+		stats, err := ReadCacheStats(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stat := range stats {
+			if got, want := [2]int64{int64(stat.Files), stat.Size}, expected[stat.Name]; got != want {
+				t.Errorf("%s: expected %d files of %d bytes, got %d files of %d bytes", stat.Name, want[0], want[1], got[0], got[1])
+			}
+		}
+	}
+	checkStats(map[string][2]int64{
+		"packages":  {1, 100},
+		"programs":  {2, 210},
+		"C objects": {1, 30},
+		"ThinLTO":   {1, 40},
+		"libraries": {2, 505},
+	})
+
+	err := CleanBuildCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStats(map[string][2]int64{
+		"libraries": {2, 505},
+	})
+
+	// A missing cache directory is just an empty cache.
+	_, err = ReadCacheStats(filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
+
+// Test that old and least recently used programs are removed from the cache,
+// together with their JSON file.
+func TestTrimProgramCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	programs := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"program-new", 100, time.Minute},
+		{"program-recent", 100, time.Hour},
+		{"program-lru", 100, 2 * time.Hour},
+		{"program-old", 10, 10 * 24 * time.Hour},
+		{"program-keep", 100, 20 * 24 * time.Hour},
+	}
+	for _, program := range programs {
+		for _, ext := range []string{".bc", ".json"} {
+			path := filepath.Join(dir, program.name+ext)
+			err := os.WriteFile(path, make([]byte, program.size), 0o666)
+			if err != nil {
+				t.Fatal(err)
+			}
+			modTime := now.Add(-program.age)
+			err = os.Chtimes(path, modTime, modTime)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The total size of the three most recent programs is above the limit, so
+	// the third one is removed. The old program is removed because of its age,
+	// but the program that was just stored must be kept.
+	trimProgramCache(dir, filepath.Join(dir, "program-keep.bc"), now, 24*time.Hour, 250)
+	for _, program := range programs {
+		removed := program.name == "program-lru" || program.name == "program-old"
+		for _, ext := range []string{".bc", ".json"} {
+			_, err := os.Stat(filepath.Join(dir, program.name+ext))
+			if removed && err == nil {
+				t.Errorf("%s%s was not removed", program.name, ext)
+			} else if !removed && err != nil {
+				t.Errorf("%s%s was removed: %v", program.name, ext, err)
+			}
+		}
+	}
+}
+
+// Test that a second build of the same program loads the optimized program
+// from the cache, and results in the same binary.
+func TestProgramCache(t *testing.T) {
+	// Include a unique interp timeout in the cache key, so that the first
+	// build can't find the program in the cache.
+	options := compileopts.Options{
+		Target:        "microbit",
+		Opt:           "z",
+		Semaphore:     sema,
+		InterpTimeout: time.Minute + time.Duration(time.Now().UnixNano()%int64(time.Second)),
+	}
+	target, err := compileopts.LoadTarget(&options)
+	if err != nil {
+		t.Fatal("could not load target:", err)
+	}
+	config := &compileopts.Config{
+		Options: &options,
+		Target:  target,
+	}
+	build := func() (BuildResult, []byte) {
+		t.Helper()
+		result, err := Build("testdata/stackreport.go", "", t.TempDir(), config)
+		if err != nil {
+			t.Fatal("could not build:", err)
+		}
+		binary, err := os.ReadFile(result.Binary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result, binary
+	}
+
+	result1, binary1 := build()
+	if result1.ProgramCached {
+		t.Error("first build: program unexpectedly loaded from the cache")
+	}
+	result2, binary2 := build()
+	if !result2.ProgramCached {
+		t.Error("second build: program not loaded from the cache")
+	}
+	if !bytes.Equal(binary1, binary2) {
+		t.Error("binary from the cached program differs from the original binary")
+	}
+}
@@ -2,9 +2,14 @@ package builder
 
 import (
 	_ "embed"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
+	"sort"
+	"strconv"
 )
 
 //go:embed size-report.html
@@ -54,3 +59,179 @@ func writeSizeReport(sizes *programSize, filename, pkgName string) error {
 	}
 	return nil
 }
+
+// Size of a package, a file in a package, or the whole program in the JSON
+// and CSV size reports.
+type sizeReportSize struct {
+	Code   uint64 `json:"code"`
+	ROData uint64 `json:"rodata"`
+	Data   uint64 `json:"data"`
+	BSS    uint64 `json:"bss"`
+	Flash  uint64 `json:"flash"`
+	RAM    uint64 `json:"ram"`
+}
+
+type sizeReportPackage struct {
+	Name string `json:"name"`
+	sizeReportSize
+	Files []sizeReportFile `json:"files"`
+}
+
+type sizeReportFile struct {
+	Name string `json:"name"`
+	sizeReportSize
+}
+
+type sizeReportSection struct {
+	Type    string `json:"type"`
+	Address uint64 `json:"address"`
+	Size    uint64 `json:"size"`
+}
+
+type sizeReportSymbol struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Type    string `json:"type"`
+	Address uint64 `json:"address"`
+	Size    uint64 `json:"size"`
+}
+
+// Machine readable size report, for -size=json.
+type sizeReport struct {
+	Package string `json:"package"`
+	sizeReportSize
+	Sections []sizeReportSection `json:"sections"`
+	Packages []sizeReportPackage `json:"packages"`
+	Symbols  []sizeReportSymbol  `json:"symbols"`
+}
+
+func makeSizeReportSize(size *packageSize) sizeReportSize {
+	return sizeReportSize{
+		Code:   size.Code,
+		ROData: size.ROData,
+		Data:   size.Data,
+		BSS:    size.BSS,
+		Flash:  size.Flash(),
+		RAM:    size.RAM(),
+	}
+}
+
+// makeSizeReport converts the program size to a report that doesn't depend on
+// map iteration order, so that reports of two builds can be easily compared.
+func makeSizeReport(sizes *programSize, pkgName string) *sizeReport {
+	report := &sizeReport{
+		Package: pkgName,
+		sizeReportSize: sizeReportSize{
+			Code:   sizes.Code,
+			ROData: sizes.ROData,
+			Data:   sizes.Data,
+			BSS:    sizes.BSS,
+			Flash:  sizes.Flash(),
+			RAM:    sizes.RAM(),
+		},
+		Sections: []sizeReportSection{},
+		Packages: []sizeReportPackage{},
+		Symbols:  []sizeReportSymbol{},
+	}
+	for _, section := range sizes.Sections {
+		report.Sections = append(report.Sections, sizeReportSection{
+			Type:    section.Type.String(),
+			Address: section.Address,
+			Size:    section.Size,
+		})
+	}
+	for _, name := range sizes.sortedPackageNames() {
+		pkgSize := sizes.Packages[name]
+		pkg := sizeReportPackage{
+			Name:           name,
+			sizeReportSize: makeSizeReportSize(pkgSize),
+			Files:          []sizeReportFile{},
+		}
+		filenames := make([]string, 0, len(pkgSize.Sub))
+		for filename := range pkgSize.Sub {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+		for _, filename := range filenames {
+			pkg.Files = append(pkg.Files, sizeReportFile{
+				Name:           filename,
+				sizeReportSize: makeSizeReportSize(pkgSize.Sub[filename]),
+			})
+		}
+		report.Packages = append(report.Packages, pkg)
+	}
+	for _, symbol := range sizes.Symbols {
+		report.Symbols = append(report.Symbols, sizeReportSymbol{
+			Name:    symbol.Name,
+			Package: symbol.Package,
+			Type:    symbol.Type.String(),
+			Address: symbol.Address,
+			Size:    symbol.Size,
+		})
+	}
+	return report
+}
+
+// writeSizeReportJSON writes the size report for -size=json to w.
+func writeSizeReportJSON(w io.Writer, sizes *programSize, pkgName string) error {
+	data, err := json.MarshalIndent(makeSizeReport(sizes, pkgName), "", "\t")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
+
+// writeSizeReportCSV writes the size report for -size=csv to w. There is one
+// row for the whole program, each section, each package, each file in a
+// package and each symbol. The kind column says which one it is. Sections and
+// symbols have their size in the column of their memory type.
+func writeSizeReportCSV(w io.Writer, sizes *programSize, pkgName string) error {
+	report := makeSizeReport(sizes, pkgName)
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "package", "name", "address", "code", "rodata", "data", "bss", "flash", "ram"})
+	sizeColumns := func(size sizeReportSize) []string {
+		return []string{
+			strconv.FormatUint(size.Code, 10),
+			strconv.FormatUint(size.ROData, 10),
+			strconv.FormatUint(size.Data, 10),
+			strconv.FormatUint(size.BSS, 10),
+			strconv.FormatUint(size.Flash, 10),
+			strconv.FormatUint(size.RAM, 10),
+		}
+	}
+	typeSize := func(memType string, size uint64) sizeReportSize {
+		var s sizeReportSize
+		switch memType {
+		case "code":
+			s.Code = size
+		case "rodata":
+			s.ROData = size
+		case "data":
+			s.Data = size
+		case "bss", "stack":
+			s.BSS = size
+		}
+		s.Flash = s.Code + s.ROData + s.Data
+		s.RAM = s.Data + s.BSS
+		return s
+	}
+	cw.Write(append([]string{"program", report.Package, "", ""}, sizeColumns(report.sizeReportSize)...))
+	for _, section := range report.Sections {
+		address := "0x" + strconv.FormatUint(section.Address, 16)
+		cw.Write(append([]string{"section", "", section.Type, address}, sizeColumns(typeSize(section.Type, section.Size))...))
+	}
+	for _, pkg := range report.Packages {
+		cw.Write(append([]string{"package", pkg.Name, "", ""}, sizeColumns(pkg.sizeReportSize)...))
+		for _, file := range pkg.Files {
+			cw.Write(append([]string{"file", pkg.Name, file.Name, ""}, sizeColumns(file.sizeReportSize)...))
+		}
+	}
+	for _, symbol := range report.Symbols {
+		address := "0x" + strconv.FormatUint(symbol.Address, 16)
+		cw.Write(append([]string{"symbol", symbol.Package, symbol.Name, address}, sizeColumns(typeSize(symbol.Type, symbol.Size))...))
+	}
+	cw.Flush()
+	return cw.Error()
+}
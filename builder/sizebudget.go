@@ -0,0 +1,130 @@
+package builder
+
+// This file checks the size of a program against a size budget.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+// Number of packages to list when the program is over budget.
+const sizeBudgetTopPackages = 5
+
+// checkSizeBudget checks the program size against the budget, and returns an
+// error listing every budget that was exceeded. When a baseline report (created
+// with -size=json) is given, the packages that grew the most compared to the
+// baseline are listed, otherwise the largest packages are listed.
+func checkSizeBudget(sizes *programSize, budget *compileopts.SizeBudget, baselinePath string) error {
+	var baseline *sizeReport
+	if baselinePath != "" {
+		data, err := os.ReadFile(baselinePath)
+		if err != nil {
+			return err
+		}
+		baseline = &sizeReport{}
+		err = json.Unmarshal(data, baseline)
+		if err != nil {
+			return fmt.Errorf("could not read size baseline %s: %w", baselinePath, err)
+		}
+	}
+
+	var errs []error
+	if budget.Flash != 0 && sizes.Flash() > budget.Flash {
+		errs = append(errs, fmt.Errorf("flash usage of %d bytes exceeds the size budget of %d bytes by %d bytes (%s)",
+			sizes.Flash(), budget.Flash, sizes.Flash()-budget.Flash, sizeBudgetCulprits(sizes, baseline, (*packageSize).Flash, func(size sizeReportSize) uint64 { return size.Flash })))
+	}
+	if budget.RAM != 0 && sizes.RAM() > budget.RAM {
+		errs = append(errs, fmt.Errorf("RAM usage of %d bytes exceeds the size budget of %d bytes by %d bytes (%s)",
+			sizes.RAM(), budget.RAM, sizes.RAM()-budget.RAM, sizeBudgetCulprits(sizes, baseline, (*packageSize).RAM, func(size sizeReportSize) uint64 { return size.RAM })))
+	}
+
+	// Check the package budgets in a stable order.
+	pkgNames := make([]string, 0, len(budget.Packages))
+	for name := range budget.Packages {
+		pkgNames = append(pkgNames, name)
+	}
+	sort.Strings(pkgNames)
+	for _, name := range pkgNames {
+		pkgBudget := budget.Packages[name]
+		pkgSize := sizes.Packages[name]
+		if pkgSize == nil {
+			// Package isn't part of the program (anymore), so it can't be over
+			// budget.
+			continue
+		}
+		if pkgBudget.Flash != 0 && pkgSize.Flash() > pkgBudget.Flash {
+			errs = append(errs, fmt.Errorf("package %s: flash usage of %d bytes exceeds the size budget of %d bytes by %d bytes%s",
+				name, pkgSize.Flash(), pkgBudget.Flash, pkgSize.Flash()-pkgBudget.Flash, sizeBudgetPackageGrowth(name, pkgSize.Flash(), baseline, func(size sizeReportSize) uint64 { return size.Flash })))
+		}
+		if pkgBudget.RAM != 0 && pkgSize.RAM() > pkgBudget.RAM {
+			errs = append(errs, fmt.Errorf("package %s: RAM usage of %d bytes exceeds the size budget of %d bytes by %d bytes%s",
+				name, pkgSize.RAM(), pkgBudget.RAM, pkgSize.RAM()-pkgBudget.RAM, sizeBudgetPackageGrowth(name, pkgSize.RAM(), baseline, func(size sizeReportSize) uint64 { return size.RAM })))
+		}
+	}
+	return newMultiError(errs, "")
+}
+
+// sizeBudgetCulprits returns a short description of the packages that are most
+// likely responsible for going over budget: the packages that grew the most
+// since the baseline, or the largest packages if there is no baseline.
+func sizeBudgetCulprits(sizes *programSize, baseline *sizeReport, getSize func(*packageSize) uint64, getBaselineSize func(sizeReportSize) uint64) string {
+	type pkgDelta struct {
+		name  string
+		delta int64
+	}
+	baselineSizes := map[string]uint64{}
+	if baseline != nil {
+		for _, pkg := range baseline.Packages {
+			baselineSizes[pkg.Name] = getBaselineSize(pkg.sizeReportSize)
+		}
+	}
+	var deltas []pkgDelta
+	for _, name := range sizes.sortedPackageNames() {
+		delta := int64(getSize(sizes.Packages[name])) - int64(baselineSizes[name])
+		if delta > 0 {
+			deltas = append(deltas, pkgDelta{name, delta})
+		}
+	}
+	sort.SliceStable(deltas, func(i, j int) bool {
+		return deltas[i].delta > deltas[j].delta
+	})
+	if len(deltas) > sizeBudgetTopPackages {
+		deltas = deltas[:sizeBudgetTopPackages]
+	}
+
+	var parts []string
+	for _, d := range deltas {
+		if baseline != nil {
+			parts = append(parts, fmt.Sprintf("%s +%d", d.name, d.delta))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %d", d.name, d.delta))
+		}
+	}
+	if baseline != nil {
+		if len(parts) == 0 {
+			return "no package grew compared to the baseline"
+		}
+		return "packages that grew compared to the baseline: " + strings.Join(parts, ", ")
+	}
+	return "largest packages: " + strings.Join(parts, ", ")
+}
+
+// sizeBudgetPackageGrowth returns how much a package grew compared to the
+// baseline, for use in a diagnostic. It returns the empty string if there is no
+// baseline.
+func sizeBudgetPackageGrowth(name string, size uint64, baseline *sizeReport, getBaselineSize func(sizeReportSize) uint64) string {
+	if baseline == nil {
+		return ""
+	}
+	for _, pkg := range baseline.Packages {
+		if pkg.Name == name {
+			return fmt.Sprintf(" (%+d bytes compared to the baseline)", int64(size)-int64(getBaselineSize(pkg.sizeReportSize)))
+		}
+	}
+	return " (package is new compared to the baseline)"
+}
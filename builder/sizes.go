@@ -26,6 +26,8 @@ const sizesDebug = false
 // programSize contains size statistics per package of a compiled program.
 type programSize struct {
 	Packages map[string]*packageSize
+	Sections []memorySection // allocated sections, sorted by address
+	Symbols  []symbolSize    // symbols with a size, sorted by address (ELF only)
 	Code     uint64
 	ROData   uint64
 	Data     uint64
@@ -122,6 +124,15 @@ type addressLine struct {
 	IsVariable bool   // true if this is a variable (or constant), false if it is code
 }
 
+// A single symbol (function or global) in the binary.
+type symbolSize struct {
+	Name    string
+	Package string // package the symbol belongs to, or "" if unknown
+	Type    memoryType
+	Address uint64
+	Size    uint64
+}
+
 // Sections defined in the input file. This struct defines them in a
 // filetype-agnostic way but roughly follow the ELF types (.text, .data, .bss,
 // etc).
@@ -429,6 +440,10 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 	// This stores all chunks of addresses found in the binary.
 	var addresses []addressLine
 
+	// Symbols found in the binary, if the file format has a symbol table with
+	// symbol sizes.
+	var symbols []symbolSize
+
 	// Load the binary file, which could be in a number of file formats.
 	var sections []memorySection
 	if file, err := elf.NewFile(f); err == nil {
@@ -474,6 +489,11 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 			if section.Flags&elf.SHF_ALLOC == 0 {
 				continue
 			}
+			symbols = append(symbols, symbolSize{
+				Name:    symbol.Name,
+				Address: symbol.Value,
+				Size:    symbol.Size,
+			})
 			if packageSymbolRegexp.MatchString(symbol.Name) || symbol.Name == "__isr_vector" {
 				addresses = append(addresses, addressLine{
 					Address:    symbol.Value,
@@ -826,9 +846,14 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 
 	// Now finally determine the binary/RAM size usage per package by going
 	// through each allocated section.
+	sort.Slice(sections, func(i, j int) bool {
+		return sections[i].Address < sections[j].Address
+	})
 	sizes := make(map[string]*packageSize)
 	program := &programSize{
 		Packages: sizes,
+		Sections: sections,
+		Symbols:  readSymbols(symbols, sections, addresses, packagePathMap),
 	}
 	for _, section := range sections {
 		switch section.Type {
@@ -869,6 +894,51 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 	return program, nil
 }
 
+// readSymbols determines the memory type and package of each symbol, and
+// returns them sorted by address. Symbols outside of the allocated sections are
+// dropped. The package is looked up in the address
+// lines (which are sorted by address), which means it is only known when the
+// binary has debug information.
+func readSymbols(symbols []symbolSize, sections []memorySection, addresses []addressLine, packagePathMap map[string]string) []symbolSize {
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].Address == symbols[j].Address {
+			return symbols[i].Name < symbols[j].Name
+		}
+		return symbols[i].Address < symbols[j].Address
+	})
+	result := symbols[:0]
+	for i := range symbols {
+		symbol := &symbols[i]
+
+		// Find the address line (code or variable) that contains the start of
+		// this symbol, if any.
+		var line *addressLine
+		index := sort.Search(len(addresses), func(i int) bool {
+			return addresses[i].Address > symbol.Address
+		})
+		if index > 0 && symbol.Address < addresses[index-1].Address+addresses[index-1].Length {
+			line = &addresses[index-1]
+			symbol.Package, _ = findPackagePath(line.File, packagePathMap)
+		}
+
+		// Find the section this symbol is part of.
+		for _, section := range sections {
+			if symbol.Address >= section.Address && symbol.Address < section.Address+section.Size {
+				symbol.Type = section.Type
+				if section.Type == memoryCode && line != nil && line.IsVariable {
+					// Constants can be stored in the code section too.
+					symbol.Type = memoryROData
+				}
+				break
+			}
+		}
+		if symbol.Type != 0 {
+			result = append(result, *symbol)
+		}
+	}
+	return result
+}
+
 // readSection determines for each byte in this section to which package it
 // belongs.
 func readSection(section memorySection, addresses []addressLine, program *programSize, getField func(*packageSize, bool) *uint64, packagePathMap map[string]string) {
@@ -1,12 +1,17 @@
 package builder
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"testing"
 	"time"
 
 	"github.com/tinygo-org/tinygo/compileopts"
+	"tinygo.org/x/go-llvm"
 )
 
 var sema = make(chan struct{}, runtime.NumCPU())
@@ -115,15 +120,228 @@ func TestSizeFull(t *testing.T) {
 	}
 }
 
-func buildBinary(t *testing.T, targetString, pkgName string) BuildResult {
-	options := compileopts.Options{
-		Target:        targetString,
-		Opt:           "z",
-		Semaphore:     sema,
-		InterpTimeout: 60 * time.Second,
-		Debug:         true,
-		VerifyIR:      true,
+// Check that the -size=json report is consistent: the package sizes must add up
+// to the program size, and symbols must be attributed to packages.
+func TestSizeJSON(t *testing.T) {
+	t.Parallel()
+
+	result := buildBinary(t, "microbit", "examples/serial")
+	sizes, err := loadProgramSize(result.Executable, result.PackagePathMap)
+	if err != nil {
+		t.Fatal("could not read program size:", err)
+	}
+	buf := &bytes.Buffer{}
+	err = writeSizeReportJSON(buf, sizes, "examples/serial")
+	if err != nil {
+		t.Fatal("could not write size report:", err)
+	}
+	var report sizeReport
+	err = json.Unmarshal(buf.Bytes(), &report)
+	if err != nil {
+		t.Fatal("could not parse size report:", err)
+	}
+
+	var total sizeReportSize
+	for _, pkg := range report.Packages {
+		total.Code += pkg.Code
+		total.ROData += pkg.ROData
+		total.Data += pkg.Data
+		total.BSS += pkg.BSS
+		total.Flash += pkg.Flash
+		total.RAM += pkg.RAM
+	}
+	if total != report.sizeReportSize {
+		t.Errorf("package sizes don't add up to the program size: %+v != %+v", total, report.sizeReportSize)
+	}
+
+	foundRuntime := false
+	for _, symbol := range report.Symbols {
+		if symbol.Package == "runtime" && symbol.Type == "code" {
+			foundRuntime = true
+		}
+	}
+	if !foundRuntime {
+		t.Error("no code symbols found in the runtime package")
+	}
+}
+
+// Check that size budgets are enforced, and that the error names the packages
+// that are responsible.
+func TestSizeBudget(t *testing.T) {
+	t.Parallel()
+
+	sizes := &programSize{Packages: map[string]*packageSize{}}
+	sizes.getPackage("runtime").Code = 3000
+	sizes.getPackage("fmt").Code = 5000
+	sizes.getPackage("main").BSS = 100
+	sizes.Code = 8000
+	sizes.BSS = 100
+
+	// Within budget.
+	err := checkSizeBudget(sizes, &compileopts.SizeBudget{Flash: 8000, RAM: 100}, "")
+	if err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	// Over budget, without baseline.
+	err = checkSizeBudget(sizes, &compileopts.SizeBudget{
+		Flash: 7000,
+		Packages: map[string]compileopts.PackageSizeBudget{
+			"fmt": {Flash: 4000},
+		},
+	}, "")
+	expected := []string{
+		"flash usage of 8000 bytes exceeds the size budget of 7000 bytes by 1000 bytes (largest packages: fmt 5000, runtime 3000)",
+		"package fmt: flash usage of 5000 bytes exceeds the size budget of 4000 bytes by 1000 bytes",
+	}
+	checkSizeBudgetErrors(t, err, expected)
+
+	// Over budget, with a baseline.
+	baseline := &programSize{Packages: map[string]*packageSize{}}
+	baseline.getPackage("runtime").Code = 2900
+	baseline.getPackage("fmt").Code = 3000
+	baselinePath := filepath.Join(t.TempDir(), "baseline.json")
+	f, err := os.Create(baselinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writeSizeReportJSON(f, baseline, "main")
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = checkSizeBudget(sizes, &compileopts.SizeBudget{
+		Flash: 7000,
+		RAM:   50,
+		Packages: map[string]compileopts.PackageSizeBudget{
+			"fmt": {Flash: 4000},
+		},
+	}, baselinePath)
+	expected = []string{
+		"flash usage of 8000 bytes exceeds the size budget of 7000 bytes by 1000 bytes (packages that grew compared to the baseline: fmt +2000, runtime +100)",
+		"RAM usage of 100 bytes exceeds the size budget of 50 bytes by 50 bytes (packages that grew compared to the baseline: main +100)",
+		"package fmt: flash usage of 5000 bytes exceeds the size budget of 4000 bytes by 1000 bytes (+2000 bytes compared to the baseline)",
 	}
+	checkSizeBudgetErrors(t, err, expected)
+}
+
+func checkSizeBudgetErrors(t *testing.T, err error, expected []string) {
+	t.Helper()
+	var errs []error
+	switch err := err.(type) {
+	case nil:
+	case *MultiError:
+		errs = err.Errs
+	default:
+		errs = []error{err}
+	}
+	if len(errs) != len(expected) {
+		t.Errorf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+		return
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("unexpected error:\nexpected: %s\nactual:   %s", expected[i], err)
+		}
+	}
+}
+
+func TestSymbolInPackage(t *testing.T) {
+	tests := []struct {
+		name    string
+		pkgPath string
+		result  bool
+	}{
+		{"fmt.Sprintf", "fmt", true},
+		{"(*fmt.pp).doPrintf", "fmt", true},
+		{"(fmt.Stringer).String", "fmt", true},
+		{"fmt$string", "fmt", true},
+		{"fmtx.Foo", "fmt", false},
+		{"internal/fmtsort.Sort", "fmt", false},
+		{"internal/task.Pause", "internal/task", true},
+		{"runtime.printstring", "fmt", false},
+	}
+	for _, tc := range tests {
+		if result := symbolInPackage(tc.name, tc.pkgPath); result != tc.result {
+			t.Errorf("symbolInPackage(%q, %q) = %v, expected %v", tc.name, tc.pkgPath, result, tc.result)
+		}
+	}
+}
+
+// Check the reference chains printed by -size=why on a small module, with
+// references through calls, global initializers and constant expressions.
+func TestSizeWhy(t *testing.T) {
+	ctx := llvm.NewContext()
+	defer ctx.Dispose()
+	buf, err := llvm.NewMemoryBufferFromFile("testdata/sizewhy.ll")
+	if err != nil {
+		t.Fatal("could not read file:", err)
+	}
+	mod, err := ctx.ParseIR(buf)
+	if err != nil {
+		t.Fatal("could not load module:", err)
+	}
+	defer mod.Dispose()
+
+	sizes := &programSize{Packages: map[string]*packageSize{}}
+	sizes.getPackage("fmt").Code = 100
+
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"fmt.Println", "fmt.Println is linked because of this reference chain:\n" +
+			"    main\n" +
+			"      -> main.main\n" +
+			"      -> fmt.Println\n"},
+		{"main.handler", "main.handler is linked because of this reference chain:\n" +
+			"    main\n" +
+			"      -> main.main\n" +
+			"      -> main.table\n" +
+			"      -> main.handler\n"},
+		{"fmt$string", "fmt$string is linked because of this reference chain:\n" +
+			"    main\n" +
+			"      -> main.main\n" +
+			"      -> fmt.Println\n" +
+			"      -> (*fmt.pp).doPrint\n" +
+			"      -> fmt$string\n"},
+		{"runtime.unused", "runtime.unused is not referenced from any entry point, it will likely be removed by the linker\n"},
+		{"fmt", "package fmt (flash 100 bytes, ram 0 bytes) is linked because of 2 reference(s) from outside the package:\n" +
+			"  fmt.Sprintf:\n" +
+			"    tinygo_exported\n" +
+			"      -> fmt.Sprintf\n" +
+			"  fmt.Println:\n" +
+			"    main\n" +
+			"      -> main.main\n" +
+			"      -> fmt.Println\n"},
+		{"runtime", "package runtime is linked because of 1 reference(s) from outside the package:\n" +
+			"  runtime.alloc:\n" +
+			"    main\n" +
+			"      -> main.main\n" +
+			"      -> runtime.alloc\n"},
+		{"os", "no symbol or package named os is linked into the program\n"},
+	}
+	for _, tc := range tests {
+		buf := &bytes.Buffer{}
+		printSizeWhy(buf, mod, sizes, tc.name)
+		if buf.String() != tc.output {
+			t.Errorf("unexpected output for -size=why:%s\nexpected:\n%s\nactual:\n%s", tc.name, tc.output, buf.String())
+		}
+	}
+}
+
+func buildBinary(t *testing.T, targetString, pkgName string) BuildResult {
+	return buildBinaryWithOptions(t, compileopts.Options{Target: targetString}, pkgName)
+}
+
+// buildBinaryWithOptions builds the given package, with the options that
+// buildBinary uses in addition to the given options.
+func buildBinaryWithOptions(t *testing.T, options compileopts.Options, pkgName string) BuildResult {
+	options.Opt = "z"
+	options.Semaphore = sema
+	options.InterpTimeout = 60 * time.Second
+	options.Debug = true
+	options.VerifyIR = true
 	target, err := compileopts.LoadTarget(&options)
 	if err != nil {
 		t.Fatal("could not load target:", err)
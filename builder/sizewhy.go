@@ -0,0 +1,223 @@
+package builder
+
+// This file implements -size=why:<symbol or package>, which explains why a
+// symbol or package is part of the program by printing a chain of references
+// from an entry point (such as main) to the symbol.
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"tinygo.org/x/go-llvm"
+)
+
+// Maximum number of reference chains to print for a package.
+const sizeWhyMaxChains = 10
+
+// A graph of references between global values (functions and global
+// variables) in an LLVM module.
+type referenceGraph struct {
+	refs      map[llvm.Value][]llvm.Value // references from each global value
+	constRefs map[llvm.Value][]llvm.Value // cache of global values referenced by a constant
+	roots     []llvm.Value                // externally visible global values
+}
+
+// newReferenceGraph creates the reference graph for the given (optimized)
+// module. The roots of the graph are all globals that are visible outside of
+// the module, which includes the entry point, interrupt handlers and exported
+// functions.
+func newReferenceGraph(mod llvm.Module) *referenceGraph {
+	g := &referenceGraph{
+		refs:      make(map[llvm.Value][]llvm.Value),
+		constRefs: make(map[llvm.Value][]llvm.Value),
+	}
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if fn.IsDeclaration() {
+			continue
+		}
+		var refs []llvm.Value
+		for bb := fn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+			for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+				for i := 0; i < inst.OperandsCount(); i++ {
+					refs = append(refs, g.referencedGlobals(inst.Operand(i))...)
+				}
+			}
+		}
+		g.addGlobal(fn, refs)
+	}
+	for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if global.IsDeclaration() {
+			continue
+		}
+		g.addGlobal(global, g.referencedGlobals(global.Initializer()))
+	}
+
+	// Sort the roots so that the main entry point is preferred when there are
+	// multiple equally short reference chains.
+	sort.SliceStable(g.roots, func(i, j int) bool {
+		iMain := g.roots[i].Name() == "main"
+		jMain := g.roots[j].Name() == "main"
+		if iMain != jMain {
+			return iMain
+		}
+		return g.roots[i].Name() < g.roots[j].Name()
+	})
+	return g
+}
+
+// addGlobal adds a single function or global variable with the given
+// references to the graph.
+func (g *referenceGraph) addGlobal(global llvm.Value, refs []llvm.Value) {
+	g.refs[global] = refs
+	switch global.Linkage() {
+	case llvm.InternalLinkage, llvm.PrivateLinkage:
+	default:
+		g.roots = append(g.roots, global)
+	}
+}
+
+// referencedGlobals returns the functions and global variables that the given
+// operand refers to, looking through constant expressions and constant
+// aggregates.
+func (g *referenceGraph) referencedGlobals(value llvm.Value) []llvm.Value {
+	if value.IsNil() {
+		return nil
+	}
+	if !value.IsAGlobalValue().IsNil() {
+		return []llvm.Value{value}
+	}
+	if value.IsAConstant().IsNil() {
+		// Instruction, parameter, basic block, metadata, etc.
+		return nil
+	}
+	if refs, ok := g.constRefs[value]; ok {
+		return refs
+	}
+	var refs []llvm.Value
+	for i := 0; i < value.OperandsCount(); i++ {
+		refs = append(refs, g.referencedGlobals(value.Operand(i))...)
+	}
+	g.constRefs[value] = refs
+	return refs
+}
+
+// shortestChains does a breadth-first search from the roots of the graph, and
+// returns for each reachable global the global that referenced it first (nil
+// for the roots themselves). Together, these form the shortest reference chain
+// from a root to each reachable global.
+func (g *referenceGraph) shortestChains() map[llvm.Value]llvm.Value {
+	parents := make(map[llvm.Value]llvm.Value)
+	queue := append([]llvm.Value{}, g.roots...)
+	for _, root := range g.roots {
+		parents[root] = llvm.Value{}
+	}
+	for len(queue) != 0 {
+		global := queue[0]
+		queue = queue[1:]
+		for _, ref := range g.refs[global] {
+			if _, ok := parents[ref]; ok {
+				continue
+			}
+			parents[ref] = global
+			queue = append(queue, ref)
+		}
+	}
+	return parents
+}
+
+// chain returns the names in the reference chain from a root to the given
+// global.
+func chain(parents map[llvm.Value]llvm.Value, global llvm.Value) []string {
+	var names []string
+	for !global.IsNil() {
+		names = append(names, global.Name())
+		global = parents[global]
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return names
+}
+
+// symbolInPackage returns whether the given symbol name (for example
+// "(*fmt.pp).doPrintf" or "fmt$string") belongs to the given package.
+func symbolInPackage(name, pkgPath string) bool {
+	name = strings.TrimPrefix(name, "(")
+	name = strings.TrimPrefix(name, "*")
+	return strings.HasPrefix(name, pkgPath+".") || strings.HasPrefix(name, pkgPath+"$")
+}
+
+// printSizeWhy prints why the given symbol or package is part of the program,
+// for -size=why:<symbol or package>.
+func printSizeWhy(w io.Writer, mod llvm.Module, sizes *programSize, name string) {
+	g := newReferenceGraph(mod)
+	parents := g.shortestChains()
+	printChain := func(names []string) {
+		for i, name := range names {
+			if i == 0 {
+				fmt.Fprintf(w, "    %s\n", name)
+			} else {
+				fmt.Fprintf(w, "      -> %s\n", name)
+			}
+		}
+	}
+
+	// Check for a single symbol.
+	symbol := mod.NamedFunction(name)
+	if symbol.IsNil() {
+		symbol = mod.NamedGlobal(name)
+	}
+	if !symbol.IsNil() {
+		if _, ok := parents[symbol]; !ok {
+			fmt.Fprintf(w, "%s is not referenced from any entry point, it will likely be removed by the linker\n", name)
+			return
+		}
+		fmt.Fprintf(w, "%s is linked because of this reference chain:\n", name)
+		printChain(chain(parents, symbol))
+		return
+	}
+
+	// Not a symbol, so treat it as a package. Find all symbols in the
+	// package that are referenced from outside the package: these are the
+	// reasons the package is linked in.
+	var entries []llvm.Value
+	for global := range parents {
+		if !symbolInPackage(global.Name(), name) {
+			continue
+		}
+		parent := parents[global]
+		if parent.IsNil() || !symbolInPackage(parent.Name(), name) {
+			entries = append(entries, global)
+		}
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(w, "no symbol or package named %s is linked into the program\n", name)
+		return
+	}
+	chains := make([][]string, len(entries))
+	for i, entry := range entries {
+		chains[i] = chain(parents, entry)
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		if len(chains[i]) != len(chains[j]) {
+			return len(chains[i]) < len(chains[j])
+		}
+		return chains[i][len(chains[i])-1] < chains[j][len(chains[j])-1]
+	})
+
+	fmt.Fprintf(w, "package %s", name)
+	if pkgSize := sizes.Packages[name]; pkgSize != nil {
+		fmt.Fprintf(w, " (flash %d bytes, ram %d bytes)", pkgSize.Flash(), pkgSize.RAM())
+	}
+	fmt.Fprintf(w, " is linked because of %d reference(s) from outside the package:\n", len(chains))
+	for i, names := range chains {
+		if i == sizeWhyMaxChains {
+			fmt.Fprintf(w, "  ... and %d more\n", len(chains)-i)
+			break
+		}
+		fmt.Fprintf(w, "  %s:\n", names[len(names)-1])
+		printChain(names)
+	}
+}
@@ -0,0 +1,148 @@
+package builder
+
+// This file implements -stack-report, a whole-program report of the worst case
+// stack usage of the main stack, all goroutines and all interrupt handlers.
+
+import (
+	"debug/elf"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/stacksize"
+)
+
+// A single stack in the stack report.
+type stackReportEntry struct {
+	Kind      string `json:"kind"` // "main", "goroutine" or "interrupt"
+	Name      string `json:"name"`
+	Symbol    string `json:"symbol"`
+	Bounded   bool   `json:"bounded"`
+	StackSize uint64 `json:"stack_size,omitempty"` // worst case stack usage of the function itself
+
+	// Worst case stack usage of interrupts on top of this stack. For the main
+	// stack, this assumes every interrupt can be nested in another (as if they
+	// all have a different priority).
+	InterruptSize uint64   `json:"interrupt_size,omitempty"`
+	Total         uint64   `json:"total,omitempty"`   // StackSize + InterruptSize
+	Reason        string   `json:"reason,omitempty"`  // why the stack size is unbounded
+	Culprit       string   `json:"culprit,omitempty"` // function responsible for the unbounded stack size
+	Path          []string `json:"path"`              // call path that determines the stack size
+}
+
+// makeStackReport creates a stack report from the stack sizes determined by
+// determineStackSizes.
+func makeStackReport(executable string, calculatedStacks []string, stackSizes map[string]functionStackSize) ([]stackReportEntry, error) {
+	f, err := elf.Open(executable)
+	if err != nil {
+		return nil, fmt.Errorf("could not load executable for stack size analysis: %w", err)
+	}
+	machine := f.Machine
+	f.Close()
+
+	report := make([]stackReportEntry, 0, len(calculatedStacks))
+	var interrupts []functionStackSize
+	for _, name := range calculatedStacks {
+		fn := stackSizes[name]
+		if fn.kind == "interrupt" {
+			interrupts = append(interrupts, fn)
+		}
+	}
+	for _, name := range calculatedStacks {
+		fn := stackSizes[name]
+		entry := stackReportEntry{
+			Kind:    fn.kind,
+			Name:    fn.humanName,
+			Symbol:  name,
+			Bounded: fn.stackSizeType == stacksize.Bounded,
+			Path:    []string{},
+		}
+		for _, node := range fn.path {
+			entry.Path = append(entry.Path, node.String())
+		}
+		if !entry.Bounded {
+			entry.Reason = fn.stackSizeType.String()
+			entry.Culprit = fn.missingStackSize.String()
+			report = append(report, entry)
+			continue
+		}
+		entry.StackSize = fn.stackSize
+
+		switch fn.kind {
+		case "main":
+			// All interrupts run on the main stack, so in the worst case
+			// they're all nested on top of the deepest point of the main
+			// stack.
+			depth := fn.stackSize
+			for _, interrupt := range interrupts {
+				if interrupt.stackSizeType != stacksize.Bounded {
+					entry.Bounded = false
+					entry.Reason = interrupt.stackSizeType.String()
+					entry.Culprit = interrupt.missingStackSize.String()
+					entry.Path = append(entry.Path, "(interrupt) "+interrupt.humanName)
+					break
+				}
+				overhead, err := interruptStackOverhead(machine, depth)
+				if err != nil {
+					return nil, err
+				}
+				depth += overhead + interrupt.stackSize
+			}
+			if entry.Bounded {
+				entry.InterruptSize = depth - fn.stackSize
+			}
+		case "goroutine":
+			// Interrupts switch to the main stack, but store some registers
+			// on the goroutine stack first.
+			overhead, err := interruptStackOverhead(machine, fn.stackSize)
+			if err != nil {
+				return nil, err
+			}
+			entry.InterruptSize = overhead
+		}
+		if entry.Bounded {
+			entry.Total = entry.StackSize + entry.InterruptSize
+		}
+		report = append(report, entry)
+	}
+	return report, nil
+}
+
+// writeStackReport writes the stack report for -stack-report in the given
+// format ("table" or "json").
+func writeStackReport(w io.Writer, format, executable string, calculatedStacks []string, stackSizes map[string]functionStackSize) error {
+	report, err := makeStackReport(executable, calculatedStacks, stackSizes)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		data, err := json.MarshalIndent(report, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	fmt.Fprintf(w, "%-9s %7s %10s %7s  %s\n", "kind", "stack", "interrupts", "total", "function")
+	for _, entry := range report {
+		if entry.Bounded {
+			fmt.Fprintf(w, "%-9s %7d %10d %7d  %s\n", entry.Kind, entry.StackSize, entry.InterruptSize, entry.Total, entry.Name)
+		} else {
+			fmt.Fprintf(w, "%-9s %26s  %s\n", entry.Kind, "unbounded", entry.Name)
+			switch entry.Reason {
+			case stacksize.Recursive.String():
+				fmt.Fprintf(w, "          reason: %s may call itself\n", entry.Culprit)
+			case stacksize.IndirectCall.String():
+				fmt.Fprintf(w, "          reason: %s calls a function pointer\n", entry.Culprit)
+			default:
+				fmt.Fprintf(w, "          reason: %s does not have stack frame information\n", entry.Culprit)
+			}
+		}
+		if len(entry.Path) != 0 {
+			fmt.Fprintf(w, "          path: %s\n", strings.Join(entry.Path, " -> "))
+		}
+	}
+	return nil
+}
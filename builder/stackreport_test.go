@@ -0,0 +1,67 @@
+package builder
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+// Check that the stack report lists the main stack, goroutines and interrupts
+// on a Cortex-M target.
+func TestStackReport(t *testing.T) {
+	// This test is not parallel, as the report is written to stdout.
+	stdout := os.Stdout
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	os.Stdout = f
+	defer func() {
+		os.Stdout = stdout
+	}()
+	buildBinaryWithOptions(t, compileopts.Options{
+		Target:      "microbit",
+		StackReport: "json",
+	}, "testdata/stackreport.go")
+	os.Stdout = stdout
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	var report []stackReportEntry
+	if err := json.NewDecoder(f).Decode(&report); err != nil {
+		t.Fatal("could not parse stack report:", err)
+	}
+
+	// The report starts with the main stack, followed by the goroutines and
+	// then the interrupts.
+	var kinds []string
+	found := map[string]bool{}
+	for _, entry := range report {
+		if len(kinds) == 0 || kinds[len(kinds)-1] != entry.Kind {
+			kinds = append(kinds, entry.Kind)
+		}
+		switch {
+		case entry.Kind == "main" && entry.Symbol == "Reset_Handler",
+			entry.Kind == "goroutine" && entry.Name == "main.worker",
+			entry.Kind == "interrupt" && entry.Symbol == "RTC1_IRQHandler":
+			found[entry.Kind] = true
+		}
+		if entry.Bounded && entry.Total != entry.StackSize+entry.InterruptSize {
+			t.Errorf("inconsistent stack size for %s: %+v", entry.Name, entry)
+		}
+	}
+	if len(kinds) != 3 || kinds[0] != "main" || kinds[1] != "goroutine" || kinds[2] != "interrupt" {
+		t.Errorf("unexpected order of stacks in the report: %v", kinds)
+	}
+	for _, kind := range []string{"main", "goroutine", "interrupt"} {
+		if !found[kind] {
+			t.Errorf("%s stack not found in the report: %+v", kind, report)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+; Reference graph for TestSizeWhy. The roots are the functions and globals that
+; are visible outside the module: main and tinygo_exported.
+
+@"main.table" = internal constant [1 x ptr] [ptr @"main.handler"]
+@"fmt$string" = internal constant [5 x i8] c"hello"
+
+define void @main() {
+  call void @"main.main"()
+  ret void
+}
+
+define void @tinygo_exported() {
+  call void @"fmt.Sprintf"()
+  ret void
+}
+
+define internal void @"main.main"() {
+  %handler = load ptr, ptr @"main.table"
+  call void %handler()
+  call void @"fmt.Println"()
+  call void @"runtime.alloc"()
+  ret void
+}
+
+define internal void @"main.handler"() {
+  ret void
+}
+
+define internal void @"fmt.Println"() {
+  call void @"(*fmt.pp).doPrint"()
+  call void @"runtime.alloc"()
+  ret void
+}
+
+define internal void @"(*fmt.pp).doPrint"() {
+  %s = load i8, ptr getelementptr inbounds ([5 x i8], ptr @"fmt$string", i32 0, i32 1)
+  call void @"fmt.Sprintf"()
+  ret void
+}
+
+define internal void @"fmt.Sprintf"() {
+  ret void
+}
+
+define internal void @"runtime.alloc"() {
+  ret void
+}
+
+define internal void @"runtime.unused"() {
+  call void @"runtime.alloc"()
+  ret void
+}
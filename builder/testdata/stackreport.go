@@ -0,0 +1,18 @@
+package main
+
+import "time"
+
+func main() {
+	go worker()
+	for {
+		println("main")
+		time.Sleep(time.Second)
+	}
+}
+
+func worker() {
+	for {
+		println("worker")
+		time.Sleep(time.Second)
+	}
+}
@@ -103,9 +103,58 @@ func (c *Config) BuildTags() []string {
 		tags = append(tags, fmt.Sprintf("go1.%d", i))
 	}
 	tags = append(tags, c.Options.Tags...)
+	if c.TestConfig.CPUProfile != "" && !c.hasTag("tinygo.pprof") {
+		// A CPU profile was requested for the test binary, so build it with
+		// profiling support.
+		tags = append(tags, "tinygo.pprof")
+	}
+	if c.TestConfig.Trace != "" && !c.hasTag("tinygo.trace") {
+		// An execution trace was requested for the test binary, so build it
+		// with the tracer.
+		tags = append(tags, "tinygo.trace")
+	}
+	if c.Options.StackCheck && !c.hasTag("tinygo.stackcheck") {
+		// Paint goroutine stacks and check them on every context switch.
+		tags = append(tags, "tinygo.stackcheck")
+	}
 	return tags
 }
 
+// hasTag returns whether the given build tag was passed with -tags.
+func (c *Config) hasTag(tag string) bool {
+	for _, t := range c.Options.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Profiling returns whether the program is built with support for the
+// runtime/pprof profilers. This is the case when the tinygo.pprof build tag is
+// set, either explicitly or by requesting a CPU profile from a test binary.
+// Profiling builds keep frame pointers in all functions so that the runtime can
+// walk the stack cheaply.
+func (c *Config) Profiling() bool {
+	return c.TestConfig.CPUProfile != "" || c.hasTag("tinygo.pprof")
+}
+
+// StackCheck returns whether goroutine stacks are painted and checked for
+// overflows on every context switch. This is the case with -stack-check or
+// when the tinygo.stackcheck build tag is set.
+func (c *Config) StackCheck() bool {
+	return c.Options.StackCheck || c.hasTag("tinygo.stackcheck")
+}
+
+// WasmCanonicalABI returns whether //go:wasmimport and //go:wasmexport
+// functions may use strings, slices, structs and error results, which the
+// compiler then lowers using the component model canonical ABI. This is the
+// case when building a component (when a WIT package is set, like on wasip2)
+// or when the tinygo.wasmcanonicalabi build tag is set.
+func (c *Config) WasmCanonicalABI() bool {
+	return c.Options.WITPackage != "" || c.Target.WITPackage != "" || c.hasTag("tinygo.wasmcanonicalabi")
+}
+
 // GC returns the garbage collection strategy in use on this platform. Valid
 // values are "none", "leaking", "conservative" and "precise".
 func (c *Config) GC() string {
@@ -433,6 +482,16 @@ func (c *Config) ExtraFiles() []string {
 	return c.Target.ExtraFiles
 }
 
+// SizeBudget returns the size budget for the program, or nil if there is none.
+// A budget file passed with the -size-budget flag takes precedence over the
+// budget in the target JSON.
+func (c *Config) SizeBudget() (*SizeBudget, error) {
+	if c.Options.SizeBudget != "" {
+		return LoadSizeBudget(c.Options.SizeBudget)
+	}
+	return c.Target.SizeBudget, nil
+}
+
 // DumpSSA returns whether to dump Go SSA while compiling (-dumpssa flag). Only
 // enable this for debugging.
 func (c *Config) DumpSSA() bool {
@@ -620,4 +679,6 @@ type TestConfig struct {
 	BenchTime         string
 	BenchMem          bool
 	Shuffle           string
+	CPUProfile        string
+	Trace             string
 }
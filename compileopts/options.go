@@ -12,7 +12,8 @@ var (
 	validGCOptions            = []string{"none", "leaking", "conservative", "custom", "precise", "boehm"}
 	validSchedulerOptions     = []string{"none", "tasks", "asyncify"}
 	validSerialOptions        = []string{"none", "uart", "usb", "rtt"}
-	validPrintSizeOptions     = []string{"none", "short", "full", "html"}
+	validPrintSizeOptions     = []string{"none", "short", "full", "html", "json", "csv"}
+	validStackReportOptions   = []string{"table", "json"}
 	validPanicStrategyOptions = []string{"print", "trap"}
 	validOptOptions           = []string{"none", "0", "1", "2", "s", "z"}
 )
@@ -44,8 +45,12 @@ type Options struct {
 	Semaphore       chan struct{}                    `json:"-"` // -p flag controls cap
 	Debug           bool
 	PrintSizes      string
+	SizeBudget      string         // -size-budget flag: path to a JSON file with size budgets
+	SizeBaseline    string         // -size-baseline flag: path to a -size=json report to compare against
 	PrintAllocs     *regexp.Regexp // regexp string
 	PrintStacks     bool
+	StackReport     string // -stack-report flag: "table" or "json"
+	StackCheck      bool   // -stack-check flag: paint and check goroutine stacks
 	Tags            []string
 	GlobalValues    map[string]map[string]string // map[pkgpath]map[varname]value
 	TestConfig      TestConfig
@@ -100,13 +105,26 @@ func (o *Options) Verify() error {
 
 	if o.PrintSizes != "" {
 		valid := isInArray(validPrintSizeOptions, o.PrintSizes)
+		if strings.HasPrefix(o.PrintSizes, "why:") && len(o.PrintSizes) > len("why:") {
+			// -size=why:<symbol or package>
+			valid = true
+		}
 		if !valid {
-			return fmt.Errorf(`invalid size option '%s': valid values are %s`,
+			return fmt.Errorf(`invalid size option '%s': valid values are %s, why:<symbol or package>`,
 				o.PrintSizes,
 				strings.Join(validPrintSizeOptions, ", "))
 		}
 	}
 
+	if o.StackReport != "" {
+		valid := isInArray(validStackReportOptions, o.StackReport)
+		if !valid {
+			return fmt.Errorf(`invalid stack report option '%s': valid values are %s`,
+				o.StackReport,
+				strings.Join(validStackReportOptions, ", "))
+		}
+	}
+
 	if o.PanicStrategy != "" {
 		valid := isInArray(validPanicStrategyOptions, o.PanicStrategy)
 		if !valid {
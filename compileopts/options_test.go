@@ -11,7 +11,7 @@ func TestVerifyOptions(t *testing.T) {
 
 	expectedGCError := errors.New(`invalid gc option 'incorrect': valid values are none, leaking, conservative, custom, precise, boehm`)
 	expectedSchedulerError := errors.New(`invalid scheduler option 'incorrect': valid values are none, tasks, asyncify`)
-	expectedPrintSizeError := errors.New(`invalid size option 'incorrect': valid values are none, short, full, html`)
+	expectedPrintSizeError := errors.New(`invalid size option 'incorrect': valid values are none, short, full, html, json, csv, why:<symbol or package>`)
 	expectedPanicStrategyError := errors.New(`invalid panic option 'incorrect': valid values are print, trap`)
 
 	testCases := []struct {
@@ -98,6 +98,44 @@ func TestVerifyOptions(t *testing.T) {
 				PrintSizes: "full",
 			},
 		},
+		{
+			name: "PrintSizeOptionJSON",
+			opts: compileopts.Options{
+				PrintSizes: "json",
+			},
+		},
+		{
+			name: "PrintSizeOptionCSV",
+			opts: compileopts.Options{
+				PrintSizes: "csv",
+			},
+		},
+		{
+			name: "PrintSizeOptionWhy",
+			opts: compileopts.Options{
+				PrintSizes: "why:fmt",
+			},
+		},
+		{
+			name: "InvalidPrintSizeOptionWhy",
+			opts: compileopts.Options{
+				PrintSizes: "why:",
+			},
+			expectedError: errors.New(`invalid size option 'why:': valid values are none, short, full, html, json, csv, why:<symbol or package>`),
+		},
+		{
+			name: "StackReportOptionJSON",
+			opts: compileopts.Options{
+				StackReport: "json",
+			},
+		},
+		{
+			name: "InvalidStackReportOption",
+			opts: compileopts.Options{
+				StackReport: "incorrect",
+			},
+			expectedError: errors.New(`invalid stack report option 'incorrect': valid values are table, json`),
+		},
 		{
 			name: "InvalidPanicOption",
 			opts: compileopts.Options{
@@ -0,0 +1,40 @@
+package compileopts
+
+// This file loads size budgets, which limit how large a program is allowed to
+// be.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SizeBudget is the maximum size of a program, and optionally of individual
+// packages in it. Sizes are in bytes, and a zero size means there is no limit.
+// It can be set in the target JSON ("size-budget") or in a separate JSON file
+// passed with the -size-budget flag.
+type SizeBudget struct {
+	Flash    uint64                       `json:"flash,omitempty"`
+	RAM      uint64                       `json:"ram,omitempty"`
+	Packages map[string]PackageSizeBudget `json:"packages,omitempty"`
+}
+
+// PackageSizeBudget is the maximum size of a single package in a program.
+type PackageSizeBudget struct {
+	Flash uint64 `json:"flash,omitempty"`
+	RAM   uint64 `json:"ram,omitempty"`
+}
+
+// LoadSizeBudget reads a size budget from the given JSON file.
+func LoadSizeBudget(path string) (*SizeBudget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	budget := &SizeBudget{}
+	err = json.Unmarshal(data, budget)
+	if err != nil {
+		return nil, fmt.Errorf("could not read size budget %s: %w", path, err)
+	}
+	return budget, nil
+}
@@ -67,6 +67,9 @@ type TargetSpec struct {
 	RelocationModel  string   `json:"relocation-model,omitempty"`
 	WITPackage       string   `json:"wit-package,omitempty"`
 	WITWorld         string   `json:"wit-world,omitempty"`
+
+	// Maximum program size, checked after linking.
+	SizeBudget *SizeBudget `json:"size-budget,omitempty"`
 }
 
 // overrideProperties overrides all properties that are set in child into itself using reflection.
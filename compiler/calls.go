@@ -13,6 +13,8 @@ import (
 
 // The maximum number of arguments that can be expanded from a single struct. If
 // a struct contains more fields, it is passed as a struct without expanding.
+// This must match maxFieldsPerParam in internal/reflectlite, see
+// TestExpandFormalParamType.
 const maxFieldsPerParam = 3
 
 // paramInfo contains some information collected about a function parameter,
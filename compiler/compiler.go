@@ -59,6 +59,9 @@ type Config struct {
 	NeedsStackObjects  bool
 	Debug              bool // Whether to emit debug information in the LLVM module.
 	PanicStrategy      string
+	FramePointers      bool // Keep frame pointers, for runtime/pprof.
+	StackCheck         bool // Pass goroutine names to internal/task, for -stack-check.
+	WasmCanonicalABI   bool // Lower //go:wasmimport and //go:wasmexport using the component model canonical ABI.
 }
 
 // compilerContext contains function-independent data that should still be
@@ -844,6 +847,12 @@ func (c *compilerContext) createPackage(irbuilder llvm.Builder, pkg *ssa.Package
 				// with a LLVM intrinsic.
 				continue
 			}
+			if member.Blocks == nil && b.info.wasmCABI {
+				// This is a //go:wasmimport function that needs a wrapper to
+				// convert to the canonical ABI.
+				b.createWasmImport()
+				continue
+			}
 			if member.Blocks == nil {
 				// Try to define this as an intrinsic function.
 				b.defineIntrinsicFunction()
@@ -1380,6 +1389,11 @@ func (b *builder) createFunction() {
 		}
 	}
 
+	if b.hasDeferFrame() && b.hasWasmExceptions() {
+		// Make sure panics in called functions continue at the landing pad.
+		b.createWasmInvokes()
+	}
+
 	// Create anonymous functions (closures etc.).
 	for _, sub := range b.fn.AnonFuncs {
 		b := newBuilder(b.compilerContext, b.Builder, sub)
@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"flag"
+	"go/token"
 	"go/types"
 	"os"
 	"strconv"
@@ -44,12 +45,20 @@ func TestCompiler(t *testing.T) {
 		{"interface.go", "", ""},
 		{"func.go", "", ""},
 		{"defer.go", "cortex-m-qemu", ""},
+		{"defer.go", "riscv64-qemu", ""},
 		{"pragma.go", "", ""},
 		{"goroutine.go", "wasm", "asyncify"},
 		{"goroutine.go", "cortex-m-qemu", "tasks"},
 		{"channel.go", "", ""},
 		{"gc.go", "", ""},
 		{"zeromap.go", "", ""},
+		{"wasmcabi.go", "wasip2", ""},
+	}
+	if _, err := llvm.GetTargetFromTriple("xtensa"); err == nil {
+		// The Xtensa backend is only available in the Espressif LLVM fork.
+		tests = append(tests,
+			testCase{"defer.go", "esp32", ""},
+			testCase{"defer.go", "esp8266", ""})
 	}
 	if goMinor >= 20 {
 		tests = append(tests, testCase{"go1.20.go", "", ""})
@@ -180,36 +189,50 @@ func filterIrrelevantIRLines(lines []string) []string {
 func TestCompilerErrors(t *testing.T) {
 	t.Parallel()
 
-	// Read expected errors from the test file.
-	var expectedErrors []string
-	errorsFile, err := os.ReadFile("testdata/errors.go")
-	if err != nil {
-		t.Error(err)
-	}
-	errorsFileString := strings.ReplaceAll(string(errorsFile), "\r\n", "\n")
-	for _, line := range strings.Split(errorsFileString, "\n") {
-		if strings.HasPrefix(line, "// ERROR: ") {
-			expectedErrors = append(expectedErrors, strings.TrimPrefix(line, "// ERROR: "))
-		}
+	tests := []struct {
+		file   string
+		target string
+	}{
+		{"errors.go", "wasm"},
+		{"errors-cabi.go", "wasip2"},
 	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.file, func(t *testing.T) {
+			t.Parallel()
 
-	// Compile the Go file with errors.
-	options := &compileopts.Options{
-		Target: "wasm",
-	}
-	_, errs := testCompilePackage(t, options, "errors.go")
+			// Read expected errors from the test file.
+			var expectedErrors []string
+			errorsFile, err := os.ReadFile("testdata/" + tc.file)
+			if err != nil {
+				t.Error(err)
+			}
+			errorsFileString := strings.ReplaceAll(string(errorsFile), "\r\n", "\n")
+			for _, line := range strings.Split(errorsFileString, "\n") {
+				if strings.HasPrefix(line, "// ERROR: ") {
+					expectedErrors = append(expectedErrors, strings.TrimPrefix(line, "// ERROR: "))
+				}
+			}
 
-	// Check whether the actual errors match the expected errors.
-	expectedErrorsIdx := 0
-	for _, err := range errs {
-		err := err.(types.Error)
-		position := err.Fset.Position(err.Pos)
-		position.Filename = "errors.go" // don't use a full path
-		if expectedErrorsIdx >= len(expectedErrors) || expectedErrors[expectedErrorsIdx] != err.Msg {
-			t.Errorf("unexpected compiler error: %s: %s", position.String(), err.Msg)
-			continue
-		}
-		expectedErrorsIdx++
+			// Compile the Go file with errors.
+			options := &compileopts.Options{
+				Target: tc.target,
+			}
+			_, errs := testCompilePackage(t, options, tc.file)
+
+			// Check whether the actual errors match the expected errors.
+			expectedErrorsIdx := 0
+			for _, err := range errs {
+				err := err.(types.Error)
+				position := err.Fset.Position(err.Pos)
+				position.Filename = tc.file // don't use a full path
+				if expectedErrorsIdx >= len(expectedErrors) || expectedErrors[expectedErrorsIdx] != err.Msg {
+					t.Errorf("unexpected compiler error: %s: %s", position.String(), err.Msg)
+					continue
+				}
+				expectedErrorsIdx++
+			}
+		})
 	}
 }
 
@@ -235,6 +258,7 @@ func testCompilePackage(t *testing.T, options *compileopts.Options, file string)
 		AutomaticStackSize: config.AutomaticStackSize(),
 		DefaultStackSize:   config.StackSize(),
 		NeedsStackObjects:  config.NeedsStackObjects(),
+		WasmCanonicalABI:   config.WasmCanonicalABI(),
 	}
 	machine, err := NewTargetMachine(compilerConfig)
 	if err != nil {
@@ -259,3 +283,102 @@ func testCompilePackage(t *testing.T, options *compileopts.Options, file string)
 	pkg := lprogram.MainPkg()
 	return CompilePackage(file, pkg, program.Package(pkg.Pkg), machine, compilerConfig, false)
 }
+
+// Test how parameters are expanded into LLVM parameters. These rules are also
+// implemented by abiParams in internal/reflectlite for reflect.MakeFunc (which
+// is run by testdata/makefunc.go), so both must be updated together.
+func TestExpandFormalParamType(t *testing.T) {
+	t.Parallel()
+
+	options := &compileopts.Options{Target: "cortex-m-qemu"}
+	target, err := compileopts.LoadTarget(options)
+	if err != nil {
+		t.Fatal("failed to load target:", err)
+	}
+	config := &compileopts.Config{
+		Options: options,
+		Target:  target,
+	}
+	compilerConfig := &Config{
+		Triple:   config.Triple(),
+		Features: config.Features(),
+		GOOS:     config.GOOS(),
+		GOARCH:   config.GOARCH(),
+	}
+	machine, err := NewTargetMachine(compilerConfig)
+	if err != nil {
+		t.Fatal("failed to create target machine:", err)
+	}
+	defer machine.Dispose()
+	c := newCompilerContext("test", machine, compilerConfig, false)
+	defer c.ctx.Dispose()
+	defer c.mod.Dispose()
+	defer c.dispose()
+
+	field := func(name string, typ types.Type) *types.Var {
+		return types.NewField(token.NoPos, nil, name, typ, false)
+	}
+	structOf := func(fields ...types.Type) *types.Struct {
+		var vars []*types.Var
+		for i, typ := range fields {
+			vars = append(vars, field("F"+strconv.Itoa(i), typ))
+		}
+		return types.NewStruct(vars, nil)
+	}
+	var (
+		boolType    = types.Typ[types.Bool]
+		int8Type    = types.Typ[types.Int8]
+		int32Type   = types.Typ[types.Int32]
+		int64Type   = types.Typ[types.Int64]
+		uint16Type  = types.Typ[types.Uint16]
+		float32Type = types.Typ[types.Float32]
+		float64Type = types.Typ[types.Float64]
+	)
+	var (
+		i1     = c.ctx.Int1Type()
+		i8     = c.ctx.Int8Type()
+		i16    = c.ctx.Int16Type()
+		i32    = c.ctx.Int32Type()
+		i64    = c.ctx.Int64Type()
+		float  = c.ctx.FloatType()
+		double = c.ctx.DoubleType()
+	)
+	llvmStruct := func(fields ...llvm.Type) llvm.Type {
+		return c.ctx.StructType(fields, false)
+	}
+
+	tests := []struct {
+		goType types.Type
+		params []llvm.Type
+	}{
+		{int32Type, []llvm.Type{i32}},
+		{types.Typ[types.Complex128], []llvm.Type{double, double}},
+		// Structs are flattened if they have at most maxFieldsPerParam fields.
+		{structOf(int32Type, int32Type, int32Type), []llvm.Type{i32, i32, i32}},
+		{structOf(int32Type, int32Type, int32Type, int32Type), []llvm.Type{llvmStruct(i32, i32, i32, i32)}},
+		{structOf(int8Type, float32Type, int64Type), []llvm.Type{i8, float, i64}},
+		// Nested structs are flattened too, and count towards the limit.
+		{structOf(structOf(float64Type, boolType), uint16Type), []llvm.Type{double, i1, i16}},
+		{structOf(structOf(float64Type, float64Type), uint16Type, uint16Type), []llvm.Type{llvmStruct(llvmStruct(double, double), i16, i16)}},
+		{structOf(types.Typ[types.Complex64], types.Typ[types.Complex64]), []llvm.Type{llvmStruct(llvmStruct(float, float), llvmStruct(float, float))}},
+		// Zero-sized fields are skipped.
+		{structOf(structOf(), int32Type, types.NewArray(int64Type, 0)), []llvm.Type{i32}},
+		// Arrays are never split.
+		{types.NewArray(int32Type, 4), []llvm.Type{llvm.ArrayType(i32, 4)}},
+		{structOf(types.NewArray(int32Type, 2), int32Type), []llvm.Type{llvm.ArrayType(i32, 2), i32}},
+	}
+	for _, tc := range tests {
+		infos := c.expandFormalParamType(c.getLLVMType(tc.goType), "", tc.goType)
+		ok := len(infos) == len(tc.params)
+		for i := 0; ok && i < len(infos); i++ {
+			ok = infos[i].llvmType == tc.params[i]
+		}
+		if !ok {
+			var params []llvm.Type
+			for _, info := range infos {
+				params = append(params, info.llvmType)
+			}
+			t.Errorf("%s: expected params %v, got %v", tc.goType, tc.params, params)
+		}
+	}
+}
@@ -28,18 +28,22 @@ import (
 func (b *builder) supportsRecover() bool {
 	switch b.archFamily() {
 	case "wasm32":
-		// Probably needs to be implemented using the exception handling
-		// proposal of WebAssembly:
-		// https://github.com/WebAssembly/exception-handling
-		return false
-	case "riscv64", "xtensa":
-		// TODO: add support for these architectures
-		return false
+		// Panics are implemented as WebAssembly exceptions, which are only
+		// available with the exception-handling target feature. Without it,
+		// a panic always aborts the program.
+		return b.hasWasmExceptions()
 	default:
 		return true
 	}
 }
 
+// hasWasmExceptions returns whether the WebAssembly exception handling proposal
+// (https://github.com/WebAssembly/exception-handling) is used to implement
+// panics.
+func (b *builder) hasWasmExceptions() bool {
+	return b.archFamily() == "wasm32" && strings.Contains(b.Features, "+exception-handling")
+}
+
 // hasDeferFrame returns whether the current function needs to catch panics and
 // run defers.
 func (b *builder) hasDeferFrame() bool {
@@ -119,6 +123,10 @@ func (b *builder) createInvokeCheckpoint() {
 	var asmString, constraints string
 	resultType := b.uintptrType
 	switch b.archFamily() {
+	case "wasm32":
+		// Panics are thrown as a WebAssembly exception, not as a longjmp. The
+		// call is turned into an invoke in createWasmInvokes instead.
+		return
 	case "i386":
 		asmString = `
 xorl %eax, %eax
@@ -213,6 +221,35 @@ sw a2, 4(a1)
 li a0, 0
 1:`
 		constraints = "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"
+	case "riscv64":
+		asmString = `
+la a2, 1f
+sd a2, 8(a1)
+li a0, 0
+1:`
+		constraints = "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"
+	case "xtensa":
+		// Xtensa has no instruction to load the address of a label, so call0
+		// is used to obtain the jump PC. This overwrites a0, which is the
+		// return address (and, with the windowed ABI, the caller register
+		// window) so it can't be marked as clobbered. Instead it is saved in
+		// the defer frame, together with a15 (the frame pointer, if used).
+		// tinygo_longjmp restores both.
+		asmString = `
+s32i a0, a3, 8
+s32i a15, a3, 12
+movi a2, 0
+call0 2f
+j 3f
+.align 4
+2:
+s32i a0, a3, 4
+l32i a0, a3, 8
+3:`
+		constraints = "={a2},{a3},~{a3},~{a4},~{a5},~{a6},~{a7},~{a8},~{a9},~{a10},~{a11},~{a12},~{a13},~{a14},~{memory}"
+		if strings.Contains(b.Features, "+fp") {
+			constraints += ",~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15}"
+		}
 	default:
 		// This case should have been handled by b.supportsRecover().
 		b.addError(b.fn.Pos(), "unknown architecture for defer: "+b.archFamily())
@@ -228,6 +265,86 @@ li a0, 0
 	b.blockExits[b.currentBlock] = continueBB
 }
 
+// Runtime functions that are never turned into an invoke by createWasmInvokes.
+// Calls to these functions are rewritten by later transformation passes that
+// expect a regular call instruction, and they can't panic (except when running
+// out of memory, which can't be recovered anyway). The exception is
+// destroyDeferFrame, which re-raises a panic in the parent frame so must not
+// be caught by the current function.
+var wasmNoInvokeFunctions = map[string]struct{}{
+	"runtime.alloc":             {},
+	"runtime.destroyDeferFrame": {},
+	"runtime.hashmapMake":       {},
+	"runtime.stringEqual":       {},
+	"runtime.stringToBytes":     {},
+	"runtime.trackPointer":      {},
+	"runtime.typeAssert":        {},
+}
+
+// createWasmInvokes turns the calls in a function with a defer frame into
+// invokes that continue at the landing pad when the callee panics. On
+// WebAssembly, a panic throws a WebAssembly exception (see tinygo_longjmp in
+// the runtime) instead of jumping to the last checkpoint, so every call that
+// might panic needs to catch it. This must be done after the whole function
+// has been created, as it splits basic blocks.
+func (b *builder) createWasmInvokes() {
+	// Collect all calls that might panic.
+	var calls []llvm.Value
+	for bb := b.llvmFn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+		for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+			if inst.IsACallInst().IsNil() {
+				continue
+			}
+			callee := inst.CalledValue()
+			if !callee.IsAInlineAsm().IsNil() {
+				continue
+			}
+			if !callee.IsAFunction().IsNil() {
+				name := callee.Name()
+				if _, ok := wasmNoInvokeFunctions[name]; ok || strings.HasPrefix(name, "llvm.") {
+					continue
+				}
+			}
+			calls = append(calls, inst)
+		}
+	}
+	if len(calls) == 0 {
+		return
+	}
+
+	// Create a catch-all handler that continues at the landing pad. The
+	// personality function is required by LLVM, but isn't called for a
+	// catch-all handler.
+	personality := b.mod.NamedFunction("__gxx_wasm_personality_v0")
+	if personality.IsNil() {
+		personality = llvm.AddFunction(b.mod, "__gxx_wasm_personality_v0", llvm.FunctionType(b.ctx.Int32Type(), nil, true))
+	}
+	b.llvmFn.SetPersonality(personality)
+	dispatch := b.ctx.AddBasicBlock(b.llvmFn, "catch.dispatch")
+	catch := b.ctx.AddBasicBlock(b.llvmFn, "catch")
+	b.SetInsertPointAtEnd(dispatch)
+	catchSwitch := b.CreateCatchSwitch(llvm.Value{}, llvm.BasicBlock{}, 1, "")
+	catchSwitch.AddHandler(catch)
+	b.SetInsertPointAtEnd(catch)
+	catchPad := b.CreateCatchPad(catchSwitch, []llvm.Value{llvm.ConstPointerNull(b.dataPtrType)}, "")
+	b.CreateCatchRet(catchPad, b.landingpad)
+
+	// Replace each call with an invoke.
+	for _, call := range calls {
+		cont := llvmutil.SplitBasicBlock(b.Builder, call, call.InstructionParent(), "invoke.cont")
+		args := make([]llvm.Value, call.OperandsCount()-1)
+		for i := range args {
+			args[i] = call.Operand(i)
+		}
+		b.SetInsertPointBefore(call)
+		invoke := b.CreateInvoke(call.CalledFunctionType(), call.CalledValue(), args, cont, dispatch, "")
+		invoke.SetInstructionCallConv(call.InstructionCallConv())
+		invoke.InstructionSetDebugLoc(call.InstructionDebugLoc())
+		call.ReplaceAllUsesWith(invoke)
+		call.EraseFromParentAsInstruction()
+	}
+}
+
 // isInLoop checks if there is a path from a basic block to itself.
 func isInLoop(start *ssa.BasicBlock) bool {
 	// Use a breadth-first search to scan backwards through the block graph.
@@ -4,6 +4,7 @@ package compiler
 // goroutine-lowering.go for more details.
 
 import (
+	"go/constant"
 	"go/token"
 	"go/types"
 
@@ -118,10 +119,35 @@ func (b *builder) createGo(instr *ssa.Go) {
 		}
 		stackSize = llvm.ConstInt(b.uintptrType, b.DefaultStackSize, false)
 	}
+	if b.StackCheck {
+		// Pass the name of the goroutine start function, so that it can be
+		// reported when the goroutine overflows its stack.
+		name := b.createConst(ssa.NewConst(constant.MakeString(goroutineName(instr)), types.Typ[types.String]), instr.Pos())
+		fnType, start := b.getFunction(b.program.ImportedPackage("internal/task").Members["startNamed"].(*ssa.Function))
+		b.createCall(fnType, start, []llvm.Value{callee, paramBundle, stackSize, name, llvm.Undef(b.dataPtrType)}, "")
+		return
+	}
 	fnType, start := b.getFunction(b.program.ImportedPackage("internal/task").Members["start"].(*ssa.Function))
 	b.createCall(fnType, start, []llvm.Value{callee, paramBundle, stackSize, llvm.Undef(b.dataPtrType)}, "")
 }
 
+// goroutineName returns a human readable name for the function started by the
+// given go statement.
+func goroutineName(instr *ssa.Go) string {
+	if callee := instr.Call.StaticCallee(); callee != nil {
+		if parent := callee.Parent(); parent != nil && parent.RelString(nil) == "runtime.run" {
+			// The goroutine started by the runtime to run the init functions
+			// and main.main.
+			return "main"
+		}
+		return callee.RelString(nil)
+	}
+	if instr.Call.IsInvoke() {
+		return instr.Call.Method.FullName()
+	}
+	return "func value in " + instr.Parent().RelString(nil)
+}
+
 // Create an exported wrapper function for functions with the //go:wasmexport
 // pragma. This wrapper function is quite complex when the scheduler is enabled:
 // it needs to start a new goroutine each time the exported function is called.
@@ -139,6 +165,10 @@ func (b *builder) createWasmExport() {
 	// Declare the exported function.
 	paramTypes := b.llvmFnType.ParamTypes()
 	exportedFnType := llvm.FunctionType(b.llvmFnType.ReturnType(), paramTypes[:len(paramTypes)-1], false)
+	if b.info.wasmCABI {
+		// Parameters and results are converted from/to the canonical ABI.
+		exportedFnType = b.getCABISignature(b.fn.Signature).liftedFunctionType(b.compilerContext)
+	}
 	exportedFn := llvm.AddFunction(b.mod, b.fn.RelString(nil)+suffix, exportedFnType)
 	b.addStandardAttributes(exportedFn)
 	llvmutil.AppendToGlobal(b.mod, "llvm.used", exportedFn)
@@ -167,17 +197,29 @@ func (b *builder) createWasmExport() {
 	// is initialized).
 	builder.createRuntimeCall("wasmExportCheckRun", nil, "")
 
+	// Convert the parameters to Go values if needed.
+	params := exportedFn.Params()
+	if b.info.wasmCABI {
+		params = builder.liftCABIParams(params)
+	}
+
+	// Return the result to the caller, converting it if needed.
+	createReturn := func(retval llvm.Value) {
+		if b.info.wasmCABI {
+			builder.createCABIReturn(exportedFn.Name(), retval)
+		} else if b.fn.Signature.Results() == nil {
+			builder.CreateRetVoid()
+		} else {
+			builder.CreateRet(retval)
+		}
+	}
+
 	if b.Scheduler == "none" {
 		// When the scheduler has been disabled, this is really trivial: just
 		// call the function.
-		params := exportedFn.Params()
 		params = append(params, llvm.ConstNull(b.dataPtrType)) // context parameter
 		retval := builder.CreateCall(b.llvmFnType, b.llvmFn, params, "")
-		if b.fn.Signature.Results() == nil {
-			builder.CreateRetVoid()
-		} else {
-			builder.CreateRet(retval)
-		}
+		createReturn(retval)
 
 	} else {
 		// The scheduler is enabled, so we need to start a new goroutine, wait
@@ -212,7 +254,10 @@ func (b *builder) createWasmExport() {
 		// Build the state struct type.
 		// It stores the function parameters, the 'done' flag, and reserves
 		// space for a return value if needed.
-		stateFields := exportedFnType.ParamTypes()
+		var stateFields []llvm.Type
+		for _, param := range params {
+			stateFields = append(stateFields, param.Type())
+		}
 		numParams := len(stateFields)
 		stateFields = append(stateFields, b.ctx.Int1Type()) // 'done' field
 		if hasReturn {
@@ -231,7 +276,7 @@ func (b *builder) createWasmExport() {
 		builder.CreateStore(llvm.ConstNull(b.ctx.Int1Type()), doneGEP)
 
 		// Store all parameters in the state object.
-		for i, param := range exportedFn.Params() {
+		for i, param := range params {
 			gep := builder.CreateInBoundsGEP(stateStruct, statePtr, []llvm.Value{
 				llvm.ConstInt(b.ctx.Int32Type(), 0, false),
 				llvm.ConstInt(b.ctx.Int32Type(), uint64(i), false),
@@ -256,9 +301,9 @@ func (b *builder) createWasmExport() {
 				llvm.ConstInt(b.ctx.Int32Type(), uint64(numParams)+1, false),
 			}, "")
 			retval := builder.CreateLoad(b.llvmFnType.ReturnType(), gep, "retval")
-			builder.CreateRet(retval)
+			createReturn(retval)
 		} else {
-			builder.CreateRetVoid()
+			createReturn(llvm.Value{})
 		}
 	}
 }
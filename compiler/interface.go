@@ -140,6 +140,10 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 		}
 	}
 
+	// Concrete types with exported methods also get a method table, which is
+	// used by reflect to implement Type.Method and Value.Method.
+	hasMethodTable := !isInterface && numMethods != 0
+
 	// Short-circuit all the global pointer logic here for pointers to pointers.
 	if typ, ok := typ.(*types.Pointer); ok {
 		if _, ok := typ.Elem().(*types.Pointer); ok {
@@ -196,6 +200,13 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				types.NewVar(token.NoPos, nil, "ptrTo", types.Typ[types.UnsafePointer]),
 				types.NewVar(token.NoPos, nil, "underlying", types.Typ[types.UnsafePointer]),
 				types.NewVar(token.NoPos, nil, "pkgpath", types.Typ[types.UnsafePointer]),
+			)
+			if hasMethodTable {
+				typeFieldTypes = append(typeFieldTypes,
+					types.NewVar(token.NoPos, nil, "methods", types.Typ[types.UnsafePointer]),
+				)
+			}
+			typeFieldTypes = append(typeFieldTypes,
 				types.NewVar(token.NoPos, nil, "name", types.NewArray(types.Typ[types.Int8], int64(len(pkgname)+1+len(name)+1))),
 			)
 		case *types.Chan:
@@ -215,6 +226,11 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				types.NewVar(token.NoPos, nil, "numMethods", types.Typ[types.Uint16]),
 				types.NewVar(token.NoPos, nil, "elementType", types.Typ[types.UnsafePointer]),
 			)
+			if hasMethodTable {
+				typeFieldTypes = append(typeFieldTypes,
+					types.NewVar(token.NoPos, nil, "methods", types.Typ[types.UnsafePointer]),
+				)
+			}
 		case *types.Array:
 			typeFieldTypes = append(typeFieldTypes,
 				types.NewVar(token.NoPos, nil, "numMethods", types.Typ[types.Uint16]),
@@ -239,16 +255,27 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				types.NewVar(token.NoPos, nil, "numFields", types.Typ[types.Uint16]),
 				types.NewVar(token.NoPos, nil, "fields", types.NewArray(c.getRuntimeType("structField"), int64(typ.NumFields()))),
 			)
+			if hasMethodTable {
+				typeFieldTypes = append(typeFieldTypes,
+					types.NewVar(token.NoPos, nil, "methods", types.Typ[types.UnsafePointer]),
+				)
+			}
 		case *types.Interface:
 			typeFieldTypes = append(typeFieldTypes,
+				types.NewVar(token.NoPos, nil, "numMethods", types.Typ[types.Uint16]),
 				types.NewVar(token.NoPos, nil, "ptrTo", types.Typ[types.UnsafePointer]),
+				types.NewVar(token.NoPos, nil, "implements", interfaceImplementsSignature),
+				types.NewVar(token.NoPos, nil, "methods", types.NewArray(interfaceMethodType, int64(typ.NumMethods()))),
 			)
-			// TODO: methods
 		case *types.Signature:
 			typeFieldTypes = append(typeFieldTypes,
+				types.NewVar(token.NoPos, nil, "numIn", types.Typ[types.Uint16]),
 				types.NewVar(token.NoPos, nil, "ptrTo", types.Typ[types.UnsafePointer]),
+				types.NewVar(token.NoPos, nil, "call", reflectCallSignature),
+				types.NewVar(token.NoPos, nil, "numOut", types.Typ[types.Uint16]),
+				types.NewVar(token.NoPos, nil, "variadic", types.Typ[types.Bool]),
+				types.NewVar(token.NoPos, nil, "params", types.NewArray(types.Typ[types.UnsafePointer], int64(typ.Params().Len()+typ.Results().Len()))),
 			)
-			// TODO: signature params and return values
 		}
 		if hasMethodSet {
 			// This method set is appended at the start of the struct. It is
@@ -294,8 +321,12 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				c.getTypeCode(types.NewPointer(typ)),                        // ptrTo
 				c.getTypeCode(typ.Underlying()),                             // underlying
 				pkgPathPtr,                                                  // pkgpath pointer
-				c.ctx.ConstString(pkgname+"."+name+"\x00", false),           // name
 			}
+			if hasMethodTable {
+				typeFields = append(typeFields, c.getTypeMethodTable(typ, globalName, isLocal))
+			}
+			typeFields = append(typeFields, c.ctx.ConstString(pkgname+"."+name+"\x00", false)) // name
+
 			metabyte |= 1 << 5 // "named" flag
 		case *types.Chan:
 			var dir reflectChanDir
@@ -324,6 +355,9 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				llvm.ConstInt(c.ctx.Int16Type(), uint64(numMethods), false), // numMethods
 				c.getTypeCode(typ.Elem()),
 			}
+			if hasMethodTable {
+				typeFields = append(typeFields, c.getTypeMethodTable(typ, globalName, isLocal))
+			}
 		case *types.Array:
 			typeFields = []llvm.Value{
 				llvm.ConstInt(c.ctx.Int16Type(), 0, false),             // numMethods
@@ -404,12 +438,67 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				}))
 			}
 			typeFields = append(typeFields, llvm.ConstArray(structFieldType, fields))
+			if hasMethodTable {
+				typeFields = append(typeFields, c.getTypeMethodTable(typ, globalName, isLocal))
+			}
 		case *types.Interface:
-			typeFields = []llvm.Value{c.getTypeCode(types.NewPointer(typ))}
-			// TODO: methods
+			// The implements function checks whether a concrete type
+			// implements this interface, see getInterfaceImplementsFunc.
+			// It is removed by the OptimizeReflectCalls pass if the
+			// program never uses it.
+			implements := llvm.ConstPointerNull(c.funcPtrType)
+			if typ.NumMethods() != 0 {
+				implements = c.getInterfaceImplementsFunc(typ)
+			}
+			typeFields = []llvm.Value{
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(numMethods), false), // numMethods
+				c.getTypeCode(types.NewPointer(typ)),                        // ptrTo
+				c.ctx.ConstStruct([]llvm.Value{
+					llvm.ConstPointerNull(c.dataPtrType),
+					implements,
+				}, false), // implements
+			}
+			methodType := c.getLLVMType(interfaceMethodType)
+			var methods []llvm.Value
+			for i := 0; i < typ.NumMethods(); i++ {
+				method := typ.Method(i)
+				var pkgpath string
+				if !method.Exported() {
+					pkgpath = method.Pkg().Path()
+				}
+				sig := method.Type().(*types.Signature)
+				mtyp := types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic())
+				methods = append(methods, c.ctx.ConstStruct([]llvm.Value{
+					c.getMethodNamePtr(method.Name()), // name
+					c.pkgPathPtr(pkgpath),             // pkgpath
+					c.getTypeCode(mtyp),               // mtyp
+				}, false))
+			}
+			typeFields = append(typeFields, llvm.ConstArray(methodType, methods))
 		case *types.Signature:
-			typeFields = []llvm.Value{c.getTypeCode(types.NewPointer(typ))}
-			// TODO: params, return values, etc
+			var params []llvm.Value
+			for i := 0; i < typ.Params().Len(); i++ {
+				params = append(params, c.getTypeCode(typ.Params().At(i).Type()))
+			}
+			for i := 0; i < typ.Results().Len(); i++ {
+				params = append(params, c.getTypeCode(typ.Results().At(i).Type()))
+			}
+			var variadic uint64
+			if typ.Variadic() {
+				variadic = 1
+			}
+			call := c.ctx.ConstStruct([]llvm.Value{
+				llvm.ConstPointerNull(c.dataPtrType),
+				c.getReflectCallTrampoline(typ, typeCodeName, isLocal),
+			}, false)
+			typeFields = []llvm.Value{
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(typ.Params().Len()), false), // numIn
+				c.getTypeCode(types.NewPointer(typ)),                                // ptrTo
+				call,                                                                // call
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(typ.Results().Len()), false), // numOut
+				llvm.ConstInt(c.ctx.Int1Type(), variadic, false),                     // variadic
+				llvm.ConstArray(c.dataPtrType, params),                               // params
+			}
 		}
 		// Prepend metadata byte.
 		typeFields = append([]llvm.Value{
@@ -576,7 +665,11 @@ func getTypeCodeName(t types.Type) (string, bool) {
 			}
 			results[i] = s
 		}
-		return "func:" + "{" + strings.Join(params, ",") + "}{" + strings.Join(results, ",") + "}", isLocal
+		variadic := ""
+		if t.Variadic() {
+			variadic = "..."
+		}
+		return "func:" + "{" + strings.Join(params, ",") + variadic + "}{" + strings.Join(results, ",") + "}", isLocal
 	case *types.Slice:
 		s, isLocal := getTypeCodeName(t.Elem())
 		return "slice:" + s, isLocal
@@ -642,6 +735,237 @@ func (c *compilerContext) getTypeMethodSet(typ types.Type) llvm.Value {
 	return global
 }
 
+// reflectCallSignature is the signature of the call trampolines that are
+// stored in the type code of each function signature. See
+// getReflectCallTrampoline.
+var reflectCallSignature = types.NewSignatureType(nil, nil, nil, types.NewTuple(
+	types.NewVar(token.NoPos, nil, "fn", types.Typ[types.UnsafePointer]),
+	types.NewVar(token.NoPos, nil, "args", types.Typ[types.UnsafePointer]),
+	types.NewVar(token.NoPos, nil, "results", types.Typ[types.UnsafePointer]),
+), nil, false)
+
+// interfaceImplementsSignature is the signature of the function stored in the
+// type code of each interface type, which checks whether the given (concrete)
+// type implements the interface. See getInterfaceImplementsFunc.
+var interfaceImplementsSignature = types.NewSignatureType(nil, nil, nil, types.NewTuple(
+	types.NewVar(token.NoPos, nil, "typecode", types.Typ[types.UnsafePointer]),
+), types.NewTuple(
+	types.NewVar(token.NoPos, nil, "", types.Typ[types.Bool]),
+), false)
+
+// interfaceMethodType is the type of each entry in the method list of an
+// interface type code. It must match the imethod struct in
+// src/internal/reflectlite/type.go.
+var interfaceMethodType = types.NewStruct([]*types.Var{
+	types.NewVar(token.NoPos, nil, "name", types.Typ[types.UnsafePointer]),
+	types.NewVar(token.NoPos, nil, "pkgpath", types.Typ[types.UnsafePointer]),
+	types.NewVar(token.NoPos, nil, "mtyp", types.Typ[types.UnsafePointer]),
+}, nil)
+
+// getReflectCallTrampoline returns a function that is used by
+// reflect.Value.Call to call a func value of the given signature. It has the
+// signature of reflectCallSignature: the first parameter points to the func
+// value, the second to a struct with all parameters and the third to a struct
+// where the results will be stored. Both structs are laid out like a regular
+// Go struct with the parameter (or result) types as fields.
+//
+// The trampolines are removed by the OptimizeReflectCalls pass if the program
+// never calls a function through reflect.
+func (c *compilerContext) getReflectCallTrampoline(typ *types.Signature, typeCodeName string, isLocal bool) llvm.Value {
+	fnName := "reflect/types.call:" + typeCodeName
+	if !isLocal {
+		fn := c.mod.NamedFunction(fnName)
+		if !fn.IsNil() {
+			return fn
+		}
+	}
+
+	llvmFnType := c.getLLVMFunctionType(reflectCallSignature)
+	llvmFn := llvm.AddFunction(c.mod, fnName, llvmFnType)
+	c.addStandardAttributes(llvmFn)
+	if isLocal {
+		llvmFn.SetLinkage(llvm.InternalLinkage)
+	} else {
+		llvmFn.SetLinkage(llvm.LinkOnceODRLinkage)
+	}
+	llvmFn.SetUnnamedAddr(true)
+
+	// Create a new builder just to create this trampoline.
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+	block := b.ctx.AddBasicBlock(llvmFn, "entry")
+	b.SetInsertPointAtEnd(block)
+
+	// Load the func value to call.
+	sig := types.NewSignatureType(nil, nil, nil, typ.Params(), typ.Results(), typ.Variadic())
+	funcValue := b.CreateLoad(c.getFuncType(sig), llvmFn.Param(0), "fn")
+	funcPtr, context := b.decodeFuncValue(funcValue)
+
+	// Load all parameters from the args struct.
+	var paramTypes []llvm.Type
+	for i := 0; i < sig.Params().Len(); i++ {
+		paramTypes = append(paramTypes, c.getLLVMType(sig.Params().At(i).Type()))
+	}
+	argsType := c.ctx.StructType(paramTypes, false)
+	var params []llvm.Value
+	for i, paramType := range paramTypes {
+		gep := b.CreateInBoundsGEP(argsType, llvmFn.Param(1), []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(c.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		params = append(params, b.CreateLoad(paramType, gep, ""))
+	}
+	params = append(params, context)
+
+	// Do the call, and store the result (if any). Multiple results are
+	// returned as a struct, which has the same layout as the results struct.
+	result := b.createCall(c.getLLVMFunctionType(sig), funcPtr, params, "")
+	if sig.Results().Len() != 0 {
+		b.CreateStore(result, llvmFn.Param(2))
+	}
+	b.CreateRetVoid()
+
+	return llvmFn
+}
+
+// getTypeMethodTable returns a pointer to the method table of the given type,
+// for use in the type code. The method table contains an entry for each
+// exported method, in the same order as the method set (which is sorted by
+// name). Each entry looks like this:
+//
+//	name  *byte          // null terminated method name
+//	mtyp  *typeStruct    // method signature without receiver
+//	ftyp  *typeStruct    // method signature with receiver as first parameter
+//	bound funcPtr        // method with the receiver in the context parameter
+//	fn    funcPtr        // method with the receiver as first parameter
+//
+// Unlike the method set, the method table is kept after interface lowering. It
+// is removed by the OptimizeReflectCalls pass if it isn't used.
+func (c *compilerContext) getTypeMethodTable(typ types.Type, typeGlobalName string, isLocal bool) llvm.Value {
+	ms := c.program.MethodSets.MethodSet(typ)
+	var methods []llvm.Value
+	for i := 0; i < ms.Len(); i++ {
+		method := ms.At(i)
+		if !method.Obj().Exported() {
+			continue
+		}
+		sig := method.Type().(*types.Signature)
+		mtyp := types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic())
+		ftypParams := []*types.Var{types.NewVar(token.NoPos, nil, "", typ)}
+		for j := 0; j < sig.Params().Len(); j++ {
+			ftypParams = append(ftypParams, sig.Params().At(j))
+		}
+		ftyp := types.NewSignatureType(nil, nil, nil, types.NewTuple(ftypParams...), sig.Results(), sig.Variadic())
+
+		fn := c.program.MethodValue(method)
+		llvmFnType, llvmFn := c.getFunction(fn)
+		if llvmFn.IsNil() {
+			// compiler error, so panic
+			panic("cannot find function: " + c.getFunctionInfo(fn).linkName)
+		}
+		methods = append(methods, c.ctx.ConstStruct([]llvm.Value{
+			c.getMethodNamePtr(method.Obj().Name()),
+			c.getTypeCode(mtyp),
+			c.getTypeCode(ftyp),
+			c.getBoundMethodWrapper(fn, llvmFnType, llvmFn),
+			llvmFn,
+		}, false))
+	}
+
+	globalValue := llvm.ConstArray(methods[0].Type(), methods)
+	global := llvm.AddGlobal(c.mod, globalValue.Type(), strings.Replace(typeGlobalName, "reflect/types.type:", "reflect/types.methods:", 1))
+	global.SetInitializer(globalValue)
+	global.SetGlobalConstant(true)
+	global.SetUnnamedAddr(true)
+	if isLocal {
+		global.SetLinkage(llvm.InternalLinkage)
+	} else {
+		global.SetLinkage(llvm.LinkOnceODRLinkage)
+	}
+	return global
+}
+
+// getMethodNamePtr returns a pointer to a null terminated string with the given
+// method name, for use in method tables.
+func (c *compilerContext) getMethodNamePtr(name string) llvm.Value {
+	globalName := "reflect/types.method.name:" + name
+	global := c.mod.NamedGlobal(globalName)
+	if global.IsNil() {
+		initializer := c.ctx.ConstString(name+"\x00", false)
+		global = llvm.AddGlobal(c.mod, initializer.Type(), globalName)
+		global.SetInitializer(initializer)
+		global.SetAlignment(1)
+		global.SetUnnamedAddr(true)
+		global.SetLinkage(llvm.LinkOnceODRLinkage)
+		global.SetGlobalConstant(true)
+	}
+	return global
+}
+
+// getBoundMethodWrapper returns a wrapper for the given method so that it can
+// be used as the function pointer in a func value, with the receiver stored in
+// the context parameter. The receiver is packed in the same way as in an
+// interface value. This is used by reflect.Value.Method to create method
+// values.
+func (c *compilerContext) getBoundMethodWrapper(fn *ssa.Function, llvmFnType llvm.Type, llvmFn llvm.Value) llvm.Value {
+	wrapperName := llvmFn.Name() + "$bound"
+	wrapper := c.mod.NamedFunction(wrapperName)
+	if !wrapper.IsNil() {
+		// Wrapper already created. Return it directly.
+		return wrapper
+	}
+
+	// The invoke wrapper takes the receiver as the first parameter, in the
+	// same form as it is stored in the context parameter.
+	invokeWrapper := c.getInterfaceInvokeWrapper(fn, llvmFnType, llvmFn)
+
+	// create wrapper function
+	sig := fn.Signature
+	wrapFnType := c.getLLVMFunctionType(types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic()))
+	wrapper = llvm.AddFunction(c.mod, wrapperName, wrapFnType)
+	c.addStandardAttributes(wrapper)
+
+	wrapper.SetLinkage(llvm.LinkOnceODRLinkage)
+	wrapper.SetUnnamedAddr(true)
+
+	// Create a new builder just to create this wrapper.
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+
+	// add debug info if needed
+	if c.Debug {
+		pos := c.program.Fset.Position(fn.Pos())
+		difunc := c.attachDebugInfoRaw(fn, wrapper, "$bound", pos.Filename, pos.Line)
+		b.SetCurrentDebugLocation(uint(pos.Line), uint(pos.Column), difunc, llvm.Metadata{})
+	}
+
+	// set up IR builder
+	block := b.ctx.AddBasicBlock(wrapper, "entry")
+	b.SetInsertPointAtEnd(block)
+
+	// Move the context parameter to the front, and pass an undef context
+	// instead.
+	wrapperParams := wrapper.Params()
+	context := wrapperParams[len(wrapperParams)-1]
+	params := append([]llvm.Value{context}, wrapperParams[:len(wrapperParams)-1]...)
+	params = append(params, llvm.Undef(c.dataPtrType))
+	if wrapFnType.ReturnType().TypeKind() == llvm.VoidTypeKind {
+		b.CreateCall(invokeWrapper.GlobalValueType(), invokeWrapper, params, "")
+		b.CreateRetVoid()
+	} else {
+		ret := b.CreateCall(invokeWrapper.GlobalValueType(), invokeWrapper, params, "ret")
+		b.CreateRet(ret)
+	}
+
+	return wrapper
+}
+
 // getMethodSignatureName returns a unique name (that can be used as the name of
 // a global) for the given method.
 func (c *compilerContext) getMethodSignatureName(method *types.Func) string {
@@ -703,7 +1027,10 @@ func (b *builder) createTypeAssert(expr *ssa.TypeAssert) llvm.Value {
 			// implements each method of the interface. See:
 			// https://research.swtch.com/interfaces
 			fn := b.getInterfaceImplementsFunc(expr.AssertedType)
-			commaOk = b.CreateCall(fn.GlobalValueType(), fn, []llvm.Value{actualTypeNum}, "")
+			commaOk = b.CreateCall(fn.GlobalValueType(), fn, []llvm.Value{
+				actualTypeNum,
+				llvm.Undef(b.dataPtrType), // context
+			}, "")
 		}
 	} else {
 		name, _ := getTypeCodeName(expr.AssertedType)
@@ -781,13 +1108,15 @@ func (c *compilerContext) getMethodsString(itf *types.Interface) string {
 }
 
 // getInterfaceImplementsFunc returns a declared function that works as a type
-// switch. The interface lowering pass will define this function.
+// switch. The interface lowering pass will define this function. It has the
+// signature of interfaceImplementsSignature, so that it can also be stored as a
+// func value in the interface type code.
 func (c *compilerContext) getInterfaceImplementsFunc(assertedType types.Type) llvm.Value {
 	s, _ := getTypeCodeName(assertedType.Underlying())
 	fnName := s + ".$typeassert"
 	llvmFn := c.mod.NamedFunction(fnName)
 	if llvmFn.IsNil() {
-		llvmFnType := llvm.FunctionType(c.ctx.Int1Type(), []llvm.Type{c.dataPtrType}, false)
+		llvmFnType := c.getLLVMFunctionType(interfaceImplementsSignature)
 		llvmFn = llvm.AddFunction(c.mod, fnName, llvmFnType)
 		c.addStandardDeclaredAttributes(llvmFn)
 		methods := c.getMethodsString(assertedType.Underlying().(*types.Interface))
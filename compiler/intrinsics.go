@@ -25,6 +25,8 @@ func (b *builder) defineIntrinsicFunction() {
 		b.createMemoryZeroImpl()
 	case name == "runtime.stacksave":
 		b.createStackSaveImpl()
+	case name == "runtime.frameAddress":
+		b.createFrameAddressImpl()
 	case name == "runtime.KeepAlive":
 		b.createKeepAliveImpl()
 	case strings.HasPrefix(name, "runtime/volatile.Load"):
@@ -87,6 +89,22 @@ func (b *builder) createStackSaveImpl() {
 	b.CreateRet(sp)
 }
 
+// createFrameAddressImpl creates a call to llvm.frameaddress.p0 to read the
+// frame pointer of the function itself. The function is never inlined, so that
+// the returned frame always has the same relation to the caller.
+func (b *builder) createFrameAddressImpl() {
+	b.createFunctionStart(true)
+	b.llvmFn.AddFunctionAttr(b.ctx.CreateEnumAttribute(llvm.AttributeKindID("noinline"), 0))
+	name := "llvm.frameaddress.p0"
+	llvmFn := b.mod.NamedFunction(name)
+	if llvmFn.IsNil() {
+		fnType := llvm.FunctionType(b.dataPtrType, []llvm.Type{b.ctx.Int32Type()}, false)
+		llvmFn = llvm.AddFunction(b.mod, name, fnType)
+	}
+	fp := b.CreateCall(llvmFn.GlobalValueType(), llvmFn, []llvm.Value{llvm.ConstInt(b.ctx.Int32Type(), 0, false)}, "")
+	b.CreateRet(b.CreatePtrToInt(fp, b.uintptrType, ""))
+}
+
 // Return the llvm.memset.p0.i8 function declaration.
 func (c *compilerContext) getMemsetFunc() llvm.Value {
 	fnName := "llvm.memset.p0.i" + strconv.Itoa(c.uintptrType.IntTypeWidth())
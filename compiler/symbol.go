@@ -28,6 +28,7 @@ type functionInfo struct {
 	wasmName      string     // wasm-export-name or wasm-import-name in the IR
 	wasmExport    string     // go:wasmexport is defined (export is unset, this adds an exported wrapper)
 	wasmExportPos token.Pos  // position of //go:wasmexport comment
+	wasmCABI      bool       // go:wasmimport or go:wasmexport is lowered using the canonical ABI
 	linkName      string     // go:linkname, go:export - the IR function name
 	section       string     // go:section - object file section name
 	exported      bool       // go:export, CGo
@@ -361,8 +362,14 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 				c.addError(f.Pos(), "can only use //go:wasmimport on declarations")
 				continue
 			}
-			c.checkWasmImportExport(f, comment.Text)
-			info.exported = true
+			if c.checkWasmImportExport(f, comment.Text) {
+				// The import is called through a wrapper that converts
+				// between the Go ABI and the canonical ABI, so the Go
+				// function itself is not external.
+				info.wasmCABI = true
+			} else {
+				info.exported = true
+			}
 			info.wasmModule = parts[1]
 			info.wasmName = parts[2]
 		case "//go:wasmexport":
@@ -386,7 +393,7 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 			if c.archFamily() != "wasm32" {
 				c.addError(f.Pos(), "//go:wasmexport is only supported on wasm")
 			}
-			c.checkWasmImportExport(f, comment.Text)
+			info.wasmCABI = c.checkWasmImportExport(f, comment.Text)
 			info.wasmExport = name
 			info.wasmExportPos = comment.Slash
 		case "//go:inline":
@@ -445,11 +452,19 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 //
 // The list of allowed types is based on this proposal:
 // https://github.com/golang/go/issues/59149
-func (c *compilerContext) checkWasmImportExport(f *ssa.Function, pragma string) {
+//
+// When the canonical ABI is enabled, signatures outside of this list may also
+// use strings, slices, structs and a trailing error result. The return value
+// indicates whether the function must be lowered using the canonical ABI.
+func (c *compilerContext) checkWasmImportExport(f *ssa.Function, pragma string) bool {
 	if c.pkg.Path() == "runtime" || c.pkg.Path() == "syscall/js" || c.pkg.Path() == "syscall" || c.pkg.Path() == "crypto/internal/sysrand" {
 		// The runtime is a special case. Allow all kinds of parameters
 		// (importantly, including pointers).
-		return
+		return false
+	}
+	if c.WasmCanonicalABI && c.archFamily() == "wasm32" && !c.isValidWasmSignature(f.Signature) {
+		c.checkCABISignature(f.Signature, pragma)
+		return true
 	}
 	if f.Signature.Results().Len() > 1 {
 		c.addError(f.Signature.Results().At(1).Pos(), fmt.Sprintf("%s: too many return values", pragma))
@@ -466,6 +481,25 @@ func (c *compilerContext) checkWasmImportExport(f *ssa.Function, pragma string)
 			c.addError(param.Pos(), fmt.Sprintf("%s: unsupported parameter type %s", pragma, param.Type().String()))
 		}
 	}
+	return false
+}
+
+// isValidWasmSignature returns whether all parameters and results of this
+// signature map directly to WebAssembly types, without needing the canonical
+// ABI.
+func (c *compilerContext) isValidWasmSignature(sig *types.Signature) bool {
+	if sig.Results().Len() > 1 {
+		return false
+	}
+	if sig.Results().Len() == 1 && !c.isValidWasmType(sig.Results().At(0).Type(), siteResult) {
+		return false
+	}
+	for _, param := range getParams(sig) {
+		if !c.isValidWasmType(param.Type(), siteParam) {
+			return false
+		}
+	}
+	return true
 }
 
 // Check whether the type maps directly to a WebAssembly type.
@@ -591,6 +625,11 @@ func (c *compilerContext) addStandardDefinedAttributes(llvmFn llvm.Value) {
 		// For details, see: https://llvm.org/docs/LangRef.html#function-attributes
 		llvmFn.AddFunctionAttr(c.ctx.CreateEnumAttribute(llvm.AttributeKindID("uwtable"), 1))
 	}
+	if c.FramePointers {
+		// Keep the frame pointer in every function, so that the profilers in
+		// runtime/pprof can walk the stack without needing unwind tables.
+		llvmFn.AddFunctionAttr(c.ctx.CreateStringAttribute("frame-pointer", "all"))
+	}
 }
 
 // addStandardAttributes adds all attributes added to defined functions.
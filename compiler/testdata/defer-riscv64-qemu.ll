@@ -0,0 +1,282 @@
+; ModuleID = 'defer.go'
+source_filename = "defer.go"
+target datalayout = "e-m:e-p:64:64-i64:64-i128:128-n32:64-S128"
+target triple = "riscv64-unknown-none"
+
+%runtime.deferFrame = type { ptr, ptr, [0 x ptr], ptr, i8, %runtime._interface }
+%runtime._interface = type { ptr, ptr }
+
+; Function Attrs: allockind("alloc,zeroed") allocsize(0)
+declare noalias nonnull ptr @runtime.alloc(i64, ptr, ptr) #0
+
+; Function Attrs: nounwind
+define hidden void @main.init(ptr %context) unnamed_addr #1 {
+entry:
+  ret void
+}
+
+declare void @main.external(ptr) #2
+
+; Function Attrs: nounwind
+define hidden void @main.deferSimple(ptr %context) unnamed_addr #1 {
+entry:
+  %defer.alloca = alloca { i64, ptr }, align 8
+  %deferPtr = alloca ptr, align 8
+  store ptr null, ptr %deferPtr, align 8
+  %deferframe.buf = alloca %runtime.deferFrame, align 8
+  %0 = call ptr @llvm.stacksave.p0()
+  call void @runtime.setupDeferFrame(ptr nonnull %deferframe.buf, ptr %0, ptr undef) #4
+  store i64 0, ptr %defer.alloca, align 8
+  %defer.alloca.repack15 = getelementptr inbounds i8, ptr %defer.alloca, i64 8
+  store ptr null, ptr %defer.alloca.repack15, align 8
+  store ptr %defer.alloca, ptr %deferPtr, align 8
+  %setjmp = call i64 asm "\0Ala a2, 1f\0Asd a2, 8(a1)\0Ali a0, 0\0A1:", "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"(ptr nonnull %deferframe.buf) #5
+  %setjmp.result = icmp eq i64 %setjmp, 0
+  br i1 %setjmp.result, label %1, label %lpad
+
+1:                                                ; preds = %entry
+  call void @main.external(ptr undef) #4
+  br label %rundefers.block
+
+rundefers.after:                                  ; preds = %rundefers.end
+  call void @runtime.destroyDeferFrame(ptr nonnull %deferframe.buf, ptr undef) #4
+  ret void
+
+rundefers.block:                                  ; preds = %1
+  br label %rundefers.loophead
+
+rundefers.loophead:                               ; preds = %3, %rundefers.block
+  %2 = load ptr, ptr %deferPtr, align 8
+  %stackIsNil = icmp eq ptr %2, null
+  br i1 %stackIsNil, label %rundefers.end, label %rundefers.loop
+
+rundefers.loop:                                   ; preds = %rundefers.loophead
+  %stack.next.gep = getelementptr inbounds i8, ptr %2, i64 8
+  %stack.next = load ptr, ptr %stack.next.gep, align 8
+  store ptr %stack.next, ptr %deferPtr, align 8
+  %callback = load i64, ptr %2, align 8
+  switch i64 %callback, label %rundefers.default [
+    i64 0, label %rundefers.callback0
+  ]
+
+rundefers.callback0:                              ; preds = %rundefers.loop
+  %setjmp1 = call i64 asm "\0Ala a2, 1f\0Asd a2, 8(a1)\0Ali a0, 0\0A1:", "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"(ptr nonnull %deferframe.buf) #5
+  %setjmp.result2 = icmp eq i64 %setjmp1, 0
+  br i1 %setjmp.result2, label %3, label %lpad
+
+3:                                                ; preds = %rundefers.callback0
+  call void @"main.deferSimple$1"(ptr undef)
+  br label %rundefers.loophead
+
+rundefers.default:                                ; preds = %rundefers.loop
+  unreachable
+
+rundefers.end:                                    ; preds = %rundefers.loophead
+  br label %rundefers.after
+
+recover:                                          ; preds = %rundefers.end3
+  call void @runtime.destroyDeferFrame(ptr nonnull %deferframe.buf, ptr undef) #4
+  ret void
+
+lpad:                                             ; preds = %rundefers.callback012, %rundefers.callback0, %entry
+  br label %rundefers.loophead6
+
+rundefers.loophead6:                              ; preds = %5, %lpad
+  %4 = load ptr, ptr %deferPtr, align 8
+  %stackIsNil7 = icmp eq ptr %4, null
+  br i1 %stackIsNil7, label %rundefers.end3, label %rundefers.loop5
+
+rundefers.loop5:                                  ; preds = %rundefers.loophead6
+  %stack.next.gep8 = getelementptr inbounds i8, ptr %4, i64 8
+  %stack.next9 = load ptr, ptr %stack.next.gep8, align 8
+  store ptr %stack.next9, ptr %deferPtr, align 8
+  %callback11 = load i64, ptr %4, align 8
+  switch i64 %callback11, label %rundefers.default4 [
+    i64 0, label %rundefers.callback012
+  ]
+
+rundefers.callback012:                            ; preds = %rundefers.loop5
+  %setjmp13 = call i64 asm "\0Ala a2, 1f\0Asd a2, 8(a1)\0Ali a0, 0\0A1:", "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"(ptr nonnull %deferframe.buf) #5
+  %setjmp.result14 = icmp eq i64 %setjmp13, 0
+  br i1 %setjmp.result14, label %5, label %lpad
+
+5:                                                ; preds = %rundefers.callback012
+  call void @"main.deferSimple$1"(ptr undef)
+  br label %rundefers.loophead6
+
+rundefers.default4:                               ; preds = %rundefers.loop5
+  unreachable
+
+rundefers.end3:                                   ; preds = %rundefers.loophead6
+  br label %recover
+}
+
+; Function Attrs: nocallback nofree nosync nounwind willreturn
+declare ptr @llvm.stacksave.p0() #3
+
+declare void @runtime.setupDeferFrame(ptr dereferenceable_or_null(48), ptr, ptr) #2
+
+declare void @runtime.destroyDeferFrame(ptr dereferenceable_or_null(48), ptr) #2
+
+; Function Attrs: nounwind
+define internal void @"main.deferSimple$1"(ptr %context) unnamed_addr #1 {
+entry:
+  call void @runtime.printlock(ptr undef) #4
+  call void @runtime.printint64(i64 3, ptr undef) #4
+  call void @runtime.printunlock(ptr undef) #4
+  ret void
+}
+
+declare void @runtime.printlock(ptr) #2
+
+declare void @runtime.printint64(i64, ptr) #2
+
+declare void @runtime.printunlock(ptr) #2
+
+; Function Attrs: nounwind
+define hidden void @main.deferMultiple(ptr %context) unnamed_addr #1 {
+entry:
+  %defer.alloca2 = alloca { i64, ptr }, align 8
+  %defer.alloca = alloca { i64, ptr }, align 8
+  %deferPtr = alloca ptr, align 8
+  store ptr null, ptr %deferPtr, align 8
+  %deferframe.buf = alloca %runtime.deferFrame, align 8
+  %0 = call ptr @llvm.stacksave.p0()
+  call void @runtime.setupDeferFrame(ptr nonnull %deferframe.buf, ptr %0, ptr undef) #4
+  store i64 0, ptr %defer.alloca, align 8
+  %defer.alloca.repack22 = getelementptr inbounds i8, ptr %defer.alloca, i64 8
+  store ptr null, ptr %defer.alloca.repack22, align 8
+  store ptr %defer.alloca, ptr %deferPtr, align 8
+  store i64 1, ptr %defer.alloca2, align 8
+  %defer.alloca2.repack23 = getelementptr inbounds i8, ptr %defer.alloca2, i64 8
+  store ptr %defer.alloca, ptr %defer.alloca2.repack23, align 8
+  store ptr %defer.alloca2, ptr %deferPtr, align 8
+  %setjmp = call i64 asm "\0Ala a2, 1f\0Asd a2, 8(a1)\0Ali a0, 0\0A1:", "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"(ptr nonnull %deferframe.buf) #5
+  %setjmp.result = icmp eq i64 %setjmp, 0
+  br i1 %setjmp.result, label %1, label %lpad
+
+1:                                                ; preds = %entry
+  call void @main.external(ptr undef) #4
+  br label %rundefers.block
+
+rundefers.after:                                  ; preds = %rundefers.end
+  call void @runtime.destroyDeferFrame(ptr nonnull %deferframe.buf, ptr undef) #4
+  ret void
+
+rundefers.block:                                  ; preds = %1
+  br label %rundefers.loophead
+
+rundefers.loophead:                               ; preds = %4, %3, %rundefers.block
+  %2 = load ptr, ptr %deferPtr, align 8
+  %stackIsNil = icmp eq ptr %2, null
+  br i1 %stackIsNil, label %rundefers.end, label %rundefers.loop
+
+rundefers.loop:                                   ; preds = %rundefers.loophead
+  %stack.next.gep = getelementptr inbounds i8, ptr %2, i64 8
+  %stack.next = load ptr, ptr %stack.next.gep, align 8
+  store ptr %stack.next, ptr %deferPtr, align 8
+  %callback = load i64, ptr %2, align 8
+  switch i64 %callback, label %rundefers.default [
+    i64 0, label %rundefers.callback0
+    i64 1, label %rundefers.callback1
+  ]
+
+rundefers.callback0:                              ; preds = %rundefers.loop
+  %setjmp3 = call i64 asm "\0Ala a2, 1f\0Asd a2, 8(a1)\0Ali a0, 0\0A1:", "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"(ptr nonnull %deferframe.buf) #5
+  %setjmp.result4 = icmp eq i64 %setjmp3, 0
+  br i1 %setjmp.result4, label %3, label %lpad
+
+3:                                                ; preds = %rundefers.callback0
+  call void @"main.deferMultiple$1"(ptr undef)
+  br label %rundefers.loophead
+
+rundefers.callback1:                              ; preds = %rundefers.loop
+  %setjmp5 = call i64 asm "\0Ala a2, 1f\0Asd a2, 8(a1)\0Ali a0, 0\0A1:", "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"(ptr nonnull %deferframe.buf) #5
+  %setjmp.result6 = icmp eq i64 %setjmp5, 0
+  br i1 %setjmp.result6, label %4, label %lpad
+
+4:                                                ; preds = %rundefers.callback1
+  call void @"main.deferMultiple$2"(ptr undef)
+  br label %rundefers.loophead
+
+rundefers.default:                                ; preds = %rundefers.loop
+  unreachable
+
+rundefers.end:                                    ; preds = %rundefers.loophead
+  br label %rundefers.after
+
+recover:                                          ; preds = %rundefers.end7
+  call void @runtime.destroyDeferFrame(ptr nonnull %deferframe.buf, ptr undef) #4
+  ret void
+
+lpad:                                             ; preds = %rundefers.callback119, %rundefers.callback016, %rundefers.callback1, %rundefers.callback0, %entry
+  br label %rundefers.loophead10
+
+rundefers.loophead10:                             ; preds = %7, %6, %lpad
+  %5 = load ptr, ptr %deferPtr, align 8
+  %stackIsNil11 = icmp eq ptr %5, null
+  br i1 %stackIsNil11, label %rundefers.end7, label %rundefers.loop9
+
+rundefers.loop9:                                  ; preds = %rundefers.loophead10
+  %stack.next.gep12 = getelementptr inbounds i8, ptr %5, i64 8
+  %stack.next13 = load ptr, ptr %stack.next.gep12, align 8
+  store ptr %stack.next13, ptr %deferPtr, align 8
+  %callback15 = load i64, ptr %5, align 8
+  switch i64 %callback15, label %rundefers.default8 [
+    i64 0, label %rundefers.callback016
+    i64 1, label %rundefers.callback119
+  ]
+
+rundefers.callback016:                            ; preds = %rundefers.loop9
+  %setjmp17 = call i64 asm "\0Ala a2, 1f\0Asd a2, 8(a1)\0Ali a0, 0\0A1:", "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"(ptr nonnull %deferframe.buf) #5
+  %setjmp.result18 = icmp eq i64 %setjmp17, 0
+  br i1 %setjmp.result18, label %6, label %lpad
+
+6:                                                ; preds = %rundefers.callback016
+  call void @"main.deferMultiple$1"(ptr undef)
+  br label %rundefers.loophead10
+
+rundefers.callback119:                            ; preds = %rundefers.loop9
+  %setjmp20 = call i64 asm "\0Ala a2, 1f\0Asd a2, 8(a1)\0Ali a0, 0\0A1:", "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"(ptr nonnull %deferframe.buf) #5
+  %setjmp.result21 = icmp eq i64 %setjmp20, 0
+  br i1 %setjmp.result21, label %7, label %lpad
+
+7:                                                ; preds = %rundefers.callback119
+  call void @"main.deferMultiple$2"(ptr undef)
+  br label %rundefers.loophead10
+
+rundefers.default8:                               ; preds = %rundefers.loop9
+  unreachable
+
+rundefers.end7:                                   ; preds = %rundefers.loophead10
+  br label %recover
+}
+
+; Function Attrs: nounwind
+define internal void @"main.deferMultiple$1"(ptr %context) unnamed_addr #1 {
+entry:
+  call void @runtime.printlock(ptr undef) #4
+  call void @runtime.printint64(i64 3, ptr undef) #4
+  call void @runtime.printunlock(ptr undef) #4
+  ret void
+}
+
+; Function Attrs: nounwind
+define internal void @"main.deferMultiple$2"(ptr %context) unnamed_addr #1 {
+entry:
+  call void @runtime.printlock(ptr undef) #4
+  call void @runtime.printint64(i64 5, ptr undef) #4
+  call void @runtime.printunlock(ptr undef) #4
+  ret void
+}
+
+attributes #0 = { allockind("alloc,zeroed") allocsize(0) "alloc-family"="runtime.alloc" "target-features"="+64bit,+a,+c,+d,+f,+m,+zicsr,+zifencei,+zmmul,-b,-e,-experimental-smmpm,-experimental-smnpm,-experimental-ssnpm,-experimental-sspm,-experimental-ssqosid,-experimental-supm,-experimental-zacas,-experimental-zalasr,-experimental-zicfilp,-experimental-zicfiss,-h,-relax,-shcounterenw,-shgatpa,-shtvala,-shvsatpa,-shvstvala,-shvstvecd,-smaia,-smcdeleg,-smcsrind,-smepmp,-smstateen,-ssaia,-ssccfg,-ssccptr,-sscofpmf,-sscounterenw,-sscsrind,-ssstateen,-ssstrict,-sstc,-sstvala,-sstvecd,-ssu64xl,-svade,-svadu,-svbare,-svinval,-svnapot,-svpbmt,-v,-xcvalu,-xcvbi,-xcvbitmanip,-xcvelw,-xcvmac,-xcvmem,-xcvsimd,-xesppie,-xsfcease,-xsfvcp,-xsfvfnrclipxfqf,-xsfvfwmaccqqq,-xsfvqmaccdod,-xsfvqmaccqoq,-xsifivecdiscarddlone,-xsifivecflushdlone,-xtheadba,-xtheadbb,-xtheadbs,-xtheadcmo,-xtheadcondmov,-xtheadfmemidx,-xtheadmac,-xtheadmemidx,-xtheadmempair,-xtheadsync,-xtheadvdot,-xventanacondops,-xwchc,-za128rs,-za64rs,-zaamo,-zabha,-zalrsc,-zama16b,-zawrs,-zba,-zbb,-zbc,-zbkb,-zbkc,-zbkx,-zbs,-zca,-zcb,-zcd,-zce,-zcf,-zcmop,-zcmp,-zcmt,-zdinx,-zfa,-zfbfmin,-zfh,-zfhmin,-zfinx,-zhinx,-zhinxmin,-zic64b,-zicbom,-zicbop,-zicboz,-ziccamoa,-ziccif,-zicclsm,-ziccrse,-zicntr,-zicond,-zihintntl,-zihintpause,-zihpm,-zimop,-zk,-zkn,-zknd,-zkne,-zknh,-zkr,-zks,-zksed,-zksh,-zkt,-ztso,-zvbb,-zvbc,-zve32f,-zve32x,-zve64d,-zve64f,-zve64x,-zvfbfmin,-zvfbfwma,-zvfh,-zvfhmin,-zvkb,-zvkg,-zvkn,-zvknc,-zvkned,-zvkng,-zvknha,-zvknhb,-zvks,-zvksc,-zvksed,-zvksg,-zvksh,-zvkt,-zvl1024b,-zvl128b,-zvl16384b,-zvl2048b,-zvl256b,-zvl32768b,-zvl32b,-zvl4096b,-zvl512b,-zvl64b,-zvl65536b,-zvl8192b" }
+attributes #1 = { nounwind "target-features"="+64bit,+a,+c,+d,+f,+m,+zicsr,+zifencei,+zmmul,-b,-e,-experimental-smmpm,-experimental-smnpm,-experimental-ssnpm,-experimental-sspm,-experimental-ssqosid,-experimental-supm,-experimental-zacas,-experimental-zalasr,-experimental-zicfilp,-experimental-zicfiss,-h,-relax,-shcounterenw,-shgatpa,-shtvala,-shvsatpa,-shvstvala,-shvstvecd,-smaia,-smcdeleg,-smcsrind,-smepmp,-smstateen,-ssaia,-ssccfg,-ssccptr,-sscofpmf,-sscounterenw,-sscsrind,-ssstateen,-ssstrict,-sstc,-sstvala,-sstvecd,-ssu64xl,-svade,-svadu,-svbare,-svinval,-svnapot,-svpbmt,-v,-xcvalu,-xcvbi,-xcvbitmanip,-xcvelw,-xcvmac,-xcvmem,-xcvsimd,-xesppie,-xsfcease,-xsfvcp,-xsfvfnrclipxfqf,-xsfvfwmaccqqq,-xsfvqmaccdod,-xsfvqmaccqoq,-xsifivecdiscarddlone,-xsifivecflushdlone,-xtheadba,-xtheadbb,-xtheadbs,-xtheadcmo,-xtheadcondmov,-xtheadfmemidx,-xtheadmac,-xtheadmemidx,-xtheadmempair,-xtheadsync,-xtheadvdot,-xventanacondops,-xwchc,-za128rs,-za64rs,-zaamo,-zabha,-zalrsc,-zama16b,-zawrs,-zba,-zbb,-zbc,-zbkb,-zbkc,-zbkx,-zbs,-zca,-zcb,-zcd,-zce,-zcf,-zcmop,-zcmp,-zcmt,-zdinx,-zfa,-zfbfmin,-zfh,-zfhmin,-zfinx,-zhinx,-zhinxmin,-zic64b,-zicbom,-zicbop,-zicboz,-ziccamoa,-ziccif,-zicclsm,-ziccrse,-zicntr,-zicond,-zihintntl,-zihintpause,-zihpm,-zimop,-zk,-zkn,-zknd,-zkne,-zknh,-zkr,-zks,-zksed,-zksh,-zkt,-ztso,-zvbb,-zvbc,-zve32f,-zve32x,-zve64d,-zve64f,-zve64x,-zvfbfmin,-zvfbfwma,-zvfh,-zvfhmin,-zvkb,-zvkg,-zvkn,-zvknc,-zvkned,-zvkng,-zvknha,-zvknhb,-zvks,-zvksc,-zvksed,-zvksg,-zvksh,-zvkt,-zvl1024b,-zvl128b,-zvl16384b,-zvl2048b,-zvl256b,-zvl32768b,-zvl32b,-zvl4096b,-zvl512b,-zvl64b,-zvl65536b,-zvl8192b" }
+attributes #2 = { "target-features"="+64bit,+a,+c,+d,+f,+m,+zicsr,+zifencei,+zmmul,-b,-e,-experimental-smmpm,-experimental-smnpm,-experimental-ssnpm,-experimental-sspm,-experimental-ssqosid,-experimental-supm,-experimental-zacas,-experimental-zalasr,-experimental-zicfilp,-experimental-zicfiss,-h,-relax,-shcounterenw,-shgatpa,-shtvala,-shvsatpa,-shvstvala,-shvstvecd,-smaia,-smcdeleg,-smcsrind,-smepmp,-smstateen,-ssaia,-ssccfg,-ssccptr,-sscofpmf,-sscounterenw,-sscsrind,-ssstateen,-ssstrict,-sstc,-sstvala,-sstvecd,-ssu64xl,-svade,-svadu,-svbare,-svinval,-svnapot,-svpbmt,-v,-xcvalu,-xcvbi,-xcvbitmanip,-xcvelw,-xcvmac,-xcvmem,-xcvsimd,-xesppie,-xsfcease,-xsfvcp,-xsfvfnrclipxfqf,-xsfvfwmaccqqq,-xsfvqmaccdod,-xsfvqmaccqoq,-xsifivecdiscarddlone,-xsifivecflushdlone,-xtheadba,-xtheadbb,-xtheadbs,-xtheadcmo,-xtheadcondmov,-xtheadfmemidx,-xtheadmac,-xtheadmemidx,-xtheadmempair,-xtheadsync,-xtheadvdot,-xventanacondops,-xwchc,-za128rs,-za64rs,-zaamo,-zabha,-zalrsc,-zama16b,-zawrs,-zba,-zbb,-zbc,-zbkb,-zbkc,-zbkx,-zbs,-zca,-zcb,-zcd,-zce,-zcf,-zcmop,-zcmp,-zcmt,-zdinx,-zfa,-zfbfmin,-zfh,-zfhmin,-zfinx,-zhinx,-zhinxmin,-zic64b,-zicbom,-zicbop,-zicboz,-ziccamoa,-ziccif,-zicclsm,-ziccrse,-zicntr,-zicond,-zihintntl,-zihintpause,-zihpm,-zimop,-zk,-zkn,-zknd,-zkne,-zknh,-zkr,-zks,-zksed,-zksh,-zkt,-ztso,-zvbb,-zvbc,-zve32f,-zve32x,-zve64d,-zve64f,-zve64x,-zvfbfmin,-zvfbfwma,-zvfh,-zvfhmin,-zvkb,-zvkg,-zvkn,-zvknc,-zvkned,-zvkng,-zvknha,-zvknhb,-zvks,-zvksc,-zvksed,-zvksg,-zvksh,-zvkt,-zvl1024b,-zvl128b,-zvl16384b,-zvl2048b,-zvl256b,-zvl32768b,-zvl32b,-zvl4096b,-zvl512b,-zvl64b,-zvl65536b,-zvl8192b" }
+attributes #3 = { nocallback nofree nosync nounwind willreturn }
+attributes #4 = { nounwind }
+attributes #5 = { nounwind returns_twice }
+
+!llvm.module.flags = !{!0}
+
+!0 = !{i32 1, !"target-abi", !"lp64"}
@@ -0,0 +1,83 @@
+package main
+
+// Test //go:wasmimport and //go:wasmexport with the canonical ABI, which is
+// enabled by default on wasip2.
+
+type Point struct {
+	X, Y int32
+}
+
+type Record struct {
+	Name   string
+	Values []uint16
+	Origin Point
+	Valid  bool
+}
+
+//go:wasmimport modulename validparam
+func validparam(a string, b []byte, c Point, d int8, e []Point, f Record)
+
+//go:wasmimport modulename validreturn_string
+func validreturn_string() string
+
+//go:wasmimport modulename validreturn_slice
+func validreturn_slice() []byte
+
+//go:wasmimport modulename validreturn_struct
+func validreturn_struct() Record
+
+//go:wasmimport modulename validreturn_tuple
+func validreturn_tuple() (int32, string)
+
+//go:wasmimport modulename validreturn_error
+func validreturn_error() error
+
+//go:wasmimport modulename validreturn_value_error
+func validreturn_value_error(key string) (string, error)
+
+//go:wasmimport modulename validmanyparams
+func validmanyparams(a, b, c, d, e, f, g, h, i string) uint64
+
+// ERROR: //go:wasmimport modulename invalidparam: unsupported parameter type int
+// ERROR: //go:wasmimport modulename invalidparam: unsupported parameter type [][]byte
+// ERROR: //go:wasmimport modulename invalidparam: unsupported parameter type map[string]string
+// ERROR: //go:wasmimport modulename invalidparam: unsupported parameter type struct{a []int}
+// ERROR: //go:wasmimport modulename invalidparam: unsupported parameter type error
+//
+//go:wasmimport modulename invalidparam
+func invalidparam(a int, b [][]byte, c map[string]string, d struct{ a []int }, e error)
+
+// ERROR: //go:wasmimport modulename invalidreturn: unsupported result type error
+// ERROR: //go:wasmimport modulename invalidreturn: unsupported result type uint
+//
+//go:wasmimport modulename invalidreturn
+func invalidreturn() (error, uint, error)
+
+//go:wasmexport validexport
+func validexport(name string, data []byte) (Record, error) {
+	return Record{Name: name, Values: []uint16{uint16(len(data))}}, nil
+}
+
+//go:wasmexport validexport_string
+func validexport_string(p Point) string {
+	return "point"
+}
+
+// ERROR: //go:wasmexport invalidexport: unsupported parameter type []int
+// ERROR: //go:wasmexport invalidexport: unsupported result type chan int
+//
+//go:wasmexport invalidexport
+func invalidexport(a []int) chan int {
+	return nil
+}
+
+func main() {
+	validparam("", nil, Point{}, 0, nil, Record{})
+	validreturn_string()
+	validreturn_slice()
+	validreturn_struct()
+	validreturn_tuple()
+	validreturn_error()
+	validreturn_value_error("")
+	validmanyparams("", "", "", "", "", "", "", "", "")
+}
@@ -11,10 +11,16 @@ target triple = "wasm32-unknown-wasi"
 @"reflect/types.type:pointer:named:error" = linkonce_odr constant { i8, i16, ptr } { i8 -43, i16 0, ptr @"reflect/types.type:named:error" }, align 4
 @"reflect/types.type:named:error" = linkonce_odr constant { i8, i16, ptr, ptr, ptr, [7 x i8] } { i8 116, i16 1, ptr @"reflect/types.type:pointer:named:error", ptr @"reflect/types.type:interface:{Error:func:{}{basic:string}}", ptr @"reflect/types.type.pkgpath.empty", [7 x i8] c".error\00" }, align 4
 @"reflect/types.type.pkgpath.empty" = linkonce_odr unnamed_addr constant [1 x i8] zeroinitializer, align 1
-@"reflect/types.type:interface:{Error:func:{}{basic:string}}" = linkonce_odr constant { i8, ptr } { i8 84, ptr @"reflect/types.type:pointer:interface:{Error:func:{}{basic:string}}" }, align 4
+@"reflect/types.type:interface:{Error:func:{}{basic:string}}" = linkonce_odr constant { i8, i16, ptr, { ptr, ptr }, [1 x { ptr, ptr, ptr }] } { i8 84, i16 1, ptr @"reflect/types.type:pointer:interface:{Error:func:{}{basic:string}}", { ptr, ptr } { ptr null, ptr @"interface:{Error:func:{}{basic:string}}.$typeassert" }, [1 x { ptr, ptr, ptr }] [{ ptr, ptr, ptr } { ptr @"reflect/types.method.name:Error", ptr @"reflect/types.type.pkgpath.empty", ptr @"reflect/types.type:func:{}{basic:string}" }] }, align 4
 @"reflect/types.type:pointer:interface:{Error:func:{}{basic:string}}" = linkonce_odr constant { i8, i16, ptr } { i8 -43, i16 0, ptr @"reflect/types.type:interface:{Error:func:{}{basic:string}}" }, align 4
+@"reflect/types.method.name:Error" = linkonce_odr unnamed_addr constant [6 x i8] c"Error\00", align 1
+@"reflect/types.type:func:{}{basic:string}" = linkonce_odr constant { i8, i16, ptr, { ptr, ptr }, i16, i1, [1 x ptr] } { i8 24, i16 0, ptr @"reflect/types.type:pointer:func:{}{basic:string}", { ptr, ptr } { ptr null, ptr @"reflect/types.call:func:{}{basic:string}" }, i16 1, i1 false, [1 x ptr] [ptr @"reflect/types.type:basic:string"] }, align 4
+@"reflect/types.type:basic:string" = linkonce_odr constant { i8, ptr } { i8 81, ptr @"reflect/types.type:pointer:basic:string" }, align 4
+@"reflect/types.type:pointer:basic:string" = linkonce_odr constant { i8, i16, ptr } { i8 -43, i16 0, ptr @"reflect/types.type:basic:string" }, align 4
+@"reflect/types.type:pointer:func:{}{basic:string}" = linkonce_odr constant { i8, i16, ptr } { i8 -43, i16 0, ptr @"reflect/types.type:func:{}{basic:string}" }, align 4
 @"reflect/types.type:pointer:interface:{String:func:{}{basic:string}}" = linkonce_odr constant { i8, i16, ptr } { i8 -43, i16 0, ptr @"reflect/types.type:interface:{String:func:{}{basic:string}}" }, align 4
-@"reflect/types.type:interface:{String:func:{}{basic:string}}" = linkonce_odr constant { i8, ptr } { i8 84, ptr @"reflect/types.type:pointer:interface:{String:func:{}{basic:string}}" }, align 4
+@"reflect/types.type:interface:{String:func:{}{basic:string}}" = linkonce_odr constant { i8, i16, ptr, { ptr, ptr }, [1 x { ptr, ptr, ptr }] } { i8 84, i16 1, ptr @"reflect/types.type:pointer:interface:{String:func:{}{basic:string}}", { ptr, ptr } { ptr null, ptr @"interface:{String:func:{}{basic:string}}.$typeassert" }, [1 x { ptr, ptr, ptr }] [{ ptr, ptr, ptr } { ptr @"reflect/types.method.name:String", ptr @"reflect/types.type.pkgpath.empty", ptr @"reflect/types.type:func:{}{basic:string}" }] }, align 4
+@"reflect/types.method.name:String" = linkonce_odr unnamed_addr constant [7 x i8] c"String\00", align 1
 @"reflect/types.typeid:basic:int" = external constant i8
 
 ; Function Attrs: allockind("alloc,zeroed") allocsize(0)
@@ -55,6 +61,23 @@ entry:
   ret %runtime._interface { ptr @"reflect/types.type:pointer:named:error", ptr null }
 }
 
+declare i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr, ptr) #3
+
+; Function Attrs: nounwind
+define linkonce_odr void @"reflect/types.call:func:{}{basic:string}"(ptr %0, ptr %1, ptr %2, ptr %3) unnamed_addr #2 {
+entry:
+  %fn.unpack = load ptr, ptr %0, align 4
+  %fn.elt1 = getelementptr inbounds i8, ptr %0, i32 4
+  %fn.unpack2 = load ptr, ptr %fn.elt1, align 4
+  %4 = call %runtime._string %fn.unpack2(ptr %fn.unpack) #7
+  %.elt = extractvalue %runtime._string %4, 0
+  store ptr %.elt, ptr %2, align 4
+  %.repack4 = getelementptr inbounds i8, ptr %2, i32 4
+  %.elt5 = extractvalue %runtime._string %4, 1
+  store i32 %.elt5, ptr %.repack4, align 4
+  ret void
+}
+
 ; Function Attrs: nounwind
 define hidden %runtime._interface @main.anonymousInterfaceType(ptr %context) unnamed_addr #2 {
 entry:
@@ -64,6 +87,8 @@ entry:
   ret %runtime._interface { ptr @"reflect/types.type:pointer:interface:{String:func:{}{basic:string}}", ptr null }
 }
 
+declare i1 @"interface:{String:func:{}{basic:string}}.$typeassert"(ptr, ptr) #4
+
 ; Function Attrs: nounwind
 define hidden i1 @main.isInt(ptr %itf.typecode, ptr %itf.value, ptr %context) unnamed_addr #2 {
 entry:
@@ -82,7 +107,7 @@ declare i1 @runtime.typeAssert(ptr, ptr dereferenceable_or_null(1), ptr) #1
 ; Function Attrs: nounwind
 define hidden i1 @main.isError(ptr %itf.typecode, ptr %itf.value, ptr %context) unnamed_addr #2 {
 entry:
-  %0 = call i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr %itf.typecode) #7
+  %0 = call i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr %itf.typecode, ptr undef) #7
   br i1 %0, label %typeassert.ok, label %typeassert.next
 
 typeassert.next:                                  ; preds = %typeassert.ok, %entry
@@ -92,12 +117,10 @@ typeassert.ok:                                    ; preds = %entry
   br label %typeassert.next
 }
 
-declare i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr) #3
-
 ; Function Attrs: nounwind
 define hidden i1 @main.isStringer(ptr %itf.typecode, ptr %itf.value, ptr %context) unnamed_addr #2 {
 entry:
-  %0 = call i1 @"interface:{String:func:{}{basic:string}}.$typeassert"(ptr %itf.typecode) #7
+  %0 = call i1 @"interface:{String:func:{}{basic:string}}.$typeassert"(ptr %itf.typecode, ptr undef) #7
   br i1 %0, label %typeassert.ok, label %typeassert.next
 
 typeassert.next:                                  ; preds = %typeassert.ok, %entry
@@ -107,8 +130,6 @@ typeassert.ok:                                    ; preds = %entry
   br label %typeassert.next
 }
 
-declare i1 @"interface:{String:func:{}{basic:string}}.$typeassert"(ptr) #4
-
 ; Function Attrs: nounwind
 define hidden i8 @main.callFooMethod(ptr %itf.typecode, ptr %itf.value, ptr %context) unnamed_addr #2 {
 entry:
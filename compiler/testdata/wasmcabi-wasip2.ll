@@ -0,0 +1,304 @@
+; ModuleID = 'wasmcabi.go'
+source_filename = "wasmcabi.go"
+target datalayout = "e-m:e-p:32:32-p10:8:8-p20:8:8-i64:64-n32:64-S128-ni:1:10:20"
+target triple = "wasm32-unknown-wasi"
+
+%runtime._string = type { ptr, i32 }
+%main.Point = type { i32, i32 }
+%runtime._interface = type { ptr, ptr }
+
+@"main.stringResult#wasmimport.retarea" = internal global [8 x i8] zeroinitializer, align 4
+@"main.structResult#wasmimport.retarea" = internal global [8 x i8] zeroinitializer, align 4
+@"main.errorResult#wasmimport.retarea" = internal global [12 x i8] zeroinitializer, align 4
+@"main$string" = internal unnamed_addr constant [5 x i8] c"point", align 1
+@"main.exportString#wasmexport.retarea" = internal global [8 x i8] zeroinitializer, align 4
+@llvm.used = appending global [2 x ptr] [ptr @"main.exportString#wasmexport", ptr @"main.exportError#wasmexport"]
+@"main.exportError#wasmexport.retarea" = internal global [12 x i8] zeroinitializer, align 4
+@"main$string.1" = internal unnamed_addr constant [3 x i8] c"foo", align 1
+@"main$string.2" = internal unnamed_addr constant [3 x i8] c"key", align 1
+@"main$string.3" = internal unnamed_addr constant [1 x i8] c"a", align 1
+@"main$string.4" = internal unnamed_addr constant [1 x i8] c"b", align 1
+@"main$string.5" = internal unnamed_addr constant [1 x i8] c"c", align 1
+@"main$string.6" = internal unnamed_addr constant [1 x i8] c"d", align 1
+@"main$string.7" = internal unnamed_addr constant [1 x i8] c"e", align 1
+@"main$string.8" = internal unnamed_addr constant [1 x i8] c"f", align 1
+@"main$string.9" = internal unnamed_addr constant [1 x i8] c"g", align 1
+@"main$string.10" = internal unnamed_addr constant [1 x i8] c"h", align 1
+
+; Function Attrs: allockind("alloc,zeroed") allocsize(0)
+declare noalias nonnull ptr @runtime.alloc(i32, ptr, ptr) #0
+
+declare void @runtime.trackPointer(ptr nocapture readonly, ptr, ptr) #1
+
+; Function Attrs: nounwind
+define hidden void @main.init(ptr %context) unnamed_addr #2 {
+entry:
+  ret void
+}
+
+declare i32 @main.stringParam(ptr nocapture, i32) #3
+
+; Function Attrs: nounwind
+define hidden %runtime._string @main.stringResult(ptr %buf.data, i32 %buf.len, i32 %buf.cap, ptr %context) unnamed_addr #2 {
+entry:
+  call void @"main.stringResult#wasmimport"(ptr %buf.data, i32 %buf.len, ptr nonnull @"main.stringResult#wasmimport.retarea") #12
+  %0 = load ptr, ptr @"main.stringResult#wasmimport.retarea", align 4
+  %1 = insertvalue %runtime._string undef, ptr %0, 0
+  %2 = load i32, ptr getelementptr inbounds (i8, ptr @"main.stringResult#wasmimport.retarea", i32 4), align 4
+  %3 = insertvalue %runtime._string %1, i32 %2, 1
+  ret %runtime._string %3
+}
+
+declare void @"main.stringResult#wasmimport"(ptr, i32, ptr) #4
+
+; Function Attrs: nounwind
+define hidden %main.Point @main.structResult(ptr %context) unnamed_addr #2 {
+entry:
+  call void @"main.structResult#wasmimport"(ptr nonnull @"main.structResult#wasmimport.retarea") #12
+  %0 = load i32, ptr @"main.structResult#wasmimport.retarea", align 4
+  %1 = insertvalue %main.Point undef, i32 %0, 0
+  %2 = load i32, ptr getelementptr inbounds (i8, ptr @"main.structResult#wasmimport.retarea", i32 4), align 4
+  %3 = insertvalue %main.Point %1, i32 %2, 1
+  ret %main.Point %3
+}
+
+declare void @"main.structResult#wasmimport"(ptr) #5
+
+; Function Attrs: nounwind
+define hidden { %runtime._string, %runtime._interface } @main.errorResult(ptr %key.data, i32 %key.len, ptr %context) unnamed_addr #2 {
+entry:
+  call void @"main.errorResult#wasmimport"(ptr %key.data, i32 %key.len, ptr nonnull @"main.errorResult#wasmimport.retarea") #12
+  %0 = load i8, ptr @"main.errorResult#wasmimport.retarea", align 4
+  %.not = icmp eq i8 %0, 0
+  br i1 %.not, label %cabi.ok, label %cabi.err
+
+cabi.ok:                                          ; preds = %entry
+  %1 = load ptr, ptr getelementptr inbounds (i8, ptr @"main.errorResult#wasmimport.retarea", i32 4), align 4
+  %2 = insertvalue %runtime._string undef, ptr %1, 0
+  %3 = load i32, ptr getelementptr inbounds (i8, ptr @"main.errorResult#wasmimport.retarea", i32 8), align 4
+  %4 = insertvalue %runtime._string %2, i32 %3, 1
+  br label %cabi.done
+
+cabi.err:                                         ; preds = %entry
+  %5 = load ptr, ptr getelementptr inbounds (i8, ptr @"main.errorResult#wasmimport.retarea", i32 4), align 4
+  %6 = load i32, ptr getelementptr inbounds (i8, ptr @"main.errorResult#wasmimport.retarea", i32 8), align 4
+  %7 = call %runtime._interface @runtime.cabiNewError(ptr %5, i32 %6, ptr undef) #12
+  br label %cabi.done
+
+cabi.done:                                        ; preds = %cabi.err, %cabi.ok
+  %8 = phi %runtime._string [ %4, %cabi.ok ], [ zeroinitializer, %cabi.err ]
+  %9 = phi %runtime._interface [ zeroinitializer, %cabi.ok ], [ %7, %cabi.err ]
+  %10 = insertvalue { %runtime._string, %runtime._interface } undef, %runtime._string %8, 0
+  %11 = insertvalue { %runtime._string, %runtime._interface } %10, %runtime._interface %9, 1
+  ret { %runtime._string, %runtime._interface } %11
+}
+
+declare void @"main.errorResult#wasmimport"(ptr, i32, ptr) #6
+
+declare %runtime._interface @runtime.cabiNewError(ptr, i32, ptr) #1
+
+; Function Attrs: nounwind
+define hidden void @main.manyParams(ptr %a.data, i32 %a.len, ptr %b.data, i32 %b.len, ptr %c.data, i32 %c.len, ptr %d.data, i32 %d.len, ptr %e.data, i32 %e.len, ptr %f.data, i32 %f.len, ptr %g.data, i32 %g.len, ptr %h.data, i32 %h.len, ptr %i.data, i32 %i.len, i32 %i.cap, ptr %context) unnamed_addr #2 {
+entry:
+  %params = alloca [72 x i8], align 4
+  store ptr %a.data, ptr %params, align 4
+  %0 = getelementptr inbounds i8, ptr %params, i32 4
+  store i32 %a.len, ptr %0, align 4
+  %1 = getelementptr inbounds i8, ptr %params, i32 8
+  store ptr %b.data, ptr %1, align 4
+  %2 = getelementptr inbounds i8, ptr %params, i32 12
+  store i32 %b.len, ptr %2, align 4
+  %3 = getelementptr inbounds i8, ptr %params, i32 16
+  store ptr %c.data, ptr %3, align 4
+  %4 = getelementptr inbounds i8, ptr %params, i32 20
+  store i32 %c.len, ptr %4, align 4
+  %5 = getelementptr inbounds i8, ptr %params, i32 24
+  store ptr %d.data, ptr %5, align 4
+  %6 = getelementptr inbounds i8, ptr %params, i32 28
+  store i32 %d.len, ptr %6, align 4
+  %7 = getelementptr inbounds i8, ptr %params, i32 32
+  store ptr %e.data, ptr %7, align 4
+  %8 = getelementptr inbounds i8, ptr %params, i32 36
+  store i32 %e.len, ptr %8, align 4
+  %9 = getelementptr inbounds i8, ptr %params, i32 40
+  store ptr %f.data, ptr %9, align 4
+  %10 = getelementptr inbounds i8, ptr %params, i32 44
+  store i32 %f.len, ptr %10, align 4
+  %11 = getelementptr inbounds i8, ptr %params, i32 48
+  store ptr %g.data, ptr %11, align 4
+  %12 = getelementptr inbounds i8, ptr %params, i32 52
+  store i32 %g.len, ptr %12, align 4
+  %13 = getelementptr inbounds i8, ptr %params, i32 56
+  store ptr %h.data, ptr %13, align 4
+  %14 = getelementptr inbounds i8, ptr %params, i32 60
+  store i32 %h.len, ptr %14, align 4
+  %15 = getelementptr inbounds i8, ptr %params, i32 64
+  store ptr %i.data, ptr %15, align 4
+  %16 = getelementptr inbounds i8, ptr %params, i32 68
+  store i32 %i.len, ptr %16, align 4
+  call void @"main.manyParams#wasmimport"(ptr nonnull %params) #12
+  ret void
+}
+
+declare void @"main.manyParams#wasmimport"(ptr) #7
+
+; Function Attrs: nounwind
+define hidden %runtime._string @main.exportString(i32 %p.X, i32 %p.Y, ptr %context) unnamed_addr #2 {
+entry:
+  ret %runtime._string { ptr @"main$string", i32 5 }
+}
+
+; Function Attrs: nounwind
+define ptr @"main.exportString#wasmexport"(i32 %0, i32 %1) #8 {
+entry:
+  call void @runtime.wasmExportCheckRun(ptr undef) #12
+  %status = alloca { i32, i32, i1, %runtime._string }, align 8
+  %done.gep = getelementptr inbounds i8, ptr %status, i32 8
+  store i1 false, ptr %done.gep, align 8
+  store i32 %0, ptr %status, align 8
+  %2 = getelementptr inbounds i8, ptr %status, i32 4
+  store i32 %1, ptr %2, align 4
+  call void @"internal/task.start"(i32 ptrtoint (ptr @"main.exportString$gowrapper-wasmexport" to i32), ptr nonnull %status, i32 65536, ptr undef) #12
+  call void @runtime.wasmExportRun(ptr nonnull %done.gep, ptr undef) #12
+  %retval.elt = getelementptr inbounds i8, ptr %status, i32 12
+  %retval.unpack = load ptr, ptr %retval.elt, align 4
+  %retval.elt1 = getelementptr inbounds i8, ptr %status, i32 16
+  %retval.unpack2 = load i32, ptr %retval.elt1, align 8
+  store ptr %retval.unpack, ptr @"main.exportString#wasmexport.retarea", align 4
+  store i32 %retval.unpack2, ptr getelementptr inbounds (i8, ptr @"main.exportString#wasmexport.retarea", i32 4), align 4
+  ret ptr @"main.exportString#wasmexport.retarea"
+}
+
+declare void @runtime.wasmExportCheckRun(ptr) #1
+
+declare void @runtime.deadlock(ptr) #1
+
+; Function Attrs: nounwind
+define linkonce_odr void @"main.exportString$gowrapper-wasmexport"(ptr %0) unnamed_addr #9 {
+entry:
+  %1 = load i32, ptr %0, align 4
+  %2 = getelementptr inbounds i8, ptr %0, i32 4
+  %3 = load i32, ptr %2, align 4
+  %4 = call %runtime._string @main.exportString(i32 %1, i32 %3, ptr null)
+  %result.ptr.repack = getelementptr inbounds i8, ptr %0, i32 12
+  %.elt = extractvalue %runtime._string %4, 0
+  store ptr %.elt, ptr %result.ptr.repack, align 4
+  %result.ptr.repack1 = getelementptr inbounds i8, ptr %0, i32 16
+  %.elt2 = extractvalue %runtime._string %4, 1
+  store i32 %.elt2, ptr %result.ptr.repack1, align 4
+  %done.gep = getelementptr inbounds i8, ptr %0, i32 8
+  store i1 true, ptr %done.gep, align 1
+  call void @runtime.wasmExportExit(ptr undef) #12
+  unreachable
+}
+
+declare void @runtime.wasmExportExit(ptr) #1
+
+declare void @"internal/task.start"(i32, ptr, i32, ptr) #1
+
+declare void @runtime.wasmExportRun(ptr dereferenceable_or_null(1), ptr) #1
+
+; Function Attrs: nounwind
+define hidden { i32, %runtime._interface } @main.exportError(ptr %s.data, i32 %s.len, ptr %context) unnamed_addr #2 {
+entry:
+  %0 = insertvalue { i32, %runtime._interface } zeroinitializer, i32 %s.len, 0
+  %1 = insertvalue { i32, %runtime._interface } %0, %runtime._interface zeroinitializer, 1
+  ret { i32, %runtime._interface } %1
+}
+
+; Function Attrs: nounwind
+define ptr @"main.exportError#wasmexport"(ptr %0, i32 %1) #10 {
+entry:
+  call void @runtime.wasmExportCheckRun(ptr undef) #12
+  %status = alloca { ptr, i32, i1, { i32, %runtime._interface } }, align 8
+  %done.gep = getelementptr inbounds i8, ptr %status, i32 8
+  store i1 false, ptr %done.gep, align 8
+  store ptr %0, ptr %status, align 8
+  %2 = getelementptr inbounds i8, ptr %status, i32 4
+  store i32 %1, ptr %2, align 4
+  call void @"internal/task.start"(i32 ptrtoint (ptr @"main.exportError$gowrapper-wasmexport" to i32), ptr nonnull %status, i32 65536, ptr undef) #12
+  call void @runtime.wasmExportRun(ptr nonnull %done.gep, ptr undef) #12
+  %retval.elt = getelementptr inbounds i8, ptr %status, i32 12
+  %retval.unpack = load i32, ptr %retval.elt, align 4
+  %retval.unpack2.elt = getelementptr inbounds i8, ptr %status, i32 16
+  %retval.unpack2.unpack = load ptr, ptr %retval.unpack2.elt, align 8
+  %retval.unpack2.elt4 = getelementptr inbounds i8, ptr %status, i32 20
+  %retval.unpack2.unpack5 = load ptr, ptr %retval.unpack2.elt4, align 4
+  %3 = icmp ne ptr %retval.unpack2.unpack, null
+  %4 = zext i1 %3 to i8
+  store i8 %4, ptr @"main.exportError#wasmexport.retarea", align 4
+  br i1 %3, label %cabi.err, label %cabi.ok
+
+cabi.ok:                                          ; preds = %entry
+  store i32 %retval.unpack, ptr getelementptr inbounds (i8, ptr @"main.exportError#wasmexport.retarea", i32 4), align 4
+  ret ptr @"main.exportError#wasmexport.retarea"
+
+cabi.err:                                         ; preds = %entry
+  %5 = call %runtime._string @runtime.cabiErrorMessage(ptr nonnull %retval.unpack2.unpack, ptr %retval.unpack2.unpack5, ptr undef) #12
+  %6 = extractvalue %runtime._string %5, 0
+  store ptr %6, ptr getelementptr inbounds (i8, ptr @"main.exportError#wasmexport.retarea", i32 4), align 4
+  %7 = extractvalue %runtime._string %5, 1
+  store i32 %7, ptr getelementptr inbounds (i8, ptr @"main.exportError#wasmexport.retarea", i32 8), align 4
+  ret ptr @"main.exportError#wasmexport.retarea"
+}
+
+; Function Attrs: nounwind
+define linkonce_odr void @"main.exportError$gowrapper-wasmexport"(ptr %0) unnamed_addr #11 {
+entry:
+  %1 = load ptr, ptr %0, align 4
+  %2 = getelementptr inbounds i8, ptr %0, i32 4
+  %3 = load i32, ptr %2, align 4
+  %4 = call { i32, %runtime._interface } @main.exportError(ptr %1, i32 %3, ptr null)
+  %result.ptr.repack = getelementptr inbounds i8, ptr %0, i32 12
+  %.elt = extractvalue { i32, %runtime._interface } %4, 0
+  store i32 %.elt, ptr %result.ptr.repack, align 4
+  %.elt2 = extractvalue { i32, %runtime._interface } %4, 1
+  %result.ptr.repack1.repack = getelementptr inbounds i8, ptr %0, i32 16
+  %.elt2.elt = extractvalue %runtime._interface %.elt2, 0
+  store ptr %.elt2.elt, ptr %result.ptr.repack1.repack, align 4
+  %result.ptr.repack1.repack3 = getelementptr inbounds i8, ptr %0, i32 20
+  %.elt2.elt4 = extractvalue %runtime._interface %.elt2, 1
+  store ptr %.elt2.elt4, ptr %result.ptr.repack1.repack3, align 4
+  %done.gep = getelementptr inbounds i8, ptr %0, i32 8
+  store i1 true, ptr %done.gep, align 1
+  call void @runtime.wasmExportExit(ptr undef) #12
+  unreachable
+}
+
+declare %runtime._string @runtime.cabiErrorMessage(ptr, ptr, ptr) #1
+
+; Function Attrs: nounwind
+define hidden void @main.useImports(ptr %context) unnamed_addr #2 {
+entry:
+  %stackalloc = alloca i8, align 1
+  %0 = call i32 @main.stringParam(ptr @"main$string.1", i32 3) #12
+  %1 = call %runtime._string @main.stringResult(ptr null, i32 0, i32 0, ptr undef)
+  %2 = extractvalue %runtime._string %1, 0
+  call void @runtime.trackPointer(ptr %2, ptr nonnull %stackalloc, ptr undef) #12
+  %3 = call %main.Point @main.structResult(ptr undef)
+  %4 = call { %runtime._string, %runtime._interface } @main.errorResult(ptr @"main$string.2", i32 3, ptr undef)
+  %5 = extractvalue { %runtime._string, %runtime._interface } %4, 0
+  %6 = extractvalue %runtime._string %5, 0
+  call void @runtime.trackPointer(ptr %6, ptr nonnull %stackalloc, ptr undef) #12
+  %7 = extractvalue { %runtime._string, %runtime._interface } %4, 1
+  %8 = extractvalue %runtime._interface %7, 0
+  call void @runtime.trackPointer(ptr %8, ptr nonnull %stackalloc, ptr undef) #12
+  %9 = extractvalue %runtime._interface %7, 1
+  call void @runtime.trackPointer(ptr %9, ptr nonnull %stackalloc, ptr undef) #12
+  call void @main.manyParams(ptr @"main$string.3", i32 1, ptr @"main$string.4", i32 1, ptr @"main$string.5", i32 1, ptr @"main$string.6", i32 1, ptr @"main$string.7", i32 1, ptr @"main$string.8", i32 1, ptr @"main$string.9", i32 1, ptr @"main$string.10", i32 1, ptr null, i32 0, i32 0, ptr undef)
+  ret void
+}
+
+attributes #0 = { allockind("alloc,zeroed") allocsize(0) "alloc-family"="runtime.alloc" "target-features"="+bulk-memory,+mutable-globals,+nontrapping-fptoint,+sign-ext,-multivalue,-reference-types" }
+attributes #1 = { "target-features"="+bulk-memory,+mutable-globals,+nontrapping-fptoint,+sign-ext,-multivalue,-reference-types" }
+attributes #2 = { nounwind "target-features"="+bulk-memory,+mutable-globals,+nontrapping-fptoint,+sign-ext,-multivalue,-reference-types" }
+attributes #3 = { "target-features"="+bulk-memory,+mutable-globals,+nontrapping-fptoint,+sign-ext,-multivalue,-reference-types" "wasm-import-module"="modulename" "wasm-import-name"="stringParam" }
+attributes #4 = { "wasm-import-module"="modulename" "wasm-import-name"="stringResult" }
+attributes #5 = { "wasm-import-module"="modulename" "wasm-import-name"="structResult" }
+attributes #6 = { "wasm-import-module"="modulename" "wasm-import-name"="errorResult" }
+attributes #7 = { "wasm-import-module"="modulename" "wasm-import-name"="manyParams" }
+attributes #8 = { nounwind "target-features"="+bulk-memory,+mutable-globals,+nontrapping-fptoint,+sign-ext,-multivalue,-reference-types" "wasm-export-name"="exportString" }
+attributes #9 = { nounwind "target-features"="+bulk-memory,+mutable-globals,+nontrapping-fptoint,+sign-ext,-multivalue,-reference-types" "tinygo-gowrapper"="main.exportString" }
+attributes #10 = { nounwind "target-features"="+bulk-memory,+mutable-globals,+nontrapping-fptoint,+sign-ext,-multivalue,-reference-types" "wasm-export-name"="exportError" }
+attributes #11 = { nounwind "target-features"="+bulk-memory,+mutable-globals,+nontrapping-fptoint,+sign-ext,-multivalue,-reference-types" "tinygo-gowrapper"="main.exportError" }
+attributes #12 = { nounwind }
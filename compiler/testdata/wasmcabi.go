@@ -0,0 +1,41 @@
+package main
+
+// Test lowering of //go:wasmimport and //go:wasmexport to the canonical ABI,
+// which is enabled by default on wasip2.
+
+type Point struct {
+	X, Y int32
+}
+
+//go:wasmimport modulename stringParam
+func stringParam(s string) int32
+
+//go:wasmimport modulename stringResult
+func stringResult(buf []byte) string
+
+//go:wasmimport modulename structResult
+func structResult() Point
+
+//go:wasmimport modulename errorResult
+func errorResult(key string) (string, error)
+
+//go:wasmimport modulename manyParams
+func manyParams(a, b, c, d, e, f, g, h string, i []byte)
+
+//go:wasmexport exportString
+func exportString(p Point) string {
+	return "point"
+}
+
+//go:wasmexport exportError
+func exportError(s string) (uint32, error) {
+	return uint32(len(s)), nil
+}
+
+func useImports() {
+	stringParam("foo")
+	stringResult(nil)
+	structResult()
+	errorResult("key")
+	manyParams("a", "b", "c", "d", "e", "f", "g", "h", nil)
+}
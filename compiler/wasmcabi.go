@@ -0,0 +1,679 @@
+package compiler
+
+// This file implements lowering of //go:wasmimport and //go:wasmexport
+// functions using the component model canonical ABI. This allows strings,
+// slices, structs and error results to be used in these functions when
+// building a WebAssembly component, without hand-written glue code.
+//
+// For details on the canonical ABI, see:
+// https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md
+//
+// Go types are mapped to component model types as follows:
+//
+//	bool                      bool
+//	int8, int16, int32, int64 s8, s16, s32, s64
+//	uint8, ..., uint64        u8, u16, u32, u64
+//	float32, float64          f32, f64
+//	uintptr, unsafe.Pointer   u32
+//	*T                        u32
+//	string                    string
+//	[]T                       list<T>
+//	struct                    record
+//	(T1, T2, ...)             tuple<T1, T2, ...>
+//	(T, error)                result<T, string>
+//
+// Slice elements must have the same layout in Go as in the canonical ABI, so
+// that the backing array can be shared with the host without copying.
+
+import (
+	"fmt"
+	"go/types"
+
+	"tinygo.org/x/go-llvm"
+)
+
+// Limits on the number of flattened parameters and results. When there are
+// more, they are passed in linear memory instead.
+const (
+	cabiMaxFlatParams  = 16
+	cabiMaxFlatResults = 1
+)
+
+// cabiSignature describes how a Go function signature is lowered to a core
+// WebAssembly function signature using the canonical ABI.
+type cabiSignature struct {
+	params          []types.Type
+	results         []types.Type // results, excluding the trailing error
+	hasError        bool         // whether the last result is an error
+	flatParams      []llvm.Type
+	flatResults     []llvm.Type
+	paramsInMemory  bool // params are passed as a pointer to a record
+	resultsInMemory bool // results are passed through a return area
+}
+
+// checkCABISignature checks whether the parameters and results of this
+// signature can be lowered using the canonical ABI, and adds an error if this
+// is not the case.
+func (c *compilerContext) checkCABISignature(sig *types.Signature, pragma string) {
+	results := sig.Results()
+	for i := 0; i < results.Len(); i++ {
+		result := results.At(i)
+		if i == results.Len()-1 && isErrorType(result.Type()) {
+			continue
+		}
+		if !c.isValidCABIType(result.Type()) {
+			c.addError(result.Pos(), fmt.Sprintf("%s: unsupported result type %s", pragma, result.Type().String()))
+		}
+	}
+	for _, param := range getParams(sig) {
+		if !c.isValidCABIType(param.Type()) {
+			c.addError(param.Pos(), fmt.Sprintf("%s: unsupported parameter type %s", pragma, param.Type().String()))
+		}
+	}
+}
+
+// isValidCABIType returns whether this type can be used as a parameter or
+// result in the canonical ABI.
+func (c *compilerContext) isValidCABIType(typ types.Type) bool {
+	switch typ := typ.Underlying().(type) {
+	case *types.Slice:
+		return c.isValidCABIMemoryType(typ.Elem())
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if !c.isValidCABIType(typ.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return c.isValidCABIMemoryType(typ)
+}
+
+// isValidCABIMemoryType returns whether this type has the same memory layout in
+// Go as in the canonical ABI.
+func (c *compilerContext) isValidCABIMemoryType(typ types.Type) bool {
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch typ.Kind() {
+		case types.Bool, types.Int8, types.Int16, types.Int32, types.Int64:
+			return true
+		case types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+			return true
+		case types.Float32, types.Float64:
+			return true
+		case types.Uintptr, types.UnsafePointer:
+			return true
+		case types.String:
+			return true
+		}
+	case *types.Pointer:
+		return c.isValidWasmType(typ, siteParam)
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if !c.isValidCABIMemoryType(typ.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isErrorType returns whether this is the predeclared error type.
+func isErrorType(typ types.Type) bool {
+	return types.Identical(typ, types.Universe.Lookup("error").Type())
+}
+
+// getCABISignature returns how the given function signature is lowered using
+// the canonical ABI. The signature must have been checked with
+// checkCABISignature.
+func (c *compilerContext) getCABISignature(sig *types.Signature) *cabiSignature {
+	s := &cabiSignature{}
+	for _, param := range getParams(sig) {
+		s.params = append(s.params, param.Type())
+		s.flatParams = append(s.flatParams, c.getCABIFlatTypes(param.Type())...)
+	}
+	if len(s.flatParams) > cabiMaxFlatParams {
+		s.paramsInMemory = true
+		s.flatParams = []llvm.Type{c.dataPtrType}
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		typ := sig.Results().At(i).Type()
+		if i == sig.Results().Len()-1 && isErrorType(typ) {
+			s.hasError = true
+			break
+		}
+		s.results = append(s.results, typ)
+		s.flatResults = append(s.flatResults, c.getCABIFlatTypes(typ)...)
+	}
+	if s.hasError || len(s.flatResults) > cabiMaxFlatResults {
+		// A result<T, string> always needs at least two flat values (the
+		// discriminant and the string), so it is always passed in memory.
+		s.resultsInMemory = true
+		s.flatResults = nil
+	}
+	return s
+}
+
+// liftedFunctionType returns the core WebAssembly function type of a
+// //go:wasmexport function.
+func (s *cabiSignature) liftedFunctionType(c *compilerContext) llvm.Type {
+	returnType := c.ctx.VoidType()
+	if s.resultsInMemory {
+		returnType = c.dataPtrType
+	} else if len(s.flatResults) != 0 {
+		returnType = s.flatResults[0]
+	}
+	return llvm.FunctionType(returnType, s.flatParams, false)
+}
+
+// loweredFunctionType returns the core WebAssembly function type of a
+// //go:wasmimport function.
+func (s *cabiSignature) loweredFunctionType(c *compilerContext) llvm.Type {
+	paramTypes := s.flatParams
+	if s.resultsInMemory {
+		// The caller passes a pointer to the return area.
+		paramTypes = append(paramTypes[:len(paramTypes):len(paramTypes)], c.dataPtrType)
+	}
+	returnType := c.ctx.VoidType()
+	if len(s.flatResults) != 0 {
+		returnType = s.flatResults[0]
+	}
+	return llvm.FunctionType(returnType, paramTypes, false)
+}
+
+// resultLayout returns the layout of the results in the return area: the
+// offset of each result, the total size and the alignment. When the function
+// returns an error, the results are stored as result<T, string> where the
+// first byte is the discriminant (0 for ok, 1 for error) followed by either
+// the results or the error message at the payload offset.
+func (s *cabiSignature) resultLayout(c *compilerContext) (offsets []uint64, payloadOffset, size, alignment uint64) {
+	offsets, size, alignment = c.getCABIRecordLayout(s.results)
+	if !s.hasError {
+		if size == 0 {
+			size = 1 // avoid a zero-sized global
+		}
+		return offsets, 0, size, alignment
+	}
+	stringSize, stringAlign := c.getCABISizeAlign(types.Typ[types.String])
+	if stringAlign > alignment {
+		alignment = stringAlign
+	}
+	if stringSize > size {
+		size = stringSize
+	}
+	payloadOffset = uint64(align(1, int64(alignment)))
+	for i := range offsets {
+		offsets[i] += payloadOffset
+	}
+	size = uint64(align(int64(payloadOffset+size), int64(alignment)))
+	return offsets, payloadOffset, size, alignment
+}
+
+// getCABIFlatTypes returns the core WebAssembly types that the given type is
+// flattened to.
+func (c *compilerContext) getCABIFlatTypes(typ types.Type) []llvm.Type {
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch typ.Kind() {
+		case types.Int64, types.Uint64:
+			return []llvm.Type{c.ctx.Int64Type()}
+		case types.Float32:
+			return []llvm.Type{c.ctx.FloatType()}
+		case types.Float64:
+			return []llvm.Type{c.ctx.DoubleType()}
+		case types.UnsafePointer:
+			return []llvm.Type{c.dataPtrType}
+		case types.String:
+			return []llvm.Type{c.dataPtrType, c.ctx.Int32Type()}
+		default:
+			// bool, 8, 16 and 32 bit integers, uintptr
+			return []llvm.Type{c.ctx.Int32Type()}
+		}
+	case *types.Pointer:
+		return []llvm.Type{c.dataPtrType}
+	case *types.Slice:
+		return []llvm.Type{c.dataPtrType, c.ctx.Int32Type()}
+	case *types.Struct:
+		var flat []llvm.Type
+		for i := 0; i < typ.NumFields(); i++ {
+			flat = append(flat, c.getCABIFlatTypes(typ.Field(i).Type())...)
+		}
+		return flat
+	}
+	panic("unknown canonical ABI type: " + typ.String())
+}
+
+// getCABISizeAlign returns the size and alignment of the type when stored in
+// linear memory. The canonical ABI is only used on wasm32, so pointers are
+// always 4 bytes.
+func (c *compilerContext) getCABISizeAlign(typ types.Type) (size, alignment uint64) {
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch typ.Kind() {
+		case types.Bool, types.Int8, types.Uint8:
+			return 1, 1
+		case types.Int16, types.Uint16:
+			return 2, 2
+		case types.Int64, types.Uint64, types.Float64:
+			return 8, 8
+		case types.String:
+			return 8, 4
+		default:
+			// 32 bit integers, float32, uintptr, unsafe.Pointer
+			return 4, 4
+		}
+	case *types.Pointer:
+		return 4, 4
+	case *types.Slice:
+		return 8, 4
+	case *types.Struct:
+		_, size, alignment := c.getCABIRecordLayout(structFieldTypes(typ))
+		return size, alignment
+	}
+	panic("unknown canonical ABI type: " + typ.String())
+}
+
+// getCABIRecordLayout returns the field offsets, size and alignment of a
+// record with the given field types.
+func (c *compilerContext) getCABIRecordLayout(fields []types.Type) (offsets []uint64, size, alignment uint64) {
+	alignment = 1
+	for _, field := range fields {
+		fieldSize, fieldAlign := c.getCABISizeAlign(field)
+		size = uint64(align(int64(size), int64(fieldAlign)))
+		offsets = append(offsets, size)
+		size += fieldSize
+		if fieldAlign > alignment {
+			alignment = fieldAlign
+		}
+	}
+	size = uint64(align(int64(size), int64(alignment)))
+	return offsets, size, alignment
+}
+
+// structFieldTypes returns the types of all fields in the struct.
+func structFieldTypes(typ *types.Struct) []types.Type {
+	fields := make([]types.Type, typ.NumFields())
+	for i := range fields {
+		fields[i] = typ.Field(i).Type()
+	}
+	return fields
+}
+
+// getCABIReturnArea returns the global that is used to pass results in linear
+// memory. It is a global (and not a stack allocation) because for exports, the
+// results are read by the host after the function returns. It also keeps any
+// pointers stored in it visible to the garbage collector.
+func (c *compilerContext) getCABIReturnArea(name string, size, alignment uint64) llvm.Value {
+	global := c.mod.NamedGlobal(name)
+	if global.IsNil() {
+		globalType := llvm.ArrayType(c.ctx.Int8Type(), int(size))
+		global = llvm.AddGlobal(c.mod, globalType, name)
+		global.SetInitializer(llvm.ConstNull(globalType))
+		global.SetLinkage(llvm.InternalLinkage)
+		global.SetAlignment(int(alignment))
+	}
+	return global
+}
+
+// lowerCABIFlat converts a Go value to its flattened canonical ABI values.
+func (b *builder) lowerCABIFlat(value llvm.Value, typ types.Type) []llvm.Value {
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch typ.Kind() {
+		case types.Bool, types.Uint8, types.Uint16:
+			return []llvm.Value{b.CreateZExt(value, b.ctx.Int32Type(), "")}
+		case types.Int8, types.Int16:
+			return []llvm.Value{b.CreateSExt(value, b.ctx.Int32Type(), "")}
+		case types.String:
+			return []llvm.Value{
+				b.CreateExtractValue(value, 0, ""),
+				b.CreateExtractValue(value, 1, ""),
+			}
+		}
+	case *types.Slice:
+		return []llvm.Value{
+			b.CreateExtractValue(value, 0, ""),
+			b.CreateExtractValue(value, 1, ""),
+		}
+	case *types.Struct:
+		var flat []llvm.Value
+		for i := 0; i < typ.NumFields(); i++ {
+			field := b.CreateExtractValue(value, i, "")
+			flat = append(flat, b.lowerCABIFlat(field, typ.Field(i).Type())...)
+		}
+		return flat
+	}
+	return []llvm.Value{value}
+}
+
+// liftCABIFlat converts flattened canonical ABI values to a Go value. It
+// returns the Go value and the remaining flat values.
+func (b *builder) liftCABIFlat(flat []llvm.Value, typ types.Type) (llvm.Value, []llvm.Value) {
+	llvmType := b.getLLVMType(typ)
+	switch utyp := typ.Underlying().(type) {
+	case *types.Basic:
+		switch utyp.Kind() {
+		case types.Bool:
+			zero := llvm.ConstNull(flat[0].Type())
+			return b.CreateICmp(llvm.IntNE, flat[0], zero, ""), flat[1:]
+		case types.Int8, types.Int16, types.Uint8, types.Uint16:
+			return b.CreateTrunc(flat[0], llvmType, ""), flat[1:]
+		case types.String:
+			value := llvm.Undef(llvmType)
+			value = b.CreateInsertValue(value, flat[0], 0, "")
+			value = b.CreateInsertValue(value, flat[1], 1, "")
+			return value, flat[2:]
+		}
+	case *types.Slice:
+		value := llvm.Undef(llvmType)
+		value = b.CreateInsertValue(value, flat[0], 0, "")
+		value = b.CreateInsertValue(value, flat[1], 1, "")
+		value = b.CreateInsertValue(value, flat[1], 2, "") // cap == len
+		return value, flat[2:]
+	case *types.Struct:
+		value := llvm.Undef(llvmType)
+		for i := 0; i < utyp.NumFields(); i++ {
+			var field llvm.Value
+			field, flat = b.liftCABIFlat(flat, utyp.Field(i).Type())
+			value = b.CreateInsertValue(value, field, i, "")
+		}
+		return value, flat
+	}
+	return flat[0], flat[1:]
+}
+
+// storeCABI stores a Go value in linear memory at ptr+offset using the
+// canonical ABI memory layout.
+func (b *builder) storeCABI(value llvm.Value, typ types.Type, ptr llvm.Value, offset uint64) {
+	switch utyp := typ.Underlying().(type) {
+	case *types.Basic:
+		switch utyp.Kind() {
+		case types.Bool:
+			value = b.CreateZExt(value, b.ctx.Int8Type(), "")
+		case types.String:
+			b.storeCABI(b.CreateExtractValue(value, 0, ""), types.Typ[types.UnsafePointer], ptr, offset)
+			b.storeCABI(b.CreateExtractValue(value, 1, ""), types.Typ[types.Uintptr], ptr, offset+4)
+			return
+		}
+	case *types.Slice:
+		b.storeCABI(b.CreateExtractValue(value, 0, ""), types.Typ[types.UnsafePointer], ptr, offset)
+		b.storeCABI(b.CreateExtractValue(value, 1, ""), types.Typ[types.Uintptr], ptr, offset+4)
+		return
+	case *types.Struct:
+		fields := structFieldTypes(utyp)
+		offsets, _, _ := b.getCABIRecordLayout(fields)
+		for i, field := range fields {
+			b.storeCABI(b.CreateExtractValue(value, i, ""), field, ptr, offset+offsets[i])
+		}
+		return
+	}
+	b.CreateStore(value, b.cabiFieldPointer(ptr, offset))
+}
+
+// loadCABI loads a Go value from linear memory at ptr+offset using the
+// canonical ABI memory layout.
+func (b *builder) loadCABI(typ types.Type, ptr llvm.Value, offset uint64) llvm.Value {
+	llvmType := b.getLLVMType(typ)
+	switch utyp := typ.Underlying().(type) {
+	case *types.Basic:
+		switch utyp.Kind() {
+		case types.Bool:
+			value := b.CreateLoad(b.ctx.Int8Type(), b.cabiFieldPointer(ptr, offset), "")
+			return b.CreateICmp(llvm.IntNE, value, llvm.ConstInt(b.ctx.Int8Type(), 0, false), "")
+		case types.String:
+			value := llvm.Undef(llvmType)
+			value = b.CreateInsertValue(value, b.loadCABI(types.Typ[types.UnsafePointer], ptr, offset), 0, "")
+			value = b.CreateInsertValue(value, b.loadCABI(types.Typ[types.Uintptr], ptr, offset+4), 1, "")
+			return value
+		}
+	case *types.Slice:
+		length := b.loadCABI(types.Typ[types.Uintptr], ptr, offset+4)
+		value := llvm.Undef(llvmType)
+		value = b.CreateInsertValue(value, b.loadCABI(types.Typ[types.UnsafePointer], ptr, offset), 0, "")
+		value = b.CreateInsertValue(value, length, 1, "")
+		value = b.CreateInsertValue(value, length, 2, "") // cap == len
+		return value
+	case *types.Struct:
+		fields := structFieldTypes(utyp)
+		offsets, _, _ := b.getCABIRecordLayout(fields)
+		value := llvm.Undef(llvmType)
+		for i, field := range fields {
+			value = b.CreateInsertValue(value, b.loadCABI(field, ptr, offset+offsets[i]), i, "")
+		}
+		return value
+	}
+	return b.CreateLoad(llvmType, b.cabiFieldPointer(ptr, offset), "")
+}
+
+// cabiFieldPointer returns ptr+offset.
+func (b *builder) cabiFieldPointer(ptr llvm.Value, offset uint64) llvm.Value {
+	if offset == 0 {
+		return ptr
+	}
+	return b.CreateInBoundsGEP(b.ctx.Int8Type(), ptr, []llvm.Value{
+		llvm.ConstInt(b.ctx.Int32Type(), offset, false),
+	}, "")
+}
+
+// splitResults returns the individual Go results of a function call.
+func (b *builder) splitResults(sig *types.Signature, retval llvm.Value) []llvm.Value {
+	switch sig.Results().Len() {
+	case 0:
+		return nil
+	case 1:
+		return []llvm.Value{retval}
+	default:
+		results := make([]llvm.Value, sig.Results().Len())
+		for i := range results {
+			results[i] = b.CreateExtractValue(retval, i, "")
+		}
+		return results
+	}
+}
+
+// createWasmImport defines a //go:wasmimport function that uses the canonical
+// ABI. The Go function is a wrapper that lowers the parameters, calls the
+// imported function, and lifts the results back to Go values.
+func (b *builder) createWasmImport() {
+	sig := b.getCABISignature(b.fn.Signature)
+	b.createFunctionStart(true)
+
+	// Declare the imported function.
+	importName := b.info.linkName + "#wasmimport"
+	importFnType := sig.loweredFunctionType(b.compilerContext)
+	importFn := b.mod.NamedFunction(importName)
+	if importFn.IsNil() {
+		importFn = llvm.AddFunction(b.mod, importName, importFnType)
+		importFn.AddFunctionAttr(b.ctx.CreateStringAttribute("wasm-import-module", b.info.wasmModule))
+		importFn.AddFunctionAttr(b.ctx.CreateStringAttribute("wasm-import-name", b.info.wasmName))
+	}
+
+	// Lower the parameters.
+	var args []llvm.Value
+	if sig.paramsInMemory {
+		offsets, size, alignment := b.getCABIRecordLayout(sig.params)
+		paramsType := llvm.ArrayType(b.ctx.Int8Type(), int(size))
+		paramsPtr := b.CreateAlloca(paramsType, "params")
+		paramsPtr.SetAlignment(int(alignment))
+		for i, param := range b.fn.Params {
+			b.storeCABI(b.getValue(param, getPos(b.fn)), sig.params[i], paramsPtr, offsets[i])
+		}
+		args = append(args, paramsPtr)
+	} else {
+		for i, param := range b.fn.Params {
+			args = append(args, b.lowerCABIFlat(b.getValue(param, getPos(b.fn)), sig.params[i])...)
+		}
+	}
+	var retptr llvm.Value
+	if sig.resultsInMemory {
+		_, _, size, alignment := sig.resultLayout(b.compilerContext)
+		retptr = b.getCABIReturnArea(importName+".retarea", size, alignment)
+		args = append(args, retptr)
+	}
+
+	// Call the imported function.
+	retval := b.CreateCall(importFnType, importFn, args, "")
+
+	// Lift the results.
+	var results []llvm.Value
+	if !sig.resultsInMemory {
+		var flat []llvm.Value
+		if len(sig.flatResults) != 0 {
+			flat = []llvm.Value{retval}
+		}
+		for _, typ := range sig.results {
+			var result llvm.Value
+			result, flat = b.liftCABIFlat(flat, typ)
+			results = append(results, result)
+		}
+	} else {
+		results = b.loadCABIResults(sig, retptr)
+	}
+
+	// Return the results to the caller.
+	switch len(results) {
+	case 0:
+		b.CreateRetVoid()
+	case 1:
+		b.CreateRet(results[0])
+	default:
+		retval := llvm.Undef(b.llvmFnType.ReturnType())
+		for i, result := range results {
+			retval = b.CreateInsertValue(retval, result, i, "")
+		}
+		b.CreateRet(retval)
+	}
+}
+
+// loadCABIResults loads the results of an imported function from the return
+// area. If the function returns an error, it is created from the error
+// message using runtime.cabiNewError.
+func (b *builder) loadCABIResults(sig *cabiSignature, retptr llvm.Value) []llvm.Value {
+	offsets, payloadOffset, _, _ := sig.resultLayout(b.compilerContext)
+	if !sig.hasError {
+		var results []llvm.Value
+		for i, typ := range sig.results {
+			results = append(results, b.loadCABI(typ, retptr, offsets[i]))
+		}
+		return results
+	}
+
+	// Check the discriminant of the result<T, string>.
+	fn := b.GetInsertBlock().Parent()
+	okBlock := b.ctx.AddBasicBlock(fn, "cabi.ok")
+	errBlock := b.ctx.AddBasicBlock(fn, "cabi.err")
+	doneBlock := b.ctx.AddBasicBlock(fn, "cabi.done")
+	discriminant := b.CreateLoad(b.ctx.Int8Type(), retptr, "")
+	isErr := b.CreateICmp(llvm.IntNE, discriminant, llvm.ConstInt(b.ctx.Int8Type(), 0, false), "")
+	b.CreateCondBr(isErr, errBlock, okBlock)
+
+	// Load the results when there is no error.
+	b.SetInsertPointAtEnd(okBlock)
+	var okResults []llvm.Value
+	for i, typ := range sig.results {
+		okResults = append(okResults, b.loadCABI(typ, retptr, offsets[i]))
+	}
+	okResults = append(okResults, llvm.ConstNull(b.getLLVMType(types.Universe.Lookup("error").Type())))
+	okBlock = b.GetInsertBlock()
+	b.CreateBr(doneBlock)
+
+	// Create an error value from the error message. The other results are
+	// zero.
+	b.SetInsertPointAtEnd(errBlock)
+	msg := b.loadCABI(types.Typ[types.String], retptr, payloadOffset)
+	errValue := b.createRuntimeCall("cabiNewError", []llvm.Value{msg}, "")
+	errBlock = b.GetInsertBlock()
+	b.CreateBr(doneBlock)
+
+	// Merge the results.
+	b.SetInsertPointAtEnd(doneBlock)
+	results := make([]llvm.Value, len(okResults))
+	for i, okResult := range okResults {
+		errResult := errValue
+		if i < len(sig.results) {
+			errResult = llvm.ConstNull(okResult.Type())
+		}
+		phi := b.CreatePHI(okResult.Type(), "")
+		phi.AddIncoming([]llvm.Value{okResult, errResult}, []llvm.BasicBlock{okBlock, errBlock})
+		results[i] = phi
+	}
+	return results
+}
+
+// liftCABIParams converts the parameters of a //go:wasmexport function that
+// uses the canonical ABI to Go values, expanded as Go function parameters.
+func (b *builder) liftCABIParams(params []llvm.Value) []llvm.Value {
+	sig := b.getCABISignature(b.fn.Signature)
+	var values []llvm.Value
+	if sig.paramsInMemory {
+		offsets, _, _ := b.getCABIRecordLayout(sig.params)
+		for i, typ := range sig.params {
+			values = append(values, b.loadCABI(typ, params[0], offsets[i]))
+		}
+	} else {
+		for _, typ := range sig.params {
+			var value llvm.Value
+			value, params = b.liftCABIFlat(params, typ)
+			values = append(values, value)
+		}
+	}
+	var expanded []llvm.Value
+	for _, value := range values {
+		expanded = append(expanded, b.expandFormalParam(value)...)
+	}
+	return expanded
+}
+
+// createCABIReturn lowers the return value of a Go function to the canonical
+// ABI and returns it from a //go:wasmexport function. Results that don't fit
+// in a single value are stored in a return area, and a pointer to it is
+// returned to the host.
+func (b *builder) createCABIReturn(name string, retval llvm.Value) {
+	sig := b.getCABISignature(b.fn.Signature)
+	results := b.splitResults(b.fn.Signature, retval)
+	if !sig.resultsInMemory {
+		var flat []llvm.Value
+		for i, typ := range sig.results {
+			flat = append(flat, b.lowerCABIFlat(results[i], typ)...)
+		}
+		if len(flat) == 0 {
+			b.CreateRetVoid()
+		} else {
+			b.CreateRet(flat[0])
+		}
+		return
+	}
+
+	offsets, payloadOffset, size, alignment := sig.resultLayout(b.compilerContext)
+	retptr := b.getCABIReturnArea(name+".retarea", size, alignment)
+	if !sig.hasError {
+		for i, typ := range sig.results {
+			b.storeCABI(results[i], typ, retptr, offsets[i])
+		}
+		b.CreateRet(retptr)
+		return
+	}
+
+	// Store the result<T, string>: the discriminant, followed by either the
+	// results or the error message.
+	errValue := results[len(results)-1]
+	typecode := b.CreateExtractValue(errValue, 0, "")
+	isErr := b.CreateICmp(llvm.IntNE, typecode, llvm.ConstNull(typecode.Type()), "")
+	b.CreateStore(b.CreateZExt(isErr, b.ctx.Int8Type(), ""), retptr)
+	fn := b.GetInsertBlock().Parent()
+	okBlock := b.ctx.AddBasicBlock(fn, "cabi.ok")
+	errBlock := b.ctx.AddBasicBlock(fn, "cabi.err")
+	b.CreateCondBr(isErr, errBlock, okBlock)
+
+	b.SetInsertPointAtEnd(okBlock)
+	for i, typ := range sig.results {
+		b.storeCABI(results[i], typ, retptr, offsets[i])
+	}
+	b.CreateRet(retptr)
+
+	b.SetInsertPointAtEnd(errBlock)
+	msg := b.createRuntimeCall("cabiErrorMessage", []llvm.Value{errValue}, "")
+	b.storeCABI(msg, types.Typ[types.String], retptr, payloadOffset)
+	b.CreateRet(retptr)
+}
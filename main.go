@@ -252,6 +252,12 @@ func Test(pkgName string, stdout, stderr io.Writer, options *compileopts.Options
 	if testConfig.Shuffle != "" {
 		flags = append(flags, "-test.shuffle="+testConfig.Shuffle)
 	}
+	if testConfig.CPUProfile != "" {
+		flags = append(flags, "-test.cpuprofile="+testConfig.CPUProfile)
+	}
+	if testConfig.Trace != "" {
+		flags = append(flags, "-test.trace="+testConfig.Trace)
+	}
 
 	logToStdout := testConfig.Verbose || testConfig.BenchRegexp != ""
 
@@ -265,8 +271,9 @@ func Test(pkgName string, stdout, stderr io.Writer, options *compileopts.Options
 	passed := false
 	var duration time.Duration
 	result, err := buildAndRun(pkgName, config, output, flags, nil, 0, func(cmd *exec.Cmd, result builder.BuildResult) error {
-		if testConfig.CompileOnly || outpath != "" {
-			// Write test binary to the specified file name.
+		if testConfig.CompileOnly || outpath != "" || testConfig.CPUProfile != "" {
+			// Write test binary to the specified file name. When profiling,
+			// the binary is needed to symbolize the profile.
 			if outpath == "" {
 				// No -o path was given, so create one now.
 				// This matches the behavior of go test.
@@ -885,6 +892,12 @@ func buildAndRun(pkgName string, config *compileopts.Config, stdout io.Writer, c
 
 			emuArgs = append(emuArgs, "--dir="+wd)
 			emuArgs = append(emuArgs, "--env=PWD="+wd)
+
+			// Panics are thrown as WebAssembly exceptions when recover is
+			// supported, which wasmtime doesn't enable by default.
+			if strings.Contains(config.Features(), "+exception-handling") {
+				emuArgs = append(emuArgs, "-W", "exceptions=y")
+			}
 			for _, v := range environmentVars {
 				emuArgs = append(emuArgs, "--env", v)
 			}
@@ -1337,7 +1350,23 @@ boards (like the BBC micro:bit and most professional evaluation boards) have an
 integrated debugger.`
 
 	usageClean = `Clean the cache directory, normally stored in $HOME/.cache/tinygo. This is not
-normally needed.`
+normally needed.
+
+With -cache, only the build cache (compiled packages, optimized programs and
+compiled C files) is removed. Compiled libraries like compiler-rt and the libc
+are kept, as they take a long time to rebuild.`
+
+	usageCache = `Inspect the cache directory, normally stored in $HOME/.cache/tinygo.
+
+	tinygo cache stats
+
+prints the number of files and their total size for each kind of file in the
+cache directory.
+
+Optimized programs are reused when none of the packages of the program (or the
+build configuration) changed. Programs that weren't used in the last 5 days
+are removed automatically, as are the least recently used ones when they take
+up more than 1GiB.`
 
 	usageHelp    = `Print a short summary of the available commands, plus a list of command flags.`
 	usageVersion = `Print the version of the command and the version of the used $GOROOT.`
@@ -1360,6 +1389,7 @@ commands:
 		env:		list environment variables used during build
 		list:		run go list using the TinyGo root
 		clean:		empty cache directory (%s)
+		cache:		show build cache statistics
 		targets:	list targets
 		info:		show info for specified target
 		version:	show version
@@ -1374,6 +1404,7 @@ var (
 		"monitor": usageMonitor,
 		"gdb":     usageGdb,
 		"clean":   usageClean,
+		"cache":   usageCache,
 		"help":    usageHelp,
 		"version": usageVersion,
 		"env":     usageEnv,
@@ -1508,8 +1539,12 @@ func main() {
 		stackSize = uint64(size)
 		return err
 	})
-	printSize := flag.String("size", "", "print sizes (none, short, full, html)")
+	printSize := flag.String("size", "", "print sizes (none, short, full, html, json, csv, why:<symbol or package>)")
+	sizeBudget := flag.String("size-budget", "", "JSON file with flash/RAM size budgets, the build fails if the program exceeds them")
+	sizeBaseline := flag.String("size-baseline", "", "size report (from -size=json) to compare against when a size budget is exceeded")
 	printStacks := flag.Bool("print-stacks", false, "print stack sizes of goroutines")
+	stackReport := flag.String("stack-report", "", "print worst case stack usage of main, goroutines and interrupts (table, json)")
+	stackCheck := flag.Bool("stack-check", false, "detect goroutine stack overflows at runtime and track stack usage (requires -scheduler=tasks)")
 	printAllocsString := flag.String("print-allocs", "", "regular expression of functions for which heap allocations should be printed")
 	printCommands := flag.Bool("x", false, "Print commands")
 	parallelism := flag.Int("p", runtime.GOMAXPROCS(0), "the number of build jobs that can run in parallel")
@@ -1544,6 +1579,10 @@ func main() {
 		flag.BoolVar(&flagDeps, "deps", false, "supply -deps flag to go list")
 		flag.BoolVar(&flagTest, "test", false, "supply -test flag to go list")
 	}
+	var cleanCache bool
+	if command == "help" || command == "clean" {
+		flag.BoolVar(&cleanCache, "cache", false, "clean: only remove the build cache, keep compiled libraries")
+	}
 	var outpath string
 	if command == "help" || command == "build" || command == "test" {
 		flag.StringVar(&outpath, "o", "", "output filename")
@@ -1567,6 +1606,7 @@ func main() {
 		flag.StringVar(&testConfig.BenchTime, "benchtime", "", "run each benchmark for duration `d`")
 		flag.BoolVar(&testConfig.BenchMem, "benchmem", false, "show memory stats for benchmarks")
 		flag.StringVar(&testConfig.Shuffle, "shuffle", "", "shuffle the order the tests and benchmarks run")
+		flag.StringVar(&testConfig.Trace, "trace", "", "write an execution trace to `file`")
 	}
 
 	// Early command processing, before commands are interpreted by the Go flag
@@ -1599,6 +1639,26 @@ func main() {
 		}
 	}
 
+	if command == "test" && *cpuprofile != "" {
+		// Like with go test, -cpuprofile profiles the test binary instead of
+		// the compiler itself. The test runs in the package directory so the
+		// path must be absolute.
+		testConfig.CPUProfile, err = filepath.Abs(*cpuprofile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		*cpuprofile = ""
+	}
+	if testConfig.Trace != "" {
+		// The test runs in the package directory, so make the path absolute.
+		testConfig.Trace, err = filepath.Abs(testConfig.Trace)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	var ocdCommands []string
 	if *ocdCommandsString != "" {
 		ocdCommands = strings.Split(*ocdCommandsString, ",")
@@ -1626,7 +1686,11 @@ func main() {
 		Semaphore:       make(chan struct{}, *parallelism),
 		Debug:           !*nodebug,
 		PrintSizes:      *printSize,
+		SizeBudget:      *sizeBudget,
+		SizeBaseline:    *sizeBaseline,
 		PrintStacks:     *printStacks,
+		StackReport:     *stackReport,
+		StackCheck:      *stackCheck,
 		PrintAllocs:     printAllocs,
 		Tags:            []string(tags),
 		TestConfig:      testConfig,
@@ -1932,12 +1996,42 @@ func main() {
 			os.Exit(1)
 		}
 	case "clean":
+		if cleanCache {
+			// remove only the build cache
+			err := builder.CleanBuildCache(goenv.Get("GOCACHE"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "cannot clean cache:", err)
+				os.Exit(1)
+			}
+			return
+		}
 		// remove cache directory
 		err := os.RemoveAll(goenv.Get("GOCACHE"))
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "cannot clean cache:", err)
 			os.Exit(1)
 		}
+	case "cache":
+		if flag.NArg() != 1 || flag.Arg(0) != "stats" {
+			fmt.Fprintln(os.Stderr, "usage: tinygo cache stats")
+			os.Exit(1)
+		}
+		cacheDir := goenv.Get("GOCACHE")
+		stats, err := builder.ReadCacheStats(cacheDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "cannot read cache:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("cache directory: %s\n", cacheDir)
+		fmt.Printf("%-16s %7s %12s\n", "kind", "files", "size")
+		var totalFiles int
+		var totalSize int64
+		for _, stat := range stats {
+			fmt.Printf("%-16s %7d %12d\n", stat.Name, stat.Files, stat.Size)
+			totalFiles += stat.Files
+			totalSize += stat.Size
+		}
+		fmt.Printf("%-16s %7d %12d\n", "total", totalFiles, totalSize)
 	case "help":
 		command := ""
 		if flag.NArg() >= 1 {
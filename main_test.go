@@ -15,6 +15,7 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -74,6 +75,7 @@ func TestBuild(t *testing.T) {
 		"init_multi.go",
 		"interface.go",
 		"json.go",
+		"makefunc.go",
 		"map.go",
 		"math.go",
 		"oldgo/",
@@ -168,6 +170,15 @@ func TestBuild(t *testing.T) {
 		runPlatTests(optionsFromTarget("riscv-qemu", sema), tests, t)
 	})
 
+	t.Run("EmulatedRISCV64", func(t *testing.T) {
+		// Only test recover for now, as it needs the defer checkpoint and
+		// tinygo_longjmp that are specific to 64-bit RISC-V.
+		t.Parallel()
+		options := optionsFromTarget("riscv64-qemu", sema)
+		emuCheck(t, options)
+		runTest("recover.go", options, t, nil, nil)
+	})
+
 	t.Run("AVR", func(t *testing.T) {
 		t.Parallel()
 		runPlatTests(optionsFromTarget("simavr", sema), tests, t)
@@ -276,6 +287,16 @@ func runPlatTests(options compileopts.Options, tests []string, t *testing.T) {
 			default:
 			}
 		}
+		if name == "makefunc.go" {
+			// reflect.MakeFunc is only implemented on some architectures, see
+			// src/internal/reflectlite/makefunc.go.
+			switch {
+			case spec.GOARCH == "amd64" || spec.GOARCH == "arm64":
+			case spec.GOARCH == "arm" && (options.Target == "cortex-m-qemu" || !isBaremetal):
+			default:
+				continue
+			}
+		}
 		if options.Target == "wasip2" {
 			switch name {
 			case "cgo/":
@@ -317,6 +338,12 @@ func runPlatTests(options compileopts.Options, tests []string, t *testing.T) {
 			runTest("filesystem.go", options, t, nil, nil)
 		})
 	}
+	if options.Target == "" || isWASI {
+		t.Run("machinesim.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("machinesim.go", options, t, nil, nil)
+		})
+	}
 	if options.Target == "" || options.Target == "wasm" || isWASI {
 		t.Run("rand.go", func(t *testing.T) {
 			t.Parallel()
@@ -324,11 +351,23 @@ func runPlatTests(options compileopts.Options, tests []string, t *testing.T) {
 		})
 	}
 	if !isWebAssembly {
-		// The recover() builtin isn't supported yet on WebAssembly and Windows.
 		t.Run("recover.go", func(t *testing.T) {
 			t.Parallel()
 			runTest("recover.go", options, t, nil, nil)
 		})
+	} else if options.Target == "wasm" || isWASI {
+		// The recover() builtin needs the WebAssembly exception handling
+		// proposal, which isn't enabled by default. This also checks that
+		// the asyncify scheduler works with exceptions.
+		t.Run("recover.go", func(t *testing.T) {
+			t.Parallel()
+			if isWASI {
+				checkWasmtimeExceptions(t)
+			}
+			options := compileopts.Options(options)
+			options.LLVMFeatures = "+exception-handling"
+			runTest("recover.go", options, t, nil, nil)
+		})
 	}
 }
 
@@ -528,6 +567,55 @@ func TestWebAssembly(t *testing.T) {
 	}
 }
 
+// Test that a panic aborts the program without running deferred calls when
+// recover isn't supported, which is the case on WebAssembly without the
+// exception-handling feature.
+func TestWasmRecoverFallback(t *testing.T) {
+	t.Parallel()
+	options := optionsFromTarget("wasip1", sema)
+	emuCheck(t, options)
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	var runErr error
+	_, err = buildAndRun("./testdata/recover-fallback.go", config, stdout, nil, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+		runErr = cmd.Run()
+		return nil
+	})
+	if err != nil {
+		t.Fatal("could not build:", err)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(runErr, &exitErr) {
+		t.Errorf("expected the program to exit with an error, got: %v", runErr)
+	}
+	const expected = "before panic\npanic: no exception handling\n"
+	if stdout.String() != expected {
+		t.Errorf("unexpected output:\nexpected:\n%s\nactual:\n%s", expected, stdout.String())
+	}
+}
+
+// checkWasmtimeExceptions skips the test if the installed wasmtime doesn't
+// support the exception handling proposal, which was added in version 37.
+func checkWasmtimeExceptions(t *testing.T) {
+	out, err := exec.Command("wasmtime", "--version").Output()
+	if err != nil {
+		t.Skip("could not run wasmtime:", err)
+	}
+	// The output looks like "wasmtime 37.0.1 (...)".
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		t.Skipf("unknown wasmtime version: %q", out)
+	}
+	major, err := strconv.Atoi(strings.Split(fields[1], ".")[0])
+	if err != nil || major < 37 {
+		t.Skipf("wasmtime %s doesn't support exceptions", fields[1])
+	}
+}
+
 func stringSlicesEqual(s1, s2 []string) bool {
 	// We can use slices.Equal once we drop support for Go 1.20 (it was added in
 	// Go 1.21).
@@ -802,6 +890,127 @@ func TestWasmExportJS(t *testing.T) {
 	}
 }
 
+// Test //go:wasmimport and //go:wasmexport functions that are lowered using the
+// canonical ABI, by acting as the host and passing strings and errors in both
+// directions.
+func TestWasmCanonicalABI(t *testing.T) {
+	t.Parallel()
+
+	// Build the wasm binary.
+	tmpdir := t.TempDir()
+	options := optionsFromTarget("wasip1", sema)
+	options.BuildMode = "c-shared"
+	options.Tags = []string{"tinygo.wasmcanonicalabi"}
+	buildConfig, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := builder.Build("testdata/wasmcabi.go", ".wasm", tmpdir, buildConfig)
+	if err != nil {
+		t.Fatal("failed to build binary:", err)
+	}
+	data, err := os.ReadFile(result.Binary)
+	if err != nil {
+		t.Fatal("could not read wasm binary: ", err)
+	}
+
+	// Set up the wazero runtime.
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(ctx)
+	wasi_snapshot_preview1.MustInstantiate(ctx, r)
+
+	// Strings are passed as a pointer and length. The host allocates the
+	// memory for them using cabi_realloc.
+	writeString := func(ctx context.Context, mod api.Module, s string) (ptr, length uint32) {
+		results, err := mod.ExportedFunction("cabi_realloc").Call(ctx, 0, 0, 1, uint64(len(s)))
+		if err != nil {
+			t.Error("could not call cabi_realloc:", err)
+			return 0, 0
+		}
+		ptr = uint32(results[0])
+		mod.Memory().Write(ptr, []byte(s))
+		return ptr, uint32(len(s))
+	}
+	readString := func(mod api.Module, ptr, length uint32) string {
+		buf, ok := mod.Memory().Read(ptr, length)
+		if !ok {
+			t.Errorf("string out of range: ptr=%#x len=%d", ptr, length)
+		}
+		return string(buf)
+	}
+
+	// A (string, error) result is a result<string, string>, which is stored
+	// as a discriminant byte followed by the string.
+	writeResult := func(ctx context.Context, mod api.Module, retptr uint32, isErr bool, s string) {
+		ptr, length := writeString(ctx, mod, s)
+		discriminant := byte(0)
+		if isErr {
+			discriminant = 1
+		}
+		mod.Memory().WriteByte(retptr, discriminant)
+		mod.Memory().WriteUint32Le(retptr+4, ptr)
+		mod.Memory().WriteUint32Le(retptr+8, length)
+	}
+	readResult := func(mod api.Module, retptr uint32) (isErr bool, s string) {
+		discriminant, _ := mod.Memory().ReadByte(retptr)
+		ptr, _ := mod.Memory().ReadUint32Le(retptr + 4)
+		length, _ := mod.Memory().ReadUint32Le(retptr + 8)
+		return discriminant != 0, readString(mod, ptr, length)
+	}
+
+	// Add custom "tester" module.
+	titles := map[string]string{
+		"Alice": "Dr.",
+	}
+	lookupTitle := func(ctx context.Context, mod api.Module, namePtr, nameLen, retptr uint32) {
+		name := readString(mod, namePtr, nameLen)
+		if title, ok := titles[name]; ok {
+			writeResult(ctx, mod, retptr, false, title)
+		} else {
+			writeResult(ctx, mod, retptr, true, "unknown person: "+name)
+		}
+	}
+	_, err = r.NewHostModuleBuilder("tester").
+		NewFunctionBuilder().WithFunc(lookupTitle).Export("lookupTitle").
+		Instantiate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parse and instantiate the wasm.
+	config := wazero.NewModuleConfig().WithStartFunctions()
+	mod, err := r.InstantiateWithConfig(ctx, data, config)
+	if err != nil {
+		t.Fatal("could not instantiate wasm module:", err)
+	}
+	_, err = mod.ExportedFunction("_initialize").Call(ctx)
+	if err != nil {
+		t.Fatal("failed to run _initialize:", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		isErr  bool
+		result string
+	}{
+		{"Alice", false, "Hello, Dr. Alice!"},
+		{"Bob", true, "unknown person: Bob"},
+		{"", true, "no name"},
+	} {
+		ptr, length := writeString(ctx, mod, tc.name)
+		results, err := mod.ExportedFunction("greet").Call(ctx, uint64(ptr), uint64(length))
+		if err != nil {
+			t.Errorf("greet(%q): failed to call: %v", tc.name, err)
+			continue
+		}
+		isErr, result := readResult(mod, uint32(results[0]))
+		if isErr != tc.isErr || result != tc.result {
+			t.Errorf("greet(%q): expected (%q, error=%v), got (%q, error=%v)", tc.name, tc.result, tc.isErr, result, isErr)
+		}
+	}
+}
+
 // Test whether Go.run() (in wasm_exec.js) normally returns and returns the
 // right exit code.
 func TestWasmExit(t *testing.T) {
@@ -842,6 +1051,34 @@ func TestWasmExit(t *testing.T) {
 	}
 }
 
+// Check that -stack-check names the main goroutine, and reports which goroutine
+// overflowed its stack.
+func TestStackCheck(t *testing.T) {
+	t.Parallel()
+	options := optionsFromTarget("", sema)
+	options.StackCheck = true
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := &bytes.Buffer{}
+	_, err = buildAndRun("testdata/stackcheck.go", config, output, nil, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+		return cmd.Run()
+	})
+	if err == nil {
+		t.Error("expected the program to fail with a stack overflow")
+	}
+	for _, expected := range []string{
+		"goroutine: main true\n",
+		"stack overflow in goroutine main.overflow\n",
+		"goroutine stack overflow\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("output doesn't contain %q:\n%s", expected, output.String())
+		}
+	}
+}
+
 // Check whether the output of a test equals the expected output.
 func checkOutput(t *testing.T, filename string, actual []byte) {
 	expectedOutput, err := os.ReadFile(filename)
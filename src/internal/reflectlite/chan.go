@@ -0,0 +1,312 @@
+package reflectlite
+
+import "unsafe"
+
+// Channel operations are implemented by calling directly into the runtime, in
+// the same way as the compiler does for channel operations in Go code.
+
+// channelOp must have the same layout as runtime.channelOp.
+type channelOp struct {
+	next  unsafe.Pointer
+	task  unsafe.Pointer
+	index uint32
+	value unsafe.Pointer
+}
+
+// chanSelectState must have the same layout as runtime.chanSelectState.
+type chanSelectState struct {
+	ch    unsafe.Pointer
+	value unsafe.Pointer // nil for a receive operation
+}
+
+//go:linkname chanMake runtime.chanMake
+func chanMake(elementSize uintptr, bufSize uintptr) unsafe.Pointer
+
+//go:linkname chanSend runtime.chanSend
+func chanSend(ch unsafe.Pointer, value unsafe.Pointer, op *channelOp)
+
+//go:linkname chanRecv runtime.chanRecv
+func chanRecv(ch unsafe.Pointer, value unsafe.Pointer, op *channelOp) bool
+
+//go:linkname chanClose runtime.chanClose
+func chanClose(ch unsafe.Pointer)
+
+//go:linkname chanClosed runtime.chanClosed
+func chanClosed(ch unsafe.Pointer) bool
+
+//go:linkname chanSelect runtime.chanSelect
+func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelOp) (uint32, bool)
+
+// chanSelectNoIndex is returned by chanSelect when a non-blocking select can't
+// proceed.
+const chanSelectNoIndex = ^uint32(0)
+
+// MakeChan creates a new channel with the specified type and buffer size.
+func MakeChan(typ Type, buffer int) Value {
+	t := typ.(*RawType)
+	if t.Kind() != Chan {
+		panic("reflect.MakeChan of non-chan type")
+	}
+	if buffer < 0 {
+		panic("reflect.MakeChan: negative buffer size")
+	}
+	if t.ChanDir() != BothDir {
+		panic("reflect.MakeChan: unidirectional channel type")
+	}
+	elemSize := t.elem().Size()
+	if elemSize != 0 && uintptr(buffer) > ^uintptr(0)/2/elemSize {
+		panic("reflect.MakeChan: buffer size too large")
+	}
+	return Value{
+		typecode: t,
+		value:    chanMake(elemSize, uintptr(buffer)),
+		flags:    valueFlagExported,
+	}
+}
+
+// checkChan panics if v is not a channel that can be used in the given
+// direction.
+func (v Value) checkChan(op string, dir ChanDir) {
+	if v.Kind() != Chan {
+		panic(&ValueError{Method: "reflect.Value." + op, Kind: v.Kind()})
+	}
+	if v.isRO() {
+		panic("reflect: " + op + " using value obtained using unexported field")
+	}
+	if v.typecode.ChanDir()&dir == 0 {
+		if dir == SendDir {
+			panic("reflect: send on recv-only channel")
+		}
+		panic("reflect: recv on send-only channel")
+	}
+}
+
+// sendBuffer returns a pointer to a copy of x that can be sent on a channel
+// with the given element type.
+func sendBuffer(op string, x Value, elem *RawType) unsafe.Pointer {
+	if x.isRO() {
+		panic("reflect: " + op + " using value obtained using unexported field")
+	}
+	if !x.typecode.AssignableTo(elem) {
+		panic("reflect." + op + ": value of type " + x.typecode.String() + " is not assignable to type " + elem.String())
+	}
+	buf := Value{
+		typecode: elem,
+		value:    alloc(elem.Size(), nil),
+		flags:    valueFlagExported | valueFlagIndirect,
+	}
+	buf.Set(x)
+	return buf.value
+}
+
+// recvValue returns the received value of the given element type that is
+// stored in buf.
+func recvValue(elem *RawType, buf unsafe.Pointer) Value {
+	if size := elem.Size(); size <= unsafe.Sizeof(uintptr(0)) {
+		buf = unsafe.Pointer(loadValue(buf, size))
+	}
+	return Value{
+		typecode: elem,
+		value:    buf,
+		flags:    valueFlagExported,
+	}
+}
+
+// Send sends x on the channel v. It panics if v's kind is not Chan or if x's
+// type is not the same type as v's element type.
+func (v Value) Send(x Value) {
+	v.send("Send", x, false)
+}
+
+// TrySend attempts to send x on the channel v but will not block. It panics if
+// v's Kind is not Chan. It reports whether the value was sent.
+func (v Value) TrySend(x Value) bool {
+	return v.send("TrySend", x, true)
+}
+
+func (v Value) send(op string, x Value, nb bool) bool {
+	v.checkChan(op, SendDir)
+	value := sendBuffer("Value."+op, x, v.typecode.elem())
+	ch := v.pointer()
+	if ch != nil && chanClosed(ch) {
+		panic("send on closed channel")
+	}
+	if nb {
+		states := []chanSelectState{{ch: ch, value: value}}
+		index, _ := chanSelect(nil, states, nil)
+		return index != chanSelectNoIndex
+	}
+	var chanOp channelOp
+	chanSend(ch, value, &chanOp)
+	return true
+}
+
+// Recv receives and returns a value from the channel v. It panics if v's Kind
+// is not Chan. The receive blocks until a value is ready. The boolean value ok
+// is true if the value x corresponds to a send on the channel, false if it is a
+// zero value received because the channel is closed.
+func (v Value) Recv() (x Value, ok bool) {
+	return v.recv("Recv", false)
+}
+
+// TryRecv attempts to receive a value from the channel v but will not block.
+// It panics if v's Kind is not Chan. If the receive delivers a value, x is the
+// transferred value and ok is true. If the receive cannot finish without
+// blocking, x is the zero Value and ok is false. If the channel is closed, x is
+// the zero value for the channel's element type and ok is false.
+func (v Value) TryRecv() (x Value, ok bool) {
+	return v.recv("TryRecv", true)
+}
+
+func (v Value) recv(op string, nb bool) (Value, bool) {
+	v.checkChan(op, RecvDir)
+	elem := v.typecode.elem()
+	buf := alloc(elem.Size(), nil)
+	ch := v.pointer()
+	if nb {
+		states := []chanSelectState{{ch: ch}}
+		index, ok := chanSelect(buf, states, nil)
+		if index == chanSelectNoIndex {
+			return Value{}, false
+		}
+		return recvValue(elem, buf), ok
+	}
+	var chanOp channelOp
+	ok := chanRecv(ch, buf, &chanOp)
+	return recvValue(elem, buf), ok
+}
+
+// Close closes the channel v. It panics if v's Kind is not Chan or v is a
+// receive-only channel.
+func (v Value) Close() {
+	if v.Kind() != Chan {
+		panic(&ValueError{Method: "reflect.Value.Close", Kind: v.Kind()})
+	}
+	if v.isRO() {
+		panic("reflect: Close using value obtained using unexported field")
+	}
+	if v.typecode.ChanDir()&SendDir == 0 {
+		panic("reflect: close of receive-only channel")
+	}
+	// Check for these cases here, so that they result in a regular panic.
+	ch := v.pointer()
+	if ch == nil {
+		panic("close of nil channel")
+	}
+	if chanClosed(ch) {
+		panic("close of closed channel")
+	}
+	chanClose(ch)
+}
+
+// A SelectDir describes the communication direction of a select case.
+type SelectDir int
+
+const (
+	_             SelectDir = iota
+	SelectSend              // case Chan <- Send
+	SelectRecv              // case <-Chan:
+	SelectDefault           // default
+)
+
+// A SelectCase describes a single case in a select operation. See the reflect
+// package for details.
+type SelectCase struct {
+	Dir  SelectDir // direction of case
+	Chan Value     // channel to use (for send or receive)
+	Send Value     // value to send (for send)
+}
+
+// Select executes a select operation described by the list of cases. Like the
+// Go select statement, it blocks until at least one of the cases can proceed.
+// It returns the index of the chosen case and, if that case was a receive
+// operation, the value received and a boolean indicating whether the value
+// corresponds to a send on the channel.
+func Select(cases []SelectCase) (chosen int, recv Value, recvOK bool) {
+	if len(cases) > 65536 {
+		panic("reflect.Select: too many cases (max 65536)")
+	}
+
+	// There is one state for every case, so that the index returned by
+	// chanSelect is also the index into cases. States with a nil channel
+	// (including the default case) are ignored by the runtime.
+	states := make([]chanSelectState, len(cases))
+	defaultIndex := -1
+	var recvSize uintptr
+	for i, c := range cases {
+		switch c.Dir {
+		case SelectDefault:
+			if defaultIndex >= 0 {
+				panic("reflect.Select: multiple default cases")
+			}
+			if c.Chan.IsValid() {
+				panic("reflect.Select: default case has Chan value")
+			}
+			if c.Send.IsValid() {
+				panic("reflect.Select: default case has Send value")
+			}
+			defaultIndex = i
+		case SelectSend:
+			if !c.Chan.IsValid() {
+				break
+			}
+			if c.Chan.Kind() == Chan && c.Chan.typecode.ChanDir()&SendDir == 0 {
+				panic("reflect.Select: SendDir case using recv-only channel")
+			}
+			c.Chan.checkChan("Select", SendDir)
+			if !c.Send.IsValid() {
+				panic("reflect.Select: SendDir case missing Send value")
+			}
+			states[i] = chanSelectState{
+				ch:    c.Chan.pointer(),
+				value: sendBuffer("Select", c.Send, c.Chan.typecode.elem()),
+			}
+		case SelectRecv:
+			if c.Send.IsValid() {
+				panic("reflect.Select: RecvDir case has Send value")
+			}
+			if !c.Chan.IsValid() {
+				break
+			}
+			if c.Chan.Kind() == Chan && c.Chan.typecode.ChanDir()&RecvDir == 0 {
+				panic("reflect.Select: RecvDir case using send-only channel")
+			}
+			c.Chan.checkChan("Select", RecvDir)
+			states[i].ch = c.Chan.pointer()
+			if size := c.Chan.typecode.elem().Size(); size > recvSize {
+				recvSize = size
+			}
+		default:
+			panic("reflect.Select: invalid Dir")
+		}
+	}
+
+	// Sending on a closed channel is a runtime error that can't be recovered
+	// from inside the runtime, so check for it beforehand.
+	for _, state := range states {
+		if state.ch != nil && state.value != nil && chanClosed(state.ch) {
+			panic("send on closed channel")
+		}
+	}
+
+	// A select with a default case doesn't block.
+	var ops []channelOp
+	if defaultIndex < 0 {
+		if len(cases) == 0 {
+			select {}
+		}
+		ops = make([]channelOp, len(cases))
+	}
+
+	// All receive operations share a single buffer.
+	recvbuf := alloc(recvSize, nil)
+	index, ok := chanSelect(recvbuf, states, ops)
+	if index == chanSelectNoIndex {
+		return defaultIndex, Value{}, false
+	}
+	chosen = int(index)
+	if cases[chosen].Dir == SelectRecv {
+		return chosen, recvValue(cases[chosen].Chan.typecode.elem(), recvbuf), ok
+	}
+	return chosen, Value{}, false
+}
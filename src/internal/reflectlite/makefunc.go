@@ -0,0 +1,355 @@
+//go:build amd64 || arm64 || (arm && (cortexm || !baremetal))
+
+package reflectlite
+
+import "unsafe"
+
+// MakeFuncSupported is true on architectures where MakeFunc is implemented.
+const MakeFuncSupported = true
+
+// Functions created by MakeFunc are implemented using a small assembly stub
+// (see tinygo_makeFuncStubs in the runtime assembly files). This stub is called
+// like any other function, saves all argument registers in a makeFuncFrame,
+// and calls makeFuncHandler with the context parameter of the function and the
+// frame. The handler then converts the arguments to Values following the
+// calling convention of the target.
+//
+// The context parameter is passed after all other parameters, so its location
+// depends on the function signature. There is one stub for each register or
+// stack slot the context parameter may be in: each stub loads the context from
+// a different location and continues in the common part of the assembly code.
+
+// Maximum number of stack slots the context parameter may be in. See
+// makeFuncStub.
+const makeFuncStackStubs = 16
+
+// The maximum number of arguments that can be expanded from a single struct.
+// This must match maxFieldsPerParam in the compiler.
+const maxFieldsPerParam = 3
+
+//go:extern tinygo_makeFuncStubs
+var makeFuncStubs [0]byte
+
+// makeFuncImpl is the context of a function created by MakeFunc.
+type makeFuncImpl struct {
+	// Code pointer of makeFuncHandler. This must be the first field: it is
+	// called from the assembly stub.
+	handler unsafe.Pointer
+
+	ftyp *funcType
+	fn   func([]Value) []Value
+
+	// Location of every part of every parameter, and of every result if they
+	// are returned in registers.
+	args    []abiSlot
+	results []abiSlot
+
+	// If indirect is set, the results are stored in memory, with a pointer to
+	// that memory passed in the location given by indirectLoc.
+	indirect    bool
+	indirectLoc abiLoc
+}
+
+// abiKind is the kind of a single value as passed in registers.
+type abiKind uint8
+
+const (
+	abiInt   abiKind = iota // integer or pointer
+	abiBool                 // like abiInt, but only the lowest bit is defined
+	abiFloat                // float32 or float64
+)
+
+// abiPart is a single integer, pointer or floating point value. Parameters are
+// split up in these before they're assigned to a register or stack slot.
+type abiPart struct {
+	kind   abiKind
+	size   uintptr
+	offset uintptr // offset in the args or results buffer
+}
+
+// abiParam is a single parameter in LLVM IR. A Go parameter may be split into
+// multiple of these by the compiler, see expandFormalParamType.
+type abiParam struct {
+	parts     []abiPart
+	aggregate bool // passed as a LLVM struct or array
+	array     bool // passed as a LLVM array
+	intArray  bool // passed as a LLVM array of integers
+}
+
+// Register or stack slot area, see abiLoc.
+const (
+	abiRegInt      = iota // integer registers (or integer result registers)
+	abiRegFloat           // floating point registers (or result registers)
+	abiRegIndirect        // register for the indirect result pointer
+	abiStack              // stack slots of the caller
+)
+
+// abiLoc is the location of an abiPart: an offset in one of the register
+// areas of a makeFuncFrame, or on the stack.
+type abiLoc struct {
+	area   uint8
+	offset uintptr
+}
+
+// abiSlot is an abiPart together with the location where it is stored.
+type abiSlot struct {
+	abiPart
+	loc abiLoc
+}
+
+// MakeFunc returns a new function of the given function type that wraps the
+// function fn. When called, that new function converts its arguments to a
+// slice of Values, calls fn, and converts the results of fn back to the
+// results of the function.
+func MakeFunc(typ Type, fn func(args []Value) (results []Value)) Value {
+	if typ.Kind() != Func {
+		panic("reflect: call of MakeFunc with non-Func type")
+	}
+	t := typ.(*RawType)
+	ft := t.funcType()
+	impl := &makeFuncImpl{
+		ftyp: ft,
+		fn:   fn,
+	}
+	handler := makeFuncHandler
+	impl.handler = (*funcHeader)(unsafe.Pointer(&handler)).Code
+
+	// Determine where each part of the results is returned. If they don't all
+	// fit in registers, they are returned in memory. The pointer to that
+	// memory is passed as an extra parameter before all other parameters.
+	numIn := int(ft.numIn)
+	var assigner abiAssigner
+	var results []abiPart
+	var offset uintptr
+	var ok bool
+	for i := 0; i < int(ft.numOut); i++ {
+		typ := ft.param(numIn + i)
+		offset = align(offset, uintptr(typ.Align()))
+		results = abiParts(results, typ, offset)
+		offset += typ.Size()
+	}
+	impl.results, ok = assignResults(results)
+	if !ok {
+		impl.indirect = true
+		impl.indirectLoc = assigner.indirectResult()
+	}
+
+	// Determine where each part of the parameters is passed.
+	var params []abiParam
+	offset = 0
+	for i := 0; i < numIn; i++ {
+		typ := ft.param(i)
+		offset = align(offset, uintptr(typ.Align()))
+		params = abiParams(params, typ, offset)
+		offset += typ.Size()
+	}
+	for _, param := range params {
+		impl.args = assigner.param(param, impl.args)
+	}
+
+	// The context parameter comes last. Pick the stub that loads the context
+	// from the right place.
+	context := assigner.param(abiParam{parts: []abiPart{{kind: abiInt, size: unsafe.Sizeof(uintptr(0))}}}, nil)
+	code := makeFuncStub(context[0].loc)
+
+	return Value{
+		typecode: t,
+		value:    unsafe.Pointer(&funcHeader{Context: unsafe.Pointer(impl), Code: code}),
+		flags:    valueFlagExported,
+	}
+}
+
+// makeFuncStub returns the assembly stub that loads the context parameter from
+// the given location.
+func makeFuncStub(context abiLoc) unsafe.Pointer {
+	const ptrSize = unsafe.Sizeof(uintptr(0))
+	index := context.offset / ptrSize
+	if context.area == abiStack {
+		index += makeFuncIntRegs
+	}
+	if index >= makeFuncIntRegs+makeFuncStackStubs {
+		panic("unimplemented: reflect.MakeFunc with this many parameters")
+	}
+	return unsafe.Add(unsafe.Pointer(&makeFuncStubs), index*makeFuncStubSize)
+}
+
+// makeFuncHandler is called from the assembly stub when a function created by
+// MakeFunc is called. The frame contains the argument registers, and is used
+// to store the result registers.
+func makeFuncHandler(impl *makeFuncImpl, frame *makeFuncFrame) {
+	ft := impl.ftyp
+
+	// Collect all arguments in a buffer laid out like a struct.
+	args := alloc(ft.paramsSize(0, int(ft.numIn)), nil)
+	for _, slot := range impl.args {
+		dst := unsafe.Add(args, slot.offset)
+		memcpy(dst, frame.arg(slot.loc), slot.size)
+		if slot.kind == abiBool {
+			*(*uint8)(dst) &= 1
+		}
+	}
+
+	results := alloc(ft.paramsSize(int(ft.numIn), int(ft.numOut)), nil)
+	size := impl.call(args, results)
+
+	if impl.indirect {
+		// The caller passed a pointer to store the results in. This pointer
+		// is also returned, like C functions returning a struct do.
+		ptr := *(*unsafe.Pointer)(frame.arg(impl.indirectLoc))
+		memcpy(ptr, results, size)
+		*(*unsafe.Pointer)(frame.result(abiLoc{area: abiRegInt})) = ptr
+		return
+	}
+	for _, slot := range impl.results {
+		memcpy(frame.result(slot.loc), unsafe.Add(results, slot.offset), slot.size)
+	}
+}
+
+// call calls the function passed to MakeFunc with the arguments in args, and
+// stores the results in results. Both buffers are laid out like a struct. It
+// returns the size of the results.
+func (impl *makeFuncImpl) call(args, results unsafe.Pointer) uintptr {
+	ft := impl.ftyp
+	numIn := int(ft.numIn)
+	numOut := int(ft.numOut)
+
+	out := impl.fn(ft.loadParams(0, numIn, args))
+
+	// Store the results, checking that they have the right type.
+	if len(out) != numOut {
+		panic("reflect: wrong return count from function created by MakeFunc")
+	}
+	var offset uintptr
+	for i, v := range out {
+		typ := ft.param(numIn + i)
+		if v.typecode == nil {
+			panic("reflect: function created by MakeFunc using closure returned zero Value")
+		}
+		if v.isRO() {
+			panic("reflect: function created by MakeFunc using closure returned value obtained from unexported field")
+		}
+		if !v.typecode.AssignableTo(typ) {
+			panic("reflect: function created by MakeFunc using closure returned wrong type: have " + v.typecode.String() + " for " + typ.String())
+		}
+		offset = align(offset, uintptr(typ.Align()))
+		result := Value{
+			typecode: typ,
+			value:    unsafe.Add(results, offset),
+			flags:    valueFlagExported | valueFlagIndirect,
+		}
+		result.Set(v)
+		offset += typ.Size()
+	}
+	return offset
+}
+
+// callMakeFunc calls the func value pointed to by fn directly, without going
+// through the assembly stub, if it was created by MakeFunc. It returns false if
+// fn is some other function. The args and results buffers are laid out like a
+// struct.
+func callMakeFunc(fn, args, results unsafe.Pointer) bool {
+	f := (*funcHeader)(fn)
+	start := uintptr(unsafe.Pointer(&makeFuncStubs))
+	end := start + (makeFuncIntRegs+makeFuncStackStubs)*makeFuncStubSize
+	if code := uintptr(f.Code); code < start || code >= end {
+		return false
+	}
+	(*makeFuncImpl)(f.Context).call(args, results)
+	return true
+}
+
+// abiParams appends the LLVM IR parameters of a Go parameter of type t, which
+// is stored at the given offset in the args buffer. This matches
+// expandFormalParamType in the compiler: structs (and strings, slices, etc) are
+// expanded into their fields if there are at most maxFieldsPerParam of them.
+func abiParams(params []abiParam, t *RawType, offset uintptr) []abiParam {
+	start := len(params)
+	params = abiFields(params, t, offset)
+	if len(params)-start > maxFieldsPerParam {
+		// Too many fields, so it is passed as a single struct.
+		param := abiParam{aggregate: true}
+		for _, field := range params[start:] {
+			param.parts = append(param.parts, field.parts...)
+		}
+		params = append(params[:start], param)
+	}
+	return params
+}
+
+// abiFields appends the fields of t after flattening all nested structs. Arrays
+// are not flattened.
+func abiFields(params []abiParam, t *RawType, offset uintptr) []abiParam {
+	switch t.Kind() {
+	case String, Slice, Interface, Func, Complex64, Complex128:
+		// These are all implemented as structs.
+		for _, part := range abiParts(nil, t, offset) {
+			params = append(params, abiParam{parts: []abiPart{part}})
+		}
+	case Struct:
+		numField := t.NumField()
+		for i := 0; i < numField; i++ {
+			field := t.rawField(i)
+			params = abiFields(params, field.Type, offset+field.Offset)
+		}
+	case Array:
+		if t.Size() == 0 {
+			// Zero-sized fields are skipped.
+			break
+		}
+		elemKind := t.elem().Kind()
+		params = append(params, abiParam{
+			parts:     abiParts(nil, t, offset),
+			aggregate: true,
+			array:     true,
+			intArray:  elemKind >= Bool && elemKind <= Uintptr,
+		})
+	default:
+		params = append(params, abiParam{parts: abiParts(nil, t, offset)})
+	}
+	return params
+}
+
+// abiParts appends all integer, pointer and floating point values that make up
+// a value of type t, stored at the given offset.
+func abiParts(parts []abiPart, t *RawType, offset uintptr) []abiPart {
+	const ptrSize = unsafe.Sizeof(uintptr(0))
+	switch t.Kind() {
+	case Bool:
+		parts = append(parts, abiPart{abiBool, 1, offset})
+	case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		parts = append(parts, abiPart{abiInt, t.Size(), offset})
+	case Float32, Float64:
+		parts = append(parts, abiPart{abiFloat, t.Size(), offset})
+	case Complex64, Complex128:
+		size := t.Size() / 2
+		parts = append(parts, abiPart{abiFloat, size, offset}, abiPart{abiFloat, size, offset + size})
+	case String, Slice, Interface, Func:
+		for i := uintptr(0); i < t.Size()/ptrSize; i++ {
+			parts = append(parts, abiPart{abiInt, ptrSize, offset + i*ptrSize})
+		}
+	case Array:
+		elem := t.elem()
+		for i := 0; i < t.Len(); i++ {
+			parts = abiParts(parts, elem, offset+uintptr(i)*elem.Size())
+		}
+	case Struct:
+		numField := t.NumField()
+		for i := 0; i < numField; i++ {
+			field := t.rawField(i)
+			parts = abiParts(parts, field.Type, offset+field.Offset)
+		}
+	default: // pointer-like types
+		parts = append(parts, abiPart{abiInt, ptrSize, offset})
+	}
+	return parts
+}
+
+// abiUniform returns whether all parts have the same kind and size.
+func abiUniform(parts []abiPart) bool {
+	for _, part := range parts {
+		if part.kind != parts[0].kind || part.size != parts[0].size {
+			return false
+		}
+	}
+	return len(parts) != 0
+}
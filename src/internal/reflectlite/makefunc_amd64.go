@@ -0,0 +1,96 @@
+//go:build amd64 && !windows
+
+package reflectlite
+
+import "unsafe"
+
+// Calling convention of the System V ABI for amd64, as implemented by LLVM.
+
+const (
+	makeFuncIntRegs  = 6  // rdi, rsi, rdx, rcx, r8, r9
+	makeFuncStubSize = 16 // size of each stub in tinygo_makeFuncStubs
+)
+
+// makeFuncFrame is stored on the stack by tinygo_makeFuncStubs. Keep it in
+// sync with asm_amd64.S.
+type makeFuncFrame struct {
+	ints      [6]uintptr     // rdi, rsi, rdx, rcx, r8, r9
+	floats    [8]uint64      // xmm0-xmm7
+	stack     unsafe.Pointer // stack arguments
+	intRets   [3]uintptr     // rax, rdx, rcx
+	floatRets [2]uint64      // xmm0, xmm1
+}
+
+func (f *makeFuncFrame) arg(loc abiLoc) unsafe.Pointer {
+	switch loc.area {
+	case abiRegInt:
+		return unsafe.Add(unsafe.Pointer(&f.ints), loc.offset)
+	case abiRegFloat:
+		return unsafe.Add(unsafe.Pointer(&f.floats), loc.offset)
+	default:
+		return unsafe.Add(f.stack, loc.offset)
+	}
+}
+
+func (f *makeFuncFrame) result(loc abiLoc) unsafe.Pointer {
+	if loc.area == abiRegFloat {
+		return unsafe.Add(unsafe.Pointer(&f.floatRets), loc.offset)
+	}
+	return unsafe.Add(unsafe.Pointer(&f.intRets), loc.offset)
+}
+
+// abiAssigner keeps track of the registers and stack space that are used by
+// the parameters assigned so far.
+type abiAssigner struct {
+	ints   uintptr
+	floats uintptr
+	stack  uintptr
+}
+
+// param appends the parts of the given parameter together with their
+// location.
+func (a *abiAssigner) param(param abiParam, slots []abiSlot) []abiSlot {
+	for _, part := range param.parts {
+		switch {
+		case part.kind == abiFloat && a.floats < 8:
+			slots = append(slots, abiSlot{part, abiLoc{abiRegFloat, a.floats * 8}})
+			a.floats++
+		case part.kind != abiFloat && a.ints < 6:
+			slots = append(slots, abiSlot{part, abiLoc{abiRegInt, a.ints * 8}})
+			a.ints++
+		default:
+			slots = append(slots, abiSlot{part, abiLoc{abiStack, a.stack}})
+			a.stack += 8
+		}
+	}
+	return slots
+}
+
+// indirectResult returns the location of the pointer to the results, if they
+// are returned in memory. It is passed as the first parameter.
+func (a *abiAssigner) indirectResult() abiLoc {
+	return a.param(abiParam{parts: []abiPart{{kind: abiInt, size: 8}}}, nil)[0].loc
+}
+
+// assignResults returns the location of each part of the results, or false if
+// they don't fit in the result registers.
+func assignResults(parts []abiPart) ([]abiSlot, bool) {
+	var ints, floats uintptr
+	slots := make([]abiSlot, len(parts))
+	for i, part := range parts {
+		if part.kind == abiFloat {
+			if floats == 2 {
+				return nil, false
+			}
+			slots[i] = abiSlot{part, abiLoc{abiRegFloat, floats * 8}}
+			floats++
+		} else {
+			if ints == 3 {
+				return nil, false
+			}
+			slots[i] = abiSlot{part, abiLoc{abiRegInt, ints * 8}}
+			ints++
+		}
+	}
+	return slots, true
+}
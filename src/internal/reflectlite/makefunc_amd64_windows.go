@@ -0,0 +1,94 @@
+//go:build amd64 && windows
+
+package reflectlite
+
+import "unsafe"
+
+// Calling convention of the Windows x64 ABI, as implemented by LLVM. The first
+// four parameters are passed in either an integer or a floating point register
+// depending on their position, the rest are passed on the stack.
+
+const (
+	makeFuncIntRegs  = 4  // rcx, rdx, r8, r9
+	makeFuncStubSize = 16 // size of each stub in tinygo_makeFuncStubs
+)
+
+// makeFuncFrame is stored on the stack by tinygo_makeFuncStubs. Keep it in
+// sync with asm_amd64_windows.S.
+type makeFuncFrame struct {
+	ints      [4]uintptr     // rcx, rdx, r8, r9
+	floats    [4]uint64      // xmm0-xmm3
+	stack     unsafe.Pointer // stack arguments (after the shadow space)
+	intRets   [3]uintptr     // rax, rdx, rcx
+	floatRets [2]uint64      // xmm0, xmm1
+}
+
+func (f *makeFuncFrame) arg(loc abiLoc) unsafe.Pointer {
+	switch loc.area {
+	case abiRegInt:
+		return unsafe.Add(unsafe.Pointer(&f.ints), loc.offset)
+	case abiRegFloat:
+		return unsafe.Add(unsafe.Pointer(&f.floats), loc.offset)
+	default:
+		return unsafe.Add(f.stack, loc.offset)
+	}
+}
+
+func (f *makeFuncFrame) result(loc abiLoc) unsafe.Pointer {
+	if loc.area == abiRegFloat {
+		return unsafe.Add(unsafe.Pointer(&f.floatRets), loc.offset)
+	}
+	return unsafe.Add(unsafe.Pointer(&f.intRets), loc.offset)
+}
+
+// abiAssigner keeps track of the number of parameters assigned so far.
+type abiAssigner struct {
+	position uintptr
+}
+
+// param appends the parts of the given parameter together with their
+// location.
+func (a *abiAssigner) param(param abiParam, slots []abiSlot) []abiSlot {
+	for _, part := range param.parts {
+		switch {
+		case a.position >= 4:
+			slots = append(slots, abiSlot{part, abiLoc{abiStack, (a.position - 4) * 8}})
+		case part.kind == abiFloat:
+			slots = append(slots, abiSlot{part, abiLoc{abiRegFloat, a.position * 8}})
+		default:
+			slots = append(slots, abiSlot{part, abiLoc{abiRegInt, a.position * 8}})
+		}
+		a.position++
+	}
+	return slots
+}
+
+// indirectResult returns the location of the pointer to the results, if they
+// are returned in memory. It is passed as the first parameter.
+func (a *abiAssigner) indirectResult() abiLoc {
+	return a.param(abiParam{parts: []abiPart{{kind: abiInt, size: 8}}}, nil)[0].loc
+}
+
+// assignResults returns the location of each part of the results, or false if
+// they don't fit in the result registers. This is the same as on other
+// operating systems.
+func assignResults(parts []abiPart) ([]abiSlot, bool) {
+	var ints, floats uintptr
+	slots := make([]abiSlot, len(parts))
+	for i, part := range parts {
+		if part.kind == abiFloat {
+			if floats == 2 {
+				return nil, false
+			}
+			slots[i] = abiSlot{part, abiLoc{abiRegFloat, floats * 8}}
+			floats++
+		} else {
+			if ints == 3 {
+				return nil, false
+			}
+			slots[i] = abiSlot{part, abiLoc{abiRegInt, ints * 8}}
+			ints++
+		}
+	}
+	return slots, true
+}
@@ -0,0 +1,197 @@
+//go:build arm && (cortexm || !baremetal)
+
+package reflectlite
+
+import "unsafe"
+
+// Calling convention of the AAPCS, as implemented by LLVM. With the soft float
+// ABI (used on Cortex-M) all parameters are passed in core registers or on the
+// stack, with the hard float ABI (AAPCS-VFP) floating point parameters are
+// passed in VFP registers.
+
+const (
+	makeFuncIntRegs  = 4  // r0-r3
+	makeFuncStubSize = 16 // size of each stub in tinygo_makeFuncStubs
+)
+
+// makeFuncHardFloat is 1 when the AAPCS-VFP calling convention is used. It is
+// defined in asm_arm.S.
+//
+//go:extern tinygo_makeFuncHardFloat
+var makeFuncHardFloat uint32
+
+// makeFuncFrame is stored on the stack by tinygo_makeFuncStubs. Keep it in
+// sync with asm_arm.S.
+type makeFuncFrame struct {
+	ints   [4]uintptr     // r0-r3, also used for results
+	floats [8]uint64      // d0-d7 (or s0-s15), also used for results
+	stack  unsafe.Pointer // stack arguments
+}
+
+func (f *makeFuncFrame) arg(loc abiLoc) unsafe.Pointer {
+	switch loc.area {
+	case abiRegInt:
+		return unsafe.Add(unsafe.Pointer(&f.ints), loc.offset)
+	case abiRegFloat:
+		return unsafe.Add(unsafe.Pointer(&f.floats), loc.offset)
+	default:
+		return unsafe.Add(f.stack, loc.offset)
+	}
+}
+
+func (f *makeFuncFrame) result(loc abiLoc) unsafe.Pointer {
+	return f.arg(loc)
+}
+
+// abiAssigner keeps track of the registers and stack space that are used by
+// the parameters assigned so far.
+type abiAssigner struct {
+	ints  uintptr // next core register
+	vfp   uint16  // bitmap of used single precision VFP registers
+	stack uintptr
+}
+
+// param appends the parts of the given parameter together with their
+// location.
+func (a *abiAssigner) param(param abiParam, slots []abiSlot) []abiSlot {
+	if makeFuncHardFloat != 0 && param.aggregate {
+		n := uintptr(len(param.parts))
+		if abiUniform(param.parts) && param.parts[0].kind == abiFloat && n <= 4 {
+			// Homogeneous floating point aggregates are passed in
+			// consecutive VFP registers, or entirely on the stack.
+			size := param.parts[0].size
+			if reg, ok := a.allocVFP(size, n); ok {
+				for i, part := range param.parts {
+					slots = append(slots, abiSlot{part, abiLoc{abiRegFloat, reg*4 + uintptr(i)*size}})
+				}
+				return slots
+			}
+			a.vfp = 0xffff
+			return a.stackBlock(param.parts, slots)
+		}
+		if param.intArray {
+			// Integer arrays are passed in consecutive core registers, as
+			// 32-bit words. If they don't fit and the stack is still unused,
+			// the remaining words are passed on the stack.
+			var words []abiPart
+			for _, part := range param.parts {
+				if part.size == 8 {
+					words = append(words, abiPart{part.kind, 4, part.offset}, abiPart{part.kind, 4, part.offset + 4})
+				} else {
+					words = append(words, part)
+				}
+			}
+			if param.parts[0].size == 8 {
+				a.ints = (a.ints + 1) &^ 1
+			}
+			if a.ints+uintptr(len(words)) <= 4 || a.stack == 0 {
+				for _, word := range words {
+					if a.ints < 4 {
+						slots = append(slots, abiSlot{word, abiLoc{abiRegInt, a.ints * 4}})
+						a.ints++
+					} else {
+						slots = append(slots, abiSlot{word, abiLoc{abiStack, a.stack}})
+						a.stack += 4
+					}
+				}
+				return slots
+			}
+			a.ints = 4
+			return a.stackBlock(words, slots)
+		}
+	}
+	for _, part := range param.parts {
+		slots = append(slots, abiSlot{part, a.part(part)})
+	}
+	return slots
+}
+
+// part returns the location of a single scalar parameter.
+func (a *abiAssigner) part(part abiPart) abiLoc {
+	if makeFuncHardFloat != 0 && part.kind == abiFloat {
+		if reg, ok := a.allocVFP(part.size, 1); ok {
+			return abiLoc{abiRegFloat, reg * 4}
+		}
+	} else if part.size == 8 {
+		// 64-bit values are passed in an even/odd register pair.
+		a.ints = (a.ints + 1) &^ 1
+		if a.ints < 4 {
+			loc := abiLoc{abiRegInt, a.ints * 4}
+			a.ints += 2
+			return loc
+		}
+		a.ints = 4
+	} else if a.ints < 4 {
+		loc := abiLoc{abiRegInt, a.ints * 4}
+		a.ints++
+		return loc
+	}
+	// Passed on the stack, in a 4 or 8 byte slot.
+	if part.size == 8 {
+		a.stack = align(a.stack, 8)
+	}
+	loc := abiLoc{abiStack, a.stack}
+	a.stack += align(part.size, 4)
+	return loc
+}
+
+// stackBlock assigns all parts of an aggregate to the stack. The first part is
+// aligned to 4 or 8 bytes, the other parts follow directly.
+func (a *abiAssigner) stackBlock(parts []abiPart, slots []abiSlot) []abiSlot {
+	for i, part := range parts {
+		size := align(part.size, 4)
+		if i == 0 && size == 8 {
+			a.stack = align(a.stack, 8)
+		}
+		slots = append(slots, abiSlot{part, abiLoc{abiStack, a.stack}})
+		a.stack += size
+	}
+	return slots
+}
+
+// allocVFP allocates n consecutive VFP registers of the given size (4 for
+// single precision, 8 for double precision), and returns the index of the
+// first single precision register. Like LLVM, it picks the first free
+// registers, which may be before registers that are already in use.
+func (a *abiAssigner) allocVFP(size, n uintptr) (uintptr, bool) {
+	step := size / 4
+	mask := uint16(1)<<(n*step) - 1
+	for reg := uintptr(0); reg+n*step <= 16; reg += step {
+		if a.vfp&(mask<<reg) == 0 {
+			a.vfp |= mask << reg
+			return reg, true
+		}
+	}
+	return 0, false
+}
+
+// indirectResult returns the location of the pointer to the results, if they
+// are returned in memory. It is passed as the first parameter.
+func (a *abiAssigner) indirectResult() abiLoc {
+	return a.part(abiPart{kind: abiInt, size: 4})
+}
+
+// assignResults returns the location of each part of the results, or false if
+// they don't fit in the result registers. Unlike parameters, 64-bit results
+// don't need to start at an even register.
+func assignResults(parts []abiPart) ([]abiSlot, bool) {
+	var a abiAssigner
+	slots := make([]abiSlot, len(parts))
+	for i, part := range parts {
+		if makeFuncHardFloat != 0 && part.kind == abiFloat {
+			reg, ok := a.allocVFP(part.size, 1)
+			if !ok {
+				return nil, false
+			}
+			slots[i] = abiSlot{part, abiLoc{abiRegFloat, reg * 4}}
+			continue
+		}
+		words := align(part.size, 4) / 4
+		if a.ints+words > 4 {
+			return nil, false
+		}
+		slots[i] = abiSlot{part, abiLoc{abiRegInt, a.ints * 4}}
+		a.ints += words
+	}
+	return slots, true
+}
@@ -0,0 +1,118 @@
+//go:build arm64
+
+package reflectlite
+
+import (
+	"internal/goos"
+	"unsafe"
+)
+
+// Calling convention of the AAPCS64, as implemented by LLVM. Darwin differs
+// from other operating systems in how parameters are laid out on the stack.
+
+const (
+	makeFuncIntRegs  = 8 // x0-x7
+	makeFuncStubSize = 8 // size of each stub in tinygo_makeFuncStubs
+)
+
+// makeFuncFrame is stored on the stack by tinygo_makeFuncStubs. Keep it in
+// sync with asm_arm64.S.
+type makeFuncFrame struct {
+	ints     [8]uintptr     // x0-x7, also used for results
+	floats   [8]uint64      // d0-d7, also used for results
+	indirect uintptr        // x8
+	stack    unsafe.Pointer // stack arguments
+}
+
+func (f *makeFuncFrame) arg(loc abiLoc) unsafe.Pointer {
+	switch loc.area {
+	case abiRegInt:
+		return unsafe.Add(unsafe.Pointer(&f.ints), loc.offset)
+	case abiRegFloat:
+		return unsafe.Add(unsafe.Pointer(&f.floats), loc.offset)
+	case abiRegIndirect:
+		return unsafe.Pointer(&f.indirect)
+	default:
+		return unsafe.Add(f.stack, loc.offset)
+	}
+}
+
+func (f *makeFuncFrame) result(loc abiLoc) unsafe.Pointer {
+	return f.arg(loc)
+}
+
+// abiAssigner keeps track of the registers and stack space that are used by
+// the parameters assigned so far.
+type abiAssigner struct {
+	ints   uintptr
+	floats uintptr
+	stack  uintptr
+}
+
+// param appends the parts of the given parameter together with their
+// location.
+func (a *abiAssigner) param(param abiParam, slots []abiSlot) []abiSlot {
+	if param.array && abiUniform(param.parts) && (param.parts[0].kind == abiFloat || param.parts[0].size == 8) {
+		// Arrays of floats or 64-bit integers are either passed entirely in
+		// registers or entirely on the stack.
+		n := uintptr(len(param.parts))
+		regs := &a.ints
+		if param.parts[0].kind == abiFloat {
+			regs = &a.floats
+		}
+		if *regs+n > 8 {
+			// Doesn't fit, so no more parameters of this kind are passed
+			// in registers. The first element is aligned like any other
+			// parameter, the rest follows directly.
+			*regs = 8
+			size := param.parts[0].size
+			if goos.IsDarwin == 0 {
+				a.stack = align(a.stack, 8)
+			}
+			a.stack = align(a.stack, size)
+			for _, part := range param.parts {
+				slots = append(slots, abiSlot{part, abiLoc{abiStack, a.stack}})
+				a.stack += size
+			}
+			return slots
+		}
+	}
+	for _, part := range param.parts {
+		switch {
+		case part.kind == abiFloat && a.floats < 8:
+			slots = append(slots, abiSlot{part, abiLoc{abiRegFloat, a.floats * 8}})
+			a.floats++
+		case part.kind != abiFloat && a.ints < 8:
+			slots = append(slots, abiSlot{part, abiLoc{abiRegInt, a.ints * 8}})
+			a.ints++
+		case goos.IsDarwin != 0:
+			// Darwin packs stack parameters using their natural alignment.
+			a.stack = align(a.stack, part.size)
+			slots = append(slots, abiSlot{part, abiLoc{abiStack, a.stack}})
+			a.stack += part.size
+		default:
+			slots = append(slots, abiSlot{part, abiLoc{abiStack, a.stack}})
+			a.stack += 8
+		}
+	}
+	return slots
+}
+
+// indirectResult returns the location of the pointer to the results, if they
+// are returned in memory. It is passed in x8, not as a regular parameter.
+func (a *abiAssigner) indirectResult() abiLoc {
+	return abiLoc{area: abiRegIndirect}
+}
+
+// assignResults returns the location of each part of the results, or false if
+// they don't fit in the result registers.
+func assignResults(parts []abiPart) ([]abiSlot, bool) {
+	var a abiAssigner
+	slots := a.param(abiParam{parts: parts}, nil)
+	for _, slot := range slots {
+		if slot.loc.area == abiStack {
+			return nil, false
+		}
+	}
+	return slots, true
+}
@@ -0,0 +1,21 @@
+//go:build !(amd64 || arm64 || (arm && (cortexm || !baremetal)))
+
+package reflectlite
+
+import "unsafe"
+
+// MakeFuncSupported is false as MakeFunc is not implemented on this
+// architecture.
+const MakeFuncSupported = false
+
+// MakeFunc is not supported on this architecture, as it has no assembly stub
+// to implement the calling convention.
+func MakeFunc(typ Type, fn func(args []Value) (results []Value)) Value {
+	panic("unimplemented: reflect.MakeFunc() on this architecture")
+}
+
+// callMakeFunc always returns false, as there are no functions created by
+// MakeFunc on this architecture.
+func callMakeFunc(fn, args, results unsafe.Pointer) bool {
+	return false
+}
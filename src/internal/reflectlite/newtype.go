@@ -0,0 +1,336 @@
+package reflectlite
+
+import (
+	"internal/itoa"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Types created at runtime (by SliceOf, MapOf, etc) have the same layout as
+// the types created by the compiler. Type codes are compared by pointer, so
+// before creating a new type these functions look for an identical type that
+// already exists: either in the program itself (see typeTable) or created
+// earlier at runtime (see createdTypes).
+
+// typeTable lists all array, chan, func, map, slice and struct types in the
+// program. It is created by the compiler, see CreateReflectTypeTable in the
+// transform package.
+//
+//go:extern reflect/types.table
+var typeTable struct {
+	numTypes uintptr
+	types    [0]*RawType
+}
+
+// All types that were created at runtime.
+var createdTypes []*RawType
+
+// lookupType returns an existing unnamed type of the given kind for which match
+// returns true, or nil if there is no such type.
+func lookupType(kind Kind, match func(t *RawType) bool) *RawType {
+	types := unsafe.Slice((**RawType)(unsafe.Pointer(&typeTable.types)), typeTable.numTypes)
+	for _, list := range [...][]*RawType{types, createdTypes} {
+		for _, t := range list {
+			if t.Kind() == kind && match(t) {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// addType adds a newly created type to createdTypes, and creates the pointer
+// type for it.
+func addType(t *RawType) *RawType {
+	ptr := &ptrType{
+		RawType: RawType{meta: uint8(Pointer) | flagComparable | flagIsBinary},
+		elem:    t,
+	}
+	// The ptrTo field is at the same offset in all types that have it.
+	(*elemType)(unsafe.Pointer(t)).ptrTo = &ptr.RawType
+	createdTypes = append(createdTypes, t)
+	return t
+}
+
+// hashFlags returns the flagComparable and flagIsBinary flags of this type.
+func (t *RawType) hashFlags() uint8 {
+	if t.ptrtag() != 0 {
+		// Pointer to a pointer, which doesn't have a meta byte of its own.
+		return flagComparable | flagIsBinary
+	}
+	return t.meta & (flagComparable | flagIsBinary)
+}
+
+// SliceOf returns the slice type with element type t.
+func SliceOf(t Type) Type {
+	return sliceOf(t.(*RawType))
+}
+
+func sliceOf(elem *RawType) *RawType {
+	if t := lookupType(Slice, func(t *RawType) bool {
+		return t.elem() == elem
+	}); t != nil {
+		return t
+	}
+	return addType(&(&elemType{
+		RawType: RawType{meta: uint8(Slice)},
+		elem:    elem,
+	}).RawType)
+}
+
+// ArrayOf returns the array type with the given length and element type.
+func ArrayOf(length int, elem Type) Type {
+	if length < 0 {
+		panic("reflect: negative length passed to ArrayOf")
+	}
+	e := elem.(*RawType)
+	if t := lookupType(Array, func(t *RawType) bool {
+		return t.elem() == e && t.Len() == length
+	}); t != nil {
+		return t
+	}
+	if size := e.Size(); size != 0 && uintptr(length) > ^uintptr(0)/size {
+		panic("reflect.ArrayOf: array size would exceed virtual address space")
+	}
+	return addType(&(&arrayType{
+		RawType:  RawType{meta: uint8(Array) | e.hashFlags()},
+		elem:     e,
+		arrayLen: uintptr(length),
+		slicePtr: sliceOf(e),
+	}).RawType)
+}
+
+// ChanOf returns the channel type with the given direction and element type.
+func ChanOf(dir ChanDir, t Type) Type {
+	e := t.(*RawType)
+	if dir != RecvDir && dir != SendDir && dir != BothDir {
+		panic("reflect.ChanOf: invalid dir")
+	}
+	if e.Size() >= 1<<16 {
+		panic("reflect.ChanOf: element size too large")
+	}
+	if t := lookupType(Chan, func(t *RawType) bool {
+		return t.elem() == e && t.ChanDir() == dir
+	}); t != nil {
+		return t
+	}
+	return addType(&(&elemType{
+		RawType:   RawType{meta: uint8(Chan) | flagComparable},
+		numMethod: uint16(dir), // channel direction
+		elem:      e,
+	}).RawType)
+}
+
+// MapOf returns the map type with the given key and element types. It panics
+// if the key type is not comparable.
+func MapOf(key, elem Type) Type {
+	k := key.(*RawType)
+	e := elem.(*RawType)
+	if !k.Comparable() {
+		panic("reflect.MapOf: invalid key type " + k.String())
+	}
+	if t := lookupType(Map, func(t *RawType) bool {
+		return t.key() == k && t.elem() == e
+	}); t != nil {
+		return t
+	}
+	return addType(&(&mapType{
+		RawType: RawType{meta: uint8(Map)},
+		elem:    e,
+		key:     k,
+	}).RawType)
+}
+
+// FuncOf returns the function type with the given argument and result types.
+// If variadic is set, the last argument must be a slice.
+//
+// Functions of a type created by FuncOf (which isn't used anywhere in the
+// program) can only be called using Value.Call if they were created by
+// MakeFunc.
+func FuncOf(in, out []Type, variadic bool) Type {
+	if variadic && (len(in) == 0 || in[len(in)-1].Kind() != Slice) {
+		panic("reflect.FuncOf: last arg of variadic func must be slice")
+	}
+	if len(in) > 0xffff || len(out) > 0xffff {
+		panic("reflect.FuncOf: too many arguments")
+	}
+	params := make([]*RawType, 0, len(in)+len(out))
+	for _, t := range in {
+		params = append(params, t.(*RawType))
+	}
+	for _, t := range out {
+		params = append(params, t.(*RawType))
+	}
+	if t := lookupType(Func, func(t *RawType) bool {
+		ft := t.funcType()
+		if int(ft.numIn) != len(in) || int(ft.numOut) != len(out) || ft.variadic != variadic {
+			return false
+		}
+		for i, param := range params {
+			if ft.param(i) != param {
+				return false
+			}
+		}
+		return true
+	}); t != nil {
+		return t
+	}
+
+	// The params array is variable length, so allocate the type manually.
+	size := unsafe.Offsetof(funcType{}.params) + uintptr(len(params))*unsafe.Sizeof(funcType{}.params[0])
+	ft := (*funcType)(alloc(size, nil))
+	ft.meta = uint8(Func)
+	ft.numIn = uint16(len(in))
+	ft.numOut = uint16(len(out))
+	ft.variadic = variadic
+	copy(unsafe.Slice(&ft.params[0], len(params)), params)
+	return addType(&ft.RawType)
+}
+
+// StructOf returns the struct type containing the given fields. The Offset and
+// Index of the fields are ignored and computed like the compiler would.
+//
+// Embedded fields with methods are not supported, because methods are not
+// promoted to the newly created struct.
+func StructOf(fields []StructField) Type {
+	// Check all fields and calculate the layout of the struct.
+	var pkgpath string
+	var size uintptr
+	alignment := uintptr(1)
+	hashFlags := uint8(flagComparable | flagIsBinary)
+	offsets := make([]uintptr, len(fields))
+	for i, field := range fields {
+		if field.Name == "" {
+			panic("reflect.StructOf: field " + itoa.Itoa(i) + " has no name")
+		}
+		if !isValidFieldName(field.Name) {
+			panic("reflect.StructOf: field " + itoa.Itoa(i) + " has invalid name")
+		}
+		if field.Type == nil {
+			panic("reflect.StructOf: field " + itoa.Itoa(i) + " has no type")
+		}
+		if field.Anonymous && field.PkgPath != "" {
+			panic("reflect.StructOf: field \"" + field.Name + "\" is anonymous but has PkgPath set")
+		}
+		if field.IsExported() {
+			// Best-effort check for misuse, just like upstream Go.
+			if c := field.Name[0]; 'a' <= c && c <= 'z' || c == '_' {
+				panic("reflect.StructOf: field \"" + field.Name + "\" is unexported but missing PkgPath")
+			}
+		} else if pkgpath == "" {
+			pkgpath = field.PkgPath
+		} else if pkgpath != field.PkgPath {
+			panic("reflect.Struct: fields with different PkgPath " + pkgpath + " and " + field.PkgPath)
+		}
+		if field.Anonymous && field.Type.NumMethod() != 0 {
+			panic("unimplemented: reflect.StructOf() with embedded fields that have methods")
+		}
+		if len(field.Tag) > 0xff {
+			panic("reflect.StructOf: tag of field \"" + field.Name + "\" is too long, max is 255 bytes")
+		}
+		for _, prev := range fields[:i] {
+			if prev.Name == field.Name && field.Name != "_" {
+				panic("reflect.StructOf: duplicate field " + field.Name)
+			}
+		}
+
+		typ := field.Type.(*RawType)
+		fieldAlign := uintptr(typ.Align())
+		offset := align(size, fieldAlign)
+		if offset < size || offset+typ.Size() < offset {
+			panic("reflect.StructOf: struct size would exceed virtual address space")
+		}
+		offsets[i] = offset
+		size = offset + typ.Size()
+		if fieldAlign > alignment {
+			alignment = fieldAlign
+		}
+		hashFlags &= typ.hashFlags()
+	}
+	if align(size, alignment) < size {
+		panic("reflect.StructOf: struct size would exceed virtual address space")
+	}
+	size = align(size, alignment)
+	if size > 0xffffffff {
+		panic("unimplemented: reflect.StructOf() with a struct larger than 4GB")
+	}
+
+	if t := lookupType(Struct, func(t *RawType) bool {
+		if t.NumField() != len(fields) {
+			return false
+		}
+		for i, field := range fields {
+			f := t.rawField(i)
+			if f.Name != field.Name || f.PkgPath != field.PkgPath || f.Type != field.Type.(*RawType) || f.Tag != field.Tag || f.Anonymous != field.Anonymous {
+				return false
+			}
+		}
+		return true
+	}); t != nil {
+		return t
+	}
+
+	// The fields array is variable length, so allocate the type manually.
+	typeSize := unsafe.Offsetof(structType{}.fields) + uintptr(len(fields))*unsafe.Sizeof(structField{})
+	st := (*structType)(alloc(typeSize, nil))
+	st.meta = uint8(Struct) | hashFlags
+	st.pkgpath = &append([]byte(pkgpath), 0)[0]
+	st.size = uint32(size)
+	st.numField = uint16(len(fields))
+	for i, field := range fields {
+		// Encode the field data in the same way as the compiler does.
+		var flags byte
+		if field.Anonymous {
+			flags |= structFieldFlagAnonymous | structFieldFlagIsEmbedded
+		}
+		if field.Tag != "" {
+			flags |= structFieldFlagHasTag
+		}
+		if field.IsExported() {
+			flags |= structFieldFlagIsExported
+		}
+		data := []byte{flags}
+		offset := offsets[i]
+		for offset >= 0x80 {
+			data = append(data, byte(offset)|0x80)
+			offset >>= 7
+		}
+		data = append(data, byte(offset))
+		data = append(data, field.Name...)
+		data = append(data, 0)
+		if field.Tag != "" {
+			data = append(data, byte(len(field.Tag)))
+			data = append(data, field.Tag...)
+		}
+
+		sf := (*structField)(unsafe.Add(unsafe.Pointer(&st.fields[0]), uintptr(i)*unsafe.Sizeof(structField{})))
+		sf.fieldType = field.Type.(*RawType)
+		sf.data = unsafe.Pointer(&data[0])
+	}
+	return addType(&st.RawType)
+}
+
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
+// isValidFieldName checks if a string is a valid (struct) field name or not.
+//
+// According to the language spec, a field name should be an identifier.
+//
+// identifier = letter { letter | unicode_digit } .
+// letter = unicode_letter | "_" .
+func isValidFieldName(fieldName string) bool {
+	for i, c := range fieldName {
+		if i == 0 && !isLetter(c) {
+			return false
+		}
+
+		if !(isLetter(c) || unicode.IsDigit(c)) {
+			return false
+		}
+	}
+
+	return len(fieldName) > 0
+}
@@ -127,6 +127,18 @@ const (
 	BothDir = RecvDir | SendDir             // chan
 )
 
+func (d ChanDir) String() string {
+	switch d {
+	case SendDir:
+		return "chan<-"
+	case RecvDir:
+		return "<-chan"
+	case BothDir:
+		return "chan"
+	}
+	return "ChanDir" + itoa.Itoa(int(d))
+}
+
 // Type represents the minimal interface for a Go type.
 type Type interface {
 	// These should match the reflectlite.Type implementation in Go.
@@ -175,12 +187,25 @@ type ptrType struct {
 	RawType
 	numMethod uint16
 	elem      *RawType
+	methods   *method // only present if numMethod != 0
 }
 
+// Type for interface types. The methods array isn't necessarily 1 element
+// long, instead it holds numMethod entries.
 type interfaceType struct {
 	RawType
-	ptrTo *RawType
-	// TODO: methods
+	numMethod  uint16
+	ptrTo      *RawType
+	implements func(t *RawType) bool // see implementedBy
+	methods    [1]imethod
+}
+
+// An entry in the method list of an interface type. The methods are sorted in
+// the same way as in the method table of concrete types.
+type imethod struct {
+	name    *byte    // null terminated method name
+	pkgpath *byte    // null terminated package path, empty for exported methods
+	mtyp    *RawType // method signature
 }
 
 type arrayType struct {
@@ -200,6 +225,9 @@ type mapType struct {
 	key       *RawType
 }
 
+// Type for named types. If the named type has a method table (see
+// hasMethodTable), a pointer to it is stored before the name, at the offset of
+// the name field.
 type namedType struct {
 	RawType
 	numMethod uint16
@@ -209,6 +237,28 @@ type namedType struct {
 	name      [1]byte
 }
 
+// Type for function signatures. The params array isn't necessarily 1 element
+// long, instead it holds numIn input types followed by numOut output types.
+type funcType struct {
+	RawType
+	numIn    uint16
+	ptrTo    *RawType
+	call     func(fn, args, results unsafe.Pointer) // see callFunc
+	numOut   uint16
+	variadic bool
+	params   [1]*RawType
+}
+
+// An entry in the method table of a type. The method table contains all
+// exported methods, sorted by name.
+type method struct {
+	name  *byte          // null terminated method name
+	mtyp  *RawType       // method signature without receiver
+	ftyp  *RawType       // method signature with receiver as first parameter
+	bound unsafe.Pointer // method with the receiver in the context parameter
+	fn    unsafe.Pointer // method with the receiver as first parameter
+}
+
 // Type for struct types. The numField value is intentionally put before ptrTo
 // for better struct packing on 32-bit and 64-bit architectures. On these
 // architectures, the ptrTo field still has the same offset as in all the other
@@ -224,6 +274,8 @@ type structType struct {
 	size      uint32
 	numField  uint16
 	fields    [1]structField // the remaining fields are all of type structField
+	// the fields array may be followed by a method table pointer, see
+	// hasMethodTable
 }
 
 type structField struct {
@@ -341,8 +393,55 @@ func (t *RawType) String() string {
 		s += " }"
 		return s
 	case Interface:
-		// TODO(dgryski): Needs actual method set info
-		return "interface {}"
+		numMethod := t.NumMethod()
+		if numMethod == 0 {
+			return "interface {}"
+		}
+		s := "interface {"
+		for i := 0; i < numMethod; i++ {
+			m := t.imethod(i)
+			s += " "
+			if pkgpath := readStringZ(unsafe.Pointer(m.pkgpath)); pkgpath != "" {
+				// Unexported methods are qualified with the package name.
+				s += pkgName(pkgpath) + "."
+			}
+			s += readStringZ(unsafe.Pointer(m.name)) + m.mtyp.String()[len("func"):]
+			// every method except the last needs a semicolon
+			if i < numMethod-1 {
+				s += ";"
+			}
+		}
+		s += " }"
+		return s
+	case Func:
+		ft := t.funcType()
+		s := "func("
+		for i := 0; i < int(ft.numIn); i++ {
+			if i > 0 {
+				s += ", "
+			}
+			if ft.variadic && i == int(ft.numIn)-1 {
+				s += "..." + ft.param(i).elem().String()
+			} else {
+				s += ft.param(i).String()
+			}
+		}
+		s += ")"
+		switch ft.numOut {
+		case 0:
+		case 1:
+			s += " " + ft.param(int(ft.numIn)).String()
+		default:
+			s += " ("
+			for i := 0; i < int(ft.numOut); i++ {
+				if i > 0 {
+					s += ", "
+				}
+				s += ft.param(int(ft.numIn) + i).String()
+			}
+			s += ")"
+		}
+		return s
 	default:
 		return t.Kind().String()
 	}
@@ -372,6 +471,11 @@ var (
 	errTypeChanDir      = &TypeError{"ChanDir"}
 	errTypeFieldByName  = &TypeError{"FieldByName"}
 	errTypeFieldByIndex = &TypeError{"FieldByIndex"}
+	errTypeIn           = &TypeError{"In"}
+	errTypeIsVariadic   = &TypeError{"IsVariadic"}
+	errTypeNumIn        = &TypeError{"NumIn"}
+	errTypeNumOut       = &TypeError{"NumOut"}
+	errTypeOut          = &TypeError{"Out"}
 )
 
 // Elem returns the element type for channel, slice and array types, the
@@ -733,29 +837,234 @@ func (t *RawType) FieldAlign() int {
 // AssignableTo returns whether a value of type t can be assigned to a variable
 // of type u.
 func (t *RawType) AssignableTo(u Type) bool {
-	if t == u.(*RawType) {
+	if u == nil {
+		panic("reflect: nil type passed to Type.AssignableTo")
+	}
+	uu := u.(*RawType)
+	return directlyAssignable(uu, t) || implements(uu, t)
+}
+
+// Implements reports whether the type implements the interface type u.
+func (t *RawType) Implements(u Type) bool {
+	if u == nil {
+		panic("reflect: nil type passed to Type.Implements")
+	}
+	if u.Kind() != Interface {
+		panic("reflect: non-interface type passed to Type.Implements")
+	}
+	return implements(u.(*RawType), t)
+}
+
+// ConvertibleTo reports whether a value of the type is convertible to type u.
+// Even if ConvertibleTo returns true, the conversion may still panic, for
+// example when converting a slice to an array that is longer than the slice.
+func (t *RawType) ConvertibleTo(u Type) bool {
+	if u == nil {
+		panic("reflect: nil type passed to Type.ConvertibleTo")
+	}
+	return convertible(u.(*RawType), t)
+}
+
+// directlyAssignable reports whether a value of type v can be directly
+// assigned (without conversion to an interface) to a value of type t.
+func directlyAssignable(t, v *RawType) bool {
+	if t == v {
 		return true
 	}
 
-	if t.underlying() == u.(*RawType).underlying() && (!t.isNamed() || !u.(*RawType).isNamed()) {
+	// Otherwise at least one of t and v must not be a named type, and they must
+	// have the same underlying type (or be a channel with a compatible
+	// direction, see specialChannelAssignability).
+	if t.isNamed() && v.isNamed() {
+		return false
+	}
+	if t.Kind() == Chan && specialChannelAssignability(t, v) {
 		return true
 	}
+	return t.underlying() == v.underlying()
+}
 
-	if u.Kind() == Interface && u.NumMethod() == 0 {
+// specialChannelAssignability reports whether a value of the channel type v
+// can be assigned to the channel type t: v must be a bidirectional channel,
+// at least one of them must be unnamed and the element types must be the same.
+func specialChannelAssignability(t, v *RawType) bool {
+	return v.ChanDir() == BothDir && (!t.isNamed() || !v.isNamed()) && t.elem() == v.elem()
+}
+
+// implements reports whether the type v implements the interface type t.
+func implements(t, v *RawType) bool {
+	if t.Kind() != Interface {
+		return false
+	}
+	numMethod := t.NumMethod()
+	if numMethod == 0 {
 		return true
 	}
+	if v.Kind() != Interface {
+		return implementedBy((*interfaceType)(unsafe.Pointer(t.underlying())), v)
+	}
 
-	if u.Kind() == Interface {
-		panic("reflect: unimplemented: AssignableTo with interface")
+	// Both are interface types. Both method lists are sorted in the same way,
+	// so all methods of t can be found in a single pass over the methods of v.
+	i := 0
+	vNumMethod := v.NumMethod()
+	for j := 0; j < vNumMethod; j++ {
+		tm := t.imethod(i)
+		vm := v.imethod(j)
+		if tm.mtyp == vm.mtyp && readStringZ(unsafe.Pointer(tm.name)) == readStringZ(unsafe.Pointer(vm.name)) && readStringZ(unsafe.Pointer(tm.pkgpath)) == readStringZ(unsafe.Pointer(vm.pkgpath)) {
+			i++
+			if i >= numMethod {
+				return true
+			}
+		}
 	}
 	return false
 }
 
-func (t *RawType) Implements(u Type) bool {
-	if u.Kind() != Interface {
-		panic("reflect: non-interface type passed to Type.Implements")
+// implementedBy returns whether the non-interface type t implements the
+// interface type itf. It is the only function that calls the implements
+// function stored in interface type codes: the compiler removes these
+// functions if implementedBy isn't used.
+//
+//go:noinline
+func implementedBy(itf *interfaceType, t *RawType) bool {
+	return itf.implements(t)
+}
+
+// convertible reports whether a value of type v can be converted to type t.
+// These rules must match convertOp in value.go.
+func convertible(t, v *RawType) bool {
+	switch v.Kind() {
+	case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		switch t.Kind() {
+		case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr, Float32, Float64, String:
+			return true
+		}
+	case Float32, Float64:
+		switch t.Kind() {
+		case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr, Float32, Float64:
+			return true
+		}
+	case Complex64, Complex128:
+		switch t.Kind() {
+		case Complex64, Complex128:
+			return true
+		}
+	case String:
+		if t.Kind() == Slice && t.elem().PkgPath() == "" {
+			switch t.elem().Kind() {
+			case Uint8, Int32:
+				return true
+			}
+		}
+	case Slice:
+		if t.Kind() == String && v.elem().PkgPath() == "" {
+			switch v.elem().Kind() {
+			case Uint8, Int32:
+				return true
+			}
+		}
+		// []T to *[N]T
+		if t.Kind() == Pointer && t.elem().Kind() == Array && v.elem() == t.elem().elem() {
+			return true
+		}
+		// []T to [N]T
+		if t.Kind() == Array && v.elem() == t.elem() {
+			return true
+		}
+	case Chan:
+		if t.Kind() == Chan && specialChannelAssignability(t, v) {
+			return true
+		}
 	}
-	return t.AssignableTo(u)
+
+	// Types with the same underlying type (ignoring struct tags) can be
+	// converted.
+	if haveIdenticalUnderlyingType(t, v, false) {
+		return true
+	}
+
+	// Unnamed pointer types with the same underlying element type (ignoring
+	// struct tags) can be converted.
+	if t.Kind() == Pointer && !t.isNamed() && v.Kind() == Pointer && !v.isNamed() && haveIdenticalUnderlyingType(t.elem(), v.elem(), false) {
+		return true
+	}
+
+	// A value can be converted to any interface type it implements.
+	return implements(t, v)
+}
+
+// haveIdenticalType reports whether t and v are identical types. If cmpTags is
+// false, struct tags are ignored.
+func haveIdenticalType(t, v *RawType, cmpTags bool) bool {
+	if cmpTags {
+		return t == v
+	}
+	if t.Name() != v.Name() || t.Kind() != v.Kind() || t.PkgPath() != v.PkgPath() {
+		return false
+	}
+	return haveIdenticalUnderlyingType(t, v, false)
+}
+
+// haveIdenticalUnderlyingType reports whether t and v have identical
+// underlying types. If cmpTags is false, struct tags are ignored.
+func haveIdenticalUnderlyingType(t, v *RawType, cmpTags bool) bool {
+	t = t.underlying()
+	v = v.underlying()
+	if t == v {
+		return true
+	}
+	if cmpTags {
+		// Type codes are unique, so these types are different.
+		return false
+	}
+
+	kind := t.Kind()
+	if kind != v.Kind() {
+		return false
+	}
+	switch kind {
+	case Array:
+		return t.Len() == v.Len() && haveIdenticalType(t.elem(), v.elem(), false)
+	case Chan:
+		return t.ChanDir() == v.ChanDir() && haveIdenticalType(t.elem(), v.elem(), false)
+	case Func:
+		tf := t.funcType()
+		vf := v.funcType()
+		if tf.numIn != vf.numIn || tf.numOut != vf.numOut || tf.variadic != vf.variadic {
+			return false
+		}
+		for i := 0; i < int(tf.numIn)+int(tf.numOut); i++ {
+			if !haveIdenticalType(tf.param(i), vf.param(i), false) {
+				return false
+			}
+		}
+		return true
+	case Map:
+		return haveIdenticalType(t.key(), v.key(), false) && haveIdenticalType(t.elem(), v.elem(), false)
+	case Pointer, Slice:
+		return haveIdenticalType(t.elem(), v.elem(), false)
+	case Struct:
+		numField := t.NumField()
+		if numField != v.NumField() {
+			return false
+		}
+		for i := 0; i < numField; i++ {
+			tf := t.rawField(i)
+			vf := v.rawField(i)
+			if tf.Name != vf.Name || tf.PkgPath != vf.PkgPath || tf.Offset != vf.Offset || tf.Anonymous != vf.Anonymous {
+				return false
+			}
+			if !haveIdenticalType(tf.Type, vf.Type, false) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Other types with the same kind are either basic types, which would have
+	// the same type code, or interfaces with different methods.
+	return false
 }
 
 // Comparable returns whether values of this type can be compared to each other.
@@ -791,14 +1100,24 @@ func (t *RawType) NumMethod() int {
 	case Struct:
 		return int((*structType)(unsafe.Pointer(t)).numMethod)
 	case Interface:
-		//FIXME: Use len(methods)
-		return (*interfaceType)(unsafe.Pointer(t)).ptrTo.NumMethod()
+		return int((*interfaceType)(unsafe.Pointer(t)).numMethod)
 	}
 
 	// Other types have no methods attached.  Note we don't panic here.
 	return 0
 }
 
+// pkgName returns the last element of a package path, which is usually the
+// package name.
+func pkgName(pkgpath string) string {
+	for i := len(pkgpath) - 1; i >= 0; i-- {
+		if pkgpath[i] == '/' {
+			return pkgpath[i+1:]
+		}
+	}
+	return pkgpath
+}
+
 // Read and return a null terminated string starting from data.
 func readStringZ(data unsafe.Pointer) string {
 	start := data
@@ -816,7 +1135,12 @@ func readStringZ(data unsafe.Pointer) string {
 
 func (t *RawType) name() string {
 	ntype := (*namedType)(unsafe.Pointer(t))
-	return readStringZ(unsafe.Pointer(&ntype.name[0]))
+	name := unsafe.Pointer(&ntype.name[0])
+	if t.hasMethodTable() {
+		// Skip the method table pointer.
+		name = unsafe.Add(name, unsafe.Sizeof(uintptr(0)))
+	}
+	return readStringZ(name)
 }
 
 func (t *RawType) Name() string {
@@ -843,6 +1167,177 @@ func (t *RawType) Key() Type {
 	return t.key()
 }
 
+// hasMethodTable returns whether the type code has a pointer to a method
+// table. This is the case for all non-interface types with exported methods.
+func (t *RawType) hasMethodTable() bool {
+	return t.ptrtag() == 0 && t.Kind() != Interface && t.NumMethod() != 0
+}
+
+// methodTable returns the method table of the given type, or nil if it
+// doesn't have one. It is the only function that reads the method table
+// pointer: the compiler removes all method tables if this function isn't used.
+//
+//go:noinline
+func methodTable(t *RawType) *method {
+	if !t.hasMethodTable() {
+		return nil
+	}
+	if t.isNamed() {
+		ntype := (*namedType)(unsafe.Pointer(t))
+		return *(**method)(unsafe.Pointer(&ntype.name[0]))
+	}
+	switch t.Kind() {
+	case Pointer:
+		return (*ptrType)(unsafe.Pointer(t)).methods
+	case Struct:
+		stype := (*structType)(unsafe.Pointer(t))
+		end := unsafe.Add(unsafe.Pointer(&stype.fields[0]), uintptr(stype.numField)*unsafe.Sizeof(structField{}))
+		return *(**method)(end)
+	}
+	return nil
+}
+
+// method returns the i'th entry in the method table. The index must be in
+// range.
+func (t *RawType) method(i int) *method {
+	return (*method)(unsafe.Add(unsafe.Pointer(methodTable(t)), uintptr(i)*unsafe.Sizeof(method{})))
+}
+
+// imethod returns the i'th entry in the method list of an interface type. The
+// index must be in range.
+func (t *RawType) imethod(i int) *imethod {
+	itf := (*interfaceType)(unsafe.Pointer(t.underlying()))
+	return (*imethod)(unsafe.Add(unsafe.Pointer(&itf.methods[0]), uintptr(i)*unsafe.Sizeof(imethod{})))
+}
+
+// Method describes a single method of a type. It mirrors reflect.Method.
+type Method struct {
+	Name    string
+	PkgPath string
+	Type    Type  // method type
+	Func    Value // func with receiver as first argument
+	Index   int   // index for Type.Method
+}
+
+// Method returns the i'th method of a type. For a non-interface type, the
+// Func field of the returned method takes the receiver as the first argument.
+// For an interface type, the Type field is the method signature without a
+// receiver and the Func field is invalid.
+func (t *RawType) Method(i int) Method {
+	if uint(i) >= uint(t.NumMethod()) {
+		panic("reflect: Method index out of range")
+	}
+	if t.Kind() == Interface {
+		m := t.imethod(i)
+		return Method{
+			Name:    readStringZ(unsafe.Pointer(m.name)),
+			PkgPath: readStringZ(unsafe.Pointer(m.pkgpath)),
+			Type:    m.mtyp,
+			Index:   i,
+		}
+	}
+	m := t.method(i)
+	return Method{
+		Name: readStringZ(unsafe.Pointer(m.name)),
+		Type: m.ftyp,
+		Func: Value{
+			typecode: m.ftyp,
+			value:    unsafe.Pointer(&funcHeader{Code: m.fn}),
+			flags:    valueFlagExported,
+		},
+		Index: i,
+	}
+}
+
+// MethodByName returns the method with the given name, see Method.
+func (t *RawType) MethodByName(name string) (Method, bool) {
+	if i := t.methodIndex(name); i >= 0 {
+		return t.Method(i), true
+	}
+	return Method{}, false
+}
+
+// methodIndex returns the index of the method with the given name, or -1 if
+// there is no such method.
+func (t *RawType) methodIndex(name string) int {
+	numMethod := t.NumMethod()
+	for i := 0; i < numMethod; i++ {
+		var methodName *byte
+		if t.Kind() == Interface {
+			methodName = t.imethod(i).name
+		} else {
+			methodName = t.method(i).name
+		}
+		if readStringZ(unsafe.Pointer(methodName)) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *RawType) funcType() *funcType {
+	return (*funcType)(unsafe.Pointer(t.underlying()))
+}
+
+// param returns the i'th entry in the params array: an input type if i <
+// numIn, otherwise an output type.
+func (t *funcType) param(i int) *RawType {
+	return *(**RawType)(unsafe.Add(unsafe.Pointer(&t.params[0]), uintptr(i)*unsafe.Sizeof(t.params[0])))
+}
+
+// IsVariadic returns whether the last input parameter of a function type is a
+// "..." parameter. It panics if the type is not a function type.
+func (t *RawType) IsVariadic() bool {
+	if t.Kind() != Func {
+		panic(errTypeIsVariadic)
+	}
+	return t.funcType().variadic
+}
+
+// NumIn returns the number of input parameters of a function type. It panics
+// if the type is not a function type.
+func (t *RawType) NumIn() int {
+	if t.Kind() != Func {
+		panic(errTypeNumIn)
+	}
+	return int(t.funcType().numIn)
+}
+
+// NumOut returns the number of output parameters of a function type. It
+// panics if the type is not a function type.
+func (t *RawType) NumOut() int {
+	if t.Kind() != Func {
+		panic(errTypeNumOut)
+	}
+	return int(t.funcType().numOut)
+}
+
+// In returns the type of the i'th input parameter of a function type. It
+// panics if the type is not a function type or i is out of range.
+func (t *RawType) In(i int) Type {
+	if t.Kind() != Func {
+		panic(errTypeIn)
+	}
+	ft := t.funcType()
+	if uint(i) >= uint(ft.numIn) {
+		panic("reflect: Function index out of range")
+	}
+	return ft.param(i)
+}
+
+// Out returns the type of the i'th output parameter of a function type. It
+// panics if the type is not a function type or i is out of range.
+func (t *RawType) Out(i int) Type {
+	if t.Kind() != Func {
+		panic(errTypeOut)
+	}
+	ft := t.funcType()
+	if uint(i) >= uint(ft.numOut) {
+		panic("reflect: Function index out of range")
+	}
+	return ft.param(int(ft.numIn) + i)
+}
+
 // OverflowComplex reports whether the complex128 x cannot be represented by type t.
 // It panics if t's Kind is not Complex64 or Complex128.
 func (t RawType) OverflowComplex(x complex128) bool {
@@ -1087,26 +1582,6 @@ func align(offset uintptr, alignment uintptr) uintptr {
 	return (offset + alignment - 1) &^ (alignment - 1)
 }
 
-func SliceOf(t Type) Type {
-	panic("unimplemented: reflect.SliceOf()")
-}
-
-func ArrayOf(n int, t Type) Type {
-	panic("unimplemented: reflect.ArrayOf()")
-}
-
-func StructOf([]StructField) Type {
-	panic("unimplemented: reflect.StructOf()")
-}
-
-func MapOf(key, value Type) Type {
-	panic("unimplemented: reflect.MapOf()")
-}
-
-func FuncOf(in, out []Type, variadic bool) Type {
-	panic("unimplemented: reflect.FuncOf()")
-}
-
 const maxVarintLen32 = 5
 
 // encoding/binary.Uvarint, specialized for uint32
@@ -1028,8 +1028,7 @@ func (v Value) MapKeys() []Value {
 	e := New(v.typecode.Elem())
 
 	keyType := v.typecode.key()
-	keyTypeIsEmptyInterface := keyType.Kind() == Interface && keyType.NumMethod() == 0
-	shouldUnpackInterface := !keyTypeIsEmptyInterface && keyType.Kind() != String && !keyType.isBinary()
+	shouldUnpackInterface := keyType.Kind() != Interface && keyType.Kind() != String && !keyType.isBinary()
 
 	for hashmapNext(v.pointer(), it, k.value, e.value) {
 		if shouldUnpackInterface {
@@ -1162,8 +1161,7 @@ func (iter *MapIter) Reset(v Value) {
 
 	keyType := v.typecode.key()
 
-	keyTypeIsEmptyInterface := keyType.Kind() == Interface && keyType.NumMethod() == 0
-	shouldUnpackInterface := !keyTypeIsEmptyInterface && keyType.Kind() != String && !keyType.isBinary()
+	shouldUnpackInterface := keyType.Kind() != Interface && keyType.Kind() != String && !keyType.isBinary()
 
 	*iter = MapIter{
 		m:                  v,
@@ -1378,8 +1376,13 @@ func convertOp(src Value, typ Type) (Value, bool) {
 		}, true
 	}
 
-	if rtype := typ.(*RawType); rtype.Kind() == Interface && rtype.NumMethod() == 0 {
-		iface := composeInterface(unsafe.Pointer(src.typecode), src.value)
+	if rtype := typ.(*RawType); rtype.Kind() == Interface {
+		// A value can be converted to an interface type it implements. An
+		// interface value keeps its dynamic type and value.
+		if !implements(rtype, src.typecode) {
+			return Value{}, false
+		}
+		iface := valueInterfaceUnsafe(src)
 		return Value{
 			typecode: rtype,
 			value:    unsafe.Pointer(&iface),
@@ -2090,24 +2093,225 @@ func MakeMap(typ Type) Value {
 	return MakeMapWithSize(typ, 8)
 }
 
+// Call calls the function v with the input arguments in. It returns the
+// output results as Values.
 func (v Value) Call(in []Value) []Value {
-	panic("unimplemented: (reflect.Value).Call()")
+	return v.call("Call", in, false)
 }
 
+// CallSlice calls the variadic function v with the input arguments in,
+// assigning the slice in[len(in)-1] to v's final variadic argument.
 func (v Value) CallSlice(in []Value) []Value {
-	panic("unimplemented: (reflect.Value).CallSlice()")
+	return v.call("CallSlice", in, true)
+}
+
+func (v Value) call(op string, in []Value, isSlice bool) []Value {
+	if v.Kind() != Func {
+		panic(&ValueError{Method: "reflect.Value." + op, Kind: v.Kind()})
+	}
+	if v.isRO() {
+		panic("reflect: " + op + " using value obtained using unexported field")
+	}
+	if v.IsNil() {
+		panic("reflect: call of nil function")
+	}
+
+	ft := v.typecode.funcType()
+	n := int(ft.numIn)
+	if isSlice {
+		if !ft.variadic {
+			panic("reflect: CallSlice of non-variadic function")
+		}
+		if len(in) < n {
+			panic("reflect: CallSlice with too few input arguments")
+		}
+		if len(in) > n {
+			panic("reflect: CallSlice with too many input arguments")
+		}
+	} else {
+		if ft.variadic {
+			n--
+		}
+		if len(in) < n {
+			panic("reflect: Call with too few input arguments")
+		}
+		if !ft.variadic && len(in) > n {
+			panic("reflect: Call with too many input arguments")
+		}
+	}
+	for _, x := range in {
+		if x.Kind() == Invalid {
+			panic("reflect: " + op + " using zero Value argument")
+		}
+	}
+	for i := 0; i < n; i++ {
+		if xt, targ := in[i].typecode, ft.param(i); !xt.AssignableTo(targ) {
+			panic("reflect: " + op + " using " + xt.String() + " as type " + targ.String())
+		}
+	}
+	if !isSlice && ft.variadic {
+		// Put the remaining values in a slice.
+		m := len(in) - n
+		slice := MakeSlice(ft.param(n), m, m)
+		elem := ft.param(n).elem()
+		for i := 0; i < m; i++ {
+			x := in[n+i]
+			if xt := x.typecode; !xt.AssignableTo(elem) {
+				panic("reflect: cannot use " + xt.String() + " as type " + elem.String() + " in " + op)
+			}
+			slice.Index(i).Set(x)
+		}
+		origIn := in
+		in = make([]Value, n+1)
+		copy(in[:n], origIn)
+		in[n] = slice
+	}
+
+	// Store the arguments in a buffer that is laid out like a struct.
+	numIn := int(ft.numIn)
+	args := alloc(ft.paramsSize(0, numIn), nil)
+	var offset uintptr
+	for i := 0; i < numIn; i++ {
+		typ := ft.param(i)
+		offset = align(offset, uintptr(typ.Align()))
+		arg := Value{
+			typecode: typ,
+			value:    unsafe.Add(args, offset),
+			flags:    valueFlagExported | valueFlagIndirect,
+		}
+		arg.Set(in[i])
+		offset += typ.Size()
+	}
+
+	// Allocate a buffer for the results, also laid out like a struct.
+	numOut := int(ft.numOut)
+	results := alloc(ft.paramsSize(numIn, numOut), nil)
+
+	callFunc(ft, v.value, args, results)
+
+	return ft.loadParams(numIn, numOut, results)
+}
+
+// paramsSize returns the size of a buffer that holds n parameters (or results)
+// of t starting at the given index, laid out like a struct.
+func (t *funcType) paramsSize(start, n int) uintptr {
+	var size uintptr
+	for i := start; i < start+n; i++ {
+		typ := t.param(i)
+		size = align(size, uintptr(typ.Align())) + typ.Size()
+	}
+	return size
+}
+
+// loadParams wraps n parameters (or results) of t starting at the given index
+// in Values, reading them from a buffer laid out like a struct. Small values are
+// stored directly in the Value, larger values point into the buffer.
+func (t *funcType) loadParams(start, n int, buf unsafe.Pointer) []Value {
+	values := make([]Value, n)
+	var offset uintptr
+	for i := range values {
+		typ := t.param(start + i)
+		offset = align(offset, uintptr(typ.Align()))
+		ptr := unsafe.Add(buf, offset)
+		offset += typ.Size()
+		if size := typ.Size(); size <= unsafe.Sizeof(uintptr(0)) {
+			ptr = unsafe.Pointer(loadValue(ptr, size))
+		}
+		values[i] = Value{
+			typecode: typ,
+			value:    ptr,
+			flags:    valueFlagExported,
+		}
+	}
+	return values
 }
 
+// callFunc calls the func value pointed to by fn, which must be of type t. The
+// args and results buffers are laid out like a struct of the parameter and
+// result types. This is the only function that uses the call trampoline of a
+// function type: the compiler removes the trampolines if it isn't used.
+//
+//go:noinline
+func callFunc(t *funcType, fn, args, results unsafe.Pointer) {
+	if t.call == nil {
+		// Function types created by FuncOf don't have a call trampoline.
+		// Functions created by MakeFunc can still be called directly.
+		if callMakeFunc(fn, args, results) {
+			return
+		}
+		panic("unimplemented: (reflect.Value).Call() on a function type created by FuncOf")
+	}
+	t.call(fn, args, results)
+}
+
+// Method returns a function value corresponding to v's i'th method. Calling the
+// returned function doesn't need a receiver, it always uses v as the receiver.
 func (v Value) Method(i int) Value {
-	panic("unimplemented: (reflect.Value).Method()")
+	if v.typecode == nil {
+		panic(&ValueError{Method: "reflect.Value.Method", Kind: Invalid})
+	}
+	if uint(i) >= uint(v.typecode.NumMethod()) {
+		panic("reflect: Method index out of range")
+	}
+	if v.Kind() == Interface {
+		if v.IsNil() {
+			panic("reflect: Method on nil interface value")
+		}
+		m := v.typecode.imethod(i)
+		if *m.pkgpath != 0 {
+			// The method table of the dynamic type only contains exported
+			// methods.
+			panic("unimplemented: (reflect.Value).Method() for unexported interface methods")
+		}
+		// Look the method up in the method table of the dynamic type.
+		return v.Elem().MethodByName(readStringZ(unsafe.Pointer(m.name)))
+	}
+	return v.bindMethod(v.typecode.method(i))
 }
 
+// MethodByName returns a function value corresponding to the method of v with
+// the given name, or the zero Value if no such method exists. See Method.
 func (v Value) MethodByName(name string) Value {
-	panic("unimplemented: (reflect.Value).MethodByName()")
+	if v.typecode == nil {
+		panic(&ValueError{Method: "reflect.Value.MethodByName", Kind: Invalid})
+	}
+	if v.Kind() == Interface {
+		if v.IsNil() {
+			panic("reflect: MethodByName on nil interface value")
+		}
+		// Look the method up in the method table of the dynamic type.
+		return v.Elem().MethodByName(name)
+	}
+	if i := v.typecode.methodIndex(name); i >= 0 {
+		return v.bindMethod(v.typecode.method(i))
+	}
+	return Value{}
 }
 
-func (v Value) Recv() (x Value, ok bool) {
-	panic("unimplemented: (reflect.Value).Recv()")
+// bindMethod returns a method value for the given method, with v as the
+// receiver.
+func (v Value) bindMethod(m *method) Value {
+	// The receiver is passed in the context parameter of the function value,
+	// in the same way it would be stored in an interface value.
+	recv := v.value
+	if v.isIndirect() {
+		size := v.typecode.Size()
+		if size <= unsafe.Sizeof(uintptr(0)) {
+			recv = unsafe.Pointer(loadValue(v.value, size))
+		} else {
+			// Method values bind a copy of the receiver.
+			recv = alloc(size, nil)
+			memcpy(recv, v.value, size)
+		}
+	}
+	return Value{
+		typecode: m.mtyp,
+		value: unsafe.Pointer(&funcHeader{
+			Context: recv,
+			Code:    m.bound,
+		}),
+		flags: v.flags&valueFlagExported | v.flags.ro(),
+	}
 }
 
 func NewAt(typ Type, p unsafe.Pointer) Value {
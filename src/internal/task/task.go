@@ -29,6 +29,14 @@ type Task struct {
 	DeferFrame unsafe.Pointer
 }
 
+// Number of goroutines that were started and haven't exited yet.
+var numTasks int
+
+// Count returns the number of goroutines that currently exist.
+func Count() int {
+	return numTasks
+}
+
 // DataUint32 returns the Data field as a uint32. The value is only valid after
 // setting it through SetDataUint32 or by storing to it using DataAtomicUint32.
 func (t *Task) DataUint32() uint32 {
@@ -56,3 +64,9 @@ func runtime_alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer
 
 //go:linkname scheduleTask runtime.scheduleTask
 func scheduleTask(*Task)
+
+//go:linkname traceGoCreate runtime.traceGoCreate
+func traceGoCreate(*Task)
+
+//go:linkname traceGoDestroy runtime.traceGoDestroy
+func traceGoDestroy(*Task)
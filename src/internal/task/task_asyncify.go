@@ -28,6 +28,10 @@ type state struct {
 	stackState
 
 	launched bool
+
+	// paused is true while the task is unwound in Pause, and false once the
+	// goroutine has exited.
+	paused bool
 }
 
 // stackState is the saved state of a stack while unwound.
@@ -52,6 +56,8 @@ type stackState struct {
 func start(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 	t := &Task{}
 	t.state.initialize(fn, args, stackSize)
+	numTasks++
+	traceGoCreate(t)
 	scheduleTask(t)
 }
 
@@ -97,7 +103,11 @@ func Pause() {
 		runtimePanic("stack overflow")
 	}
 
+	// Code before and after the unwind call is not run while unwinding or
+	// rewinding, so paused is only true while the task is suspended.
+	currentTask.state.paused = true
 	currentTask.state.unwind()
+	currentTask.state.paused = false
 }
 
 //export tinygo_unwind
@@ -116,6 +126,11 @@ func (t *Task) Resume() {
 	} else {
 		t.state.rewind()
 	}
+	if !t.state.paused {
+		// The goroutine function returned.
+		traceGoDestroy(t)
+		numTasks--
+	}
 	currentTask = prevTask
 	t.gcData.swap()
 	if uintptr(t.state.asyncifysp) > uintptr(t.state.csp) {
@@ -30,6 +30,9 @@ type state struct {
 	// When initializing the goroutine, the stackCanary constant is stored there.
 	// If the stack overflowed, the word will likely no longer equal stackCanary.
 	canaryPtr *uintptr
+
+	// check contains the painted stack information with -stack-check.
+	check stackCheck
 }
 
 // currentTask is the current running task, or nil if currently in the scheduler.
@@ -43,6 +46,10 @@ func Current() *Task {
 // Pause suspends the current task and returns to the scheduler.
 // This function may only be called when running on a goroutine stack, not when running on the system stack or in an interrupt.
 func Pause() {
+	// With -stack-check, do a more thorough check that also reports which
+	// goroutine overflowed its stack.
+	currentTask.state.check.verify()
+
 	// Check whether the canary (the lowest address of the stack) is still
 	// valid. If it is not, a stack overflow has occurred.
 	if *currentTask.state.canaryPtr != stackCanary {
@@ -54,8 +61,14 @@ func Pause() {
 	currentTask.state.pause()
 }
 
+// pause is called by tinygo_startTask when the goroutine function returns, to
+// exit the goroutine.
+//
 //export tinygo_pause
 func pause() {
+	currentTask.state.check.exit(currentTask)
+	traceGoDestroy(currentTask)
+	numTasks--
 	Pause()
 }
 
@@ -74,6 +87,10 @@ func (s *state) initialize(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 	// Create a stack.
 	stack := runtime_alloc(stackSize, nil)
 
+	// Paint the stack with -stack-check, so that stack usage can be measured
+	// later.
+	s.check.paint(stack, stackSize)
+
 	// Set up the stack canary, a random number that should be checked when
 	// switching from the task back to the scheduler. The stack canary pointer
 	// points to the first word of the stack. If it has changed between now and
@@ -104,8 +121,18 @@ var startTask [0]uint8
 // start creates and starts a new goroutine with the given function and arguments.
 // The new goroutine is scheduled to run later.
 func start(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
+	startNamed(fn, args, stackSize, "")
+}
+
+// startNamed is like start, but also records the name of the goroutine start
+// function. The compiler calls this function instead of start with
+// -stack-check.
+func startNamed(fn uintptr, args unsafe.Pointer, stackSize uintptr, name string) {
 	t := &Task{}
 	t.state.initialize(fn, args, stackSize)
+	t.state.check.register(t, name)
+	numTasks++
+	traceGoCreate(t)
 	scheduleTask(t)
 }
 
@@ -0,0 +1,116 @@
+//go:build scheduler.tasks && tinygo.stackcheck
+
+package task
+
+// This file implements -stack-check: goroutine stacks are painted with a known
+// pattern when they're created, and the bottom of the stack is checked on
+// every context switch. This detects stack overflows more reliably than the
+// single stack canary, reports which goroutine overflowed its stack, and makes
+// it possible to measure how much of each stack has been used.
+
+import "unsafe"
+
+// Pattern that is written to every word of a new goroutine stack. Words that
+// still contain this value have (most likely) never been used.
+const stackPaint = uintptr(uint64(0xa5a5a5a5a5a5a5a5) & uint64(^uintptr(0)))
+
+// Number of painted words just above the stack canary that must be left
+// untouched. A goroutine that comes this close to the end of its stack is
+// treated as if it overflowed, because an interrupt or a slightly deeper call
+// would overflow it.
+const stackGuardWords = 4
+
+//go:linkname printstring runtime.printstring
+func printstring(s string)
+
+//go:linkname printnl runtime.printnl
+func printnl()
+
+// stackCheck contains the information about a single painted goroutine stack.
+type stackCheck struct {
+	name  string         // start function of the goroutine
+	stack unsafe.Pointer // lowest address of the stack (where the canary is)
+	size  uintptr        // stack size in bytes
+	next  *Task          // next goroutine in stackTasks
+}
+
+// List of all running goroutines, for debug.GoroutineStacks.
+var stackTasks *Task
+
+// paint fills the entire stack with the stack paint pattern. It is called
+// before the stack canary and the initial register values are stored.
+func (c *stackCheck) paint(stack unsafe.Pointer, size uintptr) {
+	c.stack = stack
+	c.size = size
+	words := unsafe.Slice((*uintptr)(stack), size/unsafe.Sizeof(uintptr(0)))
+	for i := range words {
+		words[i] = stackPaint
+	}
+}
+
+// register records the name of the goroutine and adds it to the list of
+// running goroutines.
+func (c *stackCheck) register(t *Task, name string) {
+	c.name = name
+	c.next = stackTasks
+	stackTasks = t
+}
+
+// exit removes the goroutine from the list of running goroutines, when it
+// exits.
+func (c *stackCheck) exit(t *Task) {
+	for p := &stackTasks; *p != nil; p = &(*p).state.check.next {
+		if *p == t {
+			*p = c.next
+			break
+		}
+	}
+}
+
+// verify checks the stack canary and the guard words above it, and panics
+// with the name of the goroutine if any of them was overwritten.
+func (c *stackCheck) verify() {
+	words := unsafe.Slice((*uintptr)(c.stack), 1+stackGuardWords)
+	if words[0] == stackCanary {
+		overflow := false
+		for _, word := range words[1:] {
+			if word != stackPaint {
+				overflow = true
+			}
+		}
+		if !overflow {
+			return
+		}
+	}
+	printstring("stack overflow in goroutine ")
+	if c.name == "" {
+		printstring("<unknown>")
+	} else {
+		printstring(c.name)
+	}
+	printnl()
+	runtimePanic("goroutine stack overflow")
+}
+
+// used returns the maximum number of bytes of the stack that have been used so
+// far (the high-water mark), by looking for the lowest word that no longer
+// contains the stack paint pattern.
+func (c *stackCheck) used() uintptr {
+	wordSize := unsafe.Sizeof(uintptr(0))
+	words := unsafe.Slice((*uintptr)(c.stack), c.size/wordSize)
+	i := 1 // skip the stack canary
+	for i < len(words) && words[i] == stackPaint {
+		i++
+	}
+	return c.size - uintptr(i)*wordSize
+}
+
+// Implementation of debug.GoroutineStacks.
+//
+//go:linkname debug_readGoroutineStacks runtime/debug.runtime_readGoroutineStacks
+func debug_readGoroutineStacks(fn func(name string, size, used uintptr)) bool {
+	for t := stackTasks; t != nil; t = t.state.check.next {
+		fn(t.state.check.name, t.state.check.size, t.state.check.used())
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+//go:build !scheduler.tasks || !tinygo.stackcheck
+
+package task
+
+import "unsafe"
+
+// Goroutine stacks are not painted in this build.
+type stackCheck struct{}
+
+func (c *stackCheck) paint(stack unsafe.Pointer, size uintptr) {
+}
+
+func (c *stackCheck) register(t *Task, name string) {
+}
+
+func (c *stackCheck) exit(t *Task) {
+}
+
+func (c *stackCheck) verify() {
+}
+
+//go:linkname debug_readGoroutineStacks runtime/debug.runtime_readGoroutineStacks
+func debug_readGoroutineStacks(fn func(name string, size, used uintptr)) bool {
+	return false
+}
@@ -0,0 +1,224 @@
+//go:build !baremetal || (sam && atsame51) || (sam && atsame54) || stm32f103 || stm32f4 || stm32l5
+
+package machine
+
+import "errors"
+
+// The CAN API is shared between all chips with a CAN controller. Every
+// controller provides at least these methods:
+//
+//	Configure(config CANConfig) error
+//	SetFilter(index int, filter CANFilter) error
+//	Transmit(frame *CANFrame) error
+//	Receive(frame *CANFrame) bool
+//
+// CAN FD frames can only be sent and received on controllers that support CAN
+// FD (the SAM E5x, the FDCAN peripheral of the STM32, and the simulated
+// controller on host systems).
+
+var (
+	ErrCANTxFull        = errors.New("CAN: transmit buffers full")
+	ErrCANInvalidFrame  = errors.New("CAN: invalid frame")
+	ErrCANInvalidFilter = errors.New("CAN: invalid filter")
+
+	errCANInvalidTransferRate   = errors.New("CAN: invalid TransferRate")
+	errCANInvalidTransferRateFD = errors.New("CAN: invalid TransferRateFD")
+)
+
+type CANTransferRate uint32
+
+// CAN transfer rates for CANConfig
+const (
+	CANTransferRate125kbps  CANTransferRate = 125000
+	CANTransferRate250kbps  CANTransferRate = 250000
+	CANTransferRate500kbps  CANTransferRate = 500000
+	CANTransferRate1000kbps CANTransferRate = 1000000
+	CANTransferRate2000kbps CANTransferRate = 2000000
+	CANTransferRate4000kbps CANTransferRate = 4000000
+)
+
+// CANConfig holds CAN configuration parameters. Tx and Rx need to be
+// specified with some pins. When the Standby Pin is specified, configure it
+// as an output pin and output Low in Configure(). If this operation is not
+// necessary, specify NoPin.
+//
+// TransferRate is the bit rate of the arbitration phase, and of the data phase
+// of frames that don't switch the bit rate. TransferRateFD is the bit rate of
+// the data phase of CAN FD frames with BRS set, and is ignored by controllers
+// without CAN FD support.
+type CANConfig struct {
+	TransferRate   CANTransferRate
+	TransferRateFD CANTransferRate
+	Tx             Pin
+	Rx             Pin
+	Standby        Pin
+}
+
+// CANFrame is a single frame on the CAN bus.
+type CANFrame struct {
+	ID       uint32 // 11-bit standard or 29-bit extended identifier
+	Extended bool   // ID is a 29-bit extended identifier
+	Remote   bool   // remote transmission request (no data)
+	FD       bool   // CAN FD frame, with up to 64 bytes of data
+	BRS      bool   // switch to TransferRateFD for the data (CAN FD only)
+	Length   uint8  // number of bytes in Data
+	Data     [64]byte
+
+	// Timestamp is the time at which a received frame was seen on the bus,
+	// according to a 16-bit counter of the controller that wraps around. On
+	// the hardware controllers the counter is incremented every bit time. It
+	// is ignored for transmitted frames.
+	Timestamp uint16
+}
+
+// Payload returns the data of this frame as a slice.
+func (f *CANFrame) Payload() []byte {
+	return f.Data[:f.Length]
+}
+
+// validate checks whether this frame can be sent by a controller, with or
+// without support for CAN FD.
+func (f *CANFrame) validate(fdSupported bool) error {
+	if f.ID > canMaxID(f.Extended) {
+		return ErrCANInvalidFrame
+	}
+	if !f.FD {
+		if f.BRS || f.Length > 8 {
+			return ErrCANInvalidFrame
+		}
+		return nil
+	}
+
+	// CAN FD frames have no remote frames, and only support some lengths
+	// above 8 bytes.
+	if !fdSupported || f.Remote || f.Length > 64 || CANDlcToLength(CANLengthToDlc(f.Length, true), true) != f.Length {
+		return ErrCANInvalidFrame
+	}
+	return nil
+}
+
+// CANFilter selects which frames are received by a CAN controller. A frame
+// matches the filter when its identifier has the same type (standard or
+// extended) as the filter and is equal to ID in all the bits that are set in
+// Mask. A zero Mask matches every identifier of the given type.
+//
+// Until a filter has been set with SetFilter, all frames are received. After
+// that, only frames that match at least one of the filters are received.
+// Configure removes all filters again. Every controller supports at least 8
+// filters (index 0 through 7).
+type CANFilter struct {
+	ID       uint32
+	Mask     uint32
+	Extended bool
+}
+
+// valid returns whether the ID and mask fit in the identifier type of this
+// filter.
+func (f CANFilter) valid() bool {
+	maxID := canMaxID(f.Extended)
+	return f.ID <= maxID && f.Mask <= maxID
+}
+
+// matches returns whether the given frame matches this filter.
+func (f CANFilter) matches(frame *CANFrame) bool {
+	return f.Extended == frame.Extended && (frame.ID^f.ID)&f.Mask == 0
+}
+
+// canMaxID returns the largest standard or extended identifier.
+func canMaxID(extended bool) uint32 {
+	if extended {
+		return 0x1FFFFFFF
+	}
+	return 0x7FF
+}
+
+// canBitTiming is the bit timing of a CAN controller. The clock is divided by
+// the prescaler to get the time quantum. A bit is made up of a one quantum
+// synchronization segment, followed by seg1 quanta before and seg2 quanta
+// after the sample point. Resynchronization can change the bit time by at most
+// sjw quanta.
+type canBitTiming struct {
+	prescaler uint32
+	seg1      uint32
+	seg2      uint32
+	sjw       uint32
+}
+
+// calculateCANBitTiming returns the bit timing for the given controller clock
+// and transfer rate, with a sample point at (about) 87.5% of the bit as
+// recommended by CiA. The fields of limits contain the largest value the
+// controller supports for each field. It returns false if the transfer rate
+// can't be reached exactly.
+func calculateCANBitTiming(clock uint32, rate CANTransferRate, limits canBitTiming) (canBitTiming, bool) {
+	if rate == 0 {
+		return canBitTiming{}, false
+	}
+
+	// Use as many quanta per bit as possible, for the most accurate sample
+	// point.
+	for quanta := 1 + limits.seg1 + limits.seg2; quanta >= 8; quanta-- {
+		if clock%(uint32(rate)*quanta) != 0 {
+			continue
+		}
+		prescaler := clock / (uint32(rate) * quanta)
+		if prescaler > limits.prescaler {
+			// Fewer quanta only need a larger prescaler.
+			break
+		}
+		seg2 := (quanta + 4) / 8
+		seg1 := quanta - 1 - seg2
+		if seg1 > limits.seg1 || seg2 > limits.seg2 {
+			continue
+		}
+		sjw := seg2
+		if sjw > limits.sjw {
+			sjw = limits.sjw
+		}
+		return canBitTiming{prescaler: prescaler, seg1: seg1, seg2: seg2, sjw: sjw}, true
+	}
+	return canBitTiming{}, false
+}
+
+// CANDlcToLength() converts a DLC value to its actual length.
+func CANDlcToLength(dlc byte, isFD bool) byte {
+	length := dlc
+	if dlc == 0x09 {
+		length = 12
+	} else if dlc == 0x0A {
+		length = 16
+	} else if dlc == 0x0B {
+		length = 20
+	} else if dlc == 0x0C {
+		length = 24
+	} else if dlc == 0x0D {
+		length = 32
+	} else if dlc == 0x0E {
+		length = 48
+	} else if dlc == 0x0F {
+		length = 64
+	}
+	return length
+
+}
+
+// CANLengthToDlc() converts its actual length to a DLC value.
+func CANLengthToDlc(length byte, isFD bool) byte {
+	dlc := length
+	if length <= 0x08 {
+	} else if length <= 12 {
+		dlc = 0x09
+	} else if length <= 16 {
+		dlc = 0x0A
+	} else if length <= 20 {
+		dlc = 0x0B
+	} else if length <= 24 {
+		dlc = 0x0C
+	} else if length <= 32 {
+		dlc = 0x0D
+	} else if length <= 48 {
+		dlc = 0x0E
+	} else if length <= 64 {
+		dlc = 0x0F
+	}
+	return dlc
+}
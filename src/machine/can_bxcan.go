@@ -0,0 +1,70 @@
+//go:build !baremetal || stm32f103 || stm32f4
+
+package machine
+
+// Encoding of filters and frames for the bxCAN peripheral of the STM32F1 and
+// STM32F4. This is kept separate from the driver so that it can be tested on
+// the host.
+
+// Bits in the identifier (TIxR, RIxR, FiRx) and data length (TDTxR, RDTxR)
+// registers of the bxCAN.
+const (
+	bxcanIR_TXRQ     = 1 << 0
+	bxcanIR_RTR      = 1 << 1
+	bxcanIR_IDE      = 1 << 2
+	bxcanIR_EXID_Pos = 3
+	bxcanIR_STID_Pos = 21
+
+	bxcanDTR_TIME_Pos = 16
+)
+
+// bxcanFilter returns the values of the FR1 (identifier) and FR2 (mask)
+// registers of a filter bank in 32-bit mask mode. The identifier type (IDE) is
+// always compared, the RTR bit never.
+func bxcanFilter(filter CANFilter) (fr1, fr2 uint32) {
+	if filter.Extended {
+		return filter.ID<<bxcanIR_EXID_Pos | bxcanIR_IDE, filter.Mask<<bxcanIR_EXID_Pos | bxcanIR_IDE
+	}
+	return filter.ID << bxcanIR_STID_Pos, filter.Mask<<bxcanIR_STID_Pos | bxcanIR_IDE
+}
+
+// bxcanEncodeFrame returns the values of the IR, DTR, DLR and DHR registers of
+// a transmit mailbox for the given classic CAN frame. The TXRQ bit is not set.
+func bxcanEncodeFrame(frame *CANFrame) (ir, dtr, dlr, dhr uint32) {
+	ir = frame.ID << bxcanIR_STID_Pos
+	if frame.Extended {
+		ir = frame.ID<<bxcanIR_EXID_Pos | bxcanIR_IDE
+	}
+	if frame.Remote {
+		ir |= bxcanIR_RTR
+	}
+	data := &frame.Data
+	dtr = uint32(frame.Length)
+	dlr = uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24
+	dhr = uint32(data[4]) | uint32(data[5])<<8 | uint32(data[6])<<16 | uint32(data[7])<<24
+	return
+}
+
+// bxcanDecodeFrame stores the frame in the IR, DTR, DLR and DHR registers of a
+// receive mailbox in frame.
+func bxcanDecodeFrame(frame *CANFrame, ir, dtr, dlr, dhr uint32) {
+	frame.Extended = ir&bxcanIR_IDE != 0
+	if frame.Extended {
+		frame.ID = ir >> bxcanIR_EXID_Pos
+	} else {
+		frame.ID = ir >> bxcanIR_STID_Pos
+	}
+	frame.Remote = ir&bxcanIR_RTR != 0
+	frame.FD = false
+	frame.BRS = false
+	frame.Length = uint8(dtr & 0xf)
+	if frame.Length > 8 {
+		// A DLC above 8 means 8 bytes in classic CAN frames.
+		frame.Length = 8
+	}
+	frame.Timestamp = uint16(dtr >> bxcanDTR_TIME_Pos)
+	for i := 0; i < 4; i++ {
+		frame.Data[i] = byte(dlr >> (8 * i))
+		frame.Data[4+i] = byte(dhr >> (8 * i))
+	}
+}
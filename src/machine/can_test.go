@@ -0,0 +1,98 @@
+package machine
+
+import "testing"
+
+func TestCalculateCANBitTiming(t *testing.T) {
+	// Largest values supported by the STM32 bxCAN and FDCAN peripherals, as
+	// passed by their Configure methods.
+	bxcan := canBitTiming{prescaler: 1024, seg1: 16, seg2: 8, sjw: 4}
+	fdcanNominal := canBitTiming{prescaler: 512, seg1: 256, seg2: 128, sjw: 128}
+	fdcanData := canBitTiming{prescaler: 32, seg1: 32, seg2: 16, sjw: 16}
+
+	tests := []struct {
+		name   string
+		clock  uint32
+		rate   CANTransferRate
+		limits canBitTiming
+		timing canBitTiming
+		ok     bool
+	}{
+		{"bxcan-42MHz-500k", 42_000_000, CANTransferRate500kbps, bxcan, canBitTiming{6, 11, 2, 2}, true},
+		{"bxcan-42MHz-1M", 42_000_000, CANTransferRate1000kbps, bxcan, canBitTiming{3, 11, 2, 2}, true},
+		{"bxcan-36MHz-125k", 36_000_000, CANTransferRate125kbps, bxcan, canBitTiming{16, 15, 2, 2}, true},
+		{"bxcan-zero-rate", 42_000_000, 0, bxcan, canBitTiming{}, false},
+		{"fdcan-110MHz-500k", 110_000_000, CANTransferRate500kbps, fdcanNominal, canBitTiming{1, 191, 28, 28}, true},
+		{"fdcan-110MHz-2M", 110_000_000, CANTransferRate2000kbps, fdcanData, canBitTiming{5, 9, 1, 1}, true},
+		{"fdcan-110MHz-4M", 110_000_000, CANTransferRate4000kbps, fdcanData, canBitTiming{}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			timing, ok := calculateCANBitTiming(tc.clock, tc.rate, tc.limits)
+			if ok != tc.ok || timing != tc.timing {
+				t.Fatalf("calculateCANBitTiming(%d, %d) = %+v, %v; want %+v, %v", tc.clock, tc.rate, timing, ok, tc.timing, tc.ok)
+			}
+			if !ok {
+				return
+			}
+
+			// Check that the result is within the limits and gives exactly
+			// the requested rate.
+			if timing.prescaler > tc.limits.prescaler || timing.seg1 > tc.limits.seg1 || timing.seg2 > tc.limits.seg2 || timing.sjw > tc.limits.sjw {
+				t.Errorf("timing %+v exceeds limits %+v", timing, tc.limits)
+			}
+			if got := tc.clock / (timing.prescaler * (1 + timing.seg1 + timing.seg2)); got != uint32(tc.rate) {
+				t.Errorf("timing %+v gives %d bit/s, want %d", timing, got, tc.rate)
+			}
+		})
+	}
+}
+
+func TestBXCANFilter(t *testing.T) {
+	tests := []struct {
+		filter   CANFilter
+		fr1, fr2 uint32
+	}{
+		// STID is in bits 21-31, IDE (bit 2) is always compared.
+		{CANFilter{ID: 0x123, Mask: 0x7F0}, 0x24600000, 0xFE000004},
+		{CANFilter{}, 0, 0x00000004},
+		// EXID is in bits 3-31.
+		{CANFilter{ID: 0x1ABCDEF0, Mask: 0x1FFFFF00, Extended: true}, 0xD5E6F784, 0xFFFFF804},
+		{CANFilter{Extended: true}, 0x00000004, 0x00000004},
+	}
+	for _, tc := range tests {
+		fr1, fr2 := bxcanFilter(tc.filter)
+		if fr1 != tc.fr1 || fr2 != tc.fr2 {
+			t.Errorf("bxcanFilter(%+v) = %#08x, %#08x; want %#08x, %#08x", tc.filter, fr1, fr2, tc.fr1, tc.fr2)
+		}
+	}
+}
+
+func TestBXCANFrame(t *testing.T) {
+	tests := []struct {
+		name              string
+		frame             CANFrame
+		ir, dtr, dlr, dhr uint32
+	}{
+		{"standard", CANFrame{ID: 0x123, Length: 3, Data: [64]byte{0x11, 0x22, 0x33}}, 0x24600000, 3, 0x00332211, 0},
+		{"extended", CANFrame{ID: 0x1ABCDEF0, Extended: true, Length: 8, Data: [64]byte{1, 2, 3, 4, 5, 6, 7, 8}}, 0xD5E6F784, 8, 0x04030201, 0x08070605},
+		{"remote", CANFrame{ID: 0x7FF, Remote: true, Length: 2}, 0xFFE00002, 2, 0, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir, dtr, dlr, dhr := bxcanEncodeFrame(&tc.frame)
+			if ir != tc.ir || dtr != tc.dtr || dlr != tc.dlr || dhr != tc.dhr {
+				t.Fatalf("bxcanEncodeFrame = %#08x, %#08x, %#08x, %#08x; want %#08x, %#08x, %#08x, %#08x", ir, dtr, dlr, dhr, tc.ir, tc.dtr, tc.dlr, tc.dhr)
+			}
+
+			// A received frame uses the same encoding, with a timestamp in
+			// the upper half of DTR.
+			var frame CANFrame
+			bxcanDecodeFrame(&frame, ir, dtr|0x1234<<bxcanDTR_TIME_Pos, dlr, dhr)
+			want := tc.frame
+			want.Timestamp = 0x1234
+			if frame != want {
+				t.Errorf("bxcanDecodeFrame = %+v, want %+v", frame, want)
+			}
+		})
+	}
+}
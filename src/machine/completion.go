@@ -0,0 +1,69 @@
+//go:build baremetal && !scheduler.none
+
+package machine
+
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"runtime/volatile"
+	_ "unsafe" // for go:linkname
+)
+
+//go:linkname scheduleTask runtime.scheduleTask
+func scheduleTask(*task.Task)
+
+// completion is used to wait for an operation that finishes in an interrupt,
+// like a DMA transfer. The waiting goroutine is paused while waiting, so that
+// other goroutines can run (or the CPU can sleep) in the meantime.
+type completion struct {
+	done   volatile.Register8
+	waiter *task.Task
+}
+
+// reset prepares for a new operation. It must be called before the operation
+// is started.
+func (c *completion) reset() {
+	c.done.Set(0)
+}
+
+// wait pauses the current goroutine until signal is called.
+func (c *completion) wait() {
+	for {
+		mask := interrupt.Disable()
+		if c.done.Get() != 0 {
+			interrupt.Restore(mask)
+			return
+		}
+		if interrupt.In() {
+			// Goroutines can't be paused inside an interrupt, so spin instead.
+			// This only works if signal is called from an interrupt with a
+			// higher priority.
+			interrupt.Restore(mask)
+			continue
+		}
+		c.waiter = task.Current()
+		interrupt.Restore(mask)
+
+		// If signal is called before the goroutine is paused, it will be
+		// resumed right away.
+		task.Pause()
+	}
+}
+
+// signal marks the operation as finished, and resumes the waiting goroutine
+// (if any). It is normally called from an interrupt.
+func (c *completion) signal() {
+	mask := interrupt.Disable()
+	c.done.Set(1)
+	t := c.waiter
+	c.waiter = nil
+	interrupt.Restore(mask)
+	if t != nil {
+		scheduleTask(t)
+	}
+}
+
+// isDone returns whether signal was called since the last reset.
+func (c *completion) isDone() bool {
+	return c.done.Get() != 0
+}
@@ -0,0 +1,35 @@
+//go:build baremetal && scheduler.none
+
+package machine
+
+import "runtime/volatile"
+
+// completion is used to wait for an operation that finishes in an interrupt,
+// like a DMA transfer. Without a scheduler, there is nothing else to run so
+// wait simply spins until signal is called.
+type completion struct {
+	done volatile.Register8
+}
+
+// reset prepares for a new operation. It must be called before the operation
+// is started.
+func (c *completion) reset() {
+	c.done.Set(0)
+}
+
+// wait spins until signal is called.
+func (c *completion) wait() {
+	for c.done.Get() == 0 {
+	}
+}
+
+// signal marks the operation as finished. It is normally called from an
+// interrupt.
+func (c *completion) signal() {
+	c.done.Set(1)
+}
+
+// isDone returns whether signal was called since the last reset.
+func (c *completion) isDone() bool {
+	return c.done.Get() != 0
+}
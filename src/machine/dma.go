@@ -0,0 +1,107 @@
+//go:build rp2040 || rp2350 || (sam && atsamd51) || (sam && atsame5x) || stm32f4
+
+package machine
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// DMA (direct memory access) copies data between memory and peripherals
+// without involving the CPU. A DMA channel must be claimed with
+// ClaimDMAChannel before it can be used, and can be released again with
+// Release when it is no longer needed.
+//
+// A transfer is started with Start. While it is running, the CPU is free to do
+// other work. Use Busy or Wait to check for completion, or set a callback with
+// SetCallback to be notified when the transfer has finished. The memory used in
+// a transfer must not be modified (or freed) until the transfer has finished.
+//
+// The following methods are available on a *DMAChannel:
+//
+//	Start(t DMATransfer) error     // start a new transfer
+//	Busy() bool                    // whether a transfer is in progress
+//	Wait()                         // wait until the transfer has finished
+//	Abort()                        // stop the current transfer
+//	SetCallback(func(*DMAChannel)) // called (from an interrupt) on completion
+//	Release()                      // give up this DMA channel
+
+// Errors returned by the DMA API.
+var (
+	ErrNoDMAChannel = errors.New("machine: no DMA channel available")
+	ErrDMABusy      = errors.New("machine: DMA channel is busy")
+	ErrDMAInvalid   = errors.New("machine: invalid DMA transfer")
+)
+
+// DMADirection is the direction of a DMA transfer.
+type DMADirection uint8
+
+const (
+	// Copy from memory to memory, as fast as possible.
+	DMAMemoryToMemory DMADirection = iota
+
+	// Copy from memory to a peripheral register, paced by the peripheral
+	// (for example, to send data over SPI).
+	DMAMemoryToPeripheral
+
+	// Copy from a peripheral register to memory, paced by the peripheral
+	// (for example, to receive samples from an ADC).
+	DMAPeripheralToMemory
+)
+
+// DMASize is the size of a single unit of a DMA transfer, in bytes.
+type DMASize uint8
+
+const (
+	DMASize8  DMASize = 1
+	DMASize16 DMASize = 2
+	DMASize32 DMASize = 4
+)
+
+// DMATransfer describes a single DMA transfer.
+type DMATransfer struct {
+	Direction DMADirection
+
+	// Size of each unit that is transferred.
+	Size DMASize
+
+	// Source and destination address. The address on the peripheral side (if
+	// any) is not incremented during the transfer, the address on the memory
+	// side is.
+	Src unsafe.Pointer
+	Dst unsafe.Pointer
+
+	// Number of units (not bytes!) to transfer.
+	Count int
+}
+
+// validate checks whether this transfer can be started.
+func (t *DMATransfer) validate() error {
+	switch t.Size {
+	case DMASize8, DMASize16, DMASize32:
+	default:
+		return ErrDMAInvalid
+	}
+	if t.Count <= 0 || t.Src == nil || t.Dst == nil {
+		return ErrDMAInvalid
+	}
+	if uintptr(t.Src)%uintptr(t.Size) != 0 || uintptr(t.Dst)%uintptr(t.Size) != 0 {
+		// Unaligned transfers are not supported by most DMA controllers.
+		return ErrDMAInvalid
+	}
+	return nil
+}
+
+// Wait blocks until the current transfer (if any) has finished.
+func (ch *DMAChannel) Wait() {
+	for ch.Busy() {
+	}
+}
+
+// SetCallback sets a function that is called when a transfer on this channel
+// has finished. It is called from an interrupt, so it must be short and must
+// not allocate memory. Pass nil to remove the callback.
+func (ch *DMAChannel) SetCallback(callback func(*DMAChannel)) {
+	ch.callback = callback
+	ch.enableInterrupt(callback != nil)
+}
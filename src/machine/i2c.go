@@ -37,6 +37,9 @@ var (
 	errI2COverflow           = errors.New("I2C receive buffer overflow")
 	errI2COverread           = errors.New("I2C transmit buffer overflow")
 	errI2CNotImplemented     = errors.New("I2C operation not yet implemented")
+
+	ErrInvalidTgtAddr = errors.New("invalid target i2c address not in 0..0x80 or is reserved")
+	ErrI2CWrongMode   = errors.New("i2c wrong mode")
 )
 
 // I2CTargetEvent reflects events on the I2C bus
@@ -90,3 +93,11 @@ func (i2c *I2C) WriteRegister(address uint8, register uint8, data []byte) error
 func (i2c *I2C) ReadRegister(address uint8, register uint8, data []byte) error {
 	return i2c.Tx(uint16(address), []byte{register}, data)
 }
+
+// isReservedI2CAddr returns whether the given 7-bit address is reserved by the
+// I2C specification, and therefore can't be used as a target address.
+//
+//go:inline
+func isReservedI2CAddr(addr uint8) bool {
+	return (addr&0x78) == 0 || (addr&0x78) == 0x78
+}
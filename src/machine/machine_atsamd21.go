@@ -673,6 +673,7 @@ func (uart *UART) handleInterrupt(interrupt.Interrupt) {
 type I2C struct {
 	Bus    *sam.SERCOM_I2CM_Type
 	SERCOM uint8
+	mode   I2CMode
 }
 
 // I2CConfig is used to store config info for I2C.
@@ -680,6 +681,7 @@ type I2CConfig struct {
 	Frequency uint32
 	SCL       Pin
 	SDA       Pin
+	Mode      I2CMode
 }
 
 const (
@@ -733,6 +735,18 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 		i2c.Bus.SYNCBUSY.HasBits(sam.SERCOM_I2CM_SYNCBUSY_SWRST) {
 	}
 
+	i2c.mode = config.Mode
+	if config.Mode == I2CModeTarget {
+		// Set i2c target mode. The SERCOM is enabled in Listen.
+		i2c.configureTarget()
+
+		// enable pins
+		config.SDA.Configure(PinConfig{Mode: sdaPinMode})
+		config.SCL.Configure(PinConfig{Mode: sclPinMode})
+
+		return nil
+	}
+
 	// Set i2c controller mode
 	//SERCOM_I2CM_CTRLA_MODE( I2C_MASTER_OPERATION )
 	i2c.Bus.CTRLA.Set(sam.SERCOM_I2CM_CTRLA_MODE_I2C_MASTER << sam.SERCOM_I2CM_CTRLA_MODE_Pos) // |
@@ -770,6 +784,10 @@ func (i2c *I2C) SetBaudRate(br uint32) error {
 // It clocks out the given address, writes the bytes in w, reads back len(r)
 // bytes and stores them in r, and generates a stop condition on the bus.
 func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
+	if i2c.mode != I2CModeController {
+		return ErrI2CWrongMode
+	}
+
 	var err error
 	if len(w) != 0 {
 		// send start/address for write
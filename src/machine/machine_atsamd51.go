@@ -1156,6 +1156,7 @@ func (uart *UART) handleInterrupt(interrupt.Interrupt) {
 type I2C struct {
 	Bus    *sam.SERCOM_I2CM_Type
 	SERCOM uint8
+	mode   I2CMode
 }
 
 // I2CConfig is used to store config info for I2C.
@@ -1163,6 +1164,7 @@ type I2CConfig struct {
 	Frequency uint32
 	SCL       Pin
 	SDA       Pin
+	Mode      I2CMode
 }
 
 const (
@@ -1225,6 +1227,18 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 	// set clock
 	setSERCOMClockGenerator(i2c.SERCOM, sam.GCLK_PCHCTRL_GEN_GCLK1)
 
+	i2c.mode = config.Mode
+	if config.Mode == I2CModeTarget {
+		// Set i2c target mode. The SERCOM is enabled in Listen.
+		i2c.configureTarget()
+
+		// enable pins
+		config.SDA.Configure(PinConfig{Mode: sdaPinMode})
+		config.SCL.Configure(PinConfig{Mode: sclPinMode})
+
+		return nil
+	}
+
 	// Set i2c controller mode
 	//SERCOM_I2CM_CTRLA_MODE( I2C_MASTER_OPERATION )
 	// sam.SERCOM_I2CM_CTRLA_MODE_I2C_MASTER = 5?
@@ -1263,6 +1277,10 @@ func (i2c *I2C) SetBaudRate(br uint32) error {
 // It clocks out the given address, writes the bytes in w, reads back len(r)
 // bytes and stores them in r, and generates a stop condition on the bus.
 func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
+	if i2c.mode != I2CModeController {
+		return ErrI2CWrongMode
+	}
+
 	var err error
 	if len(w) != 0 {
 		// send start/address for write
@@ -1418,6 +1436,7 @@ func (i2c *I2C) readByte() byte {
 type SPI struct {
 	Bus    *sam.SERCOM_SPIM_Type
 	SERCOM uint8
+	dma    *DMAChannel // set by EnableDMA
 }
 
 // SPIConfig is used to store config info for SPI.
@@ -1624,11 +1643,40 @@ func (spi *SPI) Tx(w, r []byte) error {
 	return nil
 }
 
+// EnableDMA claims a DMA channel that Tx then uses to send data when the
+// received bytes are ignored, for example when sending pixel data to a
+// display. It returns ErrNoDMAChannel if no DMA channel is available.
+func (spi *SPI) EnableDMA() error {
+	if spi.dma != nil {
+		return nil
+	}
+	ch, err := ClaimDMAChannel(DMARequestSERCOM0TX + DMARequest(spi.SERCOM)*2)
+	if err != nil {
+		return err
+	}
+	spi.dma = ch
+	return nil
+}
+
 func (spi *SPI) tx(tx []byte) {
-	for i := 0; i < len(tx); i++ {
-		for !spi.Bus.INTFLAG.HasBits(sam.SERCOM_SPIM_INTFLAG_DRE) {
+	err := ErrNoDMAChannel
+	if spi.dma != nil && len(tx) > 0 && len(tx) <= 0xffff {
+		err = spi.dma.Start(DMATransfer{
+			Direction: DMAMemoryToPeripheral,
+			Size:      DMASize8,
+			Src:       unsafe.Pointer(&tx[0]),
+			Dst:       unsafe.Pointer(&spi.Bus.DATA),
+			Count:     len(tx),
+		})
+	}
+	if err == nil {
+		spi.dma.Wait()
+	} else {
+		for i := 0; i < len(tx); i++ {
+			for !spi.Bus.INTFLAG.HasBits(sam.SERCOM_SPIM_INTFLAG_DRE) {
+			}
+			spi.Bus.DATA.Set(uint32(tx[i]))
 		}
-		spi.Bus.DATA.Set(uint32(tx[i]))
 	}
 	for !spi.Bus.INTFLAG.HasBits(sam.SERCOM_SPIM_INTFLAG_TXC) {
 	}
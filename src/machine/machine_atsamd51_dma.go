@@ -0,0 +1,248 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"runtime/interrupt"
+	"unsafe"
+)
+
+// Number of DMA channels that can be claimed. The DMAC has 32 channels, but
+// every channel needs two transfer descriptors in RAM. Only the first few are
+// used to save memory.
+const dmaChannelCount = 8
+
+// DMARequest is the trigger source (CHCTRLA.TRIGSRC) of the peripheral that
+// paces a transfer. See the DMARequest* constants for common peripherals.
+type DMARequest uint8
+
+// DMA trigger sources of peripherals on the SAM D5x/E5x. See table 22-1 in
+// the datasheet.
+const (
+	// DMARequestNone is used for memory-to-memory transfers, which run as
+	// fast as possible.
+	DMARequestNone DMARequest = 0x00
+
+	DMARequestSERCOM0RX DMARequest = 0x04
+	DMARequestSERCOM0TX DMARequest = 0x05
+	DMARequestSERCOM1RX DMARequest = 0x06
+	DMARequestSERCOM1TX DMARequest = 0x07
+	DMARequestSERCOM2RX DMARequest = 0x08
+	DMARequestSERCOM2TX DMARequest = 0x09
+	DMARequestSERCOM3RX DMARequest = 0x0A
+	DMARequestSERCOM3TX DMARequest = 0x0B
+	DMARequestSERCOM4RX DMARequest = 0x0C
+	DMARequestSERCOM4TX DMARequest = 0x0D
+	DMARequestSERCOM5RX DMARequest = 0x0E
+	DMARequestSERCOM5TX DMARequest = 0x0F
+	DMARequestSERCOM6RX DMARequest = 0x10
+	DMARequestSERCOM6TX DMARequest = 0x11
+	DMARequestSERCOM7RX DMARequest = 0x12
+	DMARequestSERCOM7TX DMARequest = 0x13
+)
+
+// Transfer descriptor, as read by the DMAC. See section 22.8 in the datasheet.
+type dmaDescriptor struct {
+	btctrl   uint16
+	btcnt    uint16
+	srcaddr  uint32
+	dstaddr  uint32
+	descaddr uint32
+}
+
+// Bits in the BTCTRL field of a transfer descriptor.
+const (
+	dmaBTCTRL_VALID        = 1 << 0
+	dmaBTCTRL_BEATSIZE_Pos = 8
+	dmaBTCTRL_SRCINC       = 1 << 10
+	dmaBTCTRL_DSTINC       = 1 << 11
+)
+
+// DMAChannel is a single DMA channel, see ClaimDMAChannel.
+type DMAChannel struct {
+	id       uint8
+	claimed  bool
+	request  DMARequest
+	callback func(*DMAChannel)
+}
+
+var (
+	dmaChannelState [dmaChannelCount]DMAChannel
+	dmaInitialized  bool
+	dmaAvailable    bool // false if the DMAC is in use by other code
+
+	// The DMAC requires the descriptor and write-back sections to be 128-bit
+	// aligned, so reserve some extra space to align them.
+	dmaDescriptorMemory [dmaChannelCount*2 + 1]dmaDescriptor
+	dmaDescriptors      *[dmaChannelCount]dmaDescriptor
+	dmaWriteback        *[dmaChannelCount]dmaDescriptor
+)
+
+// ClaimDMAChannel claims a free DMA channel for transfers paced by the given
+// peripheral request, or DMARequestNone for memory-to-memory transfers. It
+// returns ErrNoDMAChannel if all channels are in use, or if the DMAC was
+// already set up by other code.
+func ClaimDMAChannel(request DMARequest) (*DMAChannel, error) {
+	mask := interrupt.Disable()
+	defer interrupt.Restore(mask)
+	if !dmaInitialized {
+		dmaInit()
+	}
+	if !dmaAvailable {
+		return nil, ErrNoDMAChannel
+	}
+	for i := range dmaChannelState {
+		ch := &dmaChannelState[i]
+		if !ch.claimed {
+			*ch = DMAChannel{
+				id:      uint8(i),
+				claimed: true,
+				request: request,
+			}
+			return ch, nil
+		}
+	}
+	return nil, ErrNoDMAChannel
+}
+
+func dmaInit() {
+	dmaInitialized = true
+
+	sam.MCLK.AHBMASK.SetBits(sam.MCLK_AHBMASK_DMAC_)
+	if sam.DMAC.CTRL.HasBits(sam.DMAC_CTRL_DMAENABLE) {
+		// The DMAC was already set up by other code (for example a driver
+		// that uses the DMAC directly), which may have transfers in progress
+		// and may have allocated fewer descriptors than needed here. Leave
+		// it alone and don't hand out any channels.
+		return
+	}
+	dmaAvailable = true
+
+	// Align the descriptor sections on a 16-byte boundary.
+	base := (uintptr(unsafe.Pointer(&dmaDescriptorMemory[0])) + 15) &^ 15
+	dmaDescriptors = (*[dmaChannelCount]dmaDescriptor)(unsafe.Pointer(base))
+	dmaWriteback = (*[dmaChannelCount]dmaDescriptor)(unsafe.Pointer(base + dmaChannelCount*16))
+
+	// Reset and configure the DMAC.
+	sam.DMAC.CTRL.SetBits(sam.DMAC_CTRL_SWRST)
+	for sam.DMAC.CTRL.HasBits(sam.DMAC_CTRL_SWRST) {
+	}
+	sam.DMAC.BASEADDR.Set(uint32(uintptr(unsafe.Pointer(dmaDescriptors))))
+	sam.DMAC.WRBADDR.Set(uint32(uintptr(unsafe.Pointer(dmaWriteback))))
+	sam.DMAC.CTRL.Set(sam.DMAC_CTRL_DMAENABLE |
+		sam.DMAC_CTRL_LVLEN0 | sam.DMAC_CTRL_LVLEN1 | sam.DMAC_CTRL_LVLEN2 | sam.DMAC_CTRL_LVLEN3)
+
+	// Channels 0-3 have their own interrupt, the others share one.
+	interrupt.New(sam.IRQ_DMAC_0, dmaHandleInterrupt).Enable()
+	interrupt.New(sam.IRQ_DMAC_1, dmaHandleInterrupt).Enable()
+	interrupt.New(sam.IRQ_DMAC_2, dmaHandleInterrupt).Enable()
+	interrupt.New(sam.IRQ_DMAC_3, dmaHandleInterrupt).Enable()
+	interrupt.New(sam.IRQ_DMAC_OTHER, dmaHandleInterrupt).Enable()
+}
+
+// Release aborts any running transfer and makes this channel available to be
+// claimed again.
+func (ch *DMAChannel) Release() {
+	ch.Abort()
+	ch.SetCallback(nil)
+	ch.claimed = false
+}
+
+// Start starts a new transfer on this channel. It returns ErrDMABusy if the
+// previous transfer hasn't finished yet.
+func (ch *DMAChannel) Start(t DMATransfer) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	if t.Count > 0xffff {
+		// The block transfer count is only 16 bits.
+		return ErrDMAInvalid
+	}
+	if ch.Busy() {
+		return ErrDMABusy
+	}
+
+	// Configure the transfer descriptor. When an address is incremented, the
+	// descriptor contains the address just past the end of the block.
+	desc := &dmaDescriptors[ch.id]
+	btctrl := uint16(dmaBTCTRL_VALID | uint16(t.Size>>1)<<dmaBTCTRL_BEATSIZE_Pos)
+	src := uint32(uintptr(t.Src))
+	dst := uint32(uintptr(t.Dst))
+	size := uint32(t.Count) * uint32(t.Size)
+	request := ch.request
+	trigact := uint32(sam.DMAC_CHANNEL_CHCTRLA_TRIGACT_BURST)
+	switch t.Direction {
+	case DMAMemoryToMemory:
+		btctrl |= dmaBTCTRL_SRCINC | dmaBTCTRL_DSTINC
+		src += size
+		dst += size
+		request = DMARequestNone
+		trigact = sam.DMAC_CHANNEL_CHCTRLA_TRIGACT_BLOCK
+	case DMAMemoryToPeripheral:
+		btctrl |= dmaBTCTRL_SRCINC
+		src += size
+	case DMAPeripheralToMemory:
+		btctrl |= dmaBTCTRL_DSTINC
+		dst += size
+	default:
+		return ErrDMAInvalid
+	}
+	desc.btctrl = btctrl
+	desc.btcnt = uint16(t.Count)
+	desc.srcaddr = src
+	desc.dstaddr = dst
+	desc.descaddr = 0 // single block
+
+	// Configure and enable the channel.
+	regs := &sam.DMAC.CHANNEL[ch.id]
+	regs.CHCTRLA.Set(uint32(request)<<sam.DMAC_CHANNEL_CHCTRLA_TRIGSRC_Pos |
+		trigact<<sam.DMAC_CHANNEL_CHCTRLA_TRIGACT_Pos)
+	regs.CHINTFLAG.Set(sam.DMAC_CHANNEL_CHINTFLAG_TCMPL | sam.DMAC_CHANNEL_CHINTFLAG_TERR)
+	regs.CHCTRLA.SetBits(sam.DMAC_CHANNEL_CHCTRLA_ENABLE)
+	if request == DMARequestNone {
+		// There is no peripheral to trigger the transfer, so trigger it from
+		// software.
+		sam.DMAC.SWTRIGCTRL.SetBits(1 << ch.id)
+	}
+	return nil
+}
+
+// Busy returns whether a transfer is in progress. The channel is disabled by
+// hardware once the transfer is complete.
+func (ch *DMAChannel) Busy() bool {
+	return sam.DMAC.CHANNEL[ch.id].CHCTRLA.HasBits(sam.DMAC_CHANNEL_CHCTRLA_ENABLE)
+}
+
+// Abort stops the current transfer, if any.
+func (ch *DMAChannel) Abort() {
+	regs := &sam.DMAC.CHANNEL[ch.id]
+	regs.CHCTRLA.ClearBits(sam.DMAC_CHANNEL_CHCTRLA_ENABLE)
+	for regs.CHCTRLA.HasBits(sam.DMAC_CHANNEL_CHCTRLA_ENABLE) {
+	}
+	regs.CHINTFLAG.Set(sam.DMAC_CHANNEL_CHINTFLAG_TCMPL | sam.DMAC_CHANNEL_CHINTFLAG_TERR)
+}
+
+func (ch *DMAChannel) enableInterrupt(enable bool) {
+	regs := &sam.DMAC.CHANNEL[ch.id]
+	if enable {
+		regs.CHINTENSET.Set(sam.DMAC_CHANNEL_CHINTENSET_TCMPL | sam.DMAC_CHANNEL_CHINTENSET_TERR)
+	} else {
+		regs.CHINTENCLR.Set(sam.DMAC_CHANNEL_CHINTENCLR_TCMPL | sam.DMAC_CHANNEL_CHINTENCLR_TERR)
+	}
+}
+
+func dmaHandleInterrupt(interrupt.Interrupt) {
+	for i := range dmaChannelState {
+		ch := &dmaChannelState[i]
+		regs := &sam.DMAC.CHANNEL[i]
+		flags := regs.CHINTFLAG.Get() & (sam.DMAC_CHANNEL_CHINTFLAG_TCMPL | sam.DMAC_CHANNEL_CHINTFLAG_TERR)
+		if flags == 0 {
+			continue
+		}
+		regs.CHINTFLAG.Set(flags) // clear the interrupt flags
+		if ch.callback != nil {
+			ch.callback(ch)
+		}
+	}
+}
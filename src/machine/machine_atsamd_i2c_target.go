@@ -0,0 +1,159 @@
+//go:build (sam && atsamd21) || (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"unsafe"
+)
+
+// I2C target (slave) mode on the SAMD21 and SAMD51. The SERCOM is put in target
+// mode by Configure when I2CConfig.Mode is I2CModeTarget.
+
+const (
+	// Value of CTRLA.MODE for I2C target operation.
+	i2cTargetMode = 4
+
+	// I2C target commands (CTRLB.CMD).
+	wireTargetCmdWaitStart = 2 // execute the ack action and wait for a start condition
+	wireTargetCmdContinue  = 3 // execute the ack action and continue with the next byte
+)
+
+// target returns the registers of this SERCOM in I2C target mode. They are at
+// the same place as the registers in controller mode, but have a different
+// meaning.
+func (i2c *I2C) target() *sam.SERCOM_I2CS_Type {
+	return (*sam.SERCOM_I2CS_Type)(unsafe.Pointer(i2c.Bus))
+}
+
+// configureTarget puts the SERCOM in I2C target mode. It is enabled once
+// Listen is called.
+func (i2c *I2C) configureTarget() {
+	i2c.target().CTRLA.Set(i2cTargetMode << sam.SERCOM_I2CS_CTRLA_MODE_Pos)
+}
+
+// Listen starts listening for I2C requests sent to specified address
+//
+// addr is the address to listen to
+func (i2c *I2C) Listen(addr uint16) error {
+	if i2c.mode != I2CModeTarget {
+		return ErrI2CWrongMode
+	}
+	if addr >= 0x80 || isReservedI2CAddr(uint8(addr)) {
+		return ErrInvalidTgtAddr
+	}
+
+	bus := i2c.target()
+	bus.CTRLA.ClearBits(sam.SERCOM_I2CS_CTRLA_ENABLE)
+	for bus.SYNCBUSY.HasBits(sam.SERCOM_I2CS_SYNCBUSY_ENABLE) {
+	}
+	bus.ADDR.Set(uint32(addr) << sam.SERCOM_I2CS_ADDR_ADDR_Pos)
+	bus.CTRLA.SetBits(sam.SERCOM_I2CS_CTRLA_ENABLE)
+	for bus.SYNCBUSY.HasBits(sam.SERCOM_I2CS_SYNCBUSY_ENABLE) {
+	}
+	return nil
+}
+
+// WaitForEvent blocks the current go-routine until an I2C event is received (when in Target mode).
+//
+// The passed buffer will be populated for receive events, with the number of bytes
+// received returned in count.  For other event types, buf is not modified and a count
+// of zero is returned.
+//
+// For request events, the caller MUST call `Reply` to avoid hanging the i2c bus indefinitely.
+func (i2c *I2C) WaitForEvent(buf []byte) (evt I2CTargetEvent, count int, err error) {
+	if i2c.mode != I2CModeTarget {
+		return I2CFinish, 0, ErrI2CWrongMode
+	}
+	bus := i2c.target()
+	rxPtr := 0
+	for {
+		flags := bus.INTFLAG.Get()
+
+		// Data received from the controller.
+		if flags&sam.SERCOM_I2CS_INTFLAG_DRDY != 0 && !bus.STATUS.HasBits(sam.SERCOM_I2CS_STATUS_DIR) {
+			// The DATA register is the same in controller mode.
+			b := byte(i2c.Bus.DATA.Get())
+			if rxPtr < len(buf) {
+				buf[rxPtr] = b
+				rxPtr++
+			}
+			bus.CTRLB.ClearBits(sam.SERCOM_I2CS_CTRLB_ACKACT)
+			bus.CTRLB.SetBits(wireTargetCmdContinue << sam.SERCOM_I2CS_CTRLB_CMD_Pos)
+			continue
+		}
+
+		// Stop - leave the flag set after receiving data, so that the next
+		// call returns I2CFinish.
+		if flags&sam.SERCOM_I2CS_INTFLAG_PREC != 0 {
+			if rxPtr > 0 {
+				return I2CReceive, rxPtr, nil
+			}
+
+			bus.INTFLAG.Set(sam.SERCOM_I2CS_INTFLAG_PREC) // clear
+			return I2CFinish, 0, nil
+		}
+
+		// Start or restart with our address.
+		if flags&sam.SERCOM_I2CS_INTFLAG_AMATCH != 0 {
+			// Restart - return the data received so far first.
+			if rxPtr > 0 {
+				return I2CReceive, rxPtr, nil
+			}
+
+			// Read request - leave the flag set until we start to reply. The
+			// clock is stretched in the meantime.
+			if bus.STATUS.HasBits(sam.SERCOM_I2CS_STATUS_DIR) {
+				return I2CRequest, 0, nil
+			}
+
+			// Write request - acknowledge the address and receive the data.
+			bus.CTRLB.ClearBits(sam.SERCOM_I2CS_CTRLB_ACKACT)
+			bus.CTRLB.SetBits(wireTargetCmdContinue << sam.SERCOM_I2CS_CTRLB_CMD_Pos)
+			continue
+		}
+
+		gosched()
+	}
+}
+
+// Reply supplies the response data the controller.
+//
+// If the controller reads more bytes than there are in buf, 0xff is sent for
+// the remaining bytes.
+func (i2c *I2C) Reply(buf []byte) error {
+	bus := i2c.target()
+	if !bus.INTFLAG.HasBits(sam.SERCOM_I2CS_INTFLAG_AMATCH) || !bus.STATUS.HasBits(sam.SERCOM_I2CS_STATUS_DIR) {
+		return ErrI2CWrongMode
+	}
+
+	// Acknowledge the address, after which the first byte is requested.
+	bus.CTRLB.ClearBits(sam.SERCOM_I2CS_CTRLB_ACKACT)
+	bus.CTRLB.SetBits(wireTargetCmdContinue << sam.SERCOM_I2CS_CTRLB_CMD_Pos)
+
+	for txPtr := 0; ; txPtr++ {
+		for !bus.INTFLAG.HasBits(sam.SERCOM_I2CS_INTFLAG_DRDY | sam.SERCOM_I2CS_INTFLAG_PREC | sam.SERCOM_I2CS_INTFLAG_AMATCH) {
+			gosched()
+		}
+		if !bus.INTFLAG.HasBits(sam.SERCOM_I2CS_INTFLAG_DRDY) {
+			// Stop or restart: the controller doesn't want more data.
+			return nil
+		}
+		if txPtr > 0 && bus.STATUS.HasBits(sam.SERCOM_I2CS_STATUS_RXNACK) {
+			// The controller didn't acknowledge the last byte, so it doesn't
+			// want more data.
+			bus.CTRLB.SetBits(wireTargetCmdWaitStart << sam.SERCOM_I2CS_CTRLB_CMD_Pos)
+			return nil
+		}
+
+		// The DATA register is the same in controller mode. Smart mode
+		// (CTRLB.SMEN) is not enabled, so the byte is only sent and DRDY
+		// cleared once the continue command is given.
+		b := byte(0xff)
+		if txPtr < len(buf) {
+			b = buf[txPtr]
+		}
+		i2c.Bus.DATA.Set(b)
+		bus.CTRLB.SetBits(wireTargetCmdContinue << sam.SERCOM_I2CS_CTRLB_CMD_Pos)
+	}
+}
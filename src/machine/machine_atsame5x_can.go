@@ -4,7 +4,6 @@ package machine
 
 import (
 	"device/sam"
-	"errors"
 	"runtime/interrupt"
 	"unsafe"
 )
@@ -27,39 +26,26 @@ var CANTxFifo [2][(8 + 64) * CANTxFifoSize]byte
 //go:align 4
 var CANEvFifo [2][(8) * CANEvFifoSize]byte
 
-type CAN struct {
-	Bus *sam.CAN_Type
-}
+// Number of filters for SetFilter. Every filter has a standard and an extended
+// filter element, only one of which is enabled.
+const canFilterCount = 8
 
-type CANTransferRate uint32
+//go:align 4
+var canStdFilters [2][canFilterCount]uint32
+
+//go:align 4
+var canExtFilters [2][canFilterCount][2]uint32
 
-// CAN transfer rates for CANConfig
+// Values of GFC.ANFS and GFC.ANFE, for frames that don't match any filter.
 const (
-	CANTransferRate125kbps  CANTransferRate = 125000
-	CANTransferRate250kbps  CANTransferRate = 250000
-	CANTransferRate500kbps  CANTransferRate = 500000
-	CANTransferRate1000kbps CANTransferRate = 1000000
-	CANTransferRate2000kbps CANTransferRate = 2000000
-	CANTransferRate4000kbps CANTransferRate = 4000000
+	canAcceptNonMatching = 0 // store in Rx FIFO 0
+	canRejectNonMatching = 2
 )
 
-// CANConfig holds CAN configuration parameters. Tx and Rx need to be
-// specified with some pins. When the Standby Pin is specified, configure it
-// as an output pin and output Low in Configure(). If this operation is not
-// necessary, specify NoPin.
-type CANConfig struct {
-	TransferRate   CANTransferRate
-	TransferRateFD CANTransferRate
-	Tx             Pin
-	Rx             Pin
-	Standby        Pin
+type CAN struct {
+	Bus *sam.CAN_Type
 }
 
-var (
-	errCANInvalidTransferRate   = errors.New("CAN: invalid TransferRate")
-	errCANInvalidTransferRateFD = errors.New("CAN: invalid TransferRateFD")
-)
-
 // Configure this CAN peripheral with the given configuration.
 func (can *CAN) Configure(config CANConfig) error {
 	if config.Standby != NoPin {
@@ -137,10 +123,17 @@ func (can *CAN) Configure(config CANConfig) error {
 
 	can.Bus.TSCC.Set(sam.CAN_TSCC_TSS_INC)
 
-	can.Bus.GFC.Set(0<<sam.CAN_GFC_ANFS_Pos | 0<<sam.CAN_GFC_ANFE_Pos)
+	// Accept all frames until a filter is set.
+	can.Bus.GFC.Set(canAcceptNonMatching<<sam.CAN_GFC_ANFS_Pos | canAcceptNonMatching<<sam.CAN_GFC_ANFE_Pos)
 
-	can.Bus.SIDFC.Set(0 << sam.CAN_SIDFC_LSS_Pos)
-	can.Bus.XIDFC.Set(0 << sam.CAN_SIDFC_LSS_Pos)
+	stdFilters := &canStdFilters[can.instance()]
+	extFilters := &canExtFilters[can.instance()]
+	for i := range stdFilters {
+		stdFilters[i] = 0 // disabled
+		extFilters[i] = [2]uint32{}
+	}
+	can.Bus.SIDFC.Set(canFilterCount<<sam.CAN_SIDFC_LSS_Pos | uint32(uintptr(unsafe.Pointer(&stdFilters[0])))&0xFFFF)
+	can.Bus.XIDFC.Set(canFilterCount<<sam.CAN_XIDFC_LSE_Pos | uint32(uintptr(unsafe.Pointer(&extFilters[0])))&0xFFFF)
 
 	can.Bus.XIDAM.Set(0x1FFFFFFF << sam.CAN_XIDAM_EIDM_Pos)
 
@@ -384,6 +377,88 @@ func (can *CAN) Rx() (id uint32, dlc byte, data []byte, isFd, isExtendedID bool)
 	return e.ID, length, e.DB[:length], e.FDF, e.XTD
 }
 
+// SetFilter sets the filter with the given index (0 through 7). See CANFilter
+// for how received frames are filtered.
+func (can *CAN) SetFilter(index int, filter CANFilter) error {
+	if index < 0 || index >= canFilterCount || !filter.valid() {
+		return ErrCANInvalidFilter
+	}
+
+	// Enable either the standard or the extended filter element, as a classic
+	// filter (SFT/EFT = 2) that stores matching frames in Rx FIFO 0
+	// (SFEC/EFEC = 1).
+	i := can.instance()
+	if filter.Extended {
+		canStdFilters[i][index] = 0
+		canExtFilters[i][index] = [2]uint32{1<<29 | filter.ID, 2<<30 | filter.Mask}
+	} else {
+		canExtFilters[i][index] = [2]uint32{}
+		canStdFilters[i][index] = 2<<30 | 1<<27 | filter.ID<<16 | filter.Mask
+	}
+
+	// Reject frames that don't match a filter from now on. GFC can only be
+	// changed in initialization mode.
+	if (can.Bus.GFC.Get()&sam.CAN_GFC_ANFS_Msk)>>sam.CAN_GFC_ANFS_Pos != canRejectNonMatching {
+		can.Bus.CCCR.SetBits(sam.CAN_CCCR_INIT)
+		for !can.Bus.CCCR.HasBits(sam.CAN_CCCR_INIT) {
+		}
+		can.Bus.CCCR.SetBits(sam.CAN_CCCR_CCE)
+		can.Bus.GFC.Set(canRejectNonMatching<<sam.CAN_GFC_ANFS_Pos | canRejectNonMatching<<sam.CAN_GFC_ANFE_Pos)
+		can.Bus.CCCR.ClearBits(sam.CAN_CCCR_CCE)
+		can.Bus.CCCR.ClearBits(sam.CAN_CCCR_INIT)
+		for can.Bus.CCCR.HasBits(sam.CAN_CCCR_INIT) {
+		}
+	}
+	return nil
+}
+
+// Transmit adds the frame to the Tx FIFO. It returns ErrCANTxFull if the Tx
+// FIFO is full.
+func (can *CAN) Transmit(frame *CANFrame) error {
+	if err := frame.validate(true); err != nil {
+		return err
+	}
+	if can.TxFifoIsFull() {
+		return ErrCANTxFull
+	}
+
+	e := CANTxBufferElement{
+		XTD: frame.Extended,
+		RTR: frame.Remote,
+		ID:  frame.ID,
+		FDF: frame.FD,
+		BRS: frame.BRS,
+		DLC: CANLengthToDlc(frame.Length, frame.FD),
+	}
+	copy(e.DB[:], frame.Payload())
+	can.TxRaw(&e)
+	return nil
+}
+
+// Receive reads the oldest frame in the Rx FIFO into frame. It returns false if
+// there is no frame.
+func (can *CAN) Receive(frame *CANFrame) bool {
+	if can.RxFifoIsEmpty() {
+		return false
+	}
+
+	e := CANRxBufferElement{}
+	can.RxRaw(&e)
+	frame.ID = e.ID
+	frame.Extended = e.XTD
+	frame.Remote = e.RTR
+	frame.FD = e.FDF
+	frame.BRS = e.BRS
+	frame.Length = e.Length()
+	if !e.FDF && frame.Length > 8 {
+		// A DLC above 8 means 8 bytes in classic CAN frames.
+		frame.Length = 8
+	}
+	frame.Data = e.DB
+	frame.Timestamp = e.RXTS
+	return true
+}
+
 func (can *CAN) instance() byte {
 	if can.Bus == sam.CAN0 {
 		return 0
@@ -432,47 +507,3 @@ func (e CANRxBufferElement) Data() []byte {
 func (e CANRxBufferElement) Length() byte {
 	return CANDlcToLength(e.DLC, e.FDF)
 }
-
-// CANDlcToLength() converts a DLC value to its actual length.
-func CANDlcToLength(dlc byte, isFD bool) byte {
-	length := dlc
-	if dlc == 0x09 {
-		length = 12
-	} else if dlc == 0x0A {
-		length = 16
-	} else if dlc == 0x0B {
-		length = 20
-	} else if dlc == 0x0C {
-		length = 24
-	} else if dlc == 0x0D {
-		length = 32
-	} else if dlc == 0x0E {
-		length = 48
-	} else if dlc == 0x0F {
-		length = 64
-	}
-	return length
-
-}
-
-// CANLengthToDlc() converts its actual length to a DLC value.
-func CANLengthToDlc(length byte, isFD bool) byte {
-	dlc := length
-	if length <= 0x08 {
-	} else if length <= 12 {
-		dlc = 0x09
-	} else if length <= 16 {
-		dlc = 0x0A
-	} else if length <= 20 {
-		dlc = 0x0B
-	} else if length <= 24 {
-		dlc = 0x0C
-	} else if length <= 32 {
-		dlc = 0x0D
-	} else if length <= 48 {
-		dlc = 0x0E
-	} else if length <= 64 {
-		dlc = 0x0F
-	}
-	return dlc
-}
@@ -164,9 +164,14 @@ func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
 		rptr = &r[0]
 		rlen = len(r)
 	}
-	i2cTransfer(i2c.Bus, wptr, wlen, rptr, rlen)
-	// TODO: do something with the returned error code.
-	return nil
+	switch i2cTx(i2c.Bus, addr, wptr, wlen, rptr, rlen) {
+	case 0:
+		return nil
+	case 1:
+		return errI2CAckExpected
+	default:
+		return errI2CBusError
+	}
 }
 
 //export __tinygo_i2c_configure
@@ -175,9 +180,6 @@ func i2cConfigure(bus uint8, scl Pin, sda Pin)
 //export __tinygo_i2c_set_baud_rate
 func i2cSetBaudRate(bus uint8, br uint32)
 
-//export __tinygo_i2c_transfer
-func i2cTransfer(bus uint8, w *byte, wlen int, r *byte, rlen int) int
-
 type UART struct {
 	Bus uint8
 }
@@ -199,7 +201,7 @@ func (uart *UART) Write(data []byte) (n int, err error) {
 
 // Buffered returns the number of bytes currently stored in the RX buffer.
 func (uart *UART) Buffered() int {
-	return 0
+	return uartBuffered(uart.Bus)
 }
 
 // ReadByte reads a single byte from the UART.
@@ -0,0 +1,111 @@
+//go:build !baremetal
+
+package machine
+
+// CAN is a simulated CAN controller, for testing programs on a host system.
+// All controllers with the same Bus number are connected to the same in-memory
+// bus: a frame sent by one of them is received by all of them (including the
+// sender itself, as if in loopback mode) subject to their filters. Both
+// classic CAN and CAN FD frames are supported.
+type CAN struct {
+	Bus uint8
+
+	configured bool
+	filters    [canFilterCount]CANFilter
+	filterSet  [canFilterCount]bool
+	rx         []CANFrame
+}
+
+// Number of filters for SetFilter.
+const canFilterCount = 8
+
+// Number of frames that can be received before Receive is called. Frames that
+// arrive when the receive queue is full are lost, like with a hardware FIFO.
+const canRxQueueSize = 16
+
+var (
+	// All configured simulated controllers.
+	canControllers []*CAN
+
+	// The timestamp of simulated frames counts the frames sent on all buses.
+	canTime uint16
+)
+
+// Configure this simulated CAN controller. It removes all filters and received
+// frames, and connects the controller to the bus.
+func (can *CAN) Configure(config CANConfig) error {
+	if config.TransferRate == 0 {
+		config.TransferRate = CANTransferRate500kbps
+	}
+	if config.TransferRateFD == 0 {
+		config.TransferRateFD = CANTransferRate1000kbps
+	}
+	if config.TransferRateFD < config.TransferRate {
+		return errCANInvalidTransferRateFD
+	}
+
+	can.filterSet = [canFilterCount]bool{}
+	can.rx = can.rx[:0]
+	if !can.configured {
+		can.configured = true
+		canControllers = append(canControllers, can)
+	}
+	return nil
+}
+
+// SetFilter sets the filter with the given index (0 through 7). See CANFilter
+// for how received frames are filtered.
+func (can *CAN) SetFilter(index int, filter CANFilter) error {
+	if index < 0 || index >= canFilterCount || !filter.valid() {
+		return ErrCANInvalidFilter
+	}
+	can.filters[index] = filter
+	can.filterSet[index] = true
+	return nil
+}
+
+// Transmit sends the frame to all controllers on the same bus, including this
+// one. It never returns ErrCANTxFull.
+func (can *CAN) Transmit(frame *CANFrame) error {
+	if err := frame.validate(true); err != nil {
+		return err
+	}
+	canTime++
+	for _, receiver := range canControllers {
+		if receiver.Bus == can.Bus {
+			receiver.deliver(frame)
+		}
+	}
+	return nil
+}
+
+// deliver adds the frame to the receive queue, if it passes the filters.
+func (can *CAN) deliver(frame *CANFrame) {
+	accepted := true
+	for i, filter := range can.filters {
+		if can.filterSet[i] {
+			accepted = filter.matches(frame)
+			if accepted {
+				break
+			}
+		}
+	}
+	if !accepted || len(can.rx) >= canRxQueueSize {
+		return
+	}
+	received := *frame
+	received.Timestamp = canTime
+	can.rx = append(can.rx, received)
+}
+
+// Receive reads the oldest received frame into frame. It returns false if
+// there is no frame.
+func (can *CAN) Receive(frame *CANFrame) bool {
+	if len(can.rx) == 0 {
+		return false
+	}
+	*frame = can.rx[0]
+	copy(can.rx, can.rx[1:])
+	can.rx = can.rx[:len(can.rx)-1]
+	return true
+}
@@ -0,0 +1,22 @@
+//go:build !baremetal && (js || wasm_unknown)
+
+package machine
+
+// Hosts embedding a GOOS=js or wasm-unknown program implement the original set
+// of hooks: the I2C transfer hook doesn't get the device address and there is
+// no hook for the number of buffered UART bytes. Keep using these, so that
+// existing hosts can still instantiate the program.
+
+func i2cTx(bus uint8, addr uint16, w *byte, wlen int, r *byte, rlen int) int {
+	i2cTransfer(bus, w, wlen, r, rlen)
+	// The result of __tinygo_i2c_transfer was never specified, so assume the
+	// transaction succeeded.
+	return 0
+}
+
+//export __tinygo_i2c_transfer
+func i2cTransfer(bus uint8, w *byte, wlen int, r *byte, rlen int) int
+
+func uartBuffered(bus uint8) int {
+	return 0
+}
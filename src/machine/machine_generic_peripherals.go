@@ -11,4 +11,6 @@ var (
 	SPI0  = &SPI{0}
 	SPI1  = &SPI{1}
 	I2C0  = &I2C{0}
+	CAN0  = &CAN{Bus: 0}
+	CAN1  = &CAN{Bus: 1}
 )
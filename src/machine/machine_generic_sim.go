@@ -0,0 +1,17 @@
+//go:build !baremetal && !js && !wasm_unknown
+
+package machine
+
+// On host systems (Linux, WASI, etc), the hooks used by the generic machine
+// package are implemented by a peripheral simulator. In a browser (GOOS=js) or
+// with wasm-unknown, they are expected to be provided by the host instead.
+import _ "machine/sim"
+
+// Returns 0 on success, 1 if the device did not acknowledge the transaction,
+// or another value on any other error.
+//
+//export __tinygo_i2c_tx
+func i2cTx(bus uint8, addr uint16, w *byte, wlen int, r *byte, rlen int) int
+
+//export __tinygo_uart_buffered
+func uartBuffered(bus uint8) int
@@ -12,6 +12,12 @@ type I2C struct {
 	Bus  *nrf.TWIM_Type // Called Bus to align with Bus field in nrf51
 	BusT *nrf.TWIS_Type
 	mode I2CMode
+
+	// State of a transaction started with StartTx.
+	w, r    []byte // buffers, kept alive while EasyDMA uses them
+	err     error
+	pending bool
+	done    completion
 }
 
 // There are 2 I2C interfaces on the NRF.
@@ -36,7 +42,27 @@ func (i2c *I2C) disable() {
 //
 // It clocks out the given address, writes the bytes in w, reads back len(r)
 // bytes and stores them in r, and generates a stop condition on the bus.
+//
+// The calling goroutine is paused until the transaction has finished, so that
+// other goroutines can run in the meantime.
 func (i2c *I2C) Tx(addr uint16, w, r []byte) (err error) {
+	err = i2c.StartTx(addr, w, r)
+	if err != nil {
+		return err
+	}
+	return i2c.Wait()
+}
+
+// StartTx starts a single I2C transaction in the background, like Tx, and
+// returns right away. Call Wait to wait until the transaction has finished:
+// until then, the buffers must not be accessed. If a previous transaction is
+// still in progress, StartTx waits for it first.
+func (i2c *I2C) StartTx(addr uint16, w, r []byte) error {
+	i2c.Wait()
+	if len(w) == 0 && len(r) == 0 {
+		return nil
+	}
+
 	i2c.Bus.ADDRESS.Set(uint32(addr))
 
 	i2c.Bus.EVENTS_STOPPED.Set(0)
@@ -65,6 +91,14 @@ func (i2c *I2C) Tx(addr uint16, w, r []byte) (err error) {
 		i2c.Bus.SHORTS.Set(nrf.TWIM_SHORTS_LASTTX_STARTRX | nrf.TWIM_SHORTS_LASTRX_STOP)
 	}
 
+	// Get an interrupt when the transaction has stopped, or on an error.
+	i2c.w, i2c.r = w, r
+	i2c.err = nil
+	i2c.pending = true
+	i2c.done.reset()
+	i2c.Bus.INTENSET.Set(nrf.TWIM_INTENSET_STOPPED | nrf.TWIM_INTENSET_ERROR)
+	enableSerialInterrupt(i2c.index(), i2c)
+
 	// Fire the transaction
 	i2c.Bus.TASKS_RESUME.Set(1)
 	if len(w) != 0 {
@@ -72,24 +106,49 @@ func (i2c *I2C) Tx(addr uint16, w, r []byte) (err error) {
 	} else if len(r) != 0 {
 		i2c.Bus.TASKS_STARTRX.Set(1)
 	}
+	return nil
+}
 
-	// Wait until transaction stopped to ensure buffers fully processed
-	for i2c.Bus.EVENTS_STOPPED.Get() == 0 {
-		// Allow scheduler to run
-		gosched()
+// Wait waits until the transaction started with StartTx has finished, and
+// returns its result. The calling goroutine is paused while waiting, so that
+// other goroutines can run.
+func (i2c *I2C) Wait() error {
+	if !i2c.pending {
+		return nil
+	}
+	i2c.done.wait()
+	i2c.pending = false
+	i2c.w, i2c.r = nil, nil
+	return i2c.err
+}
 
-		// Handle errors by ensuring STOP sent on bus
-		if i2c.Bus.EVENTS_ERROR.Get() != 0 {
-			if i2c.Bus.EVENTS_STOPPED.Get() == 0 {
-				// STOP cannot be sent during SUSPEND
-				i2c.Bus.TASKS_RESUME.Set(1)
-				i2c.Bus.TASKS_STOP.Set(1)
-			}
-			err = twiCError(i2c.Bus.ERRORSRC.Get())
+// handleInterrupt finishes a transaction started with StartTx.
+func (i2c *I2C) handleInterrupt() {
+	// Handle errors by ensuring STOP sent on bus
+	if i2c.Bus.EVENTS_ERROR.Get() != 0 {
+		i2c.Bus.EVENTS_ERROR.Set(0)
+		errorsrc := i2c.Bus.ERRORSRC.Get()
+		i2c.Bus.ERRORSRC.Set(errorsrc) // clear the error source
+		i2c.err = twiCError(errorsrc)
+		if i2c.Bus.EVENTS_STOPPED.Get() == 0 {
+			// STOP cannot be sent during SUSPEND
+			i2c.Bus.TASKS_RESUME.Set(1)
+			i2c.Bus.TASKS_STOP.Set(1)
 		}
 	}
 
-	return
+	// Wait until transaction stopped to ensure buffers fully processed
+	if i2c.Bus.EVENTS_STOPPED.Get() != 0 {
+		i2c.Bus.INTENCLR.Set(nrf.TWIM_INTENCLR_STOPPED | nrf.TWIM_INTENCLR_ERROR)
+		i2c.done.signal()
+	}
+}
+
+func (i2c *I2C) index() int {
+	if i2c.Bus == nrf.TWIM0 {
+		return 0
+	}
+	return 1
 }
 
 // Listen starts listening for I2C requests sent to specified address
@@ -4,6 +4,7 @@ package machine
 
 import (
 	"device/nrf"
+	"runtime/interrupt"
 	"runtime/volatile"
 	"unsafe"
 )
@@ -195,6 +196,11 @@ func (a *ADC) Get() uint16 {
 type SPI struct {
 	Bus *nrf.SPIM_Type
 	buf *[1]byte // 1-byte buffer for the Transfer method
+
+	// State of a transfer started with StartTx.
+	w, r    []byte // remaining parts of the buffers
+	pending bool
+	done    completion
 }
 
 // There are 3 SPI interfaces on the NRF528xx.
@@ -301,45 +307,142 @@ func (spi *SPI) Transfer(w byte) (byte, error) {
 // padded until they fit: if len(w) > len(r) the extra bytes received will be
 // dropped and if len(w) < len(r) extra 0 bytes will be sent.
 func (spi *SPI) Tx(w, r []byte) error {
+	spi.Wait()
+	for len(r) != 0 || len(w) != 0 {
+		// Do the transfer. Use StartTx instead to not wait until it is
+		// finished.
+		w, r = spi.startTransfer(w, r)
+		for spi.Bus.EVENTS_END.Get() == 0 {
+		}
+		spi.Bus.EVENTS_END.Set(0)
+	}
+
+	return nil
+}
+
+// StartTx starts a transfer in the background and returns right away, so that
+// the calling goroutine can do other work in the meantime. Call Wait to wait
+// until the transfer has finished: until then, the buffers must not be
+// accessed. If a previous transfer is still in progress, StartTx waits for it
+// first. The buffers are used in the same way as in Tx.
+func (spi *SPI) StartTx(w, r []byte) error {
+	spi.Wait()
+	if len(w) == 0 && len(r) == 0 {
+		return nil
+	}
+	spi.done.reset()
+	spi.pending = true
+	spi.Bus.EVENTS_END.Set(0)
+	spi.Bus.INTENSET.Set(nrf.SPIM_INTENSET_END)
+	enableSerialInterrupt(spi.index(), spi)
+	spi.w, spi.r = spi.startTransfer(w, r)
+	return nil
+}
+
+// Wait waits until the transfer started with StartTx has finished. The calling
+// goroutine is paused while waiting, so that other goroutines can run.
+func (spi *SPI) Wait() error {
+	if !spi.pending {
+		return nil
+	}
+	spi.done.wait()
+	spi.pending = false
+	spi.w, spi.r = nil, nil
+	return nil
+}
+
+// startTransfer starts transferring the first part of the given buffers, and
+// returns the parts that remain.
+func (spi *SPI) startTransfer(w, r []byte) ([]byte, []byte) {
 	// Unfortunately the hardware (on the nrf52832) only supports up to 255
 	// bytes in the buffers, so if either w or r is longer than that the
 	// transfer needs to be broken up in pieces.
 	// The nrf52840 supports far larger buffers however, which isn't yet
 	// supported.
-	for len(r) != 0 || len(w) != 0 {
-		// Prepare the SPI transfer: set the DMA pointers and lengths.
-		// read buffer
-		nr := uint32(len(r))
-		if nr > 0 {
-			if nr > 255 {
-				nr = 255
-			}
-			spi.Bus.RXD.PTR.Set(uint32(uintptr(unsafe.Pointer(&r[0]))))
-			r = r[nr:]
-		}
-		spi.Bus.RXD.MAXCNT.Set(nr)
-
-		// write buffer
-		nw := uint32(len(w))
-		if nw > 0 {
-			if nw > 255 {
-				nw = 255
-			}
-			spi.Bus.TXD.PTR.Set(uint32(uintptr(unsafe.Pointer(&w[0]))))
-			w = w[nw:]
+
+	// Prepare the SPI transfer: set the DMA pointers and lengths.
+	// read buffer
+	nr := uint32(len(r))
+	if nr > 0 {
+		if nr > 255 {
+			nr = 255
 		}
-		spi.Bus.TXD.MAXCNT.Set(nw)
+		spi.Bus.RXD.PTR.Set(uint32(uintptr(unsafe.Pointer(&r[0]))))
+		r = r[nr:]
+	}
+	spi.Bus.RXD.MAXCNT.Set(nr)
 
-		// Do the transfer.
-		// Note: this can be improved by not waiting until the transfer is
-		// finished if the transfer is send-only (a common case).
-		spi.Bus.TASKS_START.Set(1)
-		for spi.Bus.EVENTS_END.Get() == 0 {
+	// write buffer
+	nw := uint32(len(w))
+	if nw > 0 {
+		if nw > 255 {
+			nw = 255
 		}
-		spi.Bus.EVENTS_END.Set(0)
+		spi.Bus.TXD.PTR.Set(uint32(uintptr(unsafe.Pointer(&w[0]))))
+		w = w[nw:]
 	}
+	spi.Bus.TXD.MAXCNT.Set(nw)
 
-	return nil
+	spi.Bus.TASKS_START.Set(1)
+	return w, r
+}
+
+// handleInterrupt continues a transfer started with StartTx once a part of it
+// has finished.
+func (spi *SPI) handleInterrupt() {
+	if spi.Bus.EVENTS_END.Get() == 0 {
+		return
+	}
+	spi.Bus.EVENTS_END.Set(0)
+	if len(spi.w) != 0 || len(spi.r) != 0 {
+		spi.w, spi.r = spi.startTransfer(spi.w, spi.r)
+		return
+	}
+	spi.Bus.INTENCLR.Set(nrf.SPIM_INTENCLR_END)
+	spi.done.signal()
+}
+
+func (spi *SPI) index() int {
+	switch spi.Bus {
+	case nrf.SPIM0:
+		return 0
+	case nrf.SPIM1:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// The SPIM and TWIM peripherals with the same index share their registers and
+// their interrupt, so only one of them can be used at a time. The interrupt is
+// forwarded to whichever of them started the last transfer.
+type serialInterruptHandler interface {
+	handleInterrupt()
+}
+
+var serialInterruptHandlers [3]serialInterruptHandler
+
+// enableSerialInterrupt forwards the interrupt of the SPIM/TWIM peripheral with
+// the given index to the given handler, and enables the interrupt.
+func enableSerialInterrupt(index int, handler serialInterruptHandler) {
+	serialInterruptHandlers[index] = handler
+	var intr interrupt.Interrupt
+	switch index {
+	case 0:
+		intr = interrupt.New(nrf.IRQ_SPIM0_SPIS0_TWIM0_TWIS0_SPI0_TWI0, func(interrupt.Interrupt) {
+			serialInterruptHandlers[0].handleInterrupt()
+		})
+	case 1:
+		intr = interrupt.New(nrf.IRQ_SPIM1_SPIS1_TWIM1_TWIS1_SPI1_TWI1, func(interrupt.Interrupt) {
+			serialInterruptHandlers[1].handleInterrupt()
+		})
+	default:
+		intr = interrupt.New(nrf.IRQ_SPIM2_SPIS2_SPI2, func(interrupt.Interrupt) {
+			serialInterruptHandlers[2].handleInterrupt()
+		})
+	}
+	intr.SetPriority(0xc0) // low priority
+	intr.Enable()
 }
 
 // PWM is one PWM peripheral, which consists of a counter and multiple output
@@ -5,7 +5,6 @@ package machine
 import (
 	"device/rp"
 	"runtime/interrupt"
-	"runtime/volatile"
 	"unsafe"
 )
 
@@ -95,26 +94,6 @@ func ChipVersion() uint8 {
 	return uint8(version)
 }
 
-// Single DMA channel. See rp.DMA_Type.
-type dmaChannel struct {
-	READ_ADDR   volatile.Register32
-	WRITE_ADDR  volatile.Register32
-	TRANS_COUNT volatile.Register32
-	CTRL_TRIG   volatile.Register32
-	_           [12]volatile.Register32 // aliases
-}
-
-// Static assignment of DMA channels to peripherals.
-// Allocating them statically is good enough for now. If lots of peripherals use
-// DMA, these might need to be assigned at runtime.
-const (
-	spi0DMAChannel = iota
-	spi1DMAChannel
-)
-
-// DMA channels usable on the RP2040.
-var dmaChannels = (*[12 + 4*rp2350ExtraReg]dmaChannel)(unsafe.Pointer(rp.DMA))
-
 //go:inline
 func boolToBit(a bool) uint32 {
 	if a {
@@ -130,8 +109,3 @@ func u32max(a, b uint32) uint32 {
 	}
 	return b
 }
-
-//go:inline
-func isReservedI2CAddr(addr uint8) bool {
-	return (addr&0x78) == 0 || (addr&0x78) == 0x78
-}
@@ -8,6 +8,23 @@ import (
 	"unsafe"
 )
 
+// DMA requests (DREQ numbers) of peripherals on the RP2040.
+const (
+	DMARequestSPI0TX  DMARequest = 16
+	DMARequestSPI0RX  DMARequest = 17
+	DMARequestSPI1TX  DMARequest = 18
+	DMARequestSPI1RX  DMARequest = 19
+	DMARequestUART0TX DMARequest = 20
+	DMARequestUART0RX DMARequest = 21
+	DMARequestUART1TX DMARequest = 22
+	DMARequestUART1RX DMARequest = 23
+	DMARequestI2C0TX  DMARequest = 32
+	DMARequestI2C0RX  DMARequest = 33
+	DMARequestI2C1TX  DMARequest = 34
+	DMARequestI2C1RX  DMARequest = 35
+	DMARequestADC     DMARequest = 36
+)
+
 const (
 	cpuFreq          = 200 * MHz
 	_NUMBANK0_GPIOS  = 30
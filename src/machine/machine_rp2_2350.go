@@ -8,6 +8,23 @@ import (
 	"unsafe"
 )
 
+// DMA requests (DREQ numbers) of peripherals on the RP2350.
+const (
+	DMARequestSPI0TX  DMARequest = 24
+	DMARequestSPI0RX  DMARequest = 25
+	DMARequestSPI1TX  DMARequest = 26
+	DMARequestSPI1RX  DMARequest = 27
+	DMARequestUART0TX DMARequest = 28
+	DMARequestUART0RX DMARequest = 29
+	DMARequestUART1TX DMARequest = 30
+	DMARequestUART1RX DMARequest = 31
+	DMARequestI2C0TX  DMARequest = 44
+	DMARequestI2C0RX  DMARequest = 45
+	DMARequestI2C1TX  DMARequest = 46
+	DMARequestI2C1RX  DMARequest = 47
+	DMARequestADC     DMARequest = 48
+)
+
 const (
 	cpuFreq          = 150 * MHz
 	_NUMBANK0_GPIOS  = 48
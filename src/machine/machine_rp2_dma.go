@@ -0,0 +1,151 @@
+//go:build rp2040 || rp2350
+
+package machine
+
+import (
+	"device/rp"
+	"runtime/interrupt"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Single DMA channel. See rp.DMA_Type.
+type dmaChannel struct {
+	READ_ADDR   volatile.Register32
+	WRITE_ADDR  volatile.Register32
+	TRANS_COUNT volatile.Register32
+	CTRL_TRIG   volatile.Register32
+	_           [12]volatile.Register32 // aliases
+}
+
+const dmaChannelCount = 12 + 4*rp2350ExtraReg
+
+// DMA channels usable on the RP2040 and RP2350.
+var dmaChannels = (*[dmaChannelCount]dmaChannel)(unsafe.Pointer(rp.DMA))
+
+// DMARequest is the DREQ signal of the peripheral that paces a transfer. See
+// the DMARequest* constants for common peripherals.
+type DMARequest uint8
+
+// DMARequestNone is used for memory-to-memory transfers, which run as fast as
+// possible.
+const DMARequestNone DMARequest = 0x3f
+
+// DMAChannel is a single DMA channel, see ClaimDMAChannel.
+type DMAChannel struct {
+	id       uint8
+	claimed  bool
+	request  DMARequest
+	callback func(*DMAChannel)
+}
+
+var (
+	dmaChannelState     [dmaChannelCount]DMAChannel
+	dmaInterruptEnabled bool
+)
+
+// ClaimDMAChannel claims a free DMA channel for transfers paced by the given
+// peripheral request, or DMARequestNone for memory-to-memory transfers. It
+// returns ErrNoDMAChannel if all channels are in use.
+func ClaimDMAChannel(request DMARequest) (*DMAChannel, error) {
+	mask := interrupt.Disable()
+	defer interrupt.Restore(mask)
+	for i := range dmaChannelState {
+		ch := &dmaChannelState[i]
+		if !ch.claimed {
+			*ch = DMAChannel{
+				id:      uint8(i),
+				claimed: true,
+				request: request,
+			}
+			return ch, nil
+		}
+	}
+	return nil, ErrNoDMAChannel
+}
+
+// Release aborts any running transfer and makes this channel available to be
+// claimed again.
+func (ch *DMAChannel) Release() {
+	ch.Abort()
+	ch.SetCallback(nil)
+	ch.claimed = false
+}
+
+// Start starts a new transfer on this channel. It returns ErrDMABusy if the
+// previous transfer hasn't finished yet.
+func (ch *DMAChannel) Start(t DMATransfer) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	if ch.Busy() {
+		return ErrDMABusy
+	}
+	request := ch.request
+	ctrl := uint32(t.Size>>1)<<rp.DMA_CH0_CTRL_TRIG_DATA_SIZE_Pos |
+		uint32(ch.id)<<rp.DMA_CH0_CTRL_TRIG_CHAIN_TO_Pos | // don't chain
+		rp.DMA_CH0_CTRL_TRIG_EN
+	switch t.Direction {
+	case DMAMemoryToMemory:
+		request = DMARequestNone
+		ctrl |= rp.DMA_CH0_CTRL_TRIG_INCR_READ | rp.DMA_CH0_CTRL_TRIG_INCR_WRITE
+	case DMAMemoryToPeripheral:
+		ctrl |= rp.DMA_CH0_CTRL_TRIG_INCR_READ
+	case DMAPeripheralToMemory:
+		ctrl |= rp.DMA_CH0_CTRL_TRIG_INCR_WRITE
+	default:
+		return ErrDMAInvalid
+	}
+	ctrl |= uint32(request) << rp.DMA_CH0_CTRL_TRIG_TREQ_SEL_Pos
+
+	// Writing CTRL_TRIG starts the transfer.
+	regs := &dmaChannels[ch.id]
+	regs.READ_ADDR.Set(uint32(uintptr(t.Src)))
+	regs.WRITE_ADDR.Set(uint32(uintptr(t.Dst)))
+	regs.TRANS_COUNT.Set(uint32(t.Count))
+	regs.CTRL_TRIG.Set(ctrl)
+	return nil
+}
+
+// Busy returns whether a transfer is in progress.
+func (ch *DMAChannel) Busy() bool {
+	return dmaChannels[ch.id].CTRL_TRIG.HasBits(rp.DMA_CH0_CTRL_TRIG_BUSY)
+}
+
+// Abort stops the current transfer, if any.
+func (ch *DMAChannel) Abort() {
+	// Disable the completion interrupt while aborting, because aborting also
+	// raises it.
+	rp.DMA.INTE0.ClearBits(1 << ch.id)
+	rp.DMA.CHAN_ABORT.Set(1 << ch.id)
+	for rp.DMA.CHAN_ABORT.HasBits(1 << ch.id) {
+	}
+	rp.DMA.INTS0.Set(1 << ch.id)
+	if ch.callback != nil {
+		rp.DMA.INTE0.SetBits(1 << ch.id)
+	}
+}
+
+func (ch *DMAChannel) enableInterrupt(enable bool) {
+	if !enable {
+		rp.DMA.INTE0.ClearBits(1 << ch.id)
+		return
+	}
+	if !dmaInterruptEnabled {
+		dmaInterruptEnabled = true
+		interrupt.New(rp.IRQ_DMA_IRQ_0, dmaHandleInterrupt).Enable()
+	}
+	rp.DMA.INTS0.Set(1 << ch.id)
+	rp.DMA.INTE0.SetBits(1 << ch.id)
+}
+
+func dmaHandleInterrupt(interrupt.Interrupt) {
+	status := rp.DMA.INTS0.Get()
+	rp.DMA.INTS0.Set(status) // clear the interrupts
+	for i := range dmaChannelState {
+		ch := &dmaChannelState[i]
+		if status&(1<<i) != 0 && ch.callback != nil {
+			ch.callback(ch)
+		}
+	}
+}
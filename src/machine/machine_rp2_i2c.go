@@ -6,6 +6,7 @@ import (
 	"device/rp"
 	"errors"
 	"internal/itoa"
+	"runtime/interrupt"
 )
 
 // I2C on the RP2040/RP2350
@@ -52,20 +53,25 @@ type I2CConfig struct {
 }
 
 type I2C struct {
-	Bus          *rp.I2C0_Type
-	mode         I2CMode
-	txInProgress bool
+	Bus  *rp.I2C0_Type
+	mode I2CMode
+
+	// State of a transaction started with StartTx.
+	w, r    []byte
+	cmdPos  int // number of commands written to the TX FIFO
+	rPos    int // number of bytes stored in r
+	err     error
+	pending bool
+	done    completion
 }
 
 var (
 	ErrInvalidI2CBaudrate  = errors.New("invalid i2c baudrate")
-	ErrInvalidTgtAddr      = errors.New("invalid target i2c address not in 0..0x80 or is reserved")
 	ErrI2CGeneric          = errors.New("i2c error")
 	ErrRP2040I2CDisable    = errors.New("i2c rp2040 peripheral timeout in disable")
 	errInvalidI2CSDA       = errors.New("invalid I2C SDA pin")
 	errInvalidI2CSCL       = errors.New("invalid I2C SCL pin")
 	ErrI2CAlreadyListening = errors.New("i2c already listening")
-	ErrI2CWrongMode        = errors.New("i2c wrong mode")
 	ErrI2CUnderflow        = errors.New("i2c underflow")
 )
 
@@ -82,14 +88,16 @@ var (
 //	i2c.Tx(addr, w, nil)
 //
 // Performs only a write transfer.
+//
+// Other goroutines can run until the transaction has finished. If it doesn't
+// finish within 40ms (for example because a target holds SCL low), it is
+// aborted and a timeout error is returned.
 func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
-	if i2c.mode != I2CModeController {
-		return ErrI2CWrongMode
+	err := i2c.StartTx(addr, w, r)
+	if err != nil {
+		return err
 	}
-
-	// timeout in microseconds.
-	const timeout = 40 * 1000 // 40ms is a reasonable time for a real-time system.
-	return i2c.tx(uint8(addr), w, r, timeout)
+	return i2c.Wait()
 }
 
 // Listen starts listening for I2C requests sent to specified address
@@ -252,6 +260,9 @@ func (i2c *I2C) init(config I2CConfig) error {
 		i2c.Bus.IC_RX_TL.Set(0)
 	}
 
+	// Interrupts are only used by StartTx, which enables the ones it needs.
+	i2c.Bus.IC_INTR_MASK.Set(0)
+
 	// Always enable the DREQ signalling -- harmless if DMA isn't listening
 	i2c.Bus.IC_DMA_CR.Set(rp.I2C0_IC_DMA_CR_TDMAE | rp.I2C0_IC_DMA_CR_RDMAE)
 	return i2c.SetBaudRate(config.Frequency)
@@ -284,145 +295,192 @@ func (i2c *I2C) deinit() (resetVal uint32) {
 	return resetVal
 }
 
-// tx performs blocking write followed by read to I2C bus.
-func (i2c *I2C) tx(addr uint8, tx, rx []byte, timeout_us uint64) (err error) {
-	deadline := ticks() + timeout_us
-	if addr >= 0x80 || isReservedI2CAddr(addr) {
+// StartTx starts a single I2C transaction in the background, like Tx, and
+// returns right away. Call Wait to wait until the transaction has finished:
+// until then, the buffers must not be accessed. If a previous transaction is
+// still in progress, StartTx waits for it first.
+//
+// The FIFOs are refilled and drained from the I2C interrupt.
+func (i2c *I2C) StartTx(addr uint16, w, r []byte) error {
+	i2c.Wait()
+	if i2c.mode != I2CModeController {
+		return ErrI2CWrongMode
+	}
+	if addr >= 0x80 || isReservedI2CAddr(uint8(addr)) {
 		return ErrInvalidTgtAddr
 	}
-	txlen := len(tx)
-	rxlen := len(rx)
 	// Quick return if possible.
-	if txlen == 0 && rxlen == 0 {
+	if len(w) == 0 && len(r) == 0 {
 		return nil
 	}
 
-	err = i2c.disable()
+	err := i2c.disable()
 	if err != nil {
 		return err
 	}
 	i2c.Bus.IC_TAR.Set(uint32(addr))
 	i2c.enable()
-	abort := false
-	var abortReason i2cAbortError
-	txStop := rxlen == 0
-	for txCtr := 0; txCtr < txlen; txCtr++ {
-		if abort {
+
+	// Clear stale state from a previous transaction.
+	i2c.Bus.IC_CLR_STOP_DET.Get()
+	i2c.clearAbortReason()
+
+	i2c.w, i2c.r = w, r
+	i2c.cmdPos, i2c.rPos = 0, 0
+	i2c.err = nil
+	i2c.pending = true
+	i2c.done.reset()
+
+	// The interrupt fires when the TX FIFO needs more commands, when bytes
+	// were received, on an abort and once the STOP condition was sent.
+	i2c.Bus.IC_INTR_MASK.Set(rp.I2C0_IC_INTR_MASK_M_TX_EMPTY |
+		rp.I2C0_IC_INTR_MASK_M_RX_FULL |
+		rp.I2C0_IC_INTR_MASK_M_TX_ABRT |
+		rp.I2C0_IC_INTR_MASK_M_STOP_DET)
+	i2c.enableInterrupt()
+	return nil
+}
+
+// Wait waits until the transaction started with StartTx has finished, and
+// returns its result. Other goroutines can run while waiting. If the
+// transaction doesn't finish in time, it is aborted and a timeout error is
+// returned.
+func (i2c *I2C) Wait() error {
+	if !i2c.pending {
+		return nil
+	}
+	const timeout = 40 * 1000 // 40ms is a reasonable time for a real-time system.
+	deadline := ticks() + timeout
+	for !i2c.done.isDone() {
+		if ticks() > deadline {
+			i2c.abort()
 			break
 		}
-		first := txCtr == 0
-		last := txCtr == txlen-1 && rxlen == 0
-		i2c.Bus.IC_DATA_CMD.Set(
-			(boolToBit(first) << rp.I2C0_IC_DATA_CMD_RESTART_Pos) |
-				(boolToBit(last && txStop) << rp.I2C0_IC_DATA_CMD_STOP_Pos) |
-				uint32(tx[txCtr]))
-
-		// Wait until the transmission of the address/data from the internal
-		// shift register has completed. For this to function correctly, the
-		// TX_EMPTY_CTRL flag in IC_CON must be set. The TX_EMPTY_CTRL flag
-		// was set in i2c_init.
-
-		// IC_RAW_INTR_STAT_TX_EMPTY: This bit is set to 1 when the transmit buffer is at or below
-		// the threshold value set in the IC_TX_TL register and the
-		// transmission of the address/data from the internal shift
-		// register for the most recently popped command is
-		// completed. It is automatically cleared by hardware when
-		// the buffer level goes above the threshold. When
-		// IC_ENABLE[0] is set to 0, the TX FIFO is flushed and held
-		// in reset. There the TX FIFO looks like it has no data within
-		// it, so this bit is set to 1, provided there is activity in the
-		// controller or target state machines. When there is no longer
-		// any activity, then with ic_en=0, this bit is set to 0.
-		for !i2c.interrupted(rp.I2C0_IC_RAW_INTR_STAT_TX_EMPTY) {
-			if ticks() > deadline {
-				return errI2CWriteTimeout // If there was a timeout, don't attempt to do anything else.
-			}
+		gosched()
+	}
+	i2c.pending = false
+	i2c.w, i2c.r = nil, nil
+	return i2c.err
+}
 
-			gosched()
-		}
+// abort stops a transaction that didn't finish in time. The bus may be stuck
+// (for example without pull-ups), in which case no STOP condition is sent and
+// the interrupt would never finish the transaction.
+func (i2c *I2C) abort() {
+	mask := interrupt.Disable()
+	defer interrupt.Restore(mask)
+	if i2c.done.isDone() {
+		// It finished after all.
+		return
+	}
+	i2c.Bus.IC_INTR_MASK.Set(0)
+	i2c.Bus.IC_ENABLE.SetBits(rp.I2C0_IC_ENABLE_ABORT)
+	if len(i2c.r) == 0 || i2c.cmdPos < len(i2c.w) {
+		i2c.err = errI2CWriteTimeout
+	} else {
+		i2c.err = errI2CReadTimeout
+	}
+}
 
-		abortReason = i2c.getAbortReason()
-		if abortReason != 0 {
-			i2c.clearAbortReason()
-			abort = true
-		}
-		if abort || last {
-			// If the transaction was aborted or if it completed
-			// successfully wait until the STOP condition has occurred.
-
-			// TODO Could there be an abort while waiting for the STOP
-			// condition here? If so, additional code would be needed here
-			// to take care of the abort.
-			for !i2c.interrupted(rp.I2C0_IC_RAW_INTR_STAT_STOP_DET) {
-				if ticks() > deadline {
-					if abort {
-						return abortReason
-					}
-					return errI2CWriteTimeout
-				}
-
-				gosched()
-			}
-			i2c.Bus.IC_CLR_STOP_DET.Get()
-		}
+// enableInterrupt enables the interrupt of the I2C bus. The handler is
+// registered here instead of in init so that target mode, which polls the
+// peripheral, doesn't pull it in.
+func (i2c *I2C) enableInterrupt() {
+	switch i2c.Bus {
+	case rp.I2C0:
+		interrupt.New(rp.IRQ_I2C0_IRQ, _I2C0.handleInterrupt).Enable()
+	case rp.I2C1:
+		interrupt.New(rp.IRQ_I2C1_IRQ, _I2C1.handleInterrupt).Enable()
 	}
+}
 
-	// Midway check for abort. Related issue https://github.com/tinygo-org/tinygo/issues/3671.
-	// The root cause for an abort after writing registers was "tx data no ack" (abort code=8).
-	// If the abort code was not registered then the whole peripheral would remain in disabled state forever.
-	abortReason = i2c.getAbortReason()
-	if abortReason != 0 {
+// handleInterrupt advances the transaction started with StartTx, and finishes
+// it once the STOP condition has been sent.
+func (i2c *I2C) handleInterrupt(interrupt.Interrupt) {
+	if !i2c.pending {
+		i2c.Bus.IC_INTR_MASK.Set(0)
+		return
+	}
+	stat := i2c.Bus.IC_INTR_STAT.Get()
+
+	if stat&rp.I2C0_IC_INTR_MASK_M_TX_ABRT != 0 {
+		// The controller flushes the TX FIFO and sends a STOP condition on
+		// its own, so all that's left to do is wait for STOP_DET.
+		abortReason := i2c.getAbortReason()
 		i2c.clearAbortReason()
-		abort = true
+		i2c.err = i2cAbortErr(abortReason)
+		i2c.Bus.IC_INTR_MASK.ClearBits(rp.I2C0_IC_INTR_MASK_M_TX_EMPTY)
 	}
 
-	rxStart := txlen == 0
-	if rxlen > 0 && !abort {
-		for rxCtr := 0; rxCtr < rxlen; rxCtr++ {
-			first := rxCtr == 0
-			last := rxCtr == rxlen-1
-			for i2c.writeAvailable() == 0 {
-				gosched()
-			}
-			i2c.Bus.IC_DATA_CMD.Set(
-				boolToBit(first && rxStart)<<rp.I2C0_IC_DATA_CMD_RESTART_Pos |
-					boolToBit(last)<<rp.I2C0_IC_DATA_CMD_STOP_Pos |
-					rp.I2C0_IC_DATA_CMD_CMD) // -> 1 for read
-
-			for !abort && i2c.readAvailable() == 0 {
-				abortReason = i2c.getAbortReason()
-				if abortReason != 0 {
-					i2c.clearAbortReason()
-					abort = true
-				}
-				if ticks() > deadline {
-					return errI2CReadTimeout // If there was a timeout, don't attempt to do anything else.
-				}
-
-				gosched()
-			}
-			if abort {
+	// Store the bytes received so far.
+	for i2c.rPos < len(i2c.r) && i2c.readAvailable() != 0 {
+		i2c.r[i2c.rPos] = uint8(i2c.Bus.IC_DATA_CMD.Get())
+		i2c.rPos++
+	}
+
+	if i2c.err == nil {
+		i2c.fillTxFIFO()
+	}
+
+	if stat&rp.I2C0_IC_INTR_MASK_M_STOP_DET != 0 {
+		i2c.Bus.IC_CLR_STOP_DET.Get()
+		i2c.Bus.IC_INTR_MASK.Set(0)
+		if i2c.err == nil && i2c.rPos < len(i2c.r) {
+			// The transaction was stopped before all bytes were read,
+			// which shouldn't happen without an abort.
+			i2c.err = ErrI2CGeneric
+		}
+		i2c.done.signal()
+	}
+}
+
+// fillTxFIFO writes as many commands to the TX FIFO as possible: first the
+// bytes of w, then one read command for every byte of r. The last command
+// generates a STOP condition.
+func (i2c *I2C) fillTxFIFO() {
+	const rxFIFODepth = 16
+	total := len(i2c.w) + len(i2c.r)
+	for i2c.cmdPos < total && i2c.writeAvailable() != 0 {
+		var cmd uint32
+		if i2c.cmdPos < len(i2c.w) {
+			cmd = uint32(i2c.w[i2c.cmdPos])
+		} else {
+			// Never have more reads in flight than will fit into the RX
+			// FIFO, else it will overflow.
+			if i2c.cmdPos-len(i2c.w)-i2c.rPos >= rxFIFODepth {
 				break
 			}
-			rx[rxCtr] = uint8(i2c.Bus.IC_DATA_CMD.Get())
+			cmd = rp.I2C0_IC_DATA_CMD_CMD // -> 1 for read
 		}
+		first := i2c.cmdPos == 0
+		last := i2c.cmdPos == total-1
+		i2c.Bus.IC_DATA_CMD.Set(cmd |
+			boolToBit(first)<<rp.I2C0_IC_DATA_CMD_RESTART_Pos |
+			boolToBit(last)<<rp.I2C0_IC_DATA_CMD_STOP_Pos)
+		i2c.cmdPos++
+	}
+	if i2c.cmdPos == total {
+		// Everything was queued, so TX_EMPTY would only fire again and again
+		// until the STOP condition.
+		i2c.Bus.IC_INTR_MASK.ClearBits(rp.I2C0_IC_INTR_MASK_M_TX_EMPTY)
 	}
+}
+
+// i2cAbortErr returns the error for an aborted transaction.
+func i2cAbortErr(abortReason i2cAbortError) error {
 	// From Pico SDK: A lot of things could have just happened due to the ingenious and
 	// creative design of I2C. Try to figure things out.
-	if abort {
-		switch {
-		case abortReason == 0 || abortReason&rp.I2C0_IC_TX_ABRT_SOURCE_ABRT_7B_ADDR_NOACK != 0:
-			// No reported errors - seems to happen if there is nothing connected to the bus.
-			// Address byte not acknowledged
-			err = ErrI2CGeneric
-		case abortReason&rp.I2C0_IC_TX_ABRT_SOURCE_ABRT_TXDATA_NOACK != 0:
-			// Address acknowledged, some data not acknowledged
-			fallthrough
-		default:
-			err = abortReason
-		}
+	switch {
+	case abortReason == 0 || abortReason&rp.I2C0_IC_TX_ABRT_SOURCE_ABRT_7B_ADDR_NOACK != 0:
+		// No reported errors - seems to happen if there is nothing connected to the bus.
+		// Address byte not acknowledged
+		return ErrI2CGeneric
+	case abortReason&rp.I2C0_IC_TX_ABRT_SOURCE_ABRT_TXDATA_NOACK != 0:
+		// Address acknowledged, some data not acknowledged
+		fallthrough
+	default:
+		return abortReason
 	}
-	return err
 }
 
 // listen sets up for async handling of requests on the I2C bus.
@@ -558,16 +616,6 @@ func (i2c *I2C) getAbortReason() i2cAbortError {
 	return i2cAbortError(i2c.Bus.IC_TX_ABRT_SOURCE.Get())
 }
 
-// returns true if RAW_INTR_STAT bits in mask are all set. performs:
-//
-//	RAW_INTR_STAT & mask == mask
-//
-//go:inline
-func (i2c *I2C) interrupted(mask uint32) bool {
-	reg := i2c.Bus.IC_RAW_INTR_STAT.Get()
-	return reg&mask == mask
-}
-
 type i2cAbortError uint32
 
 func (b i2cAbortError) Error() string {
@@ -43,9 +43,20 @@ var (
 )
 
 type SPI struct {
-	Bus *rp.SPI0_Type
+	Bus     *rp.SPI0_Type
+	dma     *DMAChannel // TX channel, claimed on first use by StartTx
+	dmaRx   *DMAChannel // RX channel, claimed on first use by StartTx
+	pending uint8       // kind of transfer started by StartTx, if any
+	done    completion
 }
 
+// Kinds of transfers that can be started with StartTx.
+const (
+	spiPendingNone = iota
+	spiPendingTx   // write only, received bytes must be discarded
+	spiPendingTxRx // both channels are used
+)
+
 // Tx handles read/write operation for SPI interface. Since SPI is a synchronous write/read
 // interface, there must always be the same number of bytes written as bytes read.
 // The Tx method knows about this, and offers a few different ways of calling it.
@@ -71,7 +82,13 @@ type SPI struct {
 //
 // This form sends 0xff and puts the result into rx buffer. Useful for reading from SD cards
 // which require 0xff input on SI.
+//
+// Short transfers are done by polling, longer ones use DMA (see StartTx) if a
+// DMA channel is available.
 func (spi *SPI) Tx(w, r []byte) (err error) {
+	// Finish a transfer started with StartTx first.
+	spi.Wait()
+
 	switch {
 	case w == nil:
 		// read only, so write zero and read a result.
@@ -284,50 +301,151 @@ func (spi *SPI) isBusy() bool {
 	return spi.Bus.SSPSR.HasBits(rp.SPI0_SSPSR_BSY)
 }
 
+// Transfers shorter than this are done by polling the FIFOs in Tx, as
+// starting a DMA transfer and waiting for its interrupt takes longer than
+// that. This also avoids claiming DMA channels for SPI buses that only do
+// short transfers.
+const spiDMAMinSize = 32
+
 // tx writes buffer to SPI ignoring Rx.
 func (spi *SPI) tx(tx []byte) error {
-	if len(tx) == 0 {
+	if len(tx) >= spiDMAMinSize {
+		err := spi.StartTx(tx, nil)
+		if err != ErrNoDMAChannel {
+			if err != nil {
+				return err
+			}
+			return spi.Wait()
+		}
+	}
+
+	// Short transfer, or all DMA channels are in use, so write the bytes one
+	// by one.
+	for _, b := range tx {
+		for !spi.isWritable() {
+		}
+		spi.Bus.SSPDR.Set(uint32(b))
+	}
+	return spi.drainRx()
+}
+
+// StartTx starts a transfer in the background using DMA and returns right
+// away, so that the calling goroutine can do other work in the meantime. Call
+// Wait to wait until the transfer has finished: until then, the buffers must
+// not be accessed. If a previous transfer is still in progress, StartTx waits
+// for it first.
+//
+// The buffers are used as in Tx, except that w and r must have the same length
+// if both are given. It returns ErrNoDMAChannel if no DMA channel is available,
+// in which case Tx can be used instead.
+func (spi *SPI) StartTx(w, r []byte) error {
+	spi.Wait()
+	switch {
+	case w == nil:
+		// Read only, so write zeros. The TX channel reads every byte of r
+		// before the RX channel replaces it with the byte that was received.
+		for i := range r {
+			r[i] = 0
+		}
+		w = r
+	case r != nil && len(w) != len(r):
+		return ErrTxInvalidSliceSize
+	}
+	if len(w) == 0 {
 		// We don't have to do anything.
-		// This avoids a panic in &tx[0] when len(tx) == 0.
+		// This avoids a panic in &w[0] when len(w) == 0.
 		return nil
 	}
+	if err := spi.claimDMA(r != nil); err != nil {
+		return err
+	}
 
-	// Pick the DMA channel reserved for this SPI peripheral.
-	var ch *dmaChannel
-	var dreq uint32
-	if spi.Bus == rp.SPI0 {
-		ch = &dmaChannels[spi0DMAChannel]
-		dreq = 16 // DREQ_SPI0_TX
-	} else { // SPI1
-		ch = &dmaChannels[spi1DMAChannel]
-		dreq = 18 // DREQ_SPI1_TX
-	}
-
-	// Configure the DMA peripheral as follows:
-	//   - set read address, write address, and number of transfer units (bytes)
-	//   - increment read address (in memory), don't increment write address (SSPDR)
-	//   - set data size to single bytes
-	//   - set the DREQ so that the DMA will fill the SPI FIFO as needed
-	//   - start the transfer
-	ch.READ_ADDR.Set(uint32(uintptr(unsafe.Pointer(&tx[0]))))
-	ch.WRITE_ADDR.Set(uint32(uintptr(unsafe.Pointer(&spi.Bus.SSPDR))))
-	ch.TRANS_COUNT.Set(uint32(len(tx)))
-	ch.CTRL_TRIG.Set(rp.DMA_CH0_CTRL_TRIG_INCR_READ |
-		rp.DMA_CH0_CTRL_TRIG_DATA_SIZE_SIZE_BYTE<<rp.DMA_CH0_CTRL_TRIG_DATA_SIZE_Pos |
-		dreq<<rp.DMA_CH0_CTRL_TRIG_TREQ_SEL_Pos |
-		rp.DMA_CH0_CTRL_TRIG_EN)
-
-	// Wait until the transfer is complete.
-	// TODO: do this more efficiently:
-	//   - Add a new API to start the transfer, without waiting for it to
-	//     complete. This way, the CPU can do something useful while the
-	//     transfer is in progress.
-	//   - If we have to wait, do so by waiting for an interrupt and blocking
-	//     this goroutine until finished (so that other goroutines can run or
-	//     the CPU can go to sleep).
-	for ch.CTRL_TRIG.Get()&rp.DMA_CH0_CTRL_TRIG_BUSY != 0 {
+	spi.done.reset()
+	spi.pending = spiPendingTx
+	if r != nil {
+		spi.pending = spiPendingTxRx
+		err := spi.dmaRx.Start(DMATransfer{
+			Direction: DMAPeripheralToMemory,
+			Size:      DMASize8,
+			Src:       unsafe.Pointer(&spi.Bus.SSPDR),
+			Dst:       unsafe.Pointer(&r[0]),
+			Count:     len(r),
+		})
+		if err != nil {
+			spi.pending = spiPendingNone
+			return err
+		}
+	}
+
+	// Let the DMA peripheral fill the SPI FIFO as needed.
+	err := spi.dma.Start(DMATransfer{
+		Direction: DMAMemoryToPeripheral,
+		Size:      DMASize8,
+		Src:       unsafe.Pointer(&w[0]),
+		Dst:       unsafe.Pointer(&spi.Bus.SSPDR),
+		Count:     len(w),
+	})
+	if err != nil {
+		if r != nil {
+			spi.dmaRx.Abort()
+		}
+		spi.pending = spiPendingNone
+		return err
 	}
+	return nil
+}
 
+// Wait waits until the transfer started with StartTx has finished. The calling
+// goroutine is paused while waiting, so that other goroutines can run.
+func (spi *SPI) Wait() error {
+	if spi.pending == spiPendingNone {
+		return nil
+	}
+	spi.done.wait()
+	pending := spi.pending
+	spi.pending = spiPendingNone
+	if pending == spiPendingTx {
+		return spi.drainRx()
+	}
+	return nil
+}
+
+// claimDMA claims the DMA channels used by StartTx, if not done already.
+func (spi *SPI) claimDMA(rx bool) error {
+	txRequest, rxRequest := DMARequestSPI0TX, DMARequestSPI0RX
+	if spi.Bus == rp.SPI1 {
+		txRequest, rxRequest = DMARequestSPI1TX, DMARequestSPI1RX
+	}
+	if spi.dma == nil {
+		ch, err := ClaimDMAChannel(txRequest)
+		if err != nil {
+			return err
+		}
+		ch.SetCallback(spi.handleDMA)
+		spi.dma = ch
+	}
+	if rx && spi.dmaRx == nil {
+		ch, err := ClaimDMAChannel(rxRequest)
+		if err != nil {
+			return err
+		}
+		ch.SetCallback(spi.handleDMA)
+		spi.dmaRx = ch
+	}
+	return nil
+}
+
+// handleDMA is called from the DMA interrupt when one of the channels has
+// finished. The transfer is complete once the last byte has been received, or
+// once the last byte has been sent if the received bytes are discarded.
+func (spi *SPI) handleDMA(ch *DMAChannel) {
+	if ch == spi.dmaRx || spi.pending == spiPendingTx {
+		spi.done.signal()
+	}
+}
+
+// drainRx cleans up after a write-only transfer.
+func (spi *SPI) drainRx() error {
 	// We didn't read any result values, which means the RX FIFO has likely
 	// overflown. We have to clean up this mess now.
 
@@ -351,6 +469,24 @@ func (spi *SPI) tx(tx []byte) error {
 // Generally this can be 0, but some devices require a specific value here,
 // e.g. SD cards expect 0xff
 func (spi *SPI) rx(rx []byte, txrepeat byte) error {
+	if len(rx) >= spiDMAMinSize {
+		// The TX channel reads every byte of rx before the RX channel
+		// replaces it with the byte that was received, so rx can be the
+		// source as well.
+		for i := range rx {
+			rx[i] = txrepeat
+		}
+		err := spi.StartTx(rx, rx)
+		if err != ErrNoDMAChannel {
+			if err != nil {
+				return err
+			}
+			return spi.Wait()
+		}
+	}
+
+	// Short transfer, or all DMA channels are in use, so transfer the bytes
+	// one by one.
 	plen := len(rx)
 	const fifoDepth = 8 // see txrx
 	var rxleft, txleft = plen, plen
@@ -378,6 +514,18 @@ func (spi *SPI) txrx(tx, rx []byte) error {
 	if plen != len(rx) {
 		return ErrTxInvalidSliceSize
 	}
+	if plen >= spiDMAMinSize {
+		err := spi.StartTx(tx, rx)
+		if err != ErrNoDMAChannel {
+			if err != nil {
+				return err
+			}
+			return spi.Wait()
+		}
+	}
+
+	// Short transfer, or all DMA channels are in use, so transfer the bytes
+	// one by one.
 	// Never have more transfers in flight than will fit into the RX FIFO,
 	// else FIFO will overflow if this code is heavily interrupted.
 	const fifoDepth = 8
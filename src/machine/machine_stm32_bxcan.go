@@ -0,0 +1,220 @@
+//go:build stm32f103 || stm32f4
+
+package machine
+
+// CAN support for the bxCAN peripheral of the STM32F1 and STM32F4, see chapter
+// 32 in RM0090. It supports classic CAN frames only.
+
+import (
+	"device/stm32"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// CAN is a bxCAN controller.
+type CAN struct {
+	Bus *stm32.CAN_Type
+
+	// Whether SetFilter has been called since Configure.
+	filtered bool
+}
+
+// Registers of a transmit or receive mailbox.
+type bxcanMailbox struct {
+	IR  volatile.Register32 // identifier
+	DTR volatile.Register32 // data length and timestamp
+	DLR volatile.Register32 // data bytes 0-3
+	DHR volatile.Register32 // data bytes 4-7
+}
+
+// Registers of a bxCAN controller. See stm32.CAN_Type, which doesn't describe
+// the mailboxes and filter banks as arrays.
+type bxcanRegisters struct {
+	MCR  volatile.Register32
+	MSR  volatile.Register32
+	TSR  volatile.Register32
+	RF0R volatile.Register32
+	RF1R volatile.Register32
+	IER  volatile.Register32
+	ESR  volatile.Register32
+	BTR  volatile.Register32
+	_    [88]uint32
+	TX   [3]bxcanMailbox
+	RX   [2]bxcanMailbox
+	_    [12]uint32
+
+	// The filter registers are only used in CAN1, also for CAN2.
+	FMR   volatile.Register32
+	FM1R  volatile.Register32
+	_     uint32
+	FS1R  volatile.Register32
+	_     uint32
+	FFA1R volatile.Register32
+	_     uint32
+	FA1R  volatile.Register32
+	_     [8]uint32
+	FB    [28]struct {
+		FR1 volatile.Register32
+		FR2 volatile.Register32
+	}
+}
+
+// Bits in the bxCAN registers.
+const (
+	bxcanMCR_INRQ = 1 << 0
+	bxcanMCR_TXFP = 1 << 2
+	bxcanMCR_ABOM = 1 << 6
+	bxcanMCR_TTCM = 1 << 7
+
+	bxcanMSR_INAK = 1 << 0
+
+	bxcanTSR_TME      = 7 << 26
+	bxcanTSR_CODE_Pos = 24
+
+	bxcanRF0R_FMP0  = 3 << 0
+	bxcanRF0R_RFOM0 = 1 << 5
+
+	bxcanBTR_TS1_Pos = 16
+	bxcanBTR_TS2_Pos = 20
+	bxcanBTR_SJW_Pos = 24
+
+	bxcanFMR_FINIT = 1 << 0
+)
+
+// Number of filters for SetFilter, which is the number of filter banks of each
+// controller.
+const canFilterCount = 14
+
+func (can *CAN) regs() *bxcanRegisters {
+	return (*bxcanRegisters)(unsafe.Pointer(can.Bus))
+}
+
+// filterRegs returns the registers that contain the filters of this
+// controller, and the first filter bank of this controller.
+func (can *CAN) filterRegs() (*bxcanRegisters, int) {
+	return (*bxcanRegisters)(unsafe.Pointer(stm32.CAN1)), can.filterBankOffset()
+}
+
+// Configure this CAN peripheral with the given configuration. The controller
+// leaves the sleep mode and joins the bus, and receives all frames until a
+// filter is set.
+func (can *CAN) Configure(config CANConfig) error {
+	if config.TransferRate == 0 {
+		config.TransferRate = CANTransferRate500kbps
+	}
+	timing, ok := calculateCANBitTiming(can.enableClock(), config.TransferRate, canBitTiming{
+		prescaler: 1024,
+		seg1:      16,
+		seg2:      8,
+		sjw:       4,
+	})
+	if !ok {
+		return errCANInvalidTransferRate
+	}
+
+	if config.Standby != NoPin {
+		config.Standby.Configure(PinConfig{Mode: PinOutput})
+		config.Standby.Low()
+	}
+	can.configurePins(config)
+
+	// Leave sleep mode and enter initialization mode.
+	regs := can.regs()
+	regs.MCR.Set(bxcanMCR_INRQ)
+	for !regs.MSR.HasBits(bxcanMSR_INAK) {
+	}
+
+	// Recover from bus-off automatically, send frames in the order they were
+	// queued and capture a timestamp of every frame.
+	regs.MCR.Set(bxcanMCR_INRQ | bxcanMCR_ABOM | bxcanMCR_TXFP | bxcanMCR_TTCM)
+	regs.BTR.Set((timing.sjw-1)<<bxcanBTR_SJW_Pos |
+		(timing.seg2-1)<<bxcanBTR_TS2_Pos |
+		(timing.seg1-1)<<bxcanBTR_TS1_Pos |
+		(timing.prescaler - 1))
+	regs.IER.Set(0)
+
+	// Use all filter banks of this controller as a single 32-bit mask filter
+	// for FIFO 0. The first bank accepts all frames until a filter is set.
+	filters, bank := can.filterRegs()
+	banks := uint32(1<<canFilterCount-1) << bank
+	filters.FMR.SetBits(bxcanFMR_FINIT)
+	filters.FA1R.ClearBits(banks)
+	filters.FM1R.ClearBits(banks)
+	filters.FS1R.SetBits(banks)
+	filters.FFA1R.ClearBits(banks)
+	filters.FB[bank].FR1.Set(0)
+	filters.FB[bank].FR2.Set(0)
+	filters.FA1R.SetBits(1 << bank)
+	filters.FMR.ClearBits(bxcanFMR_FINIT)
+	can.filtered = false
+
+	// Join the bus, after 11 recessive bits have been seen.
+	regs.MCR.ClearBits(bxcanMCR_INRQ)
+	for regs.MSR.HasBits(bxcanMSR_INAK) {
+	}
+
+	return nil
+}
+
+// SetFilter sets the filter with the given index (0 through 13). See CANFilter
+// for how received frames are filtered.
+func (can *CAN) SetFilter(index int, filter CANFilter) error {
+	if index < 0 || index >= canFilterCount || !filter.valid() {
+		return ErrCANInvalidFilter
+	}
+
+	id, mask := bxcanFilter(filter)
+
+	filters, bank := can.filterRegs()
+	filters.FMR.SetBits(bxcanFMR_FINIT)
+	if !can.filtered {
+		// Remove the filter that accepts all frames.
+		filters.FA1R.ClearBits(1 << bank)
+		can.filtered = true
+	}
+	bank += index
+	filters.FA1R.ClearBits(1 << bank)
+	filters.FB[bank].FR1.Set(id)
+	filters.FB[bank].FR2.Set(mask)
+	filters.FA1R.SetBits(1 << bank)
+	filters.FMR.ClearBits(bxcanFMR_FINIT)
+	return nil
+}
+
+// Transmit puts the frame in an empty transmit mailbox. It returns
+// ErrCANTxFull if all three mailboxes are in use.
+func (can *CAN) Transmit(frame *CANFrame) error {
+	if err := frame.validate(false); err != nil {
+		return err
+	}
+
+	regs := can.regs()
+	tsr := regs.TSR.Get()
+	if tsr&bxcanTSR_TME == 0 {
+		return ErrCANTxFull
+	}
+	mailbox := &regs.TX[(tsr>>bxcanTSR_CODE_Pos)&3]
+
+	ir, dtr, dlr, dhr := bxcanEncodeFrame(frame)
+	mailbox.DTR.Set(dtr)
+	mailbox.DLR.Set(dlr)
+	mailbox.DHR.Set(dhr)
+	mailbox.IR.Set(ir | bxcanIR_TXRQ)
+	return nil
+}
+
+// Receive reads the oldest frame in receive FIFO 0 into frame. It returns false
+// if there is no frame.
+func (can *CAN) Receive(frame *CANFrame) bool {
+	regs := can.regs()
+	if regs.RF0R.Get()&bxcanRF0R_FMP0 == 0 {
+		return false
+	}
+
+	mailbox := &regs.RX[0]
+	bxcanDecodeFrame(frame, mailbox.IR.Get(), mailbox.DTR.Get(), mailbox.DLR.Get(), mailbox.DHR.Get())
+
+	// Release the mailbox, so that the next frame can be read.
+	regs.RF0R.Set(bxcanRF0R_RFOM0)
+	return true
+}
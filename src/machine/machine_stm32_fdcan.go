@@ -0,0 +1,336 @@
+//go:build stm32l5
+
+package machine
+
+// CAN support for the FDCAN peripheral of the STM32L5, see chapter 41 in
+// RM0438. It supports both classic CAN and CAN FD frames. Unlike the M_CAN of
+// the SAM E5x, the layout of the message RAM is fixed.
+
+import (
+	"device/stm32"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// CAN is an FDCAN controller.
+type CAN struct {
+	Bus *stm32.FDCAN_Type
+}
+
+// Registers of an FDCAN controller. See stm32.FDCAN_Type.
+type fdcanRegisters struct {
+	CREL   volatile.Register32
+	ENDN   volatile.Register32
+	_      uint32
+	DBTP   volatile.Register32
+	TEST   volatile.Register32
+	RWD    volatile.Register32
+	CCCR   volatile.Register32
+	NBTP   volatile.Register32
+	TSCC   volatile.Register32
+	TSCV   volatile.Register32
+	TOCC   volatile.Register32
+	TOCV   volatile.Register32
+	_      [4]uint32
+	ECR    volatile.Register32
+	PSR    volatile.Register32
+	TDCR   volatile.Register32
+	_      uint32
+	IR     volatile.Register32
+	IE     volatile.Register32
+	ILS    volatile.Register32
+	ILE    volatile.Register32
+	_      [8]uint32
+	RXGFC  volatile.Register32
+	XIDAM  volatile.Register32
+	HPMS   volatile.Register32
+	_      uint32
+	RXF0S  volatile.Register32
+	RXF0A  volatile.Register32
+	RXF1S  volatile.Register32
+	RXF1A  volatile.Register32
+	_      [8]uint32
+	TXBC   volatile.Register32
+	TXFQS  volatile.Register32
+	TXBRP  volatile.Register32
+	TXBAR  volatile.Register32
+	TXBCR  volatile.Register32
+	TXBTO  volatile.Register32
+	TXBCF  volatile.Register32
+	TXBTIE volatile.Register32
+	TXBCIE volatile.Register32
+	TXEFS  volatile.Register32
+	TXEFA  volatile.Register32
+}
+
+// A frame in the message RAM: two header words followed by up to 64 bytes of
+// data.
+type fdcanElement struct {
+	T0   volatile.Register32
+	T1   volatile.Register32
+	Data [16]volatile.Register32
+}
+
+// Message RAM of an FDCAN controller, which can only be accessed in words.
+type fdcanMessageRAM struct {
+	StdFilters [28]volatile.Register32
+	ExtFilters [8][2]volatile.Register32
+	RxFIFO0    [3]fdcanElement
+	RxFIFO1    [3]fdcanElement
+	TxEvents   [3][2]volatile.Register32
+	TxBuffers  [3]fdcanElement
+}
+
+// Bits in the FDCAN registers and message RAM elements.
+const (
+	fdcanCCCR_INIT = 1 << 0
+	fdcanCCCR_CCE  = 1 << 1
+	fdcanCCCR_FDOE = 1 << 8
+	fdcanCCCR_BRSE = 1 << 9
+
+	fdcanNBTP_NTSEG2_Pos = 0
+	fdcanNBTP_NTSEG1_Pos = 8
+	fdcanNBTP_NBRP_Pos   = 16
+	fdcanNBTP_NSJW_Pos   = 25
+
+	fdcanDBTP_DSJW_Pos   = 0
+	fdcanDBTP_DTSEG2_Pos = 4
+	fdcanDBTP_DTSEG1_Pos = 8
+	fdcanDBTP_DBRP_Pos   = 16
+
+	fdcanTSCC_TSS_INC = 1 << 0
+
+	fdcanRXGFC_ANFE_Pos = 2
+	fdcanRXGFC_ANFS_Pos = 4
+	fdcanRXGFC_ANFS_Msk = 3 << fdcanRXGFC_ANFS_Pos
+	fdcanRXGFC_LSS_Pos  = 16
+	fdcanRXGFC_LSE_Pos  = 24
+
+	fdcanRXF0S_F0FL_Msk = 0xf
+	fdcanRXF0S_F0GI_Pos = 8
+
+	fdcanTXFQS_TFQPI_Pos = 16
+	fdcanTXFQS_TFQF      = 1 << 21
+
+	fdcanT0_RTR = 1 << 29
+	fdcanT0_XTD = 1 << 30
+
+	fdcanT1_DLC_Pos = 16
+	fdcanT1_BRS     = 1 << 20
+	fdcanT1_FDF     = 1 << 21
+)
+
+// Values of RXGFC.ANFS and RXGFC.ANFE, for frames that don't match any filter.
+const (
+	canAcceptNonMatching = 0 // store in Rx FIFO 0
+	canRejectNonMatching = 2
+)
+
+// Number of filters for SetFilter. Every filter has a standard and an extended
+// filter element, only one of which is enabled.
+const canFilterCount = 8
+
+func (can *CAN) regs() *fdcanRegisters {
+	return (*fdcanRegisters)(unsafe.Pointer(can.Bus))
+}
+
+// Configure this CAN peripheral with the given configuration. The controller
+// joins the bus, and receives all frames until a filter is set.
+func (can *CAN) Configure(config CANConfig) error {
+	if config.TransferRate == 0 {
+		config.TransferRate = CANTransferRate500kbps
+	}
+	if config.TransferRateFD == 0 {
+		config.TransferRateFD = CANTransferRate1000kbps
+	}
+	if config.TransferRateFD < config.TransferRate {
+		return errCANInvalidTransferRateFD
+	}
+	clock := can.enableClock()
+	nominal, ok := calculateCANBitTiming(clock, config.TransferRate, canBitTiming{
+		prescaler: 512,
+		seg1:      256,
+		seg2:      128,
+		sjw:       128,
+	})
+	if !ok {
+		return errCANInvalidTransferRate
+	}
+	data, ok := calculateCANBitTiming(clock, config.TransferRateFD, canBitTiming{
+		prescaler: 32,
+		seg1:      32,
+		seg2:      16,
+		sjw:       16,
+	})
+	if !ok {
+		return errCANInvalidTransferRateFD
+	}
+
+	if config.Standby != NoPin {
+		config.Standby.Configure(PinConfig{Mode: PinOutput})
+		config.Standby.Low()
+	}
+	can.configurePins(config)
+
+	// Enter initialization mode, and allow changes to the configuration.
+	regs := can.regs()
+	regs.CCCR.SetBits(fdcanCCCR_INIT)
+	for !regs.CCCR.HasBits(fdcanCCCR_INIT) {
+	}
+	regs.CCCR.SetBits(fdcanCCCR_CCE)
+
+	// Allow CAN FD frames, with bit rate switching.
+	regs.CCCR.SetBits(fdcanCCCR_FDOE | fdcanCCCR_BRSE)
+	regs.NBTP.Set((nominal.sjw-1)<<fdcanNBTP_NSJW_Pos |
+		(nominal.prescaler-1)<<fdcanNBTP_NBRP_Pos |
+		(nominal.seg1-1)<<fdcanNBTP_NTSEG1_Pos |
+		(nominal.seg2-1)<<fdcanNBTP_NTSEG2_Pos)
+	regs.DBTP.Set((data.prescaler-1)<<fdcanDBTP_DBRP_Pos |
+		(data.seg1-1)<<fdcanDBTP_DTSEG1_Pos |
+		(data.seg2-1)<<fdcanDBTP_DTSEG2_Pos |
+		(data.sjw-1)<<fdcanDBTP_DSJW_Pos)
+
+	// Count the bit times for the timestamps of received frames.
+	regs.TSCC.Set(fdcanTSCC_TSS_INC)
+
+	// Send frames in the order they were queued (Tx FIFO mode).
+	regs.TXBC.Set(0)
+
+	// Disable all filters, and accept all frames until a filter is set.
+	ram := can.messageRAM()
+	for i := 0; i < canFilterCount; i++ {
+		ram.StdFilters[i].Set(0)
+		ram.ExtFilters[i][0].Set(0)
+		ram.ExtFilters[i][1].Set(0)
+	}
+	regs.RXGFC.Set(canFilterCount<<fdcanRXGFC_LSE_Pos | canFilterCount<<fdcanRXGFC_LSS_Pos |
+		canAcceptNonMatching<<fdcanRXGFC_ANFS_Pos | canAcceptNonMatching<<fdcanRXGFC_ANFE_Pos)
+	regs.XIDAM.Set(0x1FFFFFFF)
+	regs.IE.Set(0)
+
+	// Join the bus. This also clears CCCR.CCE.
+	regs.CCCR.ClearBits(fdcanCCCR_INIT)
+	for regs.CCCR.HasBits(fdcanCCCR_INIT) {
+	}
+
+	return nil
+}
+
+// SetFilter sets the filter with the given index (0 through 7). See CANFilter
+// for how received frames are filtered.
+func (can *CAN) SetFilter(index int, filter CANFilter) error {
+	if index < 0 || index >= canFilterCount || !filter.valid() {
+		return ErrCANInvalidFilter
+	}
+
+	// Enable either the standard or the extended filter element, as a classic
+	// filter (SFT/EFT = 2) that stores matching frames in Rx FIFO 0
+	// (SFEC/EFEC = 1).
+	ram := can.messageRAM()
+	if filter.Extended {
+		ram.StdFilters[index].Set(0)
+		ram.ExtFilters[index][0].Set(1<<29 | filter.ID)
+		ram.ExtFilters[index][1].Set(2<<30 | filter.Mask)
+	} else {
+		ram.ExtFilters[index][0].Set(0)
+		ram.ExtFilters[index][1].Set(0)
+		ram.StdFilters[index].Set(2<<30 | 1<<27 | filter.ID<<16 | filter.Mask)
+	}
+
+	// Reject frames that don't match a filter from now on. RXGFC can only be
+	// changed in initialization mode.
+	regs := can.regs()
+	if (regs.RXGFC.Get()&fdcanRXGFC_ANFS_Msk)>>fdcanRXGFC_ANFS_Pos != canRejectNonMatching {
+		regs.CCCR.SetBits(fdcanCCCR_INIT)
+		for !regs.CCCR.HasBits(fdcanCCCR_INIT) {
+		}
+		regs.CCCR.SetBits(fdcanCCCR_CCE)
+		regs.RXGFC.ReplaceBits(canRejectNonMatching, 3, fdcanRXGFC_ANFS_Pos)
+		regs.RXGFC.ReplaceBits(canRejectNonMatching, 3, fdcanRXGFC_ANFE_Pos)
+		regs.CCCR.ClearBits(fdcanCCCR_INIT)
+		for regs.CCCR.HasBits(fdcanCCCR_INIT) {
+		}
+	}
+	return nil
+}
+
+// Transmit adds the frame to the Tx FIFO. It returns ErrCANTxFull if the Tx
+// FIFO is full.
+func (can *CAN) Transmit(frame *CANFrame) error {
+	if err := frame.validate(true); err != nil {
+		return err
+	}
+
+	regs := can.regs()
+	txfqs := regs.TXFQS.Get()
+	if txfqs&fdcanTXFQS_TFQF != 0 {
+		return ErrCANTxFull
+	}
+	index := (txfqs >> fdcanTXFQS_TFQPI_Pos) & 3
+	element := &can.messageRAM().TxBuffers[index]
+
+	t0 := frame.ID << 18 // standard identifier is stored in ID[28:18]
+	if frame.Extended {
+		t0 = frame.ID | fdcanT0_XTD
+	}
+	if frame.Remote {
+		t0 |= fdcanT0_RTR
+	}
+	t1 := uint32(CANLengthToDlc(frame.Length, frame.FD)) << fdcanT1_DLC_Pos
+	if frame.FD {
+		t1 |= fdcanT1_FDF
+	}
+	if frame.BRS {
+		t1 |= fdcanT1_BRS
+	}
+	element.T0.Set(t0)
+	element.T1.Set(t1)
+	for i := 0; i < int(frame.Length+3)/4; i++ {
+		d := frame.Data[i*4 : i*4+4]
+		element.Data[i].Set(uint32(d[0]) | uint32(d[1])<<8 | uint32(d[2])<<16 | uint32(d[3])<<24)
+	}
+
+	regs.TXBAR.Set(1 << index)
+	return nil
+}
+
+// Receive reads the oldest frame in Rx FIFO 0 into frame. It returns false if
+// there is no frame.
+func (can *CAN) Receive(frame *CANFrame) bool {
+	regs := can.regs()
+	rxf0s := regs.RXF0S.Get()
+	if rxf0s&fdcanRXF0S_F0FL_Msk == 0 {
+		return false
+	}
+	index := (rxf0s >> fdcanRXF0S_F0GI_Pos) & 3
+	element := &can.messageRAM().RxFIFO0[index]
+
+	r0 := element.T0.Get()
+	r1 := element.T1.Get()
+	frame.Extended = r0&fdcanT0_XTD != 0
+	if frame.Extended {
+		frame.ID = r0 & 0x1FFFFFFF
+	} else {
+		frame.ID = (r0 >> 18) & 0x7FF
+	}
+	frame.Remote = r0&fdcanT0_RTR != 0
+	frame.FD = r1&fdcanT1_FDF != 0
+	frame.BRS = r1&fdcanT1_BRS != 0
+	frame.Length = CANDlcToLength(byte(r1>>fdcanT1_DLC_Pos)&0xf, frame.FD)
+	if !frame.FD && frame.Length > 8 {
+		// A DLC above 8 means 8 bytes in classic CAN frames.
+		frame.Length = 8
+	}
+	frame.Timestamp = uint16(r1)
+	for i := 0; i < int(frame.Length+3)/4; i++ {
+		word := element.Data[i].Get()
+		frame.Data[i*4] = byte(word)
+		frame.Data[i*4+1] = byte(word >> 8)
+		frame.Data[i*4+2] = byte(word >> 16)
+		frame.Data[i*4+3] = byte(word >> 24)
+	}
+
+	// Acknowledge the frame, so that the next frame can be read.
+	regs.RXF0A.Set(index)
+	return true
+}
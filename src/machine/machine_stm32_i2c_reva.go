@@ -104,6 +104,7 @@ type I2CConfig struct {
 	SCL       Pin
 	SDA       Pin
 	DutyCycle uint8
+	Mode      I2CMode
 }
 
 // Configure is intended to setup the STM32 I2C interface.
@@ -154,6 +155,9 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 	// enable I2C interface
 	i2c.Bus.CR1.SetBits(stm32.I2C_CR1_PE)
 
+	// The own address for target mode is set in Listen.
+	i2c.mode = config.Mode
+
 	return nil
 }
 
@@ -164,6 +168,9 @@ func (i2c *I2C) SetBaudRate(br uint32) error {
 }
 
 func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
+	if i2c.mode != I2CModeController {
+		return ErrI2CWrongMode
+	}
 
 	if err := i2c.controllerTransmit(addr, w); nil != err {
 		return err
@@ -434,3 +441,110 @@ func (i2c *I2C) controllerRequestRead(addr uint16, option transferOption) error
 
 	return nil
 }
+
+// Listen starts listening for I2C requests sent to specified address
+//
+// addr is the address to listen to
+func (i2c *I2C) Listen(addr uint16) error {
+	if i2c.mode != I2CModeTarget {
+		return ErrI2CWrongMode
+	}
+	if addr >= 0x80 || isReservedI2CAddr(uint8(addr)) {
+		return ErrInvalidTgtAddr
+	}
+
+	// 7 bit addressing. Bit 14 must always be kept at 1 by software.
+	i2c.Bus.OAR1.Set(uint32(addr)<<1 | 1<<14)
+
+	// acknowledge our address and the received bytes
+	i2c.Bus.CR1.SetBits(stm32.I2C_CR1_ACK)
+
+	return nil
+}
+
+// WaitForEvent blocks the current go-routine until an I2C event is received (when in Target mode).
+//
+// The passed buffer will be populated for receive events, with the number of bytes
+// received returned in count.  For other event types, buf is not modified and a count
+// of zero is returned.
+//
+// For request events, the caller MUST call `Reply` to avoid hanging the i2c bus indefinitely.
+func (i2c *I2C) WaitForEvent(buf []byte) (evt I2CTargetEvent, count int, err error) {
+	if i2c.mode != I2CModeTarget {
+		return I2CFinish, 0, ErrI2CWrongMode
+	}
+	rxPtr := 0
+	for {
+		// Data received from the controller.
+		if i2c.hasFlag(flagRXNE) {
+			b := uint8(i2c.Bus.DR.Get())
+			if rxPtr < len(buf) {
+				buf[rxPtr] = b
+				rxPtr++
+			}
+			continue
+		}
+
+		// Stop - leave the flag set after receiving data, so that the next
+		// call returns I2CFinish.
+		if i2c.hasFlag(flagSTOPF) {
+			if rxPtr > 0 {
+				return I2CReceive, rxPtr, nil
+			}
+
+			// clear the flag by reading SR1 (done above) and writing CR1
+			i2c.Bus.CR1.SetBits(stm32.I2C_CR1_PE)
+			return I2CFinish, 0, nil
+		}
+
+		// Start or restart with our address.
+		if i2c.hasFlag(flagADDR) {
+			// Restart - return the data received so far first.
+			if rxPtr > 0 {
+				return I2CReceive, rxPtr, nil
+			}
+
+			// Reading SR2 (for the TRA flag) clears the ADDR flag. For a read
+			// request, the clock is stretched until the first byte is written
+			// by Reply.
+			if i2c.hasFlag(flagTRA) {
+				return I2CRequest, 0, nil
+			}
+			continue
+		}
+
+		gosched()
+	}
+}
+
+// Reply supplies the response data the controller.
+//
+// If the controller reads more bytes than there are in buf, 0xff is sent for
+// the remaining bytes.
+func (i2c *I2C) Reply(buf []byte) error {
+	if !i2c.hasFlag(flagTRA) {
+		return ErrI2CWrongMode
+	}
+
+	for txPtr := 0; ; txPtr++ {
+		for !i2c.hasFlag(flagTXE) && !i2c.hasFlag(flagAF) && !i2c.hasFlag(flagSTOPF) {
+			gosched()
+		}
+		if i2c.hasFlag(flagAF) {
+			// The controller didn't acknowledge the last byte, so it doesn't
+			// want more data.
+			i2c.clearFlag(flagAF)
+			return nil
+		}
+		if !i2c.hasFlag(flagTXE) {
+			// Stop: the controller doesn't want more data.
+			return nil
+		}
+
+		b := byte(0xff)
+		if txPtr < len(buf) {
+			b = buf[txPtr]
+		}
+		i2c.Bus.DR.Set(uint32(b))
+	}
+}
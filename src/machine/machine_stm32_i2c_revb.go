@@ -23,6 +23,8 @@ const (
 	flagAF    = stm32.I2C_ISR_NACKF
 	flagTXIS  = stm32.I2C_ISR_TXIS
 	flagTXE   = stm32.I2C_ISR_TXE
+	flagADDR  = stm32.I2C_ISR_ADDR
+	flagDIR   = stm32.I2C_ISR_DIR
 )
 
 const (
@@ -41,6 +43,7 @@ const (
 type I2C struct {
 	Bus             *stm32.I2C_Type
 	AltFuncSelector uint8
+	mode            I2CMode
 }
 
 // I2CConfig is used to store config info for I2C.
@@ -48,6 +51,7 @@ type I2CConfig struct {
 	Frequency uint32
 	SCL       Pin
 	SDA       Pin
+	Mode      I2CMode
 }
 
 func (i2c *I2C) Configure(config I2CConfig) error {
@@ -90,6 +94,9 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 	// Disable Generalcall and NoStretch, Enable peripheral
 	i2c.Bus.CR1.Set(stm32.I2C_CR1_PE)
 
+	// The own address for target mode is set in Listen.
+	i2c.mode = config.Mode
+
 	return nil
 }
 
@@ -113,6 +120,10 @@ func (i2c *I2C) SetBaudRate(br uint32) error {
 }
 
 func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
+	if i2c.mode != I2CModeController {
+		return ErrI2CWrongMode
+	}
+
 	if len(w) > 0 {
 		if err := i2c.controllerTransmit(addr, w); nil != err {
 			return err
@@ -376,3 +387,115 @@ func (i2c *I2C) clearFlag(flag uint32) {
 		i2c.Bus.ICR.SetBits(flag)
 	}
 }
+
+// Listen starts listening for I2C requests sent to specified address
+//
+// addr is the address to listen to
+func (i2c *I2C) Listen(addr uint16) error {
+	if i2c.mode != I2CModeTarget {
+		return ErrI2CWrongMode
+	}
+	if addr >= 0x80 || isReservedI2CAddr(uint8(addr)) {
+		return ErrInvalidTgtAddr
+	}
+
+	// Disable Own Address1 before set the Own Address1 configuration
+	i2c.Bus.OAR1.ClearBits(stm32.I2C_OAR1_OA1EN)
+
+	// 7 bit addressing
+	i2c.Bus.OAR1.Set(uint32(addr)<<1 | stm32.I2C_OAR1_OA1EN)
+
+	return nil
+}
+
+// WaitForEvent blocks the current go-routine until an I2C event is received (when in Target mode).
+//
+// The passed buffer will be populated for receive events, with the number of bytes
+// received returned in count.  For other event types, buf is not modified and a count
+// of zero is returned.
+//
+// For request events, the caller MUST call `Reply` to avoid hanging the i2c bus indefinitely.
+func (i2c *I2C) WaitForEvent(buf []byte) (evt I2CTargetEvent, count int, err error) {
+	if i2c.mode != I2CModeTarget {
+		return I2CFinish, 0, ErrI2CWrongMode
+	}
+	rxPtr := 0
+	for {
+		// Data received from the controller.
+		if i2c.hasFlag(flagRXNE) {
+			b := uint8(i2c.Bus.RXDR.Get())
+			if rxPtr < len(buf) {
+				buf[rxPtr] = b
+				rxPtr++
+			}
+			continue
+		}
+
+		// Stop - leave the flag set after receiving data, so that the next
+		// call returns I2CFinish.
+		if i2c.hasFlag(flagSTOPF) {
+			if rxPtr > 0 {
+				return I2CReceive, rxPtr, nil
+			}
+
+			i2c.clearFlag(flagSTOPF)
+			return I2CFinish, 0, nil
+		}
+
+		// Start or restart with our address.
+		if i2c.hasFlag(flagADDR) {
+			// Restart - return the data received so far first.
+			if rxPtr > 0 {
+				return I2CReceive, rxPtr, nil
+			}
+
+			// Read request - leave the flag set until we start to reply. The
+			// clock is stretched in the meantime.
+			if i2c.hasFlag(flagDIR) {
+				return I2CRequest, 0, nil
+			}
+
+			// Write request - acknowledge the address and receive the data.
+			i2c.clearFlag(flagADDR)
+			continue
+		}
+
+		gosched()
+	}
+}
+
+// Reply supplies the response data the controller.
+//
+// If the controller reads more bytes than there are in buf, 0xff is sent for
+// the remaining bytes.
+func (i2c *I2C) Reply(buf []byte) error {
+	if !i2c.hasFlag(flagADDR) || !i2c.hasFlag(flagDIR) {
+		return ErrI2CWrongMode
+	}
+
+	// Flush any stale data in TXDR, then release the clock.
+	i2c.clearFlag(flagTXE)
+	i2c.clearFlag(flagADDR)
+
+	for txPtr := 0; ; txPtr++ {
+		for !i2c.hasFlag(flagTXIS | flagAF | flagSTOPF) {
+			gosched()
+		}
+		if i2c.hasFlag(flagAF) {
+			// The controller didn't acknowledge the last byte, so it doesn't
+			// want more data.
+			i2c.clearFlag(flagAF)
+			return nil
+		}
+		if !i2c.hasFlag(flagTXIS) {
+			// Stop: the controller doesn't want more data.
+			return nil
+		}
+
+		b := byte(0xff)
+		if txPtr < len(buf) {
+			b = buf[txPtr]
+		}
+		i2c.Bus.TXDR.Set(uint32(b))
+	}
+}
@@ -36,6 +36,10 @@ const (
 
 	// for PWM
 	PinModePWMOutput PinMode = 12
+
+	// for CAN
+	PinModeCANTX PinMode = 13
+	PinModeCANRX PinMode = 14
 )
 
 // Define several bitfields that have different names across chip families but
@@ -95,13 +99,13 @@ func (p Pin) ConfigureAltFunc(config PinConfig, altFunc uint8) {
 		port.MODER.ReplaceBits(gpioModeOutput, gpioModeMask, pos)
 		port.OSPEEDR.ReplaceBits(gpioOutputSpeedHigh, gpioOutputSpeedMask, pos)
 
-	// UART
-	case PinModeUARTTX:
+	// UART and CAN
+	case PinModeUARTTX, PinModeCANTX:
 		port.MODER.ReplaceBits(gpioModeAlternate, gpioModeMask, pos)
 		port.OSPEEDR.ReplaceBits(gpioOutputSpeedHigh, gpioOutputSpeedMask, pos)
 		port.PUPDR.ReplaceBits(gpioPullUp, gpioPullMask, pos)
 		p.SetAltFunc(altFunc)
-	case PinModeUARTRX:
+	case PinModeUARTRX, PinModeCANRX:
 		port.MODER.ReplaceBits(gpioModeAlternate, gpioModeMask, pos)
 		port.PUPDR.ReplaceBits(gpioPullFloating, gpioPullMask, pos)
 		p.SetAltFunc(altFunc)
@@ -232,6 +232,8 @@ func enableAltFuncClock(bus unsafe.Pointer) {
 		stm32.RCC.APB2ENR.SetBits(stm32.RCC_APB2ENR_SPI1EN)
 	case unsafe.Pointer(stm32.ADC1):
 		stm32.RCC.APB2ENR.SetBits(stm32.RCC_APB2ENR_ADC1EN)
+	case unsafe.Pointer(stm32.CAN1):
+		stm32.RCC.APB1ENR.SetBits(stm32.RCC_APB1ENR_CANEN)
 	default:
 		panic("machine: unknown peripheral")
 	}
@@ -381,7 +383,8 @@ func (spi *SPI) configurePins(config SPIConfig) {
 // TODO: implement I2C2.
 
 type I2C struct {
-	Bus *stm32.I2C_Type
+	Bus  *stm32.I2C_Type
+	mode I2CMode
 }
 
 var (
@@ -456,6 +459,38 @@ func (i2c *I2C) getSpeed(config I2CConfig) uint32 {
 	}
 }
 
+//---------- CAN related code
+
+// There is one CAN controller on the STM32F103xx. It shares its SRAM with the
+// USB peripheral, so they can't be used at the same time.
+var (
+	CAN1 = &CAN{Bus: stm32.CAN1}
+)
+
+// enableClock enables the clock of this controller, and returns its frequency.
+func (can *CAN) enableClock() uint32 {
+	enableAltFuncClock(unsafe.Pointer(can.Bus))
+
+	// pclk1 clock speed is main frequency divided by PCLK1 prescaler (div 2)
+	return CPUFrequency() / 2
+}
+
+func (can *CAN) configurePins(config CANConfig) {
+	if config.Rx == PB8 {
+		// use alternate CAN pins PB8/PB9 via AFIO mapping
+		stm32.RCC.APB2ENR.SetBits(stm32.RCC_APB2ENR_AFIOEN)
+		stm32.AFIO.MAPR.ReplaceBits(2<<stm32.AFIO_MAPR_CAN_REMAP_Pos, stm32.AFIO_MAPR_CAN_REMAP_Msk, 0)
+	}
+
+	config.Tx.Configure(PinConfig{Mode: PinOutput50MHz + PinOutputModeAltPushPull})
+	config.Rx.Configure(PinConfig{Mode: PinInputModeFloating})
+}
+
+// filterBankOffset returns the first filter bank of this controller.
+func (can *CAN) filterBankOffset() int {
+	return 0
+}
+
 //---------- Timer related code
 
 // For Pin Mappings see RM0008, pg 179
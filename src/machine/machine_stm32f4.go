@@ -670,6 +670,7 @@ func (uart *UART) setRegisters() {
 type SPI struct {
 	Bus             *stm32.SPI_Type
 	AltFuncSelector uint8
+	dma             *DMAChannel // set by EnableDMA
 }
 
 func (spi *SPI) config8Bits() {
@@ -723,6 +724,7 @@ func (spi *SPI) getBaudRate(config SPIConfig) uint32 {
 type I2C struct {
 	Bus             *stm32.I2C_Type
 	AltFuncSelector uint8
+	mode            I2CMode
 }
 
 func (i2c *I2C) configurePins(config I2CConfig) {
@@ -796,6 +798,38 @@ func (i2c *I2C) getSpeed(config I2CConfig) uint32 {
 	}
 }
 
+// -- CAN ----------------------------------------------------------------------
+
+var (
+	CAN1 = &CAN{Bus: stm32.CAN1}
+	CAN2 = &CAN{Bus: stm32.CAN2}
+)
+
+// enableClock enables the clock of this controller, and returns its frequency.
+func (can *CAN) enableClock() uint32 {
+	// CAN2 is a slave of CAN1, which is needed to access the filters.
+	enableAltFuncClock(unsafe.Pointer(stm32.CAN1))
+	enableAltFuncClock(unsafe.Pointer(can.Bus))
+
+	// both CAN controllers are on APB1
+	return CPUFrequency() / 4
+}
+
+func (can *CAN) configurePins(config CANConfig) {
+	config.Tx.ConfigureAltFunc(PinConfig{Mode: PinModeCANTX}, AF9_CAN1_CAN2_TIM12_13_14)
+	config.Rx.ConfigureAltFunc(PinConfig{Mode: PinModeCANRX}, AF9_CAN1_CAN2_TIM12_13_14)
+}
+
+// filterBankOffset returns the first filter bank of this controller. The 28
+// filter banks are split evenly between CAN1 and CAN2 (the reset value of
+// FMR.CAN2SB).
+func (can *CAN) filterBankOffset() int {
+	if can.Bus == stm32.CAN2 {
+		return 14
+	}
+	return 0
+}
+
 //---------- Flash related code
 
 // the block size actually depends on the sector.
@@ -0,0 +1,279 @@
+//go:build stm32f4
+
+package machine
+
+import (
+	"device/stm32"
+	"runtime/interrupt"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// DMARequest identifies the peripheral that paces a transfer. On the STM32F4,
+// every peripheral request is wired to a fixed stream and channel of one of the
+// two DMA controllers, see tables 42 and 43 in RM0090. A request encodes the
+// controller, stream and channel.
+type DMARequest uint8
+
+// DMA requests of peripherals on the STM32F4.
+const (
+	// DMARequestNone is used for memory-to-memory transfers, which run as
+	// fast as possible. They can only use DMA2.
+	DMARequestNone DMARequest = 0
+
+	DMARequestSPI1RX DMARequest = 0x80 | 1<<6 | 0<<3 | 3 // DMA2 stream 0 channel 3
+	DMARequestSPI1TX DMARequest = 0x80 | 1<<6 | 3<<3 | 3 // DMA2 stream 3 channel 3
+	DMARequestSPI2RX DMARequest = 0x80 | 0<<6 | 3<<3 | 0 // DMA1 stream 3 channel 0
+	DMARequestSPI2TX DMARequest = 0x80 | 0<<6 | 4<<3 | 0 // DMA1 stream 4 channel 0
+	DMARequestSPI3RX DMARequest = 0x80 | 0<<6 | 0<<3 | 0 // DMA1 stream 0 channel 0
+	DMARequestSPI3TX DMARequest = 0x80 | 0<<6 | 5<<3 | 0 // DMA1 stream 5 channel 0
+	DMARequestADC1   DMARequest = 0x80 | 1<<6 | 0<<3 | 0 // DMA2 stream 0 channel 0
+)
+
+// Registers of a single DMA stream.
+type dmaStream struct {
+	CR   volatile.Register32
+	NDTR volatile.Register32
+	PAR  volatile.Register32
+	M0AR volatile.Register32
+	M1AR volatile.Register32
+	FCR  volatile.Register32
+}
+
+// Registers of a DMA controller. See stm32.DMA_Type.
+type dmaController struct {
+	LISR   volatile.Register32
+	HISR   volatile.Register32
+	LIFCR  volatile.Register32
+	HIFCR  volatile.Register32
+	STREAM [8]dmaStream
+}
+
+var dmaControllers = [2]*dmaController{
+	(*dmaController)(unsafe.Pointer(stm32.DMA1)),
+	(*dmaController)(unsafe.Pointer(stm32.DMA2)),
+}
+
+// Bits in the stream CR register.
+const (
+	dmaCR_EN        = 1 << 0
+	dmaCR_TEIE      = 1 << 2
+	dmaCR_TCIE      = 1 << 4
+	dmaCR_DIR_Pos   = 6
+	dmaCR_PINC      = 1 << 9
+	dmaCR_MINC      = 1 << 10
+	dmaCR_PSIZE_Pos = 11
+	dmaCR_MSIZE_Pos = 13
+	dmaCR_CHSEL_Pos = 25
+
+	dmaFCR_DMDIS    = 1 << 2
+	dmaFCR_FTH_FULL = 3
+)
+
+// Offset of the interrupt flags of a stream in the (L/H)ISR and (L/H)IFCR
+// registers. Streams 0-3 use the low registers, 4-7 the high registers.
+var dmaFlagOffsets = [4]uint8{0, 6, 16, 22}
+
+// All interrupt flags of a stream: FEIF, DMEIF, TEIF, HTIF, TCIF.
+const dmaAllFlags = 0x3d
+
+// DMAChannel is a single DMA stream, see ClaimDMAChannel.
+type DMAChannel struct {
+	controller uint8 // 0 for DMA1, 1 for DMA2
+	stream     uint8
+	channel    uint8
+	claimed    bool
+	callback   func(*DMAChannel)
+}
+
+var (
+	dmaChannelState [2][8]DMAChannel
+	dmaInitialized  bool
+)
+
+// ClaimDMAChannel claims the DMA stream that is connected to the given
+// peripheral request, or a free stream of DMA2 for DMARequestNone
+// (memory-to-memory transfers). It returns ErrNoDMAChannel if this stream is
+// already in use.
+func ClaimDMAChannel(request DMARequest) (*DMAChannel, error) {
+	mask := interrupt.Disable()
+	defer interrupt.Restore(mask)
+	if !dmaInitialized {
+		dmaInitialized = true
+		stm32.RCC.AHB1ENR.SetBits(stm32.RCC_AHB1ENR_DMA1EN | stm32.RCC_AHB1ENR_DMA2EN)
+	}
+
+	if request == DMARequestNone {
+		// Search from the last stream, as the first streams are more commonly
+		// used by peripherals.
+		for i := len(dmaChannelState[1]) - 1; i >= 0; i-- {
+			ch := &dmaChannelState[1][i]
+			if !ch.claimed {
+				*ch = DMAChannel{controller: 1, stream: uint8(i), claimed: true}
+				return ch, nil
+			}
+		}
+		return nil, ErrNoDMAChannel
+	}
+
+	controller := uint8(request>>6) & 1
+	stream := uint8(request>>3) & 7
+	ch := &dmaChannelState[controller][stream]
+	if ch.claimed {
+		return nil, ErrNoDMAChannel
+	}
+	*ch = DMAChannel{
+		controller: controller,
+		stream:     stream,
+		channel:    uint8(request) & 7,
+		claimed:    true,
+	}
+	return ch, nil
+}
+
+func (ch *DMAChannel) regs() *dmaStream {
+	return &dmaControllers[ch.controller].STREAM[ch.stream]
+}
+
+// clearFlags clears all interrupt flags of this stream.
+func (ch *DMAChannel) clearFlags() {
+	dma := dmaControllers[ch.controller]
+	flags := uint32(dmaAllFlags) << dmaFlagOffsets[ch.stream%4]
+	if ch.stream < 4 {
+		dma.LIFCR.Set(flags)
+	} else {
+		dma.HIFCR.Set(flags)
+	}
+}
+
+// Release aborts any running transfer and makes this channel available to be
+// claimed again.
+func (ch *DMAChannel) Release() {
+	ch.Abort()
+	ch.SetCallback(nil)
+	ch.claimed = false
+}
+
+// Start starts a new transfer on this channel. It returns ErrDMABusy if the
+// previous transfer hasn't finished yet.
+func (ch *DMAChannel) Start(t DMATransfer) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	if t.Count > 0xffff {
+		// The number of data items is only 16 bits.
+		return ErrDMAInvalid
+	}
+	if ch.Busy() {
+		return ErrDMABusy
+	}
+
+	size := uint32(t.Size >> 1)
+	cr := uint32(ch.channel)<<dmaCR_CHSEL_Pos |
+		size<<dmaCR_MSIZE_Pos |
+		size<<dmaCR_PSIZE_Pos |
+		dmaCR_MINC |
+		dmaCR_EN
+	if ch.callback != nil {
+		cr |= dmaCR_TCIE | dmaCR_TEIE
+	}
+
+	// The peripheral address (PAR) is the source for memory-to-memory
+	// transfers, which also require the FIFO to be enabled.
+	regs := ch.regs()
+	fcr := uint32(0)
+	switch t.Direction {
+	case DMAMemoryToMemory:
+		if ch.controller != 1 {
+			// Only DMA2 can do memory-to-memory transfers.
+			return ErrDMAInvalid
+		}
+		regs.PAR.Set(uint32(uintptr(t.Src)))
+		regs.M0AR.Set(uint32(uintptr(t.Dst)))
+		cr |= 2<<dmaCR_DIR_Pos | dmaCR_PINC
+		fcr = dmaFCR_DMDIS | dmaFCR_FTH_FULL
+	case DMAMemoryToPeripheral:
+		regs.PAR.Set(uint32(uintptr(t.Dst)))
+		regs.M0AR.Set(uint32(uintptr(t.Src)))
+		cr |= 1 << dmaCR_DIR_Pos
+	case DMAPeripheralToMemory:
+		regs.PAR.Set(uint32(uintptr(t.Src)))
+		regs.M0AR.Set(uint32(uintptr(t.Dst)))
+	default:
+		return ErrDMAInvalid
+	}
+	ch.clearFlags()
+	regs.NDTR.Set(uint32(t.Count))
+	regs.FCR.Set(fcr)
+	regs.CR.Set(cr)
+	return nil
+}
+
+// Busy returns whether a transfer is in progress. The stream is disabled by
+// hardware once the transfer is complete.
+func (ch *DMAChannel) Busy() bool {
+	return ch.regs().CR.HasBits(dmaCR_EN)
+}
+
+// Abort stops the current transfer, if any.
+func (ch *DMAChannel) Abort() {
+	regs := ch.regs()
+	regs.CR.ClearBits(dmaCR_EN)
+	for regs.CR.HasBits(dmaCR_EN) {
+	}
+	ch.clearFlags()
+}
+
+func (ch *DMAChannel) enableInterrupt(enable bool) {
+	// The interrupt enable bits in CR are set for each transfer, see Start.
+	// Here the interrupt of this stream is registered in the NVIC.
+	if enable {
+		dmaStreamInterrupt(ch.controller, ch.stream).Enable()
+	}
+}
+
+// dmaStreamInterrupt returns the interrupt of the given DMA stream.
+func dmaStreamInterrupt(controller, stream uint8) interrupt.Interrupt {
+	switch controller<<3 | stream {
+	case 0:
+		return interrupt.New(stm32.IRQ_DMA1_Stream0, func(interrupt.Interrupt) { dmaHandleInterrupt(0, 0) })
+	case 1:
+		return interrupt.New(stm32.IRQ_DMA1_Stream1, func(interrupt.Interrupt) { dmaHandleInterrupt(0, 1) })
+	case 2:
+		return interrupt.New(stm32.IRQ_DMA1_Stream2, func(interrupt.Interrupt) { dmaHandleInterrupt(0, 2) })
+	case 3:
+		return interrupt.New(stm32.IRQ_DMA1_Stream3, func(interrupt.Interrupt) { dmaHandleInterrupt(0, 3) })
+	case 4:
+		return interrupt.New(stm32.IRQ_DMA1_Stream4, func(interrupt.Interrupt) { dmaHandleInterrupt(0, 4) })
+	case 5:
+		return interrupt.New(stm32.IRQ_DMA1_Stream5, func(interrupt.Interrupt) { dmaHandleInterrupt(0, 5) })
+	case 6:
+		return interrupt.New(stm32.IRQ_DMA1_Stream6, func(interrupt.Interrupt) { dmaHandleInterrupt(0, 6) })
+	case 7:
+		return interrupt.New(stm32.IRQ_DMA1_Stream7, func(interrupt.Interrupt) { dmaHandleInterrupt(0, 7) })
+	case 8:
+		return interrupt.New(stm32.IRQ_DMA2_Stream0, func(interrupt.Interrupt) { dmaHandleInterrupt(1, 0) })
+	case 9:
+		return interrupt.New(stm32.IRQ_DMA2_Stream1, func(interrupt.Interrupt) { dmaHandleInterrupt(1, 1) })
+	case 10:
+		return interrupt.New(stm32.IRQ_DMA2_Stream2, func(interrupt.Interrupt) { dmaHandleInterrupt(1, 2) })
+	case 11:
+		return interrupt.New(stm32.IRQ_DMA2_Stream3, func(interrupt.Interrupt) { dmaHandleInterrupt(1, 3) })
+	case 12:
+		return interrupt.New(stm32.IRQ_DMA2_Stream4, func(interrupt.Interrupt) { dmaHandleInterrupt(1, 4) })
+	case 13:
+		return interrupt.New(stm32.IRQ_DMA2_Stream5, func(interrupt.Interrupt) { dmaHandleInterrupt(1, 5) })
+	case 14:
+		return interrupt.New(stm32.IRQ_DMA2_Stream6, func(interrupt.Interrupt) { dmaHandleInterrupt(1, 6) })
+	default:
+		return interrupt.New(stm32.IRQ_DMA2_Stream7, func(interrupt.Interrupt) { dmaHandleInterrupt(1, 7) })
+	}
+}
+
+func dmaHandleInterrupt(controller, stream uint8) {
+	ch := &dmaChannelState[controller][stream]
+	ch.clearFlags()
+	if ch.callback != nil {
+		ch.callback(ch)
+	}
+}
@@ -0,0 +1,125 @@
+//go:build stm32f4
+
+package machine
+
+import (
+	"device/stm32"
+	"unsafe"
+)
+
+// Tx handles read/write operation for SPI interface. Since SPI is a synchronous write/read
+// interface, there must always be the same number of bytes written as bytes read.
+// The Tx method knows about this, and offers a few different ways of calling it.
+//
+// This form sends the bytes in tx buffer, putting the resulting bytes read into the rx buffer.
+// Note that the tx and rx buffers must be the same size:
+//
+//	spi.Tx(tx, rx)
+//
+// This form sends the tx buffer, ignoring the result. Useful for sending "commands" that return zeros
+// until all the bytes in the command packet have been received:
+//
+//	spi.Tx(tx, nil)
+//
+// This form sends zeros, putting the result into the rx buffer. Good for reading a "result packet":
+//
+//	spi.Tx(nil, rx)
+func (spi *SPI) Tx(w, r []byte) error {
+	var err error
+
+	switch {
+	case w == nil:
+		// read only, so write zero and read a result.
+		for i := range r {
+			r[i], err = spi.Transfer(0)
+			if err != nil {
+				return err
+			}
+		}
+	case r == nil:
+		// write only
+		return spi.tx(w)
+
+	default:
+		// write/read
+		if len(w) != len(r) {
+			return ErrTxInvalidSliceSize
+		}
+
+		for i, b := range w {
+			r[i], err = spi.Transfer(b)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// EnableDMA claims a DMA stream that Tx then uses to send data when the
+// received bytes are ignored, for example when sending pixel data to a
+// display. It returns ErrNoDMAChannel if no DMA stream is available for this
+// SPI peripheral.
+func (spi *SPI) EnableDMA() error {
+	if spi.dma != nil {
+		return nil
+	}
+	var request DMARequest
+	switch spi.Bus {
+	case stm32.SPI1:
+		request = DMARequestSPI1TX
+	case stm32.SPI2:
+		request = DMARequestSPI2TX
+	case stm32.SPI3:
+		request = DMARequestSPI3TX
+	default:
+		return ErrNoDMAChannel
+	}
+	ch, err := ClaimDMAChannel(request)
+	if err != nil {
+		return err
+	}
+	spi.dma = ch
+	return nil
+}
+
+// tx writes the given bytes, ignoring the received bytes. It uses DMA when
+// enabled with EnableDMA.
+func (spi *SPI) tx(tx []byte) error {
+	if spi.dma == nil || len(tx) == 0 || len(tx) > 0xffff {
+		// DMA is not enabled, so write the bytes one by one.
+		for _, b := range tx {
+			if _, err := spi.Transfer(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Let the DMA stream write a new byte each time the TX buffer is empty.
+	spi.Bus.CR2.SetBits(stm32.SPI_CR2_TXDMAEN)
+	err := spi.dma.Start(DMATransfer{
+		Direction: DMAMemoryToPeripheral,
+		Size:      DMASize8,
+		Src:       unsafe.Pointer(&tx[0]),
+		Dst:       unsafe.Pointer(&spi.Bus.DR),
+		Count:     len(tx),
+	})
+	if err == nil {
+		spi.dma.Wait()
+	}
+
+	// Wait until the last byte has been sent.
+	for !spi.Bus.SR.HasBits(stm32.SPI_SR_TXE) {
+	}
+	for spi.Bus.SR.HasBits(stm32.SPI_SR_BSY) {
+	}
+	spi.Bus.CR2.ClearBits(stm32.SPI_CR2_TXDMAEN)
+
+	// We didn't read any of the received bytes, so clear the overrun flag by
+	// reading DR followed by SR.
+	spi.Bus.DR.Get()
+	spi.Bus.SR.Get()
+	return err
+}
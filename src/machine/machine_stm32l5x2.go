@@ -6,6 +6,7 @@ package machine
 
 import (
 	"device/stm32"
+	"unsafe"
 )
 
 func CPUFrequency() uint32 {
@@ -66,3 +67,32 @@ func (i2c *I2C) getFreqRange(br uint32) uint32 {
 		return 0
 	}
 }
+
+//---------- CAN related code
+
+var (
+	CAN1 = &CAN{Bus: stm32.FDCAN1}
+)
+
+// Message RAM of FDCAN1.
+const fdcanMessageRAMAddress = 0x4000AC00
+
+func (can *CAN) messageRAM() *fdcanMessageRAM {
+	return (*fdcanMessageRAM)(unsafe.Pointer(uintptr(fdcanMessageRAMAddress)))
+}
+
+// enableClock enables the clock of this controller, and returns its frequency.
+// NOTE: keep this in sync with the runtime/runtime_stm32l5x2.go clock init code
+func (can *CAN) enableClock() uint32 {
+	// Use the PLL "Q" output as kernel clock (FDCANSEL = 1). It has the same
+	// divider as the system clock (PLL "R").
+	stm32.RCC.PLLCFGR.SetBits(stm32.RCC_PLLCFGR_PLLQEN)
+	stm32.RCC.CCIPR1.ReplaceBits(1, 3, stm32.RCC_CCIPR1_FDCANSEL_Pos)
+	enableAltFuncClock(unsafe.Pointer(can.Bus))
+	return CPUFrequency()
+}
+
+func (can *CAN) configurePins(config CANConfig) {
+	config.Tx.ConfigureAltFunc(PinConfig{Mode: PinModeCANTX}, AF9_FDCAN1_TSC)
+	config.Rx.ConfigureAltFunc(PinConfig{Mode: PinModeCANRX}, AF9_FDCAN1_TSC)
+}
@@ -0,0 +1,112 @@
+package sim
+
+// Pin is a GPIO pin number, the same as machine.Pin.
+type Pin uint8
+
+// Pin modes, the same as the ones used in the generic machine package.
+const (
+	PinInput uint8 = iota
+	PinOutput
+	PinInputPullup
+	PinInputPulldown
+)
+
+// PinEvent is a change of an output pin made by the program.
+type PinEvent struct {
+	Pin  Pin
+	High bool
+}
+
+type pinState struct {
+	mode     uint8
+	high     bool
+	onChange func(high bool)
+}
+
+var (
+	pins      map[Pin]*pinState
+	pinEvents []PinEvent
+)
+
+func getPin(pin Pin) *pinState {
+	state := pins[pin]
+	if state == nil {
+		state = &pinState{}
+		pins[pin] = state
+	}
+	return state
+}
+
+// SetPin sets the level of the pin as seen by the program, as if it is driven
+// by an external device. This is usually used for input pins.
+func SetPin(pin Pin, high bool) {
+	getPin(pin).high = high
+}
+
+// PinLevel returns the current level of the pin.
+func PinLevel(pin Pin) bool {
+	return getPin(pin).high
+}
+
+// PinMode returns the mode the pin was last configured with by the program.
+func PinMode(pin Pin) uint8 {
+	return getPin(pin).mode
+}
+
+// OnPinChange registers a function that is called each time the program sets
+// the level of this pin, for example to simulate a chip select line. Pass nil
+// to remove the callback.
+func OnPinChange(pin Pin, fn func(high bool)) {
+	getPin(pin).onChange = fn
+}
+
+// PinEvents returns all changes to output pins made by the program since the
+// last call to Reset.
+func PinEvents() []PinEvent {
+	return pinEvents
+}
+
+// pinConfig has the same layout as machine.PinConfig.
+type pinConfig struct {
+	mode uint8
+}
+
+//export __tinygo_gpio_configure
+func gpioConfigure(pin Pin, config pinConfig) {
+	state := getPin(pin)
+	state.mode = config.mode
+	switch config.mode {
+	case PinInputPullup:
+		state.high = true
+	case PinInputPulldown:
+		state.high = false
+	}
+}
+
+//export __tinygo_gpio_set
+func gpioSet(pin Pin, value bool) {
+	state := getPin(pin)
+	state.high = value
+	pinEvents = append(pinEvents, PinEvent{Pin: pin, High: value})
+	if state.onChange != nil {
+		state.onChange(value)
+	}
+}
+
+//export __tinygo_gpio_get
+func gpioGet(pin Pin) bool {
+	return getPin(pin).high
+}
+
+var adcValues map[Pin]uint16
+
+// SetADC sets the value that is returned when the program reads the ADC
+// connected to this pin.
+func SetADC(pin Pin, value uint16) {
+	adcValues[pin] = value
+}
+
+//export __tinygo_adc_read
+func adcRead(pin Pin) uint16 {
+	return adcValues[pin]
+}
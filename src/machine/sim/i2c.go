@@ -0,0 +1,136 @@
+package sim
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// ErrNACK can be returned by an I2C device to indicate that it did not
+// acknowledge the transaction. It is also the error that the program sees when
+// there is no device at the given address.
+var ErrNACK = errors.New("sim: I2C device did not acknowledge")
+
+// Error codes returned from __tinygo_i2c_tx, as expected by the machine
+// package.
+const (
+	i2cOK       = 0
+	i2cNACK     = 1
+	i2cBusError = 2
+)
+
+// I2CDevice is a simulated device on an I2C bus.
+type I2CDevice interface {
+	// Tx handles a single transaction: first w is written to the device, then
+	// len(r) bytes are read from the device into r.
+	Tx(w, r []byte) error
+}
+
+// I2CDeviceFunc is an adapter to use an ordinary function as an I2C device.
+type I2CDeviceFunc func(w, r []byte) error
+
+// Tx calls f(w, r).
+func (f I2CDeviceFunc) Tx(w, r []byte) error {
+	return f(w, r)
+}
+
+// I2CTransfer is a single I2C transaction made by the program.
+type I2CTransfer struct {
+	Bus  uint8
+	Addr uint16
+	W    []byte // bytes written to the device
+	R    []byte // bytes read from the device
+	Err  error  // error returned by the device, if any
+}
+
+type i2cAddress struct {
+	bus  uint8
+	addr uint16
+}
+
+var (
+	i2cDevices   map[i2cAddress]I2CDevice
+	i2cTransfers []I2CTransfer
+)
+
+// AttachI2C attaches a device to the given I2C bus at the given address,
+// replacing any device that was previously attached at that address. Pass nil
+// to remove the device.
+func AttachI2C(bus uint8, addr uint16, dev I2CDevice) {
+	key := i2cAddress{bus, addr}
+	if dev == nil {
+		delete(i2cDevices, key)
+		return
+	}
+	i2cDevices[key] = dev
+}
+
+// I2CTransfers returns all I2C transactions made by the program since the last
+// call to Reset.
+func I2CTransfers() []I2CTransfer {
+	return i2cTransfers
+}
+
+// Registers is a simulated I2C device with 8-bit register addresses, like many
+// sensors. The first byte that is written selects a register, the remaining
+// bytes are written to consecutive registers. Reads return the contents of
+// consecutive registers starting at the selected register.
+type Registers struct {
+	Data [256]byte
+
+	// Written is called (if set) after the program wrote to a register.
+	Written func(reg uint8, value byte)
+
+	reg uint8
+}
+
+// Tx implements I2CDevice.
+func (d *Registers) Tx(w, r []byte) error {
+	if len(w) != 0 {
+		d.reg = w[0]
+		for _, value := range w[1:] {
+			d.Data[d.reg] = value
+			if d.Written != nil {
+				d.Written(d.reg, value)
+			}
+			d.reg++
+		}
+	}
+	for i := range r {
+		r[i] = d.Data[d.reg]
+		d.reg++
+	}
+	return nil
+}
+
+//export __tinygo_i2c_configure
+func i2cConfigure(bus uint8, scl Pin, sda Pin) {
+}
+
+//export __tinygo_i2c_set_baud_rate
+func i2cSetBaudRate(bus uint8, br uint32) {
+}
+
+//export __tinygo_i2c_tx
+func i2cTx(bus uint8, addr uint16, w *byte, wlen int, r *byte, rlen int) int {
+	wbuf := unsafe.Slice(w, wlen)
+	rbuf := unsafe.Slice(r, rlen)
+	err := ErrNACK
+	if dev := i2cDevices[i2cAddress{bus, addr}]; dev != nil {
+		err = dev.Tx(wbuf, rbuf)
+	}
+	i2cTransfers = append(i2cTransfers, I2CTransfer{
+		Bus:  bus,
+		Addr: addr,
+		W:    append([]byte(nil), wbuf...),
+		R:    append([]byte(nil), rbuf...),
+		Err:  err,
+	})
+	switch {
+	case err == nil:
+		return i2cOK
+	case errors.Is(err, ErrNACK):
+		return i2cNACK
+	default:
+		return i2cBusError
+	}
+}
@@ -0,0 +1,43 @@
+// Package sim simulates the peripherals of the generic machine package, so that
+// programs and drivers that use the machine package can run and be tested on a
+// host system (for example with tinygo test on Linux or WASI) without real
+// hardware.
+//
+// The machine package calls out to a number of hooks (__tinygo_gpio_set,
+// __tinygo_i2c_tx, etc). This package implements these hooks and is
+// linked in automatically on host systems. Tests can import this package to
+// attach simulated devices and to inspect the traffic on the simulated buses:
+//
+//	func TestSensor(t *testing.T) {
+//		sim.Reset()
+//		dev := &sim.Registers{}
+//		dev.Data[0x0F] = 0x33 // WHO_AM_I
+//		sim.AttachI2C(0, 0x19, dev)
+//
+//		// ... run the driver using machine.I2C0 ...
+//
+//		for _, tx := range sim.I2CTransfers() {
+//			t.Logf("addr=%#x w=%x r=%x", tx.Addr, tx.W, tx.R)
+//		}
+//	}
+//
+// The simulator is not safe for concurrent use from multiple threads, but may
+// be used from multiple goroutines as they are scheduled cooperatively.
+package sim
+
+// Reset removes all attached devices and clears all pin state and recorded
+// traffic. It is typically called at the start of each test.
+func Reset() {
+	pins = map[Pin]*pinState{}
+	pinEvents = nil
+	adcValues = map[Pin]uint16{}
+	i2cDevices = map[i2cAddress]I2CDevice{}
+	i2cTransfers = nil
+	spiDevices = map[uint8]SPIDevice{}
+	spiTransfers = nil
+	uarts = map[uint8]*uartState{}
+}
+
+func init() {
+	Reset()
+}
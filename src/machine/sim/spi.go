@@ -0,0 +1,92 @@
+package sim
+
+import "unsafe"
+
+// SPIDevice is a simulated device on a SPI bus. Chip select lines are
+// ordinary GPIO pins, see OnPinChange.
+type SPIDevice interface {
+	// Transfer receives a single byte from the program, and returns the byte
+	// that is sent back at the same time.
+	Transfer(w byte) byte
+}
+
+// SPIDeviceFunc is an adapter to use an ordinary function as a SPI device.
+type SPIDeviceFunc func(w byte) byte
+
+// Transfer calls f(w).
+func (f SPIDeviceFunc) Transfer(w byte) byte {
+	return f(w)
+}
+
+// SPITransfer is a single SPI transfer made by the program, which may consist
+// of multiple bytes.
+type SPITransfer struct {
+	Bus uint8
+	W   []byte // bytes sent by the program
+	R   []byte // bytes sent back by the device
+}
+
+var (
+	spiDevices   map[uint8]SPIDevice
+	spiTransfers []SPITransfer
+)
+
+// AttachSPI attaches a device to the given SPI bus, replacing any device that
+// was previously attached. Pass nil to remove the device. When no device is
+// attached, the program reads zeroes.
+func AttachSPI(bus uint8, dev SPIDevice) {
+	if dev == nil {
+		delete(spiDevices, bus)
+		return
+	}
+	spiDevices[bus] = dev
+}
+
+// SPITransfers returns all SPI transfers made by the program since the last
+// call to Reset.
+func SPITransfers() []SPITransfer {
+	return spiTransfers
+}
+
+func spiTransferBytes(bus uint8, w, r []byte) {
+	dev := spiDevices[bus]
+	for i := range w {
+		if dev != nil {
+			r[i] = dev.Transfer(w[i])
+		} else {
+			r[i] = 0
+		}
+	}
+	spiTransfers = append(spiTransfers, SPITransfer{
+		Bus: bus,
+		W:   append([]byte(nil), w...),
+		R:   append([]byte(nil), r...),
+	})
+}
+
+//export __tinygo_spi_configure
+func spiConfigure(bus uint8, sck Pin, SDO Pin, SDI Pin) {
+}
+
+//export __tinygo_spi_transfer
+func spiTransfer(bus uint8, w uint8) uint8 {
+	var r [1]byte
+	spiTransferBytes(bus, []byte{w}, r[:])
+	return r[0]
+}
+
+//export __tinygo_spi_tx
+func spiTX(bus uint8, wptr *byte, wlen int, rptr *byte, rlen int) uint8 {
+	// Either buffer may be nil, in which case zeroes are sent or the result is
+	// discarded.
+	n := wlen
+	if rlen > n {
+		n = rlen
+	}
+	w := make([]byte, n)
+	r := make([]byte, n)
+	copy(w, unsafe.Slice(wptr, wlen))
+	spiTransferBytes(bus, w, r)
+	copy(unsafe.Slice(rptr, rlen), r)
+	return 0
+}
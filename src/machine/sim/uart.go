@@ -0,0 +1,68 @@
+package sim
+
+import "unsafe"
+
+type uartState struct {
+	rx       []byte // bytes waiting to be read by the program
+	tx       []byte // bytes written by the program
+	loopback bool
+}
+
+var uarts map[uint8]*uartState
+
+func getUART(bus uint8) *uartState {
+	state := uarts[bus]
+	if state == nil {
+		state = &uartState{}
+		uarts[bus] = state
+	}
+	return state
+}
+
+// WriteUART queues data to be read by the program from the given UART, as if
+// it was sent by an external device.
+func WriteUART(bus uint8, data []byte) {
+	state := getUART(bus)
+	state.rx = append(state.rx, data...)
+}
+
+// UARTOutput returns all data written by the program to the given UART since
+// the last call to Reset.
+func UARTOutput(bus uint8) []byte {
+	return getUART(bus).tx
+}
+
+// SetUARTLoopback enables or disables loopback mode for the given UART. In
+// loopback mode, all data written by the program can be read back from the
+// same UART, as if TX and RX were connected.
+func SetUARTLoopback(bus uint8, loopback bool) {
+	getUART(bus).loopback = loopback
+}
+
+//export __tinygo_uart_configure
+func uartConfigure(bus uint8, tx Pin, rx Pin) {
+}
+
+//export __tinygo_uart_read
+func uartRead(bus uint8, buf *byte, bufLen int) int {
+	state := getUART(bus)
+	n := copy(unsafe.Slice(buf, bufLen), state.rx)
+	state.rx = state.rx[n:]
+	return n
+}
+
+//export __tinygo_uart_write
+func uartWrite(bus uint8, buf *byte, bufLen int) int {
+	state := getUART(bus)
+	data := unsafe.Slice(buf, bufLen)
+	state.tx = append(state.tx, data...)
+	if state.loopback {
+		state.rx = append(state.rx, data...)
+	}
+	return bufLen
+}
+
+//export __tinygo_uart_buffered
+func uartBuffered(bus uint8) int {
+	return len(getUART(bus).rx)
+}
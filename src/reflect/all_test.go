@@ -10,6 +10,7 @@ import (
 	"flag"
 	"fmt"
 	"go/token"
+	"internal/reflectlite"
 	"io"
 	"math"
 	"math/rand"
@@ -1698,8 +1699,6 @@ func TestNilMap(t *testing.T) {
 	mv.SetMapIndex(ValueOf("hi"), Value{})
 }
 
-/* // TODO(tinygo): missing chan reflect support
-
 func TestChan(t *testing.T) {
 	for loop := 0; loop < 2; loop++ {
 		var c chan int
@@ -2168,8 +2167,6 @@ func fmtSelect(info []caseInfo) string {
 	return buf.String()
 }
 
-// TODO(tinygo): missing func/method/call support
-
 type two [2]uintptr
 
 // Difficult test for function call because of
@@ -2260,6 +2257,8 @@ func TestCallWithStruct(t *testing.T) {
 	}
 }
 
+/* // TODO(tinygo): missing finalizer support
+
 func TestCallReturnsEmpty(t *testing.T) {
 	// Issue 21717: past-the-end pointer write in Call with
 	// nonzero-sized frame and zero-sized return value.
@@ -2284,7 +2283,18 @@ func TestCallReturnsEmpty(t *testing.T) {
 	runtime.KeepAlive(v)
 }
 
+*/
+
+// skipIfNoMakeFunc skips the test on architectures where MakeFunc is not
+// implemented in TinyGo.
+func skipIfNoMakeFunc(t *testing.T) {
+	if !reflectlite.MakeFuncSupported {
+		t.Skip("MakeFunc is not supported on " + runtime.GOARCH)
+	}
+}
+
 func TestMakeFunc(t *testing.T) {
+	skipIfNoMakeFunc(t)
 	f := dummy
 	fv := MakeFunc(TypeOf(f), func(in []Value) []Value { return in })
 	ValueOf(&f).Elem().Set(fv)
@@ -2303,6 +2313,7 @@ func TestMakeFunc(t *testing.T) {
 }
 
 func TestMakeFuncInterface(t *testing.T) {
+	skipIfNoMakeFunc(t)
 	fn := func(i int) int { return i }
 	incr := func(in []Value) []Value {
 		return []Value{ValueOf(int(in[0].Int() + 1))}
@@ -2321,6 +2332,7 @@ func TestMakeFuncInterface(t *testing.T) {
 }
 
 func TestMakeFuncVariadic(t *testing.T) {
+	skipIfNoMakeFunc(t)
 	// Test that variadic arguments are packed into a slice and passed as last arg
 	fn := func(_ int, is ...int) []int { return nil }
 	fv := MakeFunc(TypeOf(fn), func(in []Value) []Value { return in[1:2] })
@@ -2370,6 +2382,7 @@ func (w *WC) Close() error {
 }
 
 func TestMakeFuncValidReturnAssignments(t *testing.T) {
+	skipIfNoMakeFunc(t)
 	// reflect.Values returned from the wrapped function should be assignment-converted
 	// to the types returned by the result of MakeFunc.
 
@@ -2443,8 +2456,6 @@ func TestMakeFuncInvalidReturnAssignments(t *testing.T) {
 	})
 }
 
-*/
-
 type Point struct {
 	x, y int
 }
@@ -2493,8 +2504,6 @@ func (p *Point) Int32Method(x int32) int32 {
 	return x
 }
 
-/*
-// TODO(tinygo): missing method support
 func TestMethod(t *testing.T) {
 	// Non-curried method of type.
 	p := Point{3, 4}
@@ -2951,8 +2960,6 @@ func TestInterfaceSet(t *testing.T) {
 	}
 }
 
-*/
-
 type T1 struct {
 	a string
 	int
@@ -4971,8 +4978,6 @@ func TestTypeOverflow(t *testing.T) {
 	}
 }
 
-/*
-
 func checkSameType(t *testing.T, x Type, y any) {
 	if x != TypeOf(y) || TypeOf(Zero(x).Interface()) != TypeOf(y) {
 		t.Errorf("did not find preexisting type for %s (vs %s)", TypeOf(x), TypeOf(y))
@@ -5179,6 +5184,8 @@ func TestArrayOfGenericAlg(t *testing.T) {
 	}
 }
 
+/* // TODO(tinygo): missing InterfaceData support
+
 func TestArrayOfDirectIface(t *testing.T) {
 	{
 		type T [1]*byte
@@ -5218,6 +5225,8 @@ func TestArrayOfDirectIface(t *testing.T) {
 	}
 }
 
+*/
+
 // Ensure passing in negative lengths panics.
 // See https://golang.org/issue/43603
 func TestArrayOfPanicOnNegativeLength(t *testing.T) {
@@ -5813,6 +5822,8 @@ func TestStructOfGenericAlg(t *testing.T) {
 	}
 }
 
+/* // TODO(tinygo): missing InterfaceData support and methods of embedded fields
+
 func TestStructOfDirectIface(t *testing.T) {
 	{
 		type T struct{ X [1]*byte }
@@ -6081,6 +6092,8 @@ func TestStructOfTooManyFields(t *testing.T) {
 	}
 }
 
+*/
+
 func TestStructOfDifferentPkgPath(t *testing.T) {
 	fields := []StructField{
 		{
@@ -6099,6 +6112,8 @@ func TestStructOfDifferentPkgPath(t *testing.T) {
 	})
 }
 
+/* // TODO(tinygo): missing support for structs larger than 4GB
+
 func TestStructOfTooLarge(t *testing.T) {
 	t1 := TypeOf(byte(0))
 	t2 := TypeOf(int16(0))
@@ -6180,6 +6195,8 @@ func TestStructOfTooLarge(t *testing.T) {
 	}
 }
 
+*/
+
 func TestChanOf(t *testing.T) {
 	// check construction and use of type not in binary
 	type T string
@@ -6379,6 +6396,8 @@ func TestMapOfGCValues(t *testing.T) {
 	}
 }
 
+/* // TODO(tinygo): missing TypeLinks
+
 func TestTypelinksSorted(t *testing.T) {
 	var last string
 	for i, n := range TypeLinks() {
@@ -6389,7 +6408,11 @@ func TestTypelinksSorted(t *testing.T) {
 	}
 }
 
+*/
+
 func TestFuncOf(t *testing.T) {
+	skipIfNoMakeFunc(t)
+
 	// check construction and use of type not in binary
 	type K string
 	type V float64
@@ -6447,8 +6470,6 @@ func TestFuncOf(t *testing.T) {
 	FuncOf(in, nil, false)
 }
 
-*/
-
 type R0 struct {
 	*R1
 	*R2
@@ -6549,6 +6570,8 @@ func TestAllocsInterfaceSmall(t *testing.T) {
 	}
 }
 
+*/
+
 // An exhaustive is a mechanism for writing exhaustive or stochastic tests.
 // The basic usage is:
 //
@@ -6639,6 +6662,8 @@ func (x *exhaustive) Maybe() bool {
 	return x.Choose(2) == 1
 }
 
+/*
+
 func GCFunc(args []Value) []Value {
 	runtime.GC()
 	return []Value{}
@@ -160,36 +160,36 @@ func TestValueSeq(t *testing.T) {
 		// 		t.Fatalf("should loop three times")
 		// 	}
 		// }},
-		// {"func", ValueOf(func(yield func(int) bool) {
-		// 	for i := range 4 {
-		// 		if !yield(i) {
-		// 			return
-		// 		}
-		// 	}
-		// }), func(t *testing.T, s iter.Seq[Value]) {
-		// 	i := int64(0)
-		// 	for v := range s {
-		// 		if v.Int() != i {
-		// 			t.Fatalf("got %d, want %d", v.Int(), i)
-		// 		}
-		// 		i++
-		// 	}
-		// 	if i != 4 {
-		// 		t.Fatalf("should loop four times")
-		// 	}
-		// }},
-		// {"method", ValueOf(methodIter{}).MethodByName("Seq"), func(t *testing.T, s iter.Seq[Value]) {
-		// 	i := int64(0)
-		// 	for v := range s {
-		// 		if v.Int() != i {
-		// 			t.Fatalf("got %d, want %d", v.Int(), i)
-		// 		}
-		// 		i++
-		// 	}
-		// 	if i != 4 {
-		// 		t.Fatalf("should loop four times")
-		// 	}
-		// }},
+		{"func", ValueOf(func(yield func(int) bool) {
+			for i := range 4 {
+				if !yield(i) {
+					return
+				}
+			}
+		}), func(t *testing.T, s iter.Seq[Value]) {
+			i := int64(0)
+			for v := range s {
+				if v.Int() != i {
+					t.Fatalf("got %d, want %d", v.Int(), i)
+				}
+				i++
+			}
+			if i != 4 {
+				t.Fatalf("should loop four times")
+			}
+		}},
+		{"method", ValueOf(methodIter{}).MethodByName("Seq"), func(t *testing.T, s iter.Seq[Value]) {
+			i := int64(0)
+			for v := range s {
+				if v.Int() != i {
+					t.Fatalf("got %d, want %d", v.Int(), i)
+				}
+				i++
+			}
+			if i != 4 {
+				t.Fatalf("should loop four times")
+			}
+		}},
 		{"type N int8", ValueOf(N(4)), func(t *testing.T, s iter.Seq[Value]) {
 			i := N(0)
 			for v := range s {
@@ -209,8 +209,15 @@ func TestValueSeq(t *testing.T) {
 		}},
 	}
 	for _, tc := range tests {
-		seq := tc.val.Seq()
-		tc.check(t, seq)
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.val.Kind() == Func {
+				// Seq on a func value creates the yield function with
+				// MakeFunc.
+				skipIfNoMakeFunc(t)
+			}
+			seq := tc.val.Seq()
+			tc.check(t, seq)
+		})
 	}
 }
 
@@ -308,40 +315,40 @@ func TestValueSeq2(t *testing.T) {
 				t.Fatalf("should loop four times")
 			}
 		}},
-		// {"func", ValueOf(func(f func(int, int) bool) {
-		// 	for i := range 4 {
-		// 		f(i, i+1)
-		// 	}
-		// }), func(t *testing.T, s iter.Seq2[Value, Value]) {
-		// 	i := int64(0)
-		// 	for v1, v2 := range s {
-		// 		if v1.Int() != i {
-		// 			t.Fatalf("got %d, want %d", v1.Int(), i)
-		// 		}
-		// 		i++
-		// 		if v2.Int() != i {
-		// 			t.Fatalf("got %d, want %d", v2.Int(), i)
-		// 		}
-		// 	}
-		// 	if i != 4 {
-		// 		t.Fatalf("should loop four times")
-		// 	}
-		// }},
-		// {"method", ValueOf(methodIter2{}).MethodByName("Seq2"), func(t *testing.T, s iter.Seq2[Value, Value]) {
-		// 	i := int64(0)
-		// 	for v1, v2 := range s {
-		// 		if v1.Int() != i {
-		// 			t.Fatalf("got %d, want %d", v1.Int(), i)
-		// 		}
-		// 		i++
-		// 		if v2.Int() != i {
-		// 			t.Fatalf("got %d, want %d", v2.Int(), i)
-		// 		}
-		// 	}
-		// 	if i != 4 {
-		// 		t.Fatalf("should loop four times")
-		// 	}
-		// }},
+		{"func", ValueOf(func(f func(int, int) bool) {
+			for i := range 4 {
+				f(i, i+1)
+			}
+		}), func(t *testing.T, s iter.Seq2[Value, Value]) {
+			i := int64(0)
+			for v1, v2 := range s {
+				if v1.Int() != i {
+					t.Fatalf("got %d, want %d", v1.Int(), i)
+				}
+				i++
+				if v2.Int() != i {
+					t.Fatalf("got %d, want %d", v2.Int(), i)
+				}
+			}
+			if i != 4 {
+				t.Fatalf("should loop four times")
+			}
+		}},
+		{"method", ValueOf(methodIter2{}).MethodByName("Seq2"), func(t *testing.T, s iter.Seq2[Value, Value]) {
+			i := int64(0)
+			for v1, v2 := range s {
+				if v1.Int() != i {
+					t.Fatalf("got %d, want %d", v1.Int(), i)
+				}
+				i++
+				if v2.Int() != i {
+					t.Fatalf("got %d, want %d", v2.Int(), i)
+				}
+			}
+			if i != 4 {
+				t.Fatalf("should loop four times")
+			}
+		}},
 		{"[4]N", ValueOf([4]N{0, 1, 2, 3}), func(t *testing.T, s iter.Seq2[Value, Value]) {
 			i := N(0)
 			for v1, v2 := range s {
@@ -380,37 +387,12 @@ func TestValueSeq2(t *testing.T) {
 		}},
 	}
 	for _, tc := range tests {
-		seq := tc.val.Seq2()
-		tc.check(t, seq)
-	}
-}
-
-// methodIter is a type from which we can derive a method
-// value that is an iter.Seq.
-type methodIter struct{}
-
-func (methodIter) Seq(yield func(int) bool) {
-	for i := range 4 {
-		if !yield(i) {
-			return
-		}
-	}
-}
-
-// For Type.CanSeq test.
-func (methodIter) NonSeq(yield func(int)) {}
-
-// methodIter2 is a type from which we can derive a method
-// value that is an iter.Seq2.
-type methodIter2 struct{}
-
-func (methodIter2) Seq2(yield func(int, int) bool) {
-	for i := range 4 {
-		if !yield(i, i+1) {
-			return
-		}
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.val.Kind() == Func {
+				skipIfNoMakeFunc(t)
+			}
+			seq := tc.val.Seq2()
+			tc.check(t, seq)
+		})
 	}
 }
-
-// For Type.CanSeq2 test.
-func (methodIter2) NonSeq2(yield func(int, int)) {}
@@ -1,5 +1,31 @@
 package reflect
 
+import (
+	"internal/reflectlite"
+	"unsafe"
+)
+
+// MakeFunc returns a new function of the given Type
+// that wraps the function fn. When called, that new function
+// does the following:
+//
+//   - converts its arguments to a slice of Values.
+//   - runs results := fn(args).
+//   - returns the results as a slice of Values, one per formal result.
+//
+// The implementation fn can assume that the argument Value slice
+// has the number and type of arguments given by typ.
+// If typ describes a variadic function, the final Value is itself
+// a slice representing the variadic arguments, as in the
+// body of a variadic function. The result Value slice returned by fn
+// must have the number and type of results given by typ.
+//
+// MakeFunc is only supported on amd64, arm64 and 32-bit ARM (including
+// Cortex-M).
 func MakeFunc(typ Type, fn func(args []Value) (results []Value)) Value {
-	panic("unimplemented: reflect.MakeFunc()")
+	impl := func(args []reflectlite.Value) []reflectlite.Value {
+		results := fn(*(*[]Value)(unsafe.Pointer(&args)))
+		return *(*[]reflectlite.Value)(unsafe.Pointer(&results))
+	}
+	return Value{reflectlite.MakeFunc(toRawType(typ), impl)}
 }
@@ -23,6 +23,7 @@
 //     meta         uint8
 //     nmethods     uint16
 //     elementType  *typeStruct
+//     methods      *method     // method table (only with exported methods)
 // - array types (see arrayType)
 //     meta         uint8
 //     nmethods     uint16 (0)
@@ -44,22 +45,36 @@
 //     pkgpath      *byte       // package path; null terminated
 //     numField     uint16
 //     fields       [...]structField // the remaining fields are all of type structField
-// - interface types (this is missing the interface methods):
+//     methods      *method     // method table (only with exported methods)
+// - interface types (see interfaceType):
 //     meta         uint8
+//     nmethods     uint16
 //     ptrTo        *typeStruct
-// - signature types (this is missing input and output parameters):
+//     implements   func(t *typeStruct) bool // checks for a concrete type
+//     methods      [...]imethod // name, pkgpath and signature of each method
+// - signature types (see funcType):
 //     meta         uint8
+//     numIn        uint16
 //     ptrTo        *typeStruct
+//     call         func(fn, args, results unsafe.Pointer) // call trampoline
+//     numOut       uint16
+//     variadic     bool
+//     params       [...]*typeStruct // numIn input types, then numOut output types
 // - named types
 //     meta         uint8
 //     nmethods     uint16      // number of methods
 //     ptrTo        *typeStruct
 //     elem         *typeStruct // underlying type
 //     pkgpath      *byte       // pkgpath; null terminated
+//     methods      *method     // method table (only with exported methods)
 //     name         [1]byte     // actual name; null terminated
 //
 // The type struct is essentially a union of all the above types. Which it is,
 // can be determined by looking at the meta byte.
+//
+// The call trampolines, method tables and implements functions are used to
+// implement Value.Call, Value.Method and Type.Implements. The compiler removes
+// them if they are not used.
 
 package reflect
 
@@ -364,8 +379,83 @@ func PointerTo(t Type) Type {
 	return toType(reflectlite.PointerTo(toRawType(t)))
 }
 
+// SliceOf returns the slice type with element type t.
+// For example, if t represents int, SliceOf(t) represents []int.
+func SliceOf(t Type) Type {
+	return toType(reflectlite.SliceOf(toRawType(t)))
+}
+
+// ArrayOf returns the array type with the given length and element type.
+// For example, if t represents int, ArrayOf(5, t) represents [5]int.
+//
+// If the resulting type would be larger than the available address space,
+// ArrayOf panics.
+func ArrayOf(length int, elem Type) Type {
+	return toType(reflectlite.ArrayOf(length, toRawType(elem)))
+}
+
+// ChanOf returns the channel type with the given direction and element type.
+// For example, if t represents int, ChanOf(RecvDir, t) represents <-chan int.
+func ChanOf(dir ChanDir, t Type) Type {
+	return toType(reflectlite.ChanOf(dir, toRawType(t)))
+}
+
+// MapOf returns the map type with the given key and element types.
+// For example, if k represents int and e represents string,
+// MapOf(k, e) represents map[int]string.
+//
+// If the key type is not a valid map key type (that is, if it does
+// not implement Go's == operator), MapOf panics.
+func MapOf(key, elem Type) Type {
+	return toType(reflectlite.MapOf(toRawType(key), toRawType(elem)))
+}
+
+// FuncOf returns the function type with the given argument and result types.
+// For example if k represents int and e represents string,
+// FuncOf([]Type{k}, []Type{e}, false) represents func(int) string.
+//
+// The variadic argument controls whether the function is variadic. FuncOf
+// panics if the in[len(in)-1] does not represent a slice and variadic is
+// true.
+func FuncOf(in, out []Type, variadic bool) Type {
+	rawIn := make([]reflectlite.Type, len(in))
+	for i, t := range in {
+		rawIn[i] = toRawType(t)
+	}
+	rawOut := make([]reflectlite.Type, len(out))
+	for i, t := range out {
+		rawOut[i] = toRawType(t)
+	}
+	return toType(reflectlite.FuncOf(rawIn, rawOut, variadic))
+}
+
+// StructOf returns the struct type containing fields.
+// The Offset and Index fields are ignored and computed as they would be
+// by the compiler.
+//
+// StructOf currently does not support promoted methods of embedded fields,
+// and panics if an embedded field has methods.
+func StructOf(fields []StructField) Type {
+	rawFields := make([]reflectlite.StructField, len(fields))
+	for i, f := range fields {
+		rawFields[i] = reflectlite.StructField{
+			Name:      f.Name,
+			PkgPath:   f.PkgPath,
+			Tag:       f.Tag,
+			Anonymous: f.Anonymous,
+		}
+		if f.Type != nil {
+			rawFields[i].Type = toRawType(f.Type)
+		}
+	}
+	return toType(reflectlite.StructOf(rawFields))
+}
+
 func (t *rawType) AssignableTo(u Type) bool {
-	return t.RawType.AssignableTo(&(u.(*rawType).RawType))
+	if u == nil {
+		panic("reflect: nil type passed to Type.AssignableTo")
+	}
+	return t.RawType.AssignableTo(toRawType(u))
 }
 
 func (t *rawType) CanSeq() bool {
@@ -373,9 +463,7 @@ func (t *rawType) CanSeq() bool {
 	case Int8, Int16, Int32, Int64, Int, Uint8, Uint16, Uint32, Uint64, Uint, Uintptr, Array, Slice, Chan, String, Map:
 		return true
 	case Func:
-		// TODO: implement canRangeFunc
-		// return canRangeFunc(t)
-		panic("unimplemented: (reflect.Type).CanSeq() for functions")
+		return canRangeFunc(t)
 	case Pointer:
 		return t.Elem().Kind() == Array
 	}
@@ -387,17 +475,41 @@ func (t *rawType) CanSeq2() bool {
 	case Array, Slice, String, Map:
 		return true
 	case Func:
-		// TODO: implement canRangeFunc2
-		// return canRangeFunc2(t)
-		panic("unimplemented: (reflect.Type).CanSeq2() for functions")
+		return canRangeFunc2(t)
 	case Pointer:
 		return t.Elem().Kind() == Array
 	}
 	return false
 }
 
+// canRangeFunc reports whether t is a function that can be used in a
+// range-over-func loop with a single iteration variable: func(yield func(T) bool).
+func canRangeFunc(t Type) bool {
+	return canRangeFuncN(t, 1)
+}
+
+// canRangeFunc2 reports whether t is a function that can be used in a
+// range-over-func loop with two iteration variables: func(yield func(K, V) bool).
+func canRangeFunc2(t Type) bool {
+	return canRangeFuncN(t, 2)
+}
+
+func canRangeFuncN(t Type, numIn int) bool {
+	if t.Kind() != Func || t.NumIn() != 1 || t.NumOut() != 0 {
+		return false
+	}
+	yield := t.In(0)
+	if yield.Kind() != Func || yield.NumIn() != numIn || yield.NumOut() != 1 {
+		return false
+	}
+	return yield.Out(0).Kind() == Bool
+}
+
 func (t *rawType) ConvertibleTo(u Type) bool {
-	panic("unimplemented: (reflect.Type).ConvertibleTo()")
+	if u == nil {
+		panic("reflect: nil type passed to Type.ConvertibleTo")
+	}
+	return t.RawType.ConvertibleTo(toRawType(u))
 }
 
 func (t *rawType) Elem() Type {
@@ -425,15 +537,18 @@ func (t *rawType) FieldByNameFunc(match func(string) bool) (StructField, bool) {
 }
 
 func (t *rawType) Implements(u Type) bool {
-	return t.RawType.Implements(&(u.(*rawType).RawType))
+	if u == nil {
+		panic("reflect: nil type passed to Type.Implements")
+	}
+	return t.RawType.Implements(toRawType(u))
 }
 
 func (t *rawType) In(i int) Type {
-	panic("unimplemented: (reflect.Type).In()")
+	return toType(t.RawType.In(i))
 }
 
 func (t *rawType) IsVariadic() bool {
-	panic("unimplemented: (reflect.Type).IsVariadic()")
+	return t.RawType.IsVariadic()
 }
 
 func (t *rawType) Key() Type {
@@ -441,23 +556,34 @@ func (t *rawType) Key() Type {
 }
 
 func (t *rawType) Method(i int) Method {
-	panic("unimplemented: (reflect.Type).Method()")
+	return toMethod(t.RawType.Method(i))
 }
 
 func (t *rawType) MethodByName(name string) (Method, bool) {
-	panic("unimplemented: (reflect.Type).MethodByName()")
+	m, ok := t.RawType.MethodByName(name)
+	return toMethod(m), ok
 }
 
 func (t *rawType) NumIn() int {
-	panic("unimplemented: (reflect.Type).NumIn()")
+	return t.RawType.NumIn()
 }
 
 func (t *rawType) NumOut() int {
-	panic("unimplemented: (reflect.Type).NumOut()")
+	return t.RawType.NumOut()
 }
 
 func (t *rawType) Out(i int) Type {
-	panic("unimplemented: (reflect.Type).Out()")
+	return toType(t.RawType.Out(i))
+}
+
+func toMethod(m reflectlite.Method) Method {
+	return Method{
+		Name:    m.Name,
+		PkgPath: m.PkgPath,
+		Type:    toType(m.Type),
+		Func:    Value{m.Func},
+		Index:   m.Index,
+	}
 }
 
 // A StructField describes a single field in a struct.
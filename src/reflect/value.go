@@ -155,31 +155,94 @@ func (v Value) FieldByNameFunc(match func(string) bool) Value {
 	return Value{v.Value.FieldByNameFunc(match)}
 }
 
-type SelectDir int
+// A SelectDir describes the communication direction of a select case.
+type SelectDir = reflectlite.SelectDir
 
 const (
-	_             SelectDir = iota
-	SelectSend              // case Chan <- Send
-	SelectRecv              // case <-Chan:
-	SelectDefault           // default
+	SelectSend    = reflectlite.SelectSend    // case Chan <- Send
+	SelectRecv    = reflectlite.SelectRecv    // case <-Chan:
+	SelectDefault = reflectlite.SelectDefault // default
 )
 
+// A SelectCase describes a single select operation.
+// The kind of case depends on Dir, the communication direction.
+//
+// If Dir is SelectDefault, the case represents a default case.
+// Chan and Send must be zero Values.
+//
+// If Dir is SelectSend, the case represents a send operation.
+// Normally Chan's underlying value must be a channel, and Send's underlying value must be
+// assignable to the channel's element type. As a special case, if Chan is a zero Value,
+// then the case is ignored, and the field Send will also be ignored and may be either zero
+// or non-zero.
+//
+// If Dir is SelectRecv, the case represents a receive operation.
+// Normally Chan's underlying value must be a channel and Send must be a zero Value.
+// If Chan is a zero Value, then the case is ignored, but Send must still be a zero Value.
+// When a receive operation is selected, the received Value is returned by Select.
 type SelectCase struct {
 	Dir  SelectDir // direction of case
 	Chan Value     // channel to use (for send or receive)
 	Send Value     // value to send (for send)
 }
 
+// Select executes a select operation described by the list of cases.
+// Like the Go select statement, it blocks until at least one of the cases
+// can proceed, and then executes that case. It returns the index of the chosen case
+// and, if that case was a receive operation, the value received and a
+// boolean indicating whether the value corresponds to a send on the channel
+// (as opposed to a zero value received because the channel is closed).
+// Select supports a maximum of 65536 cases.
 func Select(cases []SelectCase) (chosen int, recv Value, recvOK bool) {
-	panic("unimplemented: reflect.Select")
+	c := *(*[]reflectlite.SelectCase)(unsafe.Pointer(&cases))
+	chosen, x, recvOK := reflectlite.Select(c)
+	return chosen, Value{x}, recvOK
 }
 
+// MakeChan creates a new channel with the specified type and buffer size.
+func MakeChan(typ Type, buffer int) Value {
+	return Value{reflectlite.MakeChan(toRawType(typ), buffer)}
+}
+
+// Send sends x on the channel v.
+// It panics if v's kind is not Chan or if x's type is not the same type as v's element type.
+// As in Go, x's value must be assignable to the channel's element type.
 func (v Value) Send(x Value) {
-	panic("unimplemented: reflect.Value.Send()")
+	v.Value.Send(x.Value)
+}
+
+// TrySend attempts to send x on the channel v but will not block.
+// It panics if v's Kind is not Chan.
+// It reports whether the value was sent.
+// As in Go, x's value must be assignable to the channel's element type.
+func (v Value) TrySend(x Value) bool {
+	return v.Value.TrySend(x.Value)
+}
+
+// Recv receives and returns a value from the channel v.
+// It panics if v's Kind is not Chan.
+// The receive blocks until a value is ready.
+// The boolean value ok is true if the value x corresponds to a send
+// on the channel, false if it is a zero value received because the channel is closed.
+func (v Value) Recv() (x Value, ok bool) {
+	y, ok := v.Value.Recv()
+	return Value{y}, ok
+}
+
+// TryRecv attempts to receive a value from the channel v but will not block.
+// It panics if v's Kind is not Chan.
+// If the receive delivers a value, x is the transferred value and ok is true.
+// If the receive cannot finish without blocking, x is the zero Value and ok is false.
+// If the channel is closed, x is the zero value for the channel's element type and ok is false.
+func (v Value) TryRecv() (x Value, ok bool) {
+	y, ok := v.Value.TryRecv()
+	return Value{y}, ok
 }
 
+// Close closes the channel v.
+// It panics if v's Kind is not Chan or v is a receive-only channel.
 func (v Value) Close() {
-	panic("unimplemented: reflect.Value.Close()")
+	v.Value.Close()
 }
 
 // MakeMap creates a new map with the specified type.
@@ -194,11 +257,15 @@ func MakeMapWithSize(typ Type, n int) Value {
 }
 
 func (v Value) Call(in []Value) []Value {
-	panic("unimplemented: (reflect.Value).Call()")
+	args := *(*[]reflectlite.Value)(unsafe.Pointer(&in))
+	results := v.Value.Call(args)
+	return *(*[]Value)(unsafe.Pointer(&results))
 }
 
 func (v Value) CallSlice(in []Value) []Value {
-	panic("unimplemented: (reflect.Value).CallSlice()")
+	args := *(*[]reflectlite.Value)(unsafe.Pointer(&in))
+	results := v.Value.CallSlice(args)
+	return *(*[]Value)(unsafe.Pointer(&results))
 }
 
 func (v Value) Equal(u Value) bool {
@@ -206,15 +273,11 @@ func (v Value) Equal(u Value) bool {
 }
 
 func (v Value) Method(i int) Value {
-	panic("unimplemented: (reflect.Value).Method()")
+	return Value{v.Value.Method(i)}
 }
 
 func (v Value) MethodByName(name string) Value {
-	panic("unimplemented: (reflect.Value).MethodByName()")
-}
-
-func (v Value) Recv() (x Value, ok bool) {
-	panic("unimplemented: (reflect.Value).Recv()")
+	return Value{v.Value.MethodByName(name)}
 }
 
 func NewAt(typ Type, p unsafe.Pointer) Value {
@@ -15,6 +15,22 @@ const deferExtraRegs = 0
 
 const callInstSize = 1 // unknown and irrelevant (llvm.returnaddress doesn't work), so make something up
 
+// Throw a WebAssembly exception. Tag 0 is the C++ exception tag, which is
+// caught by the catch-all handlers that the compiler creates in functions with
+// a defer frame.
+//
+//export llvm.wasm.throw
+func wasmThrow(tag int32, param unsafe.Pointer)
+
+// Continue at the landing pad of the function with the given defer frame. This
+// is only called when recover is supported, which requires the
+// exception-handling target feature. Instead of jumping to the frame directly,
+// it throws an exception that is caught by the function that owns the frame:
+// that's always the nearest function with a defer frame on the stack.
+func tinygo_longjmp(frame *deferFrame) {
+	wasmThrow(0, unsafe.Pointer(frame))
+}
+
 //go:extern __heap_base
 var heapStartSymbol [0]byte
 
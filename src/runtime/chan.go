@@ -258,6 +258,7 @@ func chanSend(ch *channel, value unsafe.Pointer, op *channelOp) {
 	// Wait until this goroutine is resumed.
 	// It might be resumed after Unlock() and before Pause(). In that case,
 	// because we use semaphores, the Pause() will continue immediately.
+	traceGoBlock("chan send")
 	task.Pause()
 
 	// Check whether the sent happened normally (not because the channel was
@@ -334,6 +335,7 @@ func chanRecv(ch *channel, value unsafe.Pointer, op *channelOp) bool {
 	interrupt.Restore(mask)
 
 	// Wait until the goroutine is resumed.
+	traceGoBlock("chan receive")
 	task.Pause()
 
 	// Return whether the receive happened from a closed channel.
@@ -390,6 +392,18 @@ func chanClose(ch *channel) {
 	interrupt.Restore(mask)
 }
 
+// chanClosed returns whether the given (non-nil) channel has been closed. It is
+// used by the reflect package to check for a send on a closed channel
+// beforehand, because the runtime panic in trySend can't be recovered.
+func chanClosed(ch *channel) bool {
+	mask := interrupt.Disable()
+	ch.lock.Lock()
+	closed := ch.closed
+	ch.lock.Unlock()
+	interrupt.Restore(mask)
+	return closed
+}
+
 // We currently use a global select lock to avoid deadlocks while locking each
 // individual channel in the select. Without this global lock, two select
 // operations that have a different order of the same channels could end up in a
@@ -501,6 +515,7 @@ func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelO
 	unlockAllStates(states)
 	chanSelectLock.Unlock()
 	interrupt.Restore(mask)
+	traceGoBlock("select")
 	task.Pause()
 
 	// Resumed, so one channel operation must have progressed.
@@ -0,0 +1,165 @@
+package runtime
+
+// This file implements the target-independent part of the CPU profiler used by
+// runtime/pprof. Samples are stored in a fixed size hash table that is
+// allocated when profiling starts, so that adding a sample (which usually
+// happens in a signal handler) never needs to allocate memory.
+
+import "unsafe"
+
+const (
+	// Maximum number of return addresses recorded per sample. Deeper stacks
+	// are truncated.
+	cpuprofMaxDepth = 32
+
+	// Number of distinct stacks that can be recorded. Samples with a new stack
+	// that doesn't fit in the table anymore are counted as lost.
+	cpuprofBuckets = 2048
+)
+
+// A single unique stack in the CPU profile, with the number of times it was
+// seen.
+type cpuprofBucket struct {
+	count uint64
+	depth uintptr
+	stk   [cpuprofMaxDepth]uintptr
+}
+
+var (
+	cpuprofRate    int             // sampling rate in Hz, or 0 if not profiling
+	cpuprofEnabled bool            // whether samples are currently being added
+	cpuprofTable   []cpuprofBucket // allocated on the first StartCPUProfile
+	cpuprofLost    uint64          // samples that didn't fit in cpuprofTable
+)
+
+// Start or stop the CPU profiler. It returns false if profiling is not
+// supported on this system or the profiler was already in the requested state.
+//
+//go:linkname pprof_setCPUProfileRate runtime/pprof.runtime_setCPUProfileRate
+func pprof_setCPUProfileRate(hz int) bool {
+	if hz > 0 {
+		if cpuprofRate != 0 || !cpuprofSupported {
+			return false
+		}
+		if cpuprofTable == nil {
+			cpuprofTable = make([]cpuprofBucket, cpuprofBuckets)
+		} else {
+			for i := range cpuprofTable {
+				cpuprofTable[i] = cpuprofBucket{}
+			}
+		}
+		cpuprofLost = 0
+		cpuprofRate = hz
+		cpuprofEnabled = true
+		cpuprofStart(hz)
+		return true
+	}
+	if cpuprofRate == 0 {
+		return false
+	}
+	cpuprofStop()
+	cpuprofEnabled = false
+	cpuprofRate = 0
+	return true
+}
+
+// Call fn for every unique stack recorded by the CPU profiler. The stk slice is
+// only valid during the call. It returns the number of samples that were lost.
+// It must only be called while the profiler is stopped.
+//
+//go:linkname pprof_readCPUProfile runtime/pprof.runtime_readCPUProfile
+func pprof_readCPUProfile(fn func(count uint64, stk []uintptr)) (lost uint64) {
+	for i := range cpuprofTable {
+		b := &cpuprofTable[i]
+		if b.count != 0 {
+			fn(b.count, b.stk[:b.depth])
+		}
+	}
+	return cpuprofLost
+}
+
+// Add the given stack to the CPU profile. This is called from a signal
+// handler or similar context, so it must not allocate memory or block.
+func cpuprofAdd(stk []uintptr, count uint64) {
+	if !cpuprofEnabled {
+		return
+	}
+
+	// FNV-1a hash over the return addresses.
+	hash := uintptr(2166136261)
+	for _, pc := range stk {
+		hash ^= pc
+		hash *= 16777619
+	}
+
+	// Open addressing with linear probing. Stop looking after a small number
+	// of buckets, to keep the time spent in the signal handler bounded.
+	for i := uintptr(0); i < 16; i++ {
+		b := &cpuprofTable[(hash+i)%cpuprofBuckets]
+		if b.count == 0 {
+			b.depth = uintptr(copy(b.stk[:], stk))
+			b.count = count
+			return
+		}
+		if b.depth == uintptr(len(stk)) && cpuprofEqual(b.stk[:b.depth], stk) {
+			b.count += count
+			return
+		}
+	}
+	cpuprofLost += count
+}
+
+func cpuprofEqual(a, b []uintptr) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Walk the chain of frame pointers starting at fp, and store the return
+// addresses found in pcs. The chain ends at a zero frame pointer (which is how
+// goroutine and thread stacks start) or at anything that doesn't look like a
+// valid frame, so it is safe to call with a frame pointer from a signal
+// context. The sp parameter is the stack pointer of the innermost frame.
+//
+// This only works when all code is built with frame pointers (see the
+// tinygo.pprof build tag), and only on architectures where the frame record is
+// a pair of the previous frame pointer and the return address.
+func walkFramePointers(fp, sp uintptr, pcs []uintptr) int {
+	// Assume no goroutine or system stack is larger than this, to avoid
+	// following garbage frame pointers from code built without them.
+	const maxStackSize = 8 * 1024 * 1024
+
+	if !hasFramePointers {
+		return 0
+	}
+	n := 0
+	for n < len(pcs) {
+		if fp == 0 || fp < sp || fp-sp > maxStackSize || fp%unsafe.Sizeof(fp) != 0 {
+			break
+		}
+		pc := *(*uintptr)(unsafe.Pointer(fp + unsafe.Sizeof(fp)))
+		if pc == 0 {
+			break
+		}
+		pcs[n] = pc
+		n++
+		next := *(*uintptr)(unsafe.Pointer(fp))
+		if next <= fp {
+			// Stacks grow down, so the previous frame must be at a higher
+			// address.
+			break
+		}
+		fp = next
+	}
+	return n
+}
+
+// Return the frame pointer of the frameAddress call itself, so that the first
+// return address in the frame pointer chain points into the function that
+// called frameAddress.
+// This function is implemented by the compiler as a (never inlined) call to
+// the llvm.frameaddress.p0 intrinsic.
+func frameAddress() uintptr
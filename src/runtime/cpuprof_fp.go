@@ -0,0 +1,7 @@
+//go:build tinygo.pprof && (amd64 || arm64 || 386)
+
+package runtime
+
+// All code is built with frame pointers, and the frame record on this
+// architecture is a pair of the previous frame pointer and the return address.
+const hasFramePointers = true
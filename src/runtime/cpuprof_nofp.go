@@ -0,0 +1,7 @@
+//go:build !(tinygo.pprof && (amd64 || arm64 || 386))
+
+package runtime
+
+// Frame pointers are either not available or not in a layout that the runtime
+// knows how to walk. CPU profiles only contain the sampled instruction.
+const hasFramePointers = false
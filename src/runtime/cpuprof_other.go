@@ -0,0 +1,17 @@
+//go:build !darwin && !(linux && !baremetal && !wasip1 && !wasm_unknown && !wasip2 && !nintendoswitch) && !wasip1
+
+package runtime
+
+// CPU profiling is not supported on this system.
+const cpuprofSupported = false
+
+const cpuprofSchedulerHook = false
+
+func cpuprofStart(hz int) {
+}
+
+func cpuprofStop() {
+}
+
+func cpuprofTaskRan(d timeUnit) {
+}
@@ -0,0 +1,40 @@
+//go:build darwin || (linux && !baremetal && !wasip1 && !wasm_unknown && !wasip2 && !nintendoswitch)
+
+package runtime
+
+// The CPU profiler on Linux and MacOS uses setitimer with ITIMER_PROF, which
+// sends a SIGPROF signal at the given rate while the process uses CPU time.
+const cpuprofSupported = true
+
+// Samples are taken from a signal handler, not from the scheduler.
+const cpuprofSchedulerHook = false
+
+func cpuprofTaskRan(d timeUnit) {
+}
+
+//export tinygo_cpuprofile_enable
+func tinygo_cpuprofile_enable(hz uint32)
+
+//export tinygo_cpuprofile_disable
+func tinygo_cpuprofile_disable()
+
+func cpuprofStart(hz int) {
+	tinygo_cpuprofile_enable(uint32(hz))
+}
+
+func cpuprofStop() {
+	tinygo_cpuprofile_disable()
+}
+
+// Called from the SIGPROF signal handler with the program counter, frame
+// pointer and stack pointer of the interrupted code.
+//
+// void tinygo_cpuprofile_sample(uintptr_t pc, uintptr_t fp, uintptr_t sp);
+//
+//export tinygo_cpuprofile_sample
+func tinygo_cpuprofile_sample(pc, fp, sp uintptr) {
+	var stk [cpuprofMaxDepth]uintptr
+	stk[0] = pc
+	n := 1 + walkFramePointers(fp, sp, stk[1:])
+	cpuprofAdd(stk[:n], 1)
+}
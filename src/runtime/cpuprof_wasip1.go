@@ -0,0 +1,35 @@
+//go:build wasip1
+
+package runtime
+
+// WebAssembly code can't be interrupted and can't walk its own stack, so the
+// CPU profiler uses a timer hook in the scheduler instead: it measures the time
+// spent in each goroutine between two scheduling points and converts that into
+// a number of samples at the requested rate. These samples don't have a stack.
+const cpuprofSupported = true
+
+// The scheduler calls cpuprofTaskRan after each goroutine has run.
+const cpuprofSchedulerHook = true
+
+var (
+	cpuprofPeriod  timeUnit // time between two samples
+	cpuprofPending timeUnit // time not yet accounted for in a sample
+)
+
+func cpuprofStart(hz int) {
+	cpuprofPeriod = nanosecondsToTicks(1e9 / int64(hz))
+	cpuprofPending = 0
+}
+
+func cpuprofStop() {
+}
+
+// Account the given time spent running a goroutine to the CPU profile.
+func cpuprofTaskRan(d timeUnit) {
+	cpuprofPending += d
+	if cpuprofPending >= cpuprofPeriod {
+		count := cpuprofPending / cpuprofPeriod
+		cpuprofPending -= count * cpuprofPeriod
+		cpuprofAdd(nil, uint64(count))
+	}
+}
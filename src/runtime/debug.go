@@ -1,5 +1,7 @@
 package runtime
 
+import "internal/task"
+
 // NumCPU returns the number of logical CPUs usable by the current process.
 //
 // The set of available CPUs is checked by querying the operating system
@@ -14,9 +16,13 @@ func NumCgoCall() int {
 	return 0
 }
 
-// Stub for NumGoroutine, does not return the real value
+// NumGoroutine returns the number of goroutines that currently exist.
 func NumGoroutine() int {
-	return 1
+	if !hasScheduler {
+		// There is only the main goroutine.
+		return 1
+	}
+	return task.Count()
 }
 
 // Stub for Breakpoint, does not do anything.
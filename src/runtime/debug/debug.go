@@ -32,6 +32,31 @@ func Stack() []byte {
 	return nil
 }
 
+// GoroutineStack describes the stack of a single running goroutine, as
+// returned by GoroutineStacks.
+type GoroutineStack struct {
+	Name string  // function the goroutine was started with
+	Size uintptr // stack size in bytes
+	Used uintptr // maximum stack usage so far (the high-water mark) in bytes
+}
+
+// Implemented in internal/task.
+func runtime_readGoroutineStacks(fn func(name string, size, used uintptr)) bool
+
+// GoroutineStacks returns the stack size and the maximum stack usage so far of
+// every running goroutine. Stack usage is only tracked when the program is
+// built with -stack-check (which requires -scheduler=tasks), otherwise it
+// returns nil.
+//
+// This is a TinyGo extension.
+func GoroutineStacks() []GoroutineStack {
+	var stacks []GoroutineStack
+	runtime_readGoroutineStacks(func(name string, size, used uintptr) {
+		stacks = append(stacks, GoroutineStack{Name: name, Size: size, Used: used})
+	})
+	return stacks
+}
+
 // ReadBuildInfo returns the build information embedded
 // in the running binary. The information is available only
 // in binaries built with module support.
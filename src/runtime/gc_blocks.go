@@ -58,6 +58,7 @@ var (
 	gcMallocs     uint64         // total number of allocations
 	gcFrees       uint64         // total number of objects freed
 	gcFreedBlocks uint64         // total number of freed blocks
+	gcNumGC       uint32         // total number of completed GC cycles
 )
 
 // zeroSizedAlloc is just a sentinel that gets returned when allocating 0 bytes.
@@ -400,6 +401,11 @@ func alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer {
 				i.setState(blockStateTail)
 			}
 
+			if memprofSupported {
+				// Record this allocation in the heap profile.
+				memprofAlloc(thisAlloc.address(), size, returnAddress(0))
+			}
+
 			// Return a pointer to this allocation.
 			pointer := thisAlloc.pointer()
 			if preciseHeap {
@@ -455,6 +461,7 @@ func runGC() (freeBytes uintptr) {
 	if gcDebug {
 		println("running collection cycle...")
 	}
+	traceGCBegin()
 
 	// Mark phase: mark all reachable objects, recursively.
 	markStack()
@@ -495,6 +502,8 @@ func runGC() (freeBytes uintptr) {
 	// Sweep phase: free all non-marked objects and unmark marked objects for
 	// the next collection cycle.
 	freeBytes = sweep()
+	gcNumGC++
+	traceGCEnd()
 
 	// Show how much has been sweeped, for debugging.
 	if gcDebug {
@@ -669,6 +678,9 @@ func sweep() (freeBytes uintptr) {
 		switch block.state() {
 		case blockStateHead:
 			// Unmarked head. Free it, including all tail blocks following it.
+			if memprofSupported {
+				memprofFree(block.address())
+			}
 			block.markFree()
 			freeCurrentObject = true
 			gcFrees++
@@ -737,6 +749,7 @@ func ReadMemStats(m *MemStats) {
 	m.TotalAlloc = gcTotalAlloc
 	m.Mallocs = gcMallocs
 	m.Frees = gcFrees
+	m.NumGC = gcNumGC
 	m.Sys = uint64(heapEnd - heapStart)
 	m.HeapAlloc = (gcTotalBlocks - gcFreedBlocks) * uint64(bytesPerBlock)
 	m.Alloc = m.HeapAlloc
@@ -108,6 +108,7 @@ func ReadMemStats(m *MemStats) {
 	m.TotalAlloc = uint64(gcMemStats.allocd_bytes_before_gc + gcMemStats.bytes_allocd_since_gc)
 	m.Mallocs = 0 // not provided by bdwgc
 	m.Frees = 0   // not provided by bdwgc
+	m.NumGC = uint32(gcMemStats.gc_no)
 	m.Sys = uint64(gcMemStats.obtained_from_os_bytes)
 
 	gcLock.Unlock()
@@ -89,6 +89,7 @@ func ReadMemStats(m *MemStats) {
 	m.TotalAlloc = gcTotalAlloc
 	m.Mallocs = gcMallocs
 	m.Frees = gcFrees
+	m.NumGC = 0 // the GC never runs
 	m.Sys = uint64(heapEnd - heapStart)
 	// no free -- current in use heap is the total allocated
 	m.HeapAlloc = gcTotalAlloc
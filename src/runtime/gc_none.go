@@ -32,6 +32,13 @@ func SetFinalizer(obj interface{}, finalizer interface{}) {
 	// Unimplemented.
 }
 
+// ReadMemStats populates m with memory statistics.
+//
+// Nothing is ever allocated with this GC, so all statistics are zero.
+func ReadMemStats(m *MemStats) {
+	*m = MemStats{}
+}
+
 func initHeap() {
 	// Nothing to initialize.
 }
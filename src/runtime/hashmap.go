@@ -12,6 +12,10 @@ import (
 )
 
 // The underlying hashmap structure for Go.
+//
+// Maps that are never modified may be stored in read-only memory together with
+// their buckets (see transform.OptimizeMaps). Therefore, functions that read
+// from a map (hashmapGet, hashmapNext, etc) must never write to it.
 type hashmap struct {
 	buckets    unsafe.Pointer // pointer to array of buckets
 	seed       uintptr
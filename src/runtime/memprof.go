@@ -0,0 +1,13 @@
+package runtime
+
+// MemProfileRate controls the fraction of memory allocations that are recorded
+// and reported in the memory profile. The profiler aims to sample an average of
+// one allocation per MemProfileRate bytes allocated. To include every
+// allocated block in the profile, set MemProfileRate to 1. To turn off
+// profiling entirely, set MemProfileRate to 0.
+//
+// Unlike upstream Go, TinyGo only records allocations when the program is
+// built with the conservative or precise garbage collector and the
+// tinygo.pprof build tag. The default rate is much lower than in upstream Go,
+// because heaps are usually small.
+var MemProfileRate int = 4096
@@ -0,0 +1,242 @@
+//go:build (gc.conservative || gc.precise) && tinygo.pprof
+
+package runtime
+
+// This file implements the heap profiler for the block based garbage
+// collectors. Sampled allocations are recorded in a fixed size table of
+// allocation sites, and the objects themselves are tracked in a second table so
+// that the sweep phase can account for them when they are freed. Both tables
+// are statically allocated so that recording an allocation never allocates.
+
+import "unsafe"
+
+const memprofSupported = true
+
+const (
+	// Maximum number of return addresses recorded per allocation site.
+	memprofMaxDepth = 16
+
+	// Number of distinct allocation sites that can be recorded.
+	memprofBuckets = 64
+
+	// Number of sampled objects that can be alive at the same time.
+	memprofMaxLive = 256
+)
+
+// An allocation site (a unique stack) with statistics about the sampled
+// allocations made there.
+type memprofBucket struct {
+	allocs     uint64
+	frees      uint64
+	allocBytes uint64
+	freeBytes  uint64
+	depth      uintptr
+	stk        [memprofMaxDepth]uintptr
+}
+
+// A sampled object that hasn't been freed yet.
+type memprofObject struct {
+	addr   uintptr // address of the first heap block, or 0 if unused
+	size   uintptr
+	bucket *memprofBucket
+}
+
+var (
+	memprofTable     [memprofBuckets]memprofBucket
+	memprofLive      [memprofMaxLive]memprofObject
+	memprofNumLive   uintptr
+	memprofNextBytes uintptr // bytes left to allocate before the next sample
+)
+
+// Record an allocation of size bytes at the given heap address. The pc is the
+// return address of runtime.alloc, which is the allocation site.
+//
+//go:noinline
+func memprofAlloc(addr, size uintptr, pc unsafe.Pointer) {
+	rate := uintptr(MemProfileRate)
+	if rate == 0 {
+		return
+	}
+	if size < memprofNextBytes {
+		memprofNextBytes -= size
+		return
+	}
+	memprofNextBytes = memprofNextSample(rate)
+
+	// Find the allocation stack.
+	var stk [memprofMaxDepth]uintptr
+	var n int
+	if hasFramePointers {
+		// Skip the return addresses into memprofAlloc and runtime.alloc.
+		var buf [memprofMaxDepth + 2]uintptr
+		fp := frameAddress()
+		n = walkFramePointers(fp, fp, buf[:])
+		if n > 2 {
+			n = copy(stk[:], buf[2:n])
+		} else {
+			n = 0
+		}
+	} else if pc != nil {
+		stk[0] = uintptr(pc)
+		n = 1
+	}
+
+	b := memprofBucketFor(stk[:n])
+	if b == nil {
+		return
+	}
+	b.allocs++
+	b.allocBytes += uint64(size)
+
+	// Remember the object, so that it can be accounted for when it is freed.
+	// When the table is full, the object will appear to be alive forever.
+	if memprofNumLive == memprofMaxLive {
+		return
+	}
+	for i := (addr / bytesPerBlock) % memprofMaxLive; ; i = (i + 1) % memprofMaxLive {
+		obj := &memprofLive[i]
+		if obj.addr == 0 {
+			*obj = memprofObject{addr: addr, size: size, bucket: b}
+			memprofNumLive++
+			return
+		}
+	}
+}
+
+// Return the number of bytes to allocate before the next sample. Like the Go
+// runtime, this is drawn from an exponential distribution with the given mean,
+// so that allocations are sampled as a Poisson process: an object of a given
+// size is sampled with probability 1-exp(-size/rate), independent of the
+// allocations around it. The pprof package relies on this to scale the
+// samples.
+func memprofNextSample(rate uintptr) uintptr {
+	if rate == 1 {
+		// Sample every allocation.
+		return 0
+	}
+	if rate > 0x7000000 {
+		// Avoid overflow, maximum allowed mean is about 112MB.
+		rate = 0x7000000
+	}
+
+	// The exponential distribution is -ln(u)*rate for a uniformly distributed
+	// u in (0, 1], computed with a fast approximation of log2.
+	const randomBitCount = 26
+	q := fastrand()%(1<<randomBitCount) + 1
+	qlog := memprofLog2(float64(q)) - randomBitCount
+	if qlog > 0 {
+		qlog = 0
+	}
+	const minusLog2 = -0.6931471805599453 // -ln(2)
+	return uintptr(qlog*(minusLog2*float64(rate))) + 1
+}
+
+// memprofLog2 is a fast approximation of log2(x) for x > 0, by linear
+// interpolation in a table of log2 values of the mantissa.
+func memprofLog2(x float64) float64 {
+	const scaleBits = 20
+	const scaleRatio = 1.0 / (1 << scaleBits)
+	bits := float64bits(x)
+	exp := int64((bits>>52)&0x7ff) - 1023
+	index := (bits >> (52 - memprofLog2Bits)) % (1 << memprofLog2Bits)
+	scale := (bits >> (52 - memprofLog2Bits - scaleBits)) % (1 << scaleBits)
+	low, high := memprofLog2Table[index], memprofLog2Table[index+1]
+	return float64(exp) + low + (high-low)*float64(scale)*scaleRatio
+}
+
+const memprofLog2Bits = 5
+
+// memprofLog2Table[i] = log2(1 + i/32)
+var memprofLog2Table = [1<<memprofLog2Bits + 1]float64{
+	0.0, 0.044394119358453436, 0.0874628412503394, 0.12928301694496647,
+	0.16992500144231237, 0.20945336562894978, 0.2479275134435855, 0.28540221886224837,
+	0.32192809488736235, 0.3575520046180837, 0.3923174227787603, 0.42626475470209796,
+	0.45943161863729726, 0.4918530963296747, 0.5235619560570128, 0.5545888516776374,
+	0.5849625007211562, 0.6147098441152082, 0.6438561897747247, 0.6724253419714956,
+	0.7004397181410922, 0.7279204545631992, 0.7548875021634686, 0.7813597135246596,
+	0.8073549220576041, 0.8328900141647416, 0.8579809951275721, 0.8826430493618412,
+	0.9068905956085185, 0.9307373375628862, 0.9541963103868752, 0.9772799234999164,
+	1.0,
+}
+
+// Return the bucket for the given stack, or nil if the table is full.
+func memprofBucketFor(stk []uintptr) *memprofBucket {
+	// FNV-1a hash over the return addresses.
+	hash := uintptr(2166136261)
+	for _, pc := range stk {
+		hash ^= pc
+		hash *= 16777619
+	}
+	for i := uintptr(0); i < memprofBuckets; i++ {
+		b := &memprofTable[(hash+i)%memprofBuckets]
+		if b.allocs == 0 {
+			b.depth = uintptr(copy(b.stk[:], stk))
+			return b
+		}
+		if b.depth == uintptr(len(stk)) && cpuprofEqual(b.stk[:b.depth], stk) {
+			return b
+		}
+	}
+	return nil
+}
+
+// Record that the object at the given heap address was freed by the garbage
+// collector. This is called for every freed object, so it returns quickly when
+// there are no sampled objects.
+func memprofFree(addr uintptr) {
+	if memprofNumLive == 0 {
+		return
+	}
+	for i, n := (addr/bytesPerBlock)%memprofMaxLive, 0; n < memprofMaxLive; i, n = (i+1)%memprofMaxLive, n+1 {
+		obj := &memprofLive[i]
+		if obj.addr == addr {
+			obj.bucket.frees++
+			obj.bucket.freeBytes += uint64(obj.size)
+			memprofNumLive--
+			memprofRemove(i)
+			return
+		}
+		if obj.addr == 0 {
+			// Not a sampled object.
+			return
+		}
+	}
+}
+
+// Remove the object at index i from the memprofLive hash table, moving objects
+// that were placed after it back into place (backward shift deletion), so that
+// lookups stop at the first empty slot.
+func memprofRemove(i uintptr) {
+	for {
+		memprofLive[i] = memprofObject{}
+		j := i
+		for {
+			j = (j + 1) % memprofMaxLive
+			obj := &memprofLive[j]
+			if obj.addr == 0 {
+				return
+			}
+			home := (obj.addr / bytesPerBlock) % memprofMaxLive
+			// Move the object to slot i if its home slot is not in the
+			// (cyclic) range (i, j].
+			if (i < j && (home <= i || home > j)) || (i > j && home <= i && home > j) {
+				memprofLive[i] = *obj
+				i = j
+				break
+			}
+		}
+	}
+}
+
+// Call fn for every allocation site recorded by the heap profiler.
+//
+//go:linkname pprof_readMemProfile runtime/pprof.runtime_readMemProfile
+func pprof_readMemProfile(fn func(allocs, frees, allocBytes, freeBytes uint64, stk []uintptr)) bool {
+	for i := range memprofTable {
+		b := &memprofTable[i]
+		if b.allocs != 0 {
+			fn(b.allocs, b.frees, b.allocBytes, b.freeBytes, b.stk[:b.depth])
+		}
+	}
+	return true
+}
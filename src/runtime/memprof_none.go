@@ -0,0 +1,19 @@
+//go:build !((gc.conservative || gc.precise) && tinygo.pprof)
+
+package runtime
+
+import "unsafe"
+
+// Allocations are not recorded in this build.
+const memprofSupported = false
+
+func memprofAlloc(addr, size uintptr, pc unsafe.Pointer) {
+}
+
+func memprofFree(addr uintptr) {
+}
+
+//go:linkname pprof_readMemProfile runtime/pprof.runtime_readMemProfile
+func pprof_readMemProfile(fn func(allocs, frees, allocBytes, freeBytes uint64, stk []uintptr)) bool {
+	return false
+}
@@ -1,32 +1,253 @@
-// Package metrics is a dummy package that is not yet implemented.
-
+// Package metrics provides a stable interface to access implementation-defined
+// metrics exported by the Go runtime.
+//
+// TinyGo supports a subset of the metrics of upstream Go, see All for the list.
+// They are backed by the statistics kept by the garbage collector and the
+// scheduler, so some of them are always zero with a garbage collector that
+// doesn't track them (for example, -gc=leaking never runs a GC cycle).
 package metrics
 
-type Description struct{}
+import (
+	"math"
+	"runtime"
+	"unsafe"
+)
+
+// Description describes a runtime metric.
+type Description struct {
+	// Name is the full name of the metric which includes the unit.
+	Name string
+
+	// Description is an English language sentence describing the metric.
+	Description string
+
+	// Kind is the kind of value for this metric.
+	Kind ValueKind
+
+	// Cumulative is whether or not the metric is cumulative.
+	Cumulative bool
+}
+
+// A metric that can be read by Read, with the function that reads it from the
+// runtime statistics.
+type metric struct {
+	Description
+	read func(s *stats) uint64
+}
 
+// Runtime statistics, collected once for each call to Read.
+type stats struct {
+	mem        runtime.MemStats
+	goroutines int
+}
+
+var allMetrics = []metric{
+	{
+		Description: Description{
+			Name:        "/gc/cycles/total:gc-cycles",
+			Description: "Count of all completed GC cycles.",
+			Kind:        KindUint64,
+			Cumulative:  true,
+		},
+		read: func(s *stats) uint64 { return uint64(s.mem.NumGC) },
+	},
+	{
+		Description: Description{
+			Name:        "/gc/heap/allocs:bytes",
+			Description: "Cumulative sum of memory allocated to the heap by the application.",
+			Kind:        KindUint64,
+			Cumulative:  true,
+		},
+		read: func(s *stats) uint64 { return s.mem.TotalAlloc },
+	},
+	{
+		Description: Description{
+			Name:        "/gc/heap/allocs:objects",
+			Description: "Cumulative count of heap allocations triggered by the application.",
+			Kind:        KindUint64,
+			Cumulative:  true,
+		},
+		read: func(s *stats) uint64 { return s.mem.Mallocs },
+	},
+	{
+		Description: Description{
+			Name:        "/gc/heap/frees:objects",
+			Description: "Cumulative count of heap allocations whose storage was freed by the garbage collector.",
+			Kind:        KindUint64,
+			Cumulative:  true,
+		},
+		read: func(s *stats) uint64 { return s.mem.Frees },
+	},
+	{
+		Description: Description{
+			Name:        "/gc/heap/objects:objects",
+			Description: "Number of objects, live or unswept, occupying heap memory.",
+			Kind:        KindUint64,
+		},
+		read: func(s *stats) uint64 { return s.mem.Mallocs - s.mem.Frees },
+	},
+	{
+		Description: Description{
+			Name:        "/memory/classes/heap/free:bytes",
+			Description: "Memory that is completely free and eligible to be returned to the underlying system, but has not been.",
+			Kind:        KindUint64,
+		},
+		read: func(s *stats) uint64 { return s.mem.HeapIdle - s.mem.HeapReleased },
+	},
+	{
+		Description: Description{
+			Name:        "/memory/classes/heap/objects:bytes",
+			Description: "Memory occupied by live objects and dead objects that have not yet been marked free by the garbage collector.",
+			Kind:        KindUint64,
+		},
+		read: func(s *stats) uint64 { return s.mem.HeapAlloc },
+	},
+	{
+		Description: Description{
+			Name:        "/memory/classes/heap/released:bytes",
+			Description: "Memory that is completely free and has been returned to the underlying system.",
+			Kind:        KindUint64,
+		},
+		read: func(s *stats) uint64 { return s.mem.HeapReleased },
+	},
+	{
+		Description: Description{
+			Name:        "/memory/classes/metadata/other:bytes",
+			Description: "Memory that is reserved for or used to hold runtime metadata.",
+			Kind:        KindUint64,
+		},
+		read: func(s *stats) uint64 { return s.mem.GCSys },
+	},
+	{
+		Description: Description{
+			Name:        "/memory/classes/total:bytes",
+			Description: "All memory mapped by the Go runtime into the current process as read-write.",
+			Kind:        KindUint64,
+		},
+		read: func(s *stats) uint64 { return s.mem.Sys },
+	},
+	{
+		Description: Description{
+			Name:        "/sched/goroutines:goroutines",
+			Description: "Count of live goroutines.",
+			Kind:        KindUint64,
+		},
+		read: func(s *stats) uint64 { return uint64(s.goroutines) },
+	},
+}
+
+// All returns a slice containing metric descriptions for all supported
+// metrics.
 func All() []Description {
-	return nil
+	descs := make([]Description, len(allMetrics))
+	for i := range allMetrics {
+		descs[i] = allMetrics[i].Description
+	}
+	return descs
 }
 
-type Float64Histogram struct{}
+// Float64Histogram represents a distribution of float64 values.
+type Float64Histogram struct {
+	// Counts contains the weights for each histogram bucket.
+	Counts []uint64
 
-type Sample struct{}
+	// Buckets contains the boundaries of the histogram buckets, in increasing
+	// order.
+	Buckets []float64
+}
 
-func Read(m []Sample) {}
+// Sample captures a single metric sample.
+type Sample struct {
+	// Name is the name of the metric sampled.
+	//
+	// It must correspond to a name in one of the metric descriptions
+	// returned by All.
+	Name string
 
-type Value struct{}
+	// Value is the value of the metric sample.
+	Value Value
+}
 
-func (v Value) Float64() float64 {
-	return 0
+// Read populates each Value field in the given slice of metric samples.
+//
+// Desired metrics should be present in the slice with the appropriate name.
+// Samples with a name that isn't supported get a Value with kind KindBad.
+func Read(m []Sample) {
+	if len(m) == 0 {
+		return
+	}
+	var s stats
+	runtime.ReadMemStats(&s.mem)
+	s.goroutines = runtime.NumGoroutine()
+	for i := range m {
+		m[i].Value = Value{}
+		for j := range allMetrics {
+			if allMetrics[j].Name == m[i].Name {
+				m[i].Value = Value{
+					kind:   allMetrics[j].Kind,
+					scalar: allMetrics[j].read(&s),
+				}
+				break
+			}
+		}
+	}
 }
-func (v Value) Float64Histogram() *Float64Histogram {
-	return nil
+
+// ValueKind is a tag for a metric Value which indicates its type.
+type ValueKind int
+
+const (
+	// KindBad indicates that the Value has no type and should not be used.
+	KindBad ValueKind = iota
+
+	// KindUint64 indicates that the type of the Value is a uint64.
+	KindUint64
+
+	// KindFloat64 indicates that the type of the Value is a float64.
+	KindFloat64
+
+	// KindFloat64Histogram indicates that the type of the Value is a *Float64Histogram.
+	KindFloat64Histogram
+)
+
+// Value represents a metric value returned by the runtime.
+type Value struct {
+	kind    ValueKind
+	scalar  uint64         // contains scalar values for scalar Kinds.
+	pointer unsafe.Pointer // contains non-scalar values.
 }
+
+// Kind returns the tag representing the kind of value this is.
 func (v Value) Kind() ValueKind {
-	return ValueKind{}
+	return v.kind
 }
+
+// Uint64 returns the internal uint64 value for the metric.
+//
+// If v.Kind() != KindUint64, this method panics.
 func (v Value) Uint64() uint64 {
-	return 0
+	if v.kind != KindUint64 {
+		panic("called Uint64 on non-uint64 metric value")
+	}
+	return v.scalar
 }
 
-type ValueKind struct{}
+// Float64 returns the internal float64 value for the metric.
+//
+// If v.Kind() != KindFloat64, this method panics.
+func (v Value) Float64() float64 {
+	if v.kind != KindFloat64 {
+		panic("called Float64 on non-float64 metric value")
+	}
+	return math.Float64frombits(v.scalar)
+}
+
+// Float64Histogram returns the internal *Float64Histogram value for the metric.
+//
+// If v.Kind() != KindFloat64Histogram, this method panics.
+func (v Value) Float64Histogram() *Float64Histogram {
+	if v.kind != KindFloat64Histogram {
+		panic("called Float64Histogram on non-Float64Histogram metric value")
+	}
+	return (*Float64Histogram)(v.pointer)
+}
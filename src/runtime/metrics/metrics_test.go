@@ -0,0 +1,46 @@
+package metrics_test
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"testing"
+)
+
+var sink []byte
+
+func TestReadMetrics(t *testing.T) {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs)+1)
+	for i, desc := range descs {
+		samples[i].Name = desc.Name
+	}
+	samples[len(descs)].Name = "/does/not/exist:bytes"
+
+	for i := 0; i < 100; i++ {
+		sink = make([]byte, 100)
+	}
+	runtime.GC()
+	metrics.Read(samples)
+
+	values := map[string]uint64{}
+	for i, desc := range descs {
+		if samples[i].Value.Kind() != desc.Kind {
+			t.Errorf("%s: got kind %d, expected %d", desc.Name, samples[i].Value.Kind(), desc.Kind)
+			continue
+		}
+		values[desc.Name] = samples[i].Value.Uint64()
+	}
+	if kind := samples[len(descs)].Value.Kind(); kind != metrics.KindBad {
+		t.Errorf("unknown metric: got kind %d, expected KindBad", kind)
+	}
+
+	if values["/gc/heap/allocs:bytes"] < 100*100 {
+		t.Errorf("/gc/heap/allocs:bytes is too small: %d", values["/gc/heap/allocs:bytes"])
+	}
+	if values["/gc/cycles/total:gc-cycles"] == 0 {
+		t.Error("/gc/cycles/total:gc-cycles is zero after runtime.GC")
+	}
+	if values["/sched/goroutines:goroutines"] == 0 {
+		t.Error("/sched/goroutines:goroutines is zero")
+	}
+}
@@ -13,12 +13,6 @@ import (
 //export llvm.trap
 func trap()
 
-// Inline assembly stub. It is essentially C longjmp but modified a bit for the
-// purposes of TinyGo. It restores the stack pointer and jumps to the given pc.
-//
-//export tinygo_longjmp
-func tinygo_longjmp(frame *deferFrame)
-
 // Compiler intrinsic.
 // Returns whether recover is supported on the current architecture.
 func supportsRecover() bool
@@ -0,0 +1,9 @@
+//go:build !tinygo.wasm
+
+package runtime
+
+// Inline assembly stub. It is essentially C longjmp but modified a bit for the
+// purposes of TinyGo. It restores the stack pointer and jumps to the given pc.
+//
+//export tinygo_longjmp
+func tinygo_longjmp(frame *deferFrame)
@@ -1,44 +1,160 @@
+// Package pprof writes runtime profiling data in the format expected by the
+// pprof visualization tool.
+//
+// TinyGo supports a subset of the upstream package: a sampling CPU profiler on
+// Linux, MacOS and WASIp1. On Linux and MacOS, the CPU profile contains full
+// call stacks when the program is built with the tinygo.pprof build tag (which
+// is set automatically by 'tinygo test -cpuprofile'). Without it, only the
+// sampled instruction is recorded. On WASIp1, the profile only records how much
+// time was spent in the program as a whole.
+//
+// The heap and allocs profiles are available when the program is built with
+// the tinygo.pprof build tag and the conservative or precise garbage collector,
+// for example using '-gc=conservative -tags=tinygo.pprof'. Allocations are
+// sampled according to runtime.MemProfileRate.
+//
+// TinyGo has no symbol table at runtime, so profiles contain addresses only.
+// Pass the binary to pprof so that it can symbolize them using the DWARF debug
+// information:
+//
+//	go tool pprof <binary> <profile>
 package pprof
 
-// TinyGo does not implement pprof. However, a dummy shell is needed for the
-// testing package (and testing/internal/pprof).
-
 import (
 	"errors"
 	"io"
+	"os"
+	"sync"
+	"time"
 )
 
 var ErrUnimplemented = errors.New("runtime/pprof: unimplemented")
 
-type Profile struct{}
+// Sampling rate of the CPU profiler, in Hz.
+const cpuProfileRate = 100
+
+var cpu struct {
+	sync.Mutex
+	profiling bool
+	w         io.Writer
+	start     time.Time
+}
+
+// Implemented in the runtime.
+func runtime_setCPUProfileRate(hz int) bool
+func runtime_readCPUProfile(fn func(count uint64, stk []uintptr)) (lost uint64)
 
+// StartCPUProfile enables CPU profiling for the current process. While
+// profiling, the profile will be buffered and written to w when
+// StopCPUProfile is called.
+//
+// StartCPUProfile returns an error if profiling is already enabled or if it
+// isn't supported on this system.
 func StartCPUProfile(w io.Writer) error {
+	cpu.Lock()
+	defer cpu.Unlock()
+	if cpu.profiling {
+		return errors.New("cpu profiling already in use")
+	}
+	if !runtime_setCPUProfileRate(cpuProfileRate) {
+		return errors.New("runtime/pprof: cpu profiling not supported on this system")
+	}
+	cpu.profiling = true
+	cpu.w = w
+	cpu.start = time.Now()
 	return nil
 }
 
+// StopCPUProfile stops the current CPU profile, if any, and writes it to the
+// writer that was passed to StartCPUProfile.
 func StopCPUProfile() {
+	cpu.Lock()
+	defer cpu.Unlock()
+	if !cpu.profiling {
+		return
+	}
+	runtime_setCPUProfileRate(0)
+	cpu.profiling = false
+
+	const period = 1e9 / cpuProfileRate
+	b := newProfileBuilder(cpu.start)
+	b.sampleType("samples", "count")
+	b.sampleType("cpu", "nanoseconds")
+	lost := runtime_readCPUProfile(func(count uint64, stk []uintptr) {
+		values := []int64{int64(count), int64(count) * period}
+		if len(stk) == 0 {
+			// Samples without a stack, from the WebAssembly timer hook.
+			b.addSyntheticSample(values, noStackProfileEvent)
+		} else {
+			b.addSample(values, stk, true)
+		}
+	})
+	if lost != 0 {
+		b.addSyntheticSample([]int64{int64(lost), int64(lost) * period}, lostProfileEvent)
+	}
+	if err := b.build(cpu.w, "cpu", "nanoseconds", period); err != nil {
+		// StopCPUProfile has no way to return an error, so print it.
+		os.Stderr.WriteString("runtime/pprof: failed to write CPU profile: " + err.Error() + "\n")
+	}
+	cpu.w = nil
 }
 
+// WriteHeapProfile is shorthand for Lookup("heap").WriteTo(w, 0).
 func WriteHeapProfile(w io.Writer) error {
-	return nil
+	return writeHeap(w, 0)
 }
 
+// A Profile is a collection of stack traces showing the call sequences that
+// led to instances of a particular event, such as allocation.
+type Profile struct {
+	name  string
+	count func() int
+	write func(io.Writer, int) error
+}
+
+var heapProfile = &Profile{
+	name:  "heap",
+	count: countHeap,
+	write: writeHeap,
+}
+
+var allocsProfile = &Profile{
+	name:  "allocs",
+	count: countHeap, // identical to heap profile
+	write: writeAlloc,
+}
+
+// Lookup returns the profile with the given name, or nil if no such profile
+// exists. Only the "heap" and "allocs" profiles are supported.
 func Lookup(name string) *Profile {
+	switch name {
+	case "heap":
+		return heapProfile
+	case "allocs":
+		return allocsProfile
+	}
 	return nil
 }
 
+// Name returns this profile's name, which can be passed to Lookup to reobtain
+// the profile.
 func (p *Profile) Name() string {
-	return ""
+	return p.name
 }
 
+// Count returns the number of execution stacks currently in the profile.
 func (p *Profile) Count() int {
-	return 0
+	return p.count()
 }
 
+// WriteTo writes a pprof-formatted snapshot of the profile to w. If debug is
+// 0, the profile is written as a gzip-compressed protocol buffer. If debug is
+// nonzero, it is written in the legacy text format.
 func (p *Profile) WriteTo(w io.Writer, debug int) error {
-	return ErrUnimplemented
+	return p.write(w, debug)
 }
 
+// Profiles returns a slice of all the known profiles, sorted by name.
 func Profiles() []*Profile {
-	return nil
+	return []*Profile{allocsProfile, heapProfile}
 }
@@ -0,0 +1,80 @@
+package pprof_test
+
+import (
+	"bytes"
+	"internal/profile"
+	"runtime/pprof"
+	"testing"
+	"time"
+)
+
+//go:noinline
+func spin(d time.Duration) int {
+	n := 0
+	for start := time.Now(); time.Since(start) < d; n++ {
+	}
+	return n
+}
+
+func TestCPUProfile(t *testing.T) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		t.Skip("CPU profiling not supported:", err)
+	}
+	if err := pprof.StartCPUProfile(&buf); err == nil {
+		t.Error("expected an error when starting the CPU profiler twice")
+	}
+	spin(200 * time.Millisecond)
+	pprof.StopCPUProfile()
+
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatal("could not parse profile:", err)
+	}
+	if err := p.CheckValid(); err != nil {
+		t.Fatal("invalid profile:", err)
+	}
+	if len(p.SampleType) != 2 || p.SampleType[1].Type != "cpu" || p.Period != 1e7 {
+		t.Errorf("unexpected sample types or period: %v %d", p.SampleType, p.Period)
+	}
+	var samples int64
+	for _, s := range p.Sample {
+		samples += s.Value[0]
+		if len(s.Location) == 0 {
+			t.Error("sample without location")
+		}
+	}
+	if samples == 0 {
+		t.Error("profile contains no samples")
+	}
+}
+
+var sink []byte
+
+func TestHeapProfile(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		sink = make([]byte, 256)
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+		t.Skip("heap profiling not supported:", err)
+	}
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatal("could not parse profile:", err)
+	}
+	if err := p.CheckValid(); err != nil {
+		t.Fatal("invalid profile:", err)
+	}
+	if len(p.SampleType) != 4 || p.SampleType[1].Type != "alloc_space" {
+		t.Errorf("unexpected sample types: %v", p.SampleType)
+	}
+	var allocBytes int64
+	for _, s := range p.Sample {
+		allocBytes += s.Value[1]
+	}
+	if allocBytes == 0 {
+		t.Error("profile contains no allocations")
+	}
+}
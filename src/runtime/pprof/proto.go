@@ -0,0 +1,316 @@
+package pprof
+
+// This file contains a small builder for profiles in the gzipped protocol
+// buffer format that is understood by 'go tool pprof'. It is loosely based on
+// the profileBuilder in the upstream runtime/pprof package, but much simpler:
+// TinyGo has no symbol table at runtime, so profiles contain only addresses
+// and a list of the executable mappings of the process. They are symbolized
+// afterwards by pprof, using the DWARF debug information in the binary:
+//
+//	go tool pprof <binary> <profile>
+//
+// Samples that don't correspond to an address (such as lost samples) are
+// attributed to a synthetic function instead.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// message Profile
+	tagProfile_SampleType        = 1  // repeated ValueType
+	tagProfile_Sample            = 2  // repeated Sample
+	tagProfile_Mapping           = 3  // repeated Mapping
+	tagProfile_Location          = 4  // repeated Location
+	tagProfile_Function          = 5  // repeated Function
+	tagProfile_StringTable       = 6  // repeated string
+	tagProfile_TimeNanos         = 9  // int64
+	tagProfile_DurationNanos     = 10 // int64
+	tagProfile_PeriodType        = 11 // ValueType
+	tagProfile_Period            = 12 // int64
+	tagProfile_DefaultSampleType = 14 // int64 (string table index)
+
+	// message ValueType
+	tagValueType_Type = 1 // int64 (string table index)
+	tagValueType_Unit = 2 // int64 (string table index)
+
+	// message Sample
+	tagSample_Location = 1 // repeated uint64
+	tagSample_Value    = 2 // repeated int64
+
+	// message Mapping
+	tagMapping_ID       = 1 // uint64
+	tagMapping_Start    = 2 // uint64
+	tagMapping_Limit    = 3 // uint64
+	tagMapping_Offset   = 4 // uint64
+	tagMapping_Filename = 5 // int64 (string table index)
+
+	// message Location
+	tagLocation_ID        = 1 // uint64
+	tagLocation_MappingID = 2 // uint64
+	tagLocation_Address   = 3 // uint64
+	tagLocation_Line      = 4 // repeated Line
+
+	// message Line
+	tagLine_FunctionID = 1 // uint64
+
+	// message Function
+	tagFunction_ID         = 1 // uint64
+	tagFunction_Name       = 2 // int64 (string table index)
+	tagFunction_SystemName = 3 // int64 (string table index)
+)
+
+// Names of the synthetic functions to which lost samples and samples without a
+// stack are attributed. The names show up in the pprof graphs.
+const (
+	lostProfileEvent    = "runtime/pprof.lostProfileEvent"
+	noStackProfileEvent = "runtime/pprof.noStackProfileEvent"
+)
+
+// An executable memory mapping of the running process.
+type memMap struct {
+	start, end uint64
+	offset     uint64
+	file       string
+}
+
+// A profileBuilder collects samples and encodes them as a pprof profile.
+type profileBuilder struct {
+	start     time.Time
+	pb        protobuf
+	strings   []string
+	stringMap map[string]int
+	mem       []memMap
+	locs      map[uint64]uint64 // address to Location.ID
+	funcLocs  map[string]uint64 // synthetic function name to Location.ID
+	locOrder  []locEntry        // locations in the order they were created
+
+	// The sample type to show by default, if not the last one.
+	defaultSampleType string
+}
+
+type locEntry struct {
+	addr     uint64
+	function string // synthetic function (if addr is 0)
+}
+
+// newProfileBuilder returns a new profileBuilder for a profile that started at
+// the given time.
+func newProfileBuilder(start time.Time) *profileBuilder {
+	b := &profileBuilder{
+		start:     start,
+		strings:   []string{""},
+		stringMap: map[string]int{"": 0},
+		locs:      map[uint64]uint64{},
+		funcLocs:  map[string]uint64{},
+	}
+	b.readMapping()
+	return b
+}
+
+// stringIndex adds s to the string table if not already present and returns
+// the index of s in the string table.
+func (b *profileBuilder) stringIndex(s string) int64 {
+	id, ok := b.stringMap[s]
+	if !ok {
+		id = len(b.strings)
+		b.strings = append(b.strings, s)
+		b.stringMap[s] = id
+	}
+	return int64(id)
+}
+
+// pbValueType encodes a ValueType message to b.pb.
+func (b *profileBuilder) pbValueType(tag int, typ, unit string) {
+	start := b.pb.startMessage()
+	b.pb.int64(tagValueType_Type, b.stringIndex(typ))
+	b.pb.int64(tagValueType_Unit, b.stringIndex(unit))
+	b.pb.endMessage(tag, start)
+}
+
+// sampleType adds a sample type (a column of values) to the profile. It must
+// be called once for each value in a sample, before adding samples.
+func (b *profileBuilder) sampleType(typ, unit string) {
+	b.pbValueType(tagProfile_SampleType, typ, unit)
+}
+
+// addSample adds a single sample to the profile. The stk slice contains the
+// sampled instruction followed by return addresses if leaf is true, otherwise
+// it only contains return addresses.
+func (b *profileBuilder) addSample(values []int64, stk []uintptr, leaf bool) {
+	locs := make([]uint64, 0, len(stk))
+	for i, pc := range stk {
+		addr := uint64(pc)
+		if i > 0 || !leaf {
+			// This is a return address, which points to the instruction
+			// after the call. Use an address inside the call instruction
+			// instead, so that it is attributed to the right source line.
+			addr--
+		}
+		locs = append(locs, b.locForAddr(addr))
+	}
+	b.pbSample(values, locs)
+}
+
+// addSyntheticSample adds a sample that is attributed to a function with the
+// given name instead of an address.
+func (b *profileBuilder) addSyntheticSample(values []int64, function string) {
+	id, ok := b.funcLocs[function]
+	if !ok {
+		id = uint64(len(b.locOrder) + 1)
+		b.funcLocs[function] = id
+		b.locOrder = append(b.locOrder, locEntry{function: function})
+	}
+	b.pbSample(values, []uint64{id})
+}
+
+// pbSample encodes a Sample message to b.pb.
+func (b *profileBuilder) pbSample(values []int64, locs []uint64) {
+	start := b.pb.startMessage()
+	b.pb.int64s(tagSample_Value, values)
+	b.pb.uint64s(tagSample_Location, locs)
+	b.pb.endMessage(tagProfile_Sample, start)
+}
+
+// locForAddr returns the Location.ID for the given address.
+func (b *profileBuilder) locForAddr(addr uint64) uint64 {
+	id, ok := b.locs[addr]
+	if !ok {
+		id = uint64(len(b.locOrder) + 1)
+		b.locs[addr] = id
+		b.locOrder = append(b.locOrder, locEntry{addr: addr})
+	}
+	return id
+}
+
+// mappingForAddr returns the Mapping.ID for the given address, or 0 if it
+// isn't part of any known mapping.
+func (b *profileBuilder) mappingForAddr(addr uint64) uint64 {
+	for i, m := range b.mem {
+		if m.start <= addr && addr < m.end {
+			return uint64(i + 1)
+		}
+	}
+	return 0
+}
+
+// build writes the profile to w. The periodType, periodUnit and period describe
+// the sampling interval, or are empty and 0 when the profile isn't sampled.
+func (b *profileBuilder) build(w io.Writer, periodType, periodUnit string, period int64) error {
+	// Locations, and the functions for synthetic locations.
+	funcID := uint64(0)
+	for i, loc := range b.locOrder {
+		start := b.pb.startMessage()
+		b.pb.uint64(tagLocation_ID, uint64(i+1))
+		if loc.function == "" {
+			b.pb.uint64Opt(tagLocation_MappingID, b.mappingForAddr(loc.addr))
+			b.pb.uint64(tagLocation_Address, loc.addr)
+		} else {
+			funcID++
+			lineStart := b.pb.startMessage()
+			b.pb.uint64(tagLine_FunctionID, funcID)
+			b.pb.endMessage(tagLocation_Line, lineStart)
+		}
+		b.pb.endMessage(tagProfile_Location, start)
+	}
+	funcID = 0
+	for _, loc := range b.locOrder {
+		if loc.function == "" {
+			continue
+		}
+		funcID++
+		start := b.pb.startMessage()
+		b.pb.uint64(tagFunction_ID, funcID)
+		b.pb.int64(tagFunction_Name, b.stringIndex(loc.function))
+		b.pb.int64(tagFunction_SystemName, b.stringIndex(loc.function))
+		b.pb.endMessage(tagProfile_Function, start)
+	}
+
+	// Executable mappings, used by pprof to find the binary to symbolize
+	// addresses with.
+	for i, m := range b.mem {
+		start := b.pb.startMessage()
+		b.pb.uint64(tagMapping_ID, uint64(i+1))
+		b.pb.uint64Opt(tagMapping_Start, m.start)
+		b.pb.uint64Opt(tagMapping_Limit, m.end)
+		b.pb.uint64Opt(tagMapping_Offset, m.offset)
+		b.pb.int64Opt(tagMapping_Filename, b.stringIndex(m.file))
+		b.pb.endMessage(tagProfile_Mapping, start)
+	}
+
+	b.pb.int64Opt(tagProfile_TimeNanos, b.start.UnixNano())
+	b.pb.int64Opt(tagProfile_DurationNanos, time.Since(b.start).Nanoseconds())
+	if period != 0 {
+		b.pbValueType(tagProfile_PeriodType, periodType, periodUnit)
+		b.pb.int64Opt(tagProfile_Period, period)
+	}
+	if b.defaultSampleType != "" {
+		b.pb.int64Opt(tagProfile_DefaultSampleType, b.stringIndex(b.defaultSampleType))
+	}
+
+	// The string table must come last, as the code above may add strings.
+	b.pb.strings(tagProfile_StringTable, b.strings)
+
+	zw, _ := gzip.NewWriterLevel(w, gzip.BestSpeed)
+	if _, err := zw.Write(b.pb.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// readMapping reads the executable mappings of the current process from
+// /proc/self/maps. If that isn't possible, it adds a single mapping covering
+// the whole address space for the executable.
+func (b *profileBuilder) readMapping() {
+	data, _ := os.ReadFile("/proc/self/maps")
+	parseProcSelfMaps(data, func(lo, hi, offset uint64, file string) {
+		b.mem = append(b.mem, memMap{start: lo, end: hi, offset: offset, file: file})
+	})
+	if len(b.mem) == 0 {
+		file := ""
+		if len(os.Args) > 0 {
+			file = os.Args[0]
+		}
+		b.mem = append(b.mem, memMap{start: 0, end: ^uint64(0), file: file})
+	}
+}
+
+// parseProcSelfMaps calls addMapping for every executable mapping in the
+// given /proc/self/maps contents. The format looks like this:
+//
+//	00400000-0040b000 r-xp 00000000 fc:01 787766       /bin/cat
+//	7ffc34343000-7ffc34345000 r-xp 00000000 00:00 0    [vdso]
+func parseProcSelfMaps(data []byte, addMapping func(lo, hi, offset uint64, file string)) {
+	for len(data) > 0 {
+		var line []byte
+		line, data, _ = bytes.Cut(data, []byte("\n"))
+		fields := strings.Fields(string(line))
+		if len(fields) < 6 {
+			// No file name, so it can't be symbolized.
+			continue
+		}
+		if len(fields[1]) < 4 || fields[1][2] != 'x' {
+			// Only interested in executable mappings.
+			continue
+		}
+		loStr, hiStr, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			continue
+		}
+		lo, err1 := strconv.ParseUint(loStr, 16, 64)
+		hi, err2 := strconv.ParseUint(hiStr, 16, 64)
+		offset, err3 := strconv.ParseUint(fields[2], 16, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		file := strings.Join(fields[5:], " ")
+		file = strings.TrimSuffix(file, " (deleted)")
+		addMapping(lo, hi, offset, file)
+	}
+}
@@ -0,0 +1,131 @@
+package pprof
+
+// This file implements the heap and allocs profiles. The runtime records
+// sampled allocations (and when these objects are freed) per allocation site
+// when the program is built with the tinygo.pprof build tag and a garbage
+// collector that supports it (conservative or precise).
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"time"
+)
+
+var errHeapUnsupported = errors.New("runtime/pprof: heap profiling requires the tinygo.pprof build tag and -gc=conservative or -gc=precise")
+
+// Implemented in the runtime.
+func runtime_readMemProfile(fn func(allocs, frees, allocBytes, freeBytes uint64, stk []uintptr)) bool
+
+// A memProfileRecord describes the allocations at a single allocation site.
+type memProfileRecord struct {
+	allocs, frees         uint64
+	allocBytes, freeBytes uint64
+	stk                   []uintptr
+}
+
+// Read all allocation sites from the runtime.
+func readMemProfile() ([]memProfileRecord, bool) {
+	var records []memProfileRecord
+	ok := runtime_readMemProfile(func(allocs, frees, allocBytes, freeBytes uint64, stk []uintptr) {
+		records = append(records, memProfileRecord{
+			allocs:     allocs,
+			frees:      frees,
+			allocBytes: allocBytes,
+			freeBytes:  freeBytes,
+			stk:        append([]uintptr(nil), stk...),
+		})
+	})
+	return records, ok
+}
+
+func countHeap() int {
+	records, _ := readMemProfile()
+	return len(records)
+}
+
+// writeHeap writes the current heap profile, with inuse_space as the default
+// sample type.
+func writeHeap(w io.Writer, debug int) error {
+	return writeHeapInternal(w, debug, "")
+}
+
+// writeAlloc writes the current heap profile, with alloc_space as the default
+// sample type.
+func writeAlloc(w io.Writer, debug int) error {
+	return writeHeapInternal(w, debug, "alloc_space")
+}
+
+func writeHeapInternal(w io.Writer, debug int, defaultSampleType string) error {
+	records, ok := readMemProfile()
+	if !ok {
+		return errHeapUnsupported
+	}
+	rate := int64(runtime.MemProfileRate)
+	if debug != 0 {
+		return writeHeapText(w, records, rate)
+	}
+
+	b := newProfileBuilder(time.Now())
+	b.defaultSampleType = defaultSampleType
+	b.sampleType("alloc_objects", "count")
+	b.sampleType("alloc_space", "bytes")
+	b.sampleType("inuse_objects", "count")
+	b.sampleType("inuse_space", "bytes")
+	for _, r := range records {
+		allocs, allocBytes := scaleHeapSample(int64(r.allocs), int64(r.allocBytes), rate)
+		inuse, inuseBytes := scaleHeapSample(int64(r.allocs-r.frees), int64(r.allocBytes-r.freeBytes), rate)
+		values := []int64{allocs, allocBytes, inuse, inuseBytes}
+		if len(r.stk) == 0 {
+			// The allocation site isn't known on this architecture.
+			b.addSyntheticSample(values, noStackProfileEvent)
+		} else {
+			b.addSample(values, r.stk, false)
+		}
+	}
+	return b.build(w, "space", "bytes", rate)
+}
+
+// writeHeapText writes the heap profile in the legacy text format.
+func writeHeapText(w io.Writer, records []memProfileRecord, rate int64) error {
+	var total memProfileRecord
+	for _, r := range records {
+		total.allocs += r.allocs
+		total.frees += r.frees
+		total.allocBytes += r.allocBytes
+		total.freeBytes += r.freeBytes
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "heap profile: %d: %d [%d: %d] @ heap/%d\n",
+		total.allocs-total.frees, total.allocBytes-total.freeBytes,
+		total.allocs, total.allocBytes, 2*rate)
+	for _, r := range records {
+		fmt.Fprintf(bw, "%d: %d [%d: %d] @",
+			r.allocs-r.frees, r.allocBytes-r.freeBytes, r.allocs, r.allocBytes)
+		for _, pc := range r.stk {
+			fmt.Fprintf(bw, " %#x", pc)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// scaleHeapSample adjusts the data from a heap sample to account for its
+// probability of appearing in the collected data. Allocations are sampled
+// roughly once every rate bytes, so each sampled object of a given size stands
+// for 1/(1-exp(-size/rate)) objects.
+func scaleHeapSample(count, size, rate int64) (int64, int64) {
+	if count == 0 || size == 0 {
+		return 0, 0
+	}
+	if rate <= 1 {
+		// Nothing was sampled out, the data is exact.
+		return count, size
+	}
+	avgSize := float64(size) / float64(count)
+	scale := 1 / (1 - math.Exp(-avgSize/float64(rate)))
+	return int64(float64(count) * scale), int64(float64(size) * scale)
+}
@@ -9,17 +9,27 @@
 #include <stdint.h>
 #include <ucontext.h>
 #include <string.h>
+#include <sys/time.h>
 
 void tinygo_handle_fatal_signal(int sig, uintptr_t addr);
+void tinygo_cpuprofile_sample(uintptr_t pc, uintptr_t fp, uintptr_t sp);
 
-static void signal_handler(int sig, siginfo_t *info, void *context) {
+// Read the program counter, frame pointer and stack pointer from a signal
+// context. The frame pointer and stack pointer are set to 0 on architectures
+// where they aren't needed.
+static void context_registers(void *context, uintptr_t *pc, uintptr_t *fp, uintptr_t *sp) {
 	ucontext_t* uctx = context;
-	uintptr_t addr = 0;
+	*fp = 0;
+	*sp = 0;
 	#if __APPLE__
 		#if __arm64__
-			addr = uctx->uc_mcontext->__ss.__pc;
+			*pc = uctx->uc_mcontext->__ss.__pc;
+			*fp = uctx->uc_mcontext->__ss.__fp;
+			*sp = uctx->uc_mcontext->__ss.__sp;
 		#elif __x86_64__
-			addr = uctx->uc_mcontext->__ss.__rip;
+			*pc = uctx->uc_mcontext->__ss.__rip;
+			*fp = uctx->uc_mcontext->__ss.__rbp;
+			*sp = uctx->uc_mcontext->__ss.__rsp;
 		#else
 			#error unknown architecture
 		#endif
@@ -27,17 +37,30 @@ static void signal_handler(int sig, siginfo_t *info, void *context) {
 		// Note: this can probably be simplified using the MC_PC macro in musl,
 		// but this works for now.
 		#if __arm__
-			addr = uctx->uc_mcontext.arm_pc;
+			*pc = uctx->uc_mcontext.arm_pc;
 		#elif __i386__
-			addr = uctx->uc_mcontext.gregs[REG_EIP];
+			*pc = uctx->uc_mcontext.gregs[REG_EIP];
+			*fp = uctx->uc_mcontext.gregs[REG_EBP];
+			*sp = uctx->uc_mcontext.gregs[REG_ESP];
 		#elif __x86_64__
-			addr = uctx->uc_mcontext.gregs[REG_RIP];
-		#else // aarch64, mips, maybe others
-			addr = uctx->uc_mcontext.pc;
+			*pc = uctx->uc_mcontext.gregs[REG_RIP];
+			*fp = uctx->uc_mcontext.gregs[REG_RBP];
+			*sp = uctx->uc_mcontext.gregs[REG_RSP];
+		#elif __aarch64__
+			*pc = uctx->uc_mcontext.pc;
+			*fp = uctx->uc_mcontext.regs[29];
+			*sp = uctx->uc_mcontext.sp;
+		#else // mips, maybe others
+			*pc = uctx->uc_mcontext.pc;
 		#endif
 	#else
 		#error unknown platform
 	#endif
+}
+
+static void signal_handler(int sig, siginfo_t *info, void *context) {
+	uintptr_t addr, fp, sp;
+	context_registers(context, &addr, &fp, &sp);
 	tinygo_handle_fatal_signal(sig, addr);
 }
 
@@ -54,3 +77,39 @@ void tinygo_register_fatal_signals(void) {
 	sigaction(SIGILL, &act, NULL);
 	sigaction(SIGSEGV, &act, NULL);
 }
+
+static void cpuprofile_handler(int sig, siginfo_t *info, void *context) {
+	uintptr_t pc, fp, sp;
+	context_registers(context, &pc, &fp, &sp);
+	tinygo_cpuprofile_sample(pc, fp, sp);
+}
+
+// Start sending SIGPROF to the process hz times per second of CPU time.
+void tinygo_cpuprofile_enable(uint32_t hz) {
+	struct sigaction act = { 0 };
+	// SA_RESTART: don't interrupt system calls like read and write
+	act.sa_flags = SA_SIGINFO | SA_RESTART;
+	act.sa_sigaction = &cpuprofile_handler;
+	sigaction(SIGPROF, &act, NULL);
+
+	// The timer has microsecond resolution, and an interval of 0 would
+	// disable it.
+	if (hz > 1000000) {
+		hz = 1000000;
+	}
+	struct itimerval timer = { 0 };
+	timer.it_interval.tv_sec = 0;
+	timer.it_interval.tv_usec = 1000000 / hz;
+	timer.it_value = timer.it_interval;
+	setitimer(ITIMER_PROF, &timer, NULL);
+}
+
+// Stop the profiling timer. A signal that is still pending is ignored.
+void tinygo_cpuprofile_disable(void) {
+	struct itimerval timer = { 0 };
+	setitimer(ITIMER_PROF, &timer, NULL);
+
+	struct sigaction act = { 0 };
+	act.sa_handler = SIG_IGN;
+	sigaction(SIGPROF, &act, NULL);
+}
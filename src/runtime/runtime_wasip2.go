@@ -3,8 +3,6 @@
 package runtime
 
 import (
-	"unsafe"
-
 	"internal/wasi/cli/v0.2.0/environment"
 	wasiclirun "internal/wasi/cli/v0.2.0/run"
 	monotonicclock "internal/wasi/clocks/v0.2.0/monotonic-clock"
@@ -31,11 +29,6 @@ func os_runtime_args() []string {
 	return args
 }
 
-//export cabi_realloc
-func cabi_realloc(ptr, oldsize, align, newsize unsafe.Pointer) unsafe.Pointer {
-	return realloc(ptr, uintptr(newsize))
-}
-
 func ticksToNanoseconds(ticks timeUnit) int64 {
 	return int64(ticks)
 }
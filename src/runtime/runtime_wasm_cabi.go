@@ -0,0 +1,14 @@
+//go:build wasip2 || (tinygo.wasm && tinygo.wasmcanonicalabi)
+
+package runtime
+
+import "unsafe"
+
+// Called by the host to allocate memory for strings and lists that are passed
+// to the module using the canonical ABI: as parameters of a //go:wasmexport
+// function or as results of a //go:wasmimport function.
+//
+//export cabi_realloc
+func cabi_realloc(ptr, oldsize, align, newsize unsafe.Pointer) unsafe.Pointer {
+	return realloc(ptr, uintptr(newsize))
+}
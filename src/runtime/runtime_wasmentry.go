@@ -97,3 +97,25 @@ func wasmExportExit() {
 	// TODO: we could cache the allocated stack so we don't have to keep
 	// allocating a new stack on every //go:wasmexport call.
 }
+
+// Error returned from a //go:wasmimport function that uses the canonical ABI
+// when the imported function returned an error (as result<T, string>).
+type cabiError struct {
+	msg string
+}
+
+func (e *cabiError) Error() string {
+	return e.msg
+}
+
+// Called from a //go:wasmimport wrapper to create an error value from the
+// error message returned by the host.
+func cabiNewError(msg string) error {
+	return &cabiError{msg}
+}
+
+// Called from a //go:wasmexport wrapper to convert a non-nil error to an error
+// message that is returned to the host.
+func cabiErrorMessage(err error) string {
+	return err.Error()
+}
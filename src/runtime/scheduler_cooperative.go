@@ -44,17 +44,21 @@ var (
 //go:noinline
 func deadlock() {
 	// call yield without requesting a wakeup
+	traceGoBlock("forever")
 	task.Pause()
 	panic("unreachable")
 }
 
 // Add this task to the end of the run queue.
 func scheduleTask(t *task.Task) {
+	traceGoReady(t)
 	runqueue.Push(t)
 }
 
 func Gosched() {
-	runqueue.Push(task.Current())
+	t := task.Current()
+	traceGoReady(t)
+	runqueue.Push(t)
 	task.Pause()
 }
 
@@ -160,6 +164,7 @@ func scheduler(returnAtDeadlock bool) {
 		if sleepQueue != nil && now-sleepQueueBaseTime >= timeUnit(sleepQueue.Data) {
 			t := sleepQueue
 			scheduleLogTask("  awake:", t)
+			traceGoReady(t)
 			sleepQueueBaseTime += timeUnit(t.Data)
 			sleepQueue = t.Next
 			t.Next = nil
@@ -227,7 +232,20 @@ func scheduler(returnAtDeadlock bool) {
 
 		// Run the given task.
 		scheduleLogTask("  run:", t)
-		t.Resume()
+		if traceSupported {
+			traceGoStart(t)
+		}
+		if cpuprofSchedulerHook && cpuprofEnabled {
+			// Let the CPU profiler know how long this goroutine ran.
+			start := ticks()
+			t.Resume()
+			cpuprofTaskRan(ticks() - start)
+		} else {
+			t.Resume()
+		}
+		if traceSupported {
+			traceGoStop(t)
+		}
 	}
 }
 
@@ -240,6 +258,7 @@ func sleep(duration int64) {
 	}
 
 	addSleepTask(task.Current(), nanosecondsToTicks(duration))
+	traceGoBlock("sleep")
 	task.Pause()
 }
 
@@ -0,0 +1,212 @@
+//go:build tinygo.trace && (scheduler.tasks || scheduler.asyncify)
+
+package runtime
+
+// This file implements the runtime part of the execution tracer used by
+// runtime/trace. The runtime only records raw scheduler, GC and user events in
+// a buffer, with the task pointer as goroutine ID. The runtime/trace package
+// reads them periodically and converts them to the Go execution trace format.
+//
+// Events are recorded in a fixed size buffer that is allocated when tracing
+// starts, so that recording an event (which may happen in the scheduler or in
+// an interrupt) never needs to allocate memory. There are two buffers: one that
+// is being filled, and one that is being read by runtime/trace.
+
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"unsafe"
+)
+
+const traceSupported = true
+
+// Event kinds. These must be kept in sync with runtime/trace.
+const (
+	traceEvStart           = iota + 1 // tracing started on the current goroutine
+	traceEvGoCreate                   // new goroutine g
+	traceEvGoStart                    // goroutine g starts running
+	traceEvGoStop                     // goroutine g paused
+	traceEvGoDestroy                  // goroutine g exited
+	traceEvGoReady                    // goroutine g became runnable
+	traceEvGoBlock                    // the current goroutine is about to block, str[0] is the reason
+	traceEvGCBegin                    // GC cycle started
+	traceEvGCEnd                      // GC cycle finished
+	traceEvUserTaskBegin              // trace.NewTask: args[0] is the ID, args[1] the parent ID, str[0] the name
+	traceEvUserTaskEnd                // Task.End: args[0] is the ID
+	traceEvUserRegionBegin            // trace.StartRegion: args[0] is the task ID, str[0] the name
+	traceEvUserRegionEnd              // Region.End: args[0] is the task ID, str[0] the name
+	traceEvUserLog                    // trace.Log: args[0] is the task ID, str[0] the category, str[1] the message
+)
+
+// A single raw trace event.
+type traceEvent struct {
+	kind uint8
+	time int64
+	g    uintptr
+	args [2]uint64
+	str  [2]string
+}
+
+var (
+	traceEnabled   bool
+	traceBufs      [2][]traceEvent // allocated in trace_start
+	traceBuf       []traceEvent    // buffer that events are added to
+	traceLen       int             // number of events in traceBuf
+	traceTruncated bool            // the buffer was full, so tracing stopped
+	traceExited    bool            // the running goroutine exited
+)
+
+// Start recording trace events, in buffers of the given size. It returns false
+// if tracing is already enabled.
+//
+//go:linkname trace_start runtime/trace.runtime_start
+func trace_start(bufSize int) bool {
+	if traceEnabled {
+		return false
+	}
+	traceBufs[0] = make([]traceEvent, bufSize)
+	traceBufs[1] = make([]traceEvent, bufSize)
+	traceBuf = traceBufs[0]
+	traceLen = 0
+	traceTruncated = false
+	traceEnabled = true
+	traceRecord(traceEvStart, uintptr(unsafe.Pointer(task.Current())), 0, 0, "", "")
+	return true
+}
+
+// Stop recording trace events. Events that were already recorded can still be
+// read using trace_read.
+//
+//go:linkname trace_stop runtime/trace.runtime_stop
+func trace_stop() {
+	traceEnabled = false
+}
+
+// Call fn for every event that was recorded since the last call, and clear
+// them. It returns true if tracing was stopped since the last call because the
+// buffer was full.
+//
+//go:linkname trace_read runtime/trace.runtime_read
+func trace_read(fn func(kind uint8, time int64, g uintptr, args [2]uint64, str [2]string)) (truncated bool) {
+	if traceBuf == nil {
+		return false
+	}
+
+	// Swap buffers, so that new events can be recorded while calling fn.
+	mask := interrupt.Disable()
+	buf := traceBuf[:traceLen]
+	truncated = traceTruncated
+	if &traceBuf[0] == &traceBufs[0][0] {
+		traceBuf = traceBufs[1]
+	} else {
+		traceBuf = traceBufs[0]
+	}
+	traceLen = 0
+	traceTruncated = false
+	interrupt.Restore(mask)
+
+	for i := range buf {
+		ev := &buf[i]
+		fn(ev.kind, ev.time, ev.g, ev.args, ev.str)
+		*ev = traceEvent{} // don't keep strings alive
+	}
+	if !traceEnabled {
+		// Tracing has stopped and all events have been read, so the buffers
+		// aren't needed anymore.
+		traceBufs = [2][]traceEvent{}
+		traceBuf = nil
+	}
+	return truncated
+}
+
+// Record a user annotation (task, region or log message) on the current
+// goroutine.
+//
+//go:linkname trace_userEvent runtime/trace.runtime_userEvent
+func trace_userEvent(kind uint8, arg0, arg1 uint64, str0, str1 string) {
+	if !traceEnabled {
+		return
+	}
+	traceRecord(kind, uintptr(unsafe.Pointer(task.Current())), arg0, arg1, str0, str1)
+}
+
+// Add a single event to the trace buffer.
+func traceRecord(kind uint8, g uintptr, arg0, arg1 uint64, str0, str1 string) {
+	mask := interrupt.Disable()
+	if traceLen == len(traceBuf) {
+		// The buffer is full. Stop tracing, so that the trace stays consistent
+		// (instead of missing random events).
+		traceTruncated = true
+		traceEnabled = false
+	} else {
+		traceBuf[traceLen] = traceEvent{
+			kind: kind,
+			time: nanotime(),
+			g:    g,
+			args: [2]uint64{arg0, arg1},
+			str:  [2]string{str0, str1},
+		}
+		traceLen++
+	}
+	interrupt.Restore(mask)
+}
+
+// Called by internal/task when a new goroutine is started.
+func traceGoCreate(t *task.Task) {
+	if traceEnabled {
+		traceRecord(traceEvGoCreate, uintptr(unsafe.Pointer(t)), 0, 0, "", "")
+	}
+}
+
+// Called by the scheduler just before a goroutine is resumed.
+func traceGoStart(t *task.Task) {
+	traceExited = false
+	if traceEnabled {
+		traceRecord(traceEvGoStart, uintptr(unsafe.Pointer(t)), 0, 0, "", "")
+	}
+}
+
+// Called by the scheduler when a goroutine paused or exited. Exits have
+// already been recorded by traceGoDestroy.
+func traceGoStop(t *task.Task) {
+	if traceEnabled && !traceExited {
+		traceRecord(traceEvGoStop, uintptr(unsafe.Pointer(t)), 0, 0, "", "")
+	}
+}
+
+// Called by internal/task when the running goroutine exits.
+func traceGoDestroy(t *task.Task) {
+	traceExited = true
+	if traceEnabled {
+		traceRecord(traceEvGoDestroy, uintptr(unsafe.Pointer(t)), 0, 0, "", "")
+	}
+}
+
+// Called when a goroutine is added to the runqueue.
+func traceGoReady(t *task.Task) {
+	if traceEnabled {
+		traceRecord(traceEvGoReady, uintptr(unsafe.Pointer(t)), 0, 0, "", "")
+	}
+}
+
+// Called just before the current goroutine pauses to wait for something. The
+// reason is shown in the trace.
+func traceGoBlock(reason string) {
+	if traceEnabled {
+		traceRecord(traceEvGoBlock, uintptr(unsafe.Pointer(task.Current())), 0, 0, reason, "")
+	}
+}
+
+// Called when a GC cycle starts.
+func traceGCBegin() {
+	if traceEnabled {
+		traceRecord(traceEvGCBegin, uintptr(unsafe.Pointer(task.Current())), 0, 0, "", "")
+	}
+}
+
+// Called when a GC cycle has finished.
+func traceGCEnd() {
+	if traceEnabled {
+		traceRecord(traceEvGCEnd, uintptr(unsafe.Pointer(task.Current())), 0, 0, "", "")
+	}
+}
@@ -0,0 +1,314 @@
+package trace
+
+// This file converts the raw events recorded by the runtime to the Go execution
+// trace format, as used since Go 1.22 and understood by 'go tool trace'.
+//
+// The trace consists of a header followed by batches of events. Everything is
+// written in a single generation, as if there is a single thread (M 0) with a
+// single P (P 0), which is an accurate description of the cooperative
+// scheduler. Goroutines are identified by the address of their task structure
+// in the runtime, and are given small sequential IDs here. Goroutines that
+// existed before tracing started get their status emitted the first time they
+// show up in the trace.
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Raw event kinds recorded by the runtime. These must be kept in sync with the
+// runtime.
+const (
+	evStart = iota + 1
+	evGoCreate
+	evGoStart
+	evGoStop
+	evGoDestroy
+	evGoReady
+	evGoBlock
+	evGCBegin
+	evGCEnd
+	evUserTaskBegin
+	evUserTaskEnd
+	evUserRegionBegin
+	evUserRegionEnd
+	evUserLog
+)
+
+// Event types in the Go trace format.
+const (
+	traceEvEventBatch      = 1  // start of per-M batch of events [generation, M ID, timestamp, batch length]
+	traceEvStrings         = 4  // start of a section of the string dictionary [...EvString]
+	traceEvString          = 5  // string dictionary entry [ID, length, string]
+	traceEvFrequency       = 8  // timestamp units per sec [freq]
+	traceEvProcStatus      = 13 // P status at the start of a generation [timestamp, P ID, status]
+	traceEvGoCreate        = 14 // goroutine creation [timestamp, new goroutine ID, new stack ID, stack ID]
+	traceEvGoStart         = 16 // goroutine starts running [timestamp, goroutine ID, goroutine seq]
+	traceEvGoDestroy       = 17 // goroutine ends [timestamp]
+	traceEvGoStop          = 19 // goroutine yields its time, but is runnable [timestamp, reason, stack ID]
+	traceEvGoBlock         = 20 // goroutine blocks [timestamp, reason, stack ID]
+	traceEvGoUnblock       = 21 // goroutine is unblocked [timestamp, goroutine ID, goroutine seq, stack ID]
+	traceEvGoStatus        = 25 // goroutine status at the start of a generation [timestamp, goroutine ID, M ID, status]
+	traceEvGCBegin         = 29 // GC start [timestamp, seq, stack ID]
+	traceEvGCEnd           = 30 // GC done [timestamp, seq]
+	traceEvUserTaskBegin   = 40 // trace.NewTask [timestamp, internal task ID, internal parent task ID, name string ID, stack ID]
+	traceEvUserTaskEnd     = 41 // end of a task [timestamp, internal task ID, stack ID]
+	traceEvUserRegionBegin = 42 // trace.{Start,With}Region [timestamp, internal task ID, name string ID, stack ID]
+	traceEvUserRegionEnd   = 43 // trace.{End,With}Region [timestamp, internal task ID, name string ID, stack ID]
+	traceEvUserLog         = 44 // trace.Log [timestamp, internal task ID, key string ID, value string ID, stack]
+)
+
+// Goroutine and P states in the Go trace format.
+const (
+	traceGoRunnable  = 1
+	traceGoRunning   = 2
+	traceGoWaiting   = 4
+	traceProcRunning = 1
+)
+
+const (
+	traceHeader             = "go 1.22 trace\x00\x00\x00"
+	traceGeneration         = 1
+	traceNoThread           = ^uint64(0)
+	traceMaxBatchSize       = 64 << 10
+	traceMaxStringSize      = 1 << 10
+	traceTimestampFrequency = 1e9 // timestamps are in nanoseconds
+)
+
+// State of a goroutine, as far as the trace is concerned.
+type goState struct {
+	id      uint64
+	status  uint8
+	seq     uint64 // sequence number, incremented on each GoStart and GoUnblock
+	readied bool   // made runnable while running
+	reason  string // reason for blocking, if it blocks
+}
+
+// An encoder converts raw runtime events to the Go trace format.
+type encoder struct {
+	w          io.Writer
+	started    bool // header has been written
+	goroutines map[uintptr]*goState
+	lastGoID   uint64
+	running    *goState // currently running goroutine, if any
+	gcSeq      uint64   // incremented on each GCBegin and GCEnd
+	gcRunning  bool
+	strings    map[string]uint64 // string to string ID
+	newStrings []string          // strings that haven't been written yet
+	batch      []byte            // event batch that is being built
+	batchTime  int64             // timestamp of the first event in the batch
+	lastTime   int64             // timestamp of the last event in the batch
+	err        error             // first write error
+}
+
+func newEncoder(w io.Writer) *encoder {
+	return &encoder{
+		w:          w,
+		goroutines: make(map[uintptr]*goState),
+		strings:    make(map[string]uint64),
+	}
+}
+
+// event adds a single raw runtime event to the trace.
+func (e *encoder) event(kind uint8, time int64, g uintptr, args [2]uint64, str [2]string) {
+	switch kind {
+	case evStart:
+		e.emit(time, traceEvProcStatus, 0, traceProcRunning)
+		if g != 0 {
+			st := e.newGoroutine(g, traceGoRunning)
+			e.emit(time, traceEvGoStatus, st.id, 0, traceGoRunning)
+			e.running = st
+		}
+	case evGoCreate:
+		st := e.newGoroutine(g, traceGoRunnable)
+		e.emit(time, traceEvGoCreate, st.id, 0, 0)
+	case evGoReady:
+		st := e.goroutine(time, g, traceGoWaiting)
+		switch st.status {
+		case traceGoWaiting:
+			st.seq++
+			st.status = traceGoRunnable
+			e.emit(time, traceEvGoUnblock, st.id, st.seq, 0)
+		case traceGoRunning:
+			// For example a call to runtime.Gosched.
+			st.readied = true
+		}
+	case evGoStart:
+		st := e.goroutine(time, g, traceGoRunnable)
+		if st.status == traceGoWaiting {
+			// Shouldn't happen, but keep the trace consistent.
+			st.seq++
+			e.emit(time, traceEvGoUnblock, st.id, st.seq, 0)
+		}
+		if st.status != traceGoRunning {
+			st.seq++
+			st.status = traceGoRunning
+			e.emit(time, traceEvGoStart, st.id, st.seq)
+		}
+		e.running = st
+	case evGoBlock:
+		if st := e.goroutines[g]; st != nil {
+			st.reason = str[0]
+		}
+	case evGoStop:
+		st := e.goroutines[g]
+		if st == nil || st.status != traceGoRunning {
+			break
+		}
+		switch {
+		case st.readied:
+			st.status = traceGoRunnable
+			e.emit(time, traceEvGoStop, e.stringID("yield"), 0)
+		default:
+			reason := st.reason
+			if reason == "" {
+				reason = "blocked"
+			}
+			st.status = traceGoWaiting
+			e.emit(time, traceEvGoBlock, e.stringID(reason), 0)
+		}
+		st.readied = false
+		st.reason = ""
+		e.running = nil
+	case evGoDestroy:
+		st := e.goroutines[g]
+		if st == nil || st.status != traceGoRunning {
+			break
+		}
+		e.emit(time, traceEvGoDestroy)
+		delete(e.goroutines, g)
+		e.running = nil
+	case evGCBegin:
+		// GC events need a running goroutine. The GC runs to completion, so
+		// the end of the cycle happens on the same goroutine.
+		if e.running != nil {
+			e.gcSeq++
+			e.gcRunning = true
+			e.emit(time, traceEvGCBegin, e.gcSeq, 0)
+		}
+	case evGCEnd:
+		if e.gcRunning {
+			e.gcSeq++
+			e.gcRunning = false
+			e.emit(time, traceEvGCEnd, e.gcSeq)
+		}
+	default:
+		// User annotations, which need a running goroutine.
+		if e.running == nil || e.goroutines[g] != e.running {
+			break
+		}
+		switch kind {
+		case evUserTaskBegin:
+			e.emit(time, traceEvUserTaskBegin, args[0], args[1], e.stringID(str[0]), 0)
+		case evUserTaskEnd:
+			e.emit(time, traceEvUserTaskEnd, args[0], 0)
+		case evUserRegionBegin:
+			e.emit(time, traceEvUserRegionBegin, args[0], e.stringID(str[0]), 0)
+		case evUserRegionEnd:
+			e.emit(time, traceEvUserRegionEnd, args[0], e.stringID(str[0]), 0)
+		case evUserLog:
+			e.emit(time, traceEvUserLog, args[0], e.stringID(str[0]), e.stringID(str[1]), 0)
+		}
+	}
+}
+
+// newGoroutine returns the state for a goroutine that was just created (or was
+// running when tracing started).
+func (e *encoder) newGoroutine(g uintptr, status uint8) *goState {
+	e.lastGoID++
+	st := &goState{id: e.lastGoID, status: status}
+	e.goroutines[g] = st
+	return st
+}
+
+// goroutine returns the state of the given goroutine. If the goroutine hasn't
+// been seen before, it must have existed before tracing started so its status
+// is emitted first.
+func (e *encoder) goroutine(time int64, g uintptr, status uint8) *goState {
+	st := e.goroutines[g]
+	if st == nil {
+		st = e.newGoroutine(g, status)
+		e.emit(time, traceEvGoStatus, st.id, traceNoThread, uint64(status))
+	}
+	return st
+}
+
+// stringID returns the ID of the given string in the string dictionary.
+func (e *encoder) stringID(s string) uint64 {
+	if len(s) > traceMaxStringSize {
+		s = s[:traceMaxStringSize]
+	}
+	id, ok := e.strings[s]
+	if !ok {
+		id = uint64(len(e.strings) + 1)
+		e.strings[s] = id
+		e.newStrings = append(e.newStrings, s)
+	}
+	return id
+}
+
+// emit adds a single timed event to the current event batch.
+func (e *encoder) emit(time int64, typ byte, args ...uint64) {
+	if len(e.batch) > traceMaxBatchSize-128 {
+		e.flush()
+	}
+	if len(e.batch) == 0 {
+		e.batchTime = time
+		e.lastTime = time
+	}
+	if time < e.lastTime {
+		// Timestamps must not go backwards.
+		time = e.lastTime
+	}
+	e.batch = append(e.batch, typ)
+	e.batch = binary.AppendUvarint(e.batch, uint64(time-e.lastTime))
+	for _, arg := range args {
+		e.batch = binary.AppendUvarint(e.batch, arg)
+	}
+	e.lastTime = time
+}
+
+// flush writes the current event batch and any new strings to the output.
+func (e *encoder) flush() {
+	var buf []byte
+	if !e.started {
+		// Write the header and the timestamp frequency, once.
+		e.started = true
+		buf = append(buf, traceHeader...)
+		data := []byte{traceEvFrequency}
+		data = binary.AppendUvarint(data, traceTimestampFrequency)
+		buf = appendBatch(buf, 0, data)
+	}
+	if len(e.batch) != 0 {
+		buf = appendBatch(buf, uint64(e.batchTime), e.batch)
+		e.batch = e.batch[:0]
+	}
+	var data []byte
+	for i, s := range e.newStrings {
+		if len(data) == 0 {
+			data = append(data, traceEvStrings)
+		}
+		data = append(data, traceEvString)
+		data = binary.AppendUvarint(data, e.strings[s])
+		data = binary.AppendUvarint(data, uint64(len(s)))
+		data = append(data, s...)
+		if i == len(e.newStrings)-1 || len(data) > traceMaxBatchSize-2*traceMaxStringSize {
+			buf = appendBatch(buf, 0, data)
+			data = data[:0]
+		}
+	}
+	e.newStrings = e.newStrings[:0]
+	if len(buf) != 0 && e.err == nil {
+		_, e.err = e.w.Write(buf)
+	}
+}
+
+// appendBatch appends a batch on M 0 with the given base timestamp and data.
+func appendBatch(buf []byte, time uint64, data []byte) []byte {
+	buf = append(buf, traceEvEventBatch)
+	buf = binary.AppendUvarint(buf, traceGeneration)
+	buf = binary.AppendUvarint(buf, 0) // M ID
+	buf = binary.AppendUvarint(buf, time)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
@@ -0,0 +1,110 @@
+//go:build go1.23
+
+package trace_test
+
+import (
+	"bytes"
+	"fmt"
+	"internal/trace"
+	"io"
+	"testing"
+)
+
+// TestTraceEvents parses the trace like 'go tool trace' does, which checks that
+// all goroutine state transitions are valid, and checks the order of the
+// events of the two goroutines.
+func TestTraceEvents(t *testing.T) {
+	data := recordTrace(t)
+	r, err := trace.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal("could not read trace:", err)
+	}
+
+	type event struct {
+		g    trace.GoID
+		text string
+	}
+	var events []event
+	var mainG, workerG trace.GoID
+	for {
+		ev, err := r.ReadEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("invalid trace:", err)
+		}
+		switch ev.Kind() {
+		case trace.EventStateTransition:
+			st := ev.StateTransition()
+			if st.Resource.Kind != trace.ResourceGoroutine {
+				continue
+			}
+			from, to := st.Goroutine()
+			text := fmt.Sprintf("%s->%s", from, to)
+			if st.Reason != "" {
+				text += " " + st.Reason
+			}
+			events = append(events, event{st.Resource.Goroutine(), text})
+		case trace.EventTaskBegin:
+			mainG = ev.Goroutine()
+			events = append(events, event{ev.Goroutine(), "task begin " + ev.Task().Type})
+		case trace.EventTaskEnd:
+			events = append(events, event{ev.Goroutine(), "task end"})
+		case trace.EventRegionBegin:
+			if ev.Region().Type == "goroutine-region" {
+				workerG = ev.Goroutine()
+			}
+			events = append(events, event{ev.Goroutine(), "region begin " + ev.Region().Type})
+		case trace.EventRegionEnd:
+			events = append(events, event{ev.Goroutine(), "region end " + ev.Region().Type})
+		case trace.EventLog:
+			events = append(events, event{ev.Goroutine(), "log " + ev.Log().Message})
+		}
+	}
+
+	// Only look at the test goroutine and the goroutine it starts.
+	var got []string
+	for _, ev := range events {
+		switch ev.g {
+		case mainG:
+			got = append(got, "main: "+ev.text)
+		case workerG:
+			got = append(got, "worker: "+ev.text)
+		}
+	}
+
+	// These events must be in the trace in this order, possibly with other
+	// events in between. The worker exits before the main goroutine runs
+	// again, as the scheduler is cooperative.
+	want := []string{
+		"main: task begin test-task",
+		"worker: NotExist->Runnable",
+		"main: region begin wait",
+		"main: Running->Waiting chan receive",
+		"worker: Runnable->Running",
+		"worker: region begin goroutine-region",
+		"worker: Running->Waiting sleep",
+		"worker: Waiting->Runnable",
+		"worker: Runnable->Running",
+		"main: Waiting->Runnable",
+		"worker: region end goroutine-region",
+		"worker: Running->NotExist",
+		"main: Runnable->Running",
+		"main: region end wait",
+		"main: log test-message",
+		"main: task end",
+	}
+	i := 0
+	for _, text := range got {
+		if i < len(want) && text == want[i] {
+			i++
+		}
+	}
+	if i < len(want) {
+		t.Errorf("missing event %q in trace, got events:", want[i])
+		for _, text := range got {
+			t.Log("  ", text)
+		}
+	}
+}
@@ -1,42 +1,106 @@
-// Stubs for the runtime/trace package
+// Package trace contains facilities for programs to generate traces for the Go
+// execution tracer.
+//
+// TinyGo only records execution traces when the program is built with the
+// tinygo.trace build tag (which is set automatically by 'tinygo test -trace')
+// and uses the tasks or asyncify scheduler. The trace contains goroutine
+// creation, scheduling and blocking events, GC cycles and user annotations
+// (tasks, regions and log messages) but no stack traces. It can be opened with
+// 'go tool trace' or with Perfetto (after conversion with 'go tool trace').
+//
+// Events are buffered in the runtime and written to the output periodically by
+// a background goroutine. If the buffer fills up before it could be written,
+// for example because the background goroutine doesn't get a chance to run,
+// tracing stops early and the trace is cut off at that point.
 package trace
 
 import (
-	"context"
 	"errors"
 	"io"
+	"sync"
+	"time"
 )
 
-func Start(w io.Writer) error {
-	return errors.New("not implemented")
-}
+// Number of events the runtime can buffer before they are written out.
+const bufferSize = 8192
 
-func Stop() {}
+// How often the background goroutine writes buffered events to the output.
+const flushInterval = 10 * time.Millisecond
 
-func NewTask(pctx context.Context, taskType string) (ctx context.Context, task *Task) {
-	return context.TODO(), nil
+var tracing struct {
+	sync.Mutex // serializes Start, Stop and flush
+	enabled    bool
+	enc        *encoder
+	done       chan struct{}
 }
 
-type Task struct{}
-
-func (t *Task) End() {}
+// Implemented in the runtime.
+func runtime_start(bufSize int) bool
+func runtime_stop()
+func runtime_read(fn func(kind uint8, time int64, g uintptr, args [2]uint64, str [2]string)) (truncated bool)
+func runtime_userEvent(kind uint8, arg0, arg1 uint64, str0, str1 string)
 
-func Log(ctx context.Context, category, message string) {}
-
-func Logf(ctx context.Context, category, format string, args ...any) {}
-
-func WithRegion(ctx context.Context, regionType string, fn func()) {
-	fn()
+// Start enables tracing for the current program. While tracing, the trace will
+// be buffered and written to w. Start returns an error if tracing is already
+// enabled or if it isn't supported in this build.
+func Start(w io.Writer) error {
+	tracing.Lock()
+	defer tracing.Unlock()
+	if tracing.enabled {
+		return errors.New("tracing is already enabled")
+	}
+	if !runtime_start(bufferSize) {
+		return errors.New("runtime/trace: tracing not supported, build with -tags=tinygo.trace and the tasks or asyncify scheduler")
+	}
+	tracing.enabled = true
+	tracing.enc = newEncoder(w)
+	tracing.done = make(chan struct{})
+	go flushLoop(tracing.done)
+	return nil
 }
 
-func StartRegion(ctx context.Context, regionType string) *Region {
-	return nil
+// Stop stops the current tracing, if any. Stop only returns after all the
+// writes for the trace have completed.
+func Stop() {
+	tracing.Lock()
+	defer tracing.Unlock()
+	if !tracing.enabled {
+		return
+	}
+	runtime_stop()
+	tracing.enabled = false
+	close(tracing.done)
+	flush()
+	tracing.enc = nil
 }
 
-type Region struct{}
+// IsEnabled reports whether tracing is enabled. The information is advisory
+// only. The tracing status may have changed by the time this function returns.
+func IsEnabled() bool {
+	return tracing.enabled
+}
 
-func (r *Region) End() {}
+// flushLoop periodically writes events buffered in the runtime to the output,
+// until done is closed.
+func flushLoop(done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(flushInterval):
+		}
+		tracing.Lock()
+		if tracing.enabled {
+			flush()
+		}
+		tracing.Unlock()
+	}
+}
 
-func IsEnabled() bool {
-	return false
+// flush converts all events buffered in the runtime and writes them to the
+// output. It must be called with the tracing lock held.
+func flush() {
+	enc := tracing.enc
+	runtime_read(enc.event)
+	enc.flush()
 }
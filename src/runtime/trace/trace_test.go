@@ -0,0 +1,56 @@
+package trace_test
+
+import (
+	"bytes"
+	"context"
+	"runtime/trace"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrace(t *testing.T) {
+	data := string(recordTrace(t))
+	if !strings.HasPrefix(data, "go 1.22 trace\x00\x00\x00") {
+		t.Fatal("trace doesn't start with the expected header")
+	}
+	for _, s := range []string{"test-task", "goroutine-region", "wait", "test-message", "chan receive", "sleep"} {
+		if !strings.Contains(data, s) {
+			t.Errorf("trace doesn't contain string %q", s)
+		}
+	}
+}
+
+// recordTrace returns the trace of a task with two goroutines that wait for
+// each other in a region.
+func recordTrace(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skip("tracing not supported:", err)
+	}
+	if err := trace.Start(&buf); err == nil {
+		t.Error("expected an error when starting the tracer twice")
+	}
+	if !trace.IsEnabled() {
+		t.Error("tracing is not enabled after Start")
+	}
+
+	ctx, task := trace.NewTask(context.Background(), "test-task")
+	done := make(chan struct{})
+	go func() {
+		defer trace.StartRegion(ctx, "goroutine-region").End()
+		time.Sleep(time.Millisecond)
+		close(done)
+	}()
+	trace.WithRegion(ctx, "wait", func() {
+		<-done
+	})
+	trace.Log(ctx, "category", "test-message")
+	task.End()
+	trace.Stop()
+
+	if trace.IsEnabled() {
+		t.Error("tracing is still enabled after Stop")
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,50 @@
+//go:build !(tinygo.trace && (scheduler.tasks || scheduler.asyncify))
+
+package runtime
+
+import "internal/task"
+
+// The execution tracer is not included in this build.
+const traceSupported = false
+
+//go:linkname trace_start runtime/trace.runtime_start
+func trace_start(bufSize int) bool {
+	return false
+}
+
+//go:linkname trace_stop runtime/trace.runtime_stop
+func trace_stop() {
+}
+
+//go:linkname trace_read runtime/trace.runtime_read
+func trace_read(fn func(kind uint8, time int64, g uintptr, args [2]uint64, str [2]string)) (truncated bool) {
+	return false
+}
+
+//go:linkname trace_userEvent runtime/trace.runtime_userEvent
+func trace_userEvent(kind uint8, arg0, arg1 uint64, str0, str1 string) {
+}
+
+func traceGoCreate(t *task.Task) {
+}
+
+func traceGoStart(t *task.Task) {
+}
+
+func traceGoStop(t *task.Task) {
+}
+
+func traceGoDestroy(t *task.Task) {
+}
+
+func traceGoReady(t *task.Task) {
+}
+
+func traceGoBlock(reason string) {
+}
+
+func traceGCBegin() {
+}
+
+func traceGCEnd() {
+}
@@ -0,0 +1,17 @@
+//go:build tinygo.pprof
+
+package testing
+
+import "io"
+
+// startCPUProfile starts the CPU profiler of runtime/pprof. It is only
+// available with the tinygo.pprof build tag (which is set automatically by
+// 'tinygo test -cpuprofile'), so that other test binaries don't include the
+// profiler.
+func (m *M) startCPUProfile(w io.Writer) error {
+	return m.deps.StartCPUProfile(w)
+}
+
+func (m *M) stopCPUProfile() {
+	m.deps.StopCPUProfile()
+}
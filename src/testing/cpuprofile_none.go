@@ -0,0 +1,15 @@
+//go:build !tinygo.pprof
+
+package testing
+
+import (
+	"errors"
+	"io"
+)
+
+func (m *M) startCPUProfile(w io.Writer) error {
+	return errors.New("CPU profiling not supported, build with -tags=tinygo.pprof")
+}
+
+func (m *M) stopCPUProfile() {
+}
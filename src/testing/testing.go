@@ -34,6 +34,8 @@ var (
 	flagSkipRegexp string
 	flagShuffle    string
 	flagCount      int
+	flagCPUProfile string
+	flagTrace      string
 )
 
 var initRan bool
@@ -52,6 +54,8 @@ func Init() {
 	flag.StringVar(&flagShuffle, "test.shuffle", "off", "shuffle: off, on, <numeric-seed>")
 
 	flag.IntVar(&flagCount, "test.count", 1, "run each test or benchmark `count` times")
+	flag.StringVar(&flagCPUProfile, "test.cpuprofile", "", "write a cpu profile to `file`")
+	flag.StringVar(&flagTrace, "test.trace", "", "write an execution trace to `file`")
 
 	initBenchmarkFlags()
 }
@@ -548,6 +552,8 @@ type M struct {
 
 type testDeps interface {
 	MatchString(pat, str string) (bool, error)
+	StartCPUProfile(io.Writer) error
+	StopCPUProfile()
 }
 
 func (m *M) shuffle() error {
@@ -588,6 +594,9 @@ func (m *M) Run() (code int) {
 		}
 	}
 
+	m.before()
+	defer m.after()
+
 	testRan, testOk := runTests(m.deps.MatchString, m.Tests)
 	if !testRan && *matchBenchmarks == "" {
 		fmt.Fprintln(os.Stderr, "testing: warning: no tests to run")
@@ -662,6 +671,46 @@ type InternalExample struct {
 	Unordered bool
 }
 
+// before runs before all testing.
+func (m *M) before() {
+	if flagCPUProfile != "" {
+		f, err := os.Create(flagCPUProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "testing: %s\n", err)
+			return
+		}
+		if err := m.startCPUProfile(f); err != nil {
+			fmt.Fprintf(os.Stderr, "testing: can't start cpu profile: %s\n", err)
+			f.Close()
+			return
+		}
+		// Could save f so after can call f.Close; not worth the effort.
+	}
+	if flagTrace != "" {
+		f, err := os.Create(flagTrace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "testing: %s\n", err)
+			return
+		}
+		if err := startTrace(f); err != nil {
+			fmt.Fprintf(os.Stderr, "testing: can't start tracing: %s\n", err)
+			f.Close()
+			return
+		}
+		// Could save f so after can call f.Close; not worth the effort.
+	}
+}
+
+// after runs after all testing.
+func (m *M) after() {
+	if flagCPUProfile != "" {
+		m.stopCPUProfile() // flushes profile to disk
+	}
+	if flagTrace != "" {
+		stopTrace() // flushes trace to disk
+	}
+}
+
 // MainStart is meant for use by tests generated by 'go test'.
 // It is not meant to be called directly and is not subject to the Go 1 compatibility document.
 // It may change signature from release to release.
@@ -0,0 +1,19 @@
+//go:build tinygo.trace
+
+package testing
+
+import (
+	"io"
+	"runtime/trace"
+)
+
+// startTrace starts the execution tracer. It is only available with the
+// tinygo.trace build tag (which is set automatically by 'tinygo test -trace'),
+// so that other test binaries don't include runtime/trace.
+func startTrace(w io.Writer) error {
+	return trace.Start(w)
+}
+
+func stopTrace() {
+	trace.Stop()
+}
@@ -0,0 +1,15 @@
+//go:build !tinygo.trace
+
+package testing
+
+import (
+	"errors"
+	"io"
+)
+
+func startTrace(w io.Writer) error {
+	return errors.New("tracing not supported, build with -tags=tinygo.trace")
+}
+
+func stopTrace() {
+}
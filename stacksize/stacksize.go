@@ -60,6 +60,7 @@ type CallNode struct {
 	stackSize        uint64
 	stackSizeType    SizeType
 	missingFrameInfo *CallNode // the child function that is the cause for not being able to determine the stack size
+	stackPathChild   *CallNode // the child with the deepest stack, or the child that caused the stack size to be unknown
 }
 
 func (n *CallNode) String() string {
@@ -280,6 +281,25 @@ func (node *CallNode) StackSize() (uint64, SizeType, *CallNode) {
 	return node.stackSize, node.stackSizeType, node.missingFrameInfo
 }
 
+// StackPath returns the call path that determines the stack size of this
+// function, starting with the function itself. If the stack size is bounded,
+// this is the path to the deepest point in the stack. Otherwise, it is the path
+// to the function that causes the stack size to be unknown. For recursive
+// functions, the path ends with the first function that is called again.
+// StackSize must have been called before calling StackPath.
+func (node *CallNode) StackPath() []*CallNode {
+	var path []*CallNode
+	seen := make(map[*CallNode]struct{})
+	for n := node; n != nil; n = n.stackPathChild {
+		path = append(path, n)
+		if _, ok := seen[n]; ok {
+			break
+		}
+		seen[n] = struct{}{}
+	}
+	return path
+}
+
 // determineStackSize tries to determine the maximum stack size for this
 // function, recursively.
 func (node *CallNode) determineStackSize(parents map[*CallNode]struct{}) {
@@ -297,6 +317,7 @@ func (node *CallNode) determineStackSize(parents map[*CallNode]struct{}) {
 	case Bounded:
 		// Determine the stack size recursively.
 		childMaxStackSize := uint64(0)
+		var childMaxStack *CallNode
 		for _, child := range node.Children {
 			if child.stackSizeType == Undefined {
 				child.determineStackSize(parents)
@@ -305,10 +326,12 @@ func (node *CallNode) determineStackSize(parents map[*CallNode]struct{}) {
 			case Bounded:
 				if child.stackSize > childMaxStackSize {
 					childMaxStackSize = child.stackSize
+					childMaxStack = child
 				}
 			case Unknown, Recursive, IndirectCall:
 				node.stackSizeType = child.stackSizeType
 				node.missingFrameInfo = child.missingFrameInfo
+				node.stackPathChild = child
 				return
 			default:
 				panic("unknown child stack size type")
@@ -316,6 +339,7 @@ func (node *CallNode) determineStackSize(parents map[*CallNode]struct{}) {
 		}
 		node.stackSize = node.FrameSize + childMaxStackSize
 		node.stackSizeType = Bounded
+		node.stackPathChild = childMaxStack
 	case Undefined:
 		node.stackSizeType = Unknown
 		node.missingFrameInfo = node
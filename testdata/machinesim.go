@@ -0,0 +1,124 @@
+package main
+
+// Test the peripheral simulator that is used for the machine package on host
+// systems.
+
+import (
+	"machine"
+	"machine/sim"
+)
+
+func main() {
+	testGPIO()
+	testI2C()
+	testSPI()
+	testUART()
+	testADC()
+	testCAN()
+}
+
+func testGPIO() {
+	led := machine.Pin(5)
+	led.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	led.High()
+	led.Low()
+	println("gpio events:", len(sim.PinEvents()), sim.PinEvents()[0].High, sim.PinEvents()[1].High)
+
+	button := machine.Pin(6)
+	button.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	println("button pullup:", button.Get())
+	sim.SetPin(6, false)
+	println("button pressed:", button.Get())
+}
+
+func testI2C() {
+	dev := &sim.Registers{}
+	dev.Data[0x0F] = 0x33
+	sim.AttachI2C(0, 0x19, dev)
+
+	i2c := machine.I2C0
+	i2c.Configure(machine.I2CConfig{})
+	data := make([]byte, 1)
+	err := i2c.ReadRegister(0x19, 0x0F, data)
+	println("i2c read:", data[0], err == nil)
+	err = i2c.WriteRegister(0x19, 0x20, []byte{0x57, 0x08})
+	println("i2c write:", dev.Data[0x20], dev.Data[0x21], err == nil)
+	err = i2c.Tx(0x42, []byte{1}, nil)
+	println("i2c missing device:", err != nil)
+
+	transfers := sim.I2CTransfers()
+	println("i2c transfers:", len(transfers), transfers[0].Addr, len(transfers[0].W), len(transfers[0].R), transfers[2].Err == sim.ErrNACK)
+}
+
+func testSPI() {
+	sim.AttachSPI(0, sim.SPIDeviceFunc(func(w byte) byte {
+		return w + 1
+	}))
+	spi := machine.SPI0
+	spi.Configure(machine.SPIConfig{})
+	r, _ := spi.Transfer(10)
+	println("spi transfer:", r)
+	rx := make([]byte, 3)
+	spi.Tx([]byte{1, 2, 3}, rx)
+	println("spi tx:", rx[0], rx[1], rx[2])
+	println("spi transfers:", len(sim.SPITransfers()))
+}
+
+func testUART() {
+	uart := machine.UART0
+	uart.Configure(machine.UARTConfig{})
+	uart.Write([]byte("hello"))
+	println("uart output:", string(sim.UARTOutput(0)))
+
+	sim.WriteUART(0, []byte("abc"))
+	println("uart buffered:", uart.Buffered())
+	buf := make([]byte, 8)
+	n, _ := uart.Read(buf)
+	println("uart read:", string(buf[:n]))
+
+	sim.SetUARTLoopback(0, true)
+	uart.WriteByte('x')
+	b, _ := uart.ReadByte()
+	println("uart loopback:", string(rune(b)))
+}
+
+func testADC() {
+	sim.SetADC(3, 1234)
+	adc := machine.ADC{Pin: 3}
+	adc.Configure(machine.ADCConfig{})
+	println("adc:", adc.Get())
+}
+
+func testCAN() {
+	node1 := machine.CAN0
+	node2 := &machine.CAN{Bus: 0}
+	node1.Configure(machine.CANConfig{})
+	node2.Configure(machine.CANConfig{})
+	node2.SetFilter(0, machine.CANFilter{ID: 0x100, Mask: 0x700})
+
+	frame := machine.CANFrame{ID: 0x123, Length: 2}
+	frame.Data[0] = 0xAB
+	frame.Data[1] = 0xCD
+	node1.Transmit(&frame)
+	frame.ID = 0x234
+	node1.Transmit(&frame)
+
+	var rx machine.CANFrame
+	n := 0
+	for node1.Receive(&rx) {
+		n++
+	}
+	println("can loopback:", n)
+	ok := node2.Receive(&rx)
+	println("can filtered:", ok, rx.ID, rx.Length, rx.Data[0], rx.Timestamp)
+	ok = node2.Receive(&rx)
+	println("can filtered again:", ok)
+
+	fd := machine.CANFrame{ID: 0x18DAF110, Extended: true, FD: true, BRS: true, Length: 12}
+	err := node1.Transmit(&fd)
+	node1.Receive(&rx)
+	println("can fd:", err == nil, rx.Extended, rx.FD, rx.Length)
+	fd.Length = 10
+	err = node1.Transmit(&fd)
+	println("can invalid fd length:", err == machine.ErrCANInvalidFrame)
+}
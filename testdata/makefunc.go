@@ -0,0 +1,150 @@
+package main
+
+// Test reflect.MakeFunc by calling the created functions directly, so that the
+// arguments and results go through the assembly stubs and the calling
+// convention as lowered by the compiler.
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type point3 struct {
+	X, Y, Z int32
+}
+
+type point4 struct {
+	X, Y, Z, W int32
+}
+
+type mixed struct {
+	A int8
+	B float32
+	C int64
+}
+
+type nested struct {
+	A struct {
+		B float64
+		C bool
+	}
+	D uint16
+}
+
+type large struct {
+	A, B, C, D, E int64
+}
+
+// makeFunc sets fn (a pointer to a func variable) to a function created by
+// reflect.MakeFunc that prints its arguments and returns the results of impl.
+func makeFunc(fn interface{}, impl func(args []reflect.Value) []reflect.Value) {
+	v := reflect.ValueOf(fn).Elem()
+	v.Set(reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+		print("args:")
+		for _, arg := range args {
+			print(" ", fmt.Sprint(arg.Interface()))
+		}
+		println()
+		return impl(args)
+	}))
+}
+
+func main() {
+	// Simple integer parameters and results.
+	var add func(int, int) int
+	makeFunc(&add, func(args []reflect.Value) []reflect.Value {
+		return []reflect.Value{reflect.ValueOf(int(args[0].Int() + args[1].Int()))}
+	})
+	println("add:", add(3, 5))
+
+	// Integers and floats mixed, which use different registers on most
+	// architectures.
+	var fma func(float64, int8, float32, bool) (float64, int)
+	makeFunc(&fma, func(args []reflect.Value) []reflect.Value {
+		f := args[0].Float()*float64(args[1].Int()) + args[2].Float()
+		n := 0
+		if args[3].Bool() {
+			n = 1
+		}
+		return []reflect.Value{reflect.ValueOf(f), reflect.ValueOf(n)}
+	})
+	f, n := fma(1.5, -4, 0.25, true)
+	println("fma:", fmt.Sprint(f), n)
+
+	// A struct with three fields is expanded into three parameters, a struct
+	// with four fields is passed as a single aggregate.
+	var sum3 func(point3) point3
+	makeFunc(&sum3, func(args []reflect.Value) []reflect.Value {
+		p := args[0].Interface().(point3)
+		return []reflect.Value{reflect.ValueOf(point3{p.Y, p.Z, p.X + p.Y + p.Z})}
+	})
+	println("sum3:", fmt.Sprint(sum3(point3{1, 2, 3})))
+	var sum4 func(point4, int32) point4
+	makeFunc(&sum4, func(args []reflect.Value) []reflect.Value {
+		p := args[0].Interface().(point4)
+		k := int32(args[1].Int())
+		return []reflect.Value{reflect.ValueOf(point4{p.Y * k, p.Z * k, p.W * k, (p.X + p.Y + p.Z + p.W) * k})}
+	})
+	println("sum4:", fmt.Sprint(sum4(point4{1, 2, 3, 4}, 10)))
+
+	// Structs with fields of different kinds and sizes, and nested structs.
+	var swap func(mixed, nested) (nested, mixed)
+	makeFunc(&swap, func(args []reflect.Value) []reflect.Value {
+		m := args[0].Interface().(mixed)
+		s := args[1].Interface().(nested)
+		var r nested
+		r.A.B = float64(m.B)
+		r.A.C = m.A < 0
+		r.D = uint16(m.C)
+		return []reflect.Value{reflect.ValueOf(r), reflect.ValueOf(mixed{int8(s.D), float32(s.A.B), int64(s.D) << 40})}
+	})
+	r, m := swap(mixed{-3, 2.5, 1000}, nested{struct {
+		B float64
+		C bool
+	}{7.25, true}, 99})
+	println("swap:", fmt.Sprint(r), fmt.Sprint(m))
+
+	// Strings, slices, interfaces and complex numbers.
+	var describe func(string, []byte, interface{}, complex128) (string, complex64)
+	makeFunc(&describe, func(args []reflect.Value) []reflect.Value {
+		s := args[0].String() + string(args[1].Bytes()) + fmt.Sprint(args[2].Interface())
+		c := args[3].Complex()
+		return []reflect.Value{reflect.ValueOf(s), reflect.ValueOf(complex64(complex(imag(c), real(c))))}
+	})
+	s, c := describe("foo", []byte("bar"), 42, complex(1, 2))
+	println("describe:", s, fmt.Sprint(c))
+
+	// Results that don't fit in registers are returned through memory.
+	var makeLarge func(int64) large
+	makeFunc(&makeLarge, func(args []reflect.Value) []reflect.Value {
+		x := args[0].Int()
+		return []reflect.Value{reflect.ValueOf(large{x, x * 2, x * 3, x * 4, x * 5})}
+	})
+	println("large:", fmt.Sprint(makeLarge(1<<33)))
+	var split func(string, string, string) (string, string, string, int)
+	makeFunc(&split, func(args []reflect.Value) []reflect.Value {
+		return []reflect.Value{args[2], args[0], args[1], reflect.ValueOf(len(args))}
+	})
+	s1, s2, s3, n := split("a", "b", "c")
+	println("split:", s1, s2, s3, n)
+
+	// So many parameters that some are passed on the stack, including the
+	// context parameter.
+	var many func(int, int, int, int, int, int, int, int, float64, float64, float64, float64, float64, float64, float64, float64, float64, float64, int) float64
+	makeFunc(&many, func(args []reflect.Value) []reflect.Value {
+		var total float64
+		for i, arg := range args {
+			if arg.Kind() == reflect.Float64 {
+				total += arg.Float() * float64(i)
+			} else {
+				total += float64(arg.Int()) * float64(i)
+			}
+		}
+		return []reflect.Value{reflect.ValueOf(total)}
+	})
+	println("many:", fmt.Sprint(many(1, 2, 3, 4, 5, 6, 7, 8, 0.5, 1.5, 2.5, 3.5, 4.5, 5.5, 6.5, 7.5, 8.5, 9.5, 10)))
+
+	// Calling through reflect.Value.Call doesn't go through the stub.
+	out := reflect.ValueOf(sum4).Call([]reflect.Value{reflect.ValueOf(point4{5, 6, 7, 8}), reflect.ValueOf(int32(2))})
+	println("call:", fmt.Sprint(out[0].Interface()))
+}
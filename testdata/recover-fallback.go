@@ -0,0 +1,12 @@
+package main
+
+// Without support for recover, the panic aborts the program right away: the
+// deferred call isn't run.
+
+func main() {
+	defer func() {
+		println("recovered:", recover() != nil)
+	}()
+	println("before panic")
+	panic("no exception handling")
+}
@@ -364,6 +364,9 @@ func main() {
 	println("\nv.Interface() method")
 	testInterfaceMethod()
 
+	println("\nfunc calls")
+	testCall()
+
 	// Test reflect.DeepEqual.
 	var selfref1, selfref2 selfref
 	selfref1.x = &selfref1
@@ -573,6 +576,49 @@ func testInterfaceMethod() {
 	}
 }
 
+type callTester struct {
+	n int
+}
+
+func (c callTester) Add(x int) int {
+	return c.n + x
+}
+
+func (c *callTester) Set(n int) {
+	c.n = n
+}
+
+// Test calling functions and methods through reflect.
+func testCall() {
+	add := func(a int, b int8, s string) (int, string) {
+		return a + int(b), s + "!"
+	}
+	fv := reflect.ValueOf(add)
+	println("type:", fv.Type().String(), "in:", fv.Type().NumIn(), "out:", fv.Type().NumOut())
+	out := fv.Call([]reflect.Value{reflect.ValueOf(3), reflect.ValueOf(int8(4)), reflect.ValueOf("hi")})
+	println("call:", out[0].Int(), out[1].String())
+
+	sum := reflect.ValueOf(func(xs ...int) int {
+		total := 0
+		for _, x := range xs {
+			total += x
+		}
+		return total
+	})
+	out = sum.Call([]reflect.Value{reflect.ValueOf(1), reflect.ValueOf(2), reflect.ValueOf(3)})
+	println("variadic:", sum.Type().IsVariadic(), out[0].Int())
+
+	c := &callTester{n: 10}
+	out = reflect.ValueOf(c).MethodByName("Add").Call([]reflect.Value{reflect.ValueOf(5)})
+	println("method:", out[0].Int())
+	reflect.ValueOf(c).MethodByName("Set").Call([]reflect.Value{reflect.ValueOf(20)})
+	println("method with pointer receiver:", c.n)
+	t := reflect.TypeOf(c)
+	for i := 0; i < t.NumMethod(); i++ {
+		println("method", i, t.Method(i).Name, t.Method(i).Type.String())
+	}
+}
+
 var xorshift32State uint32 = 1
 
 func xorshift32(x uint32) uint32 {
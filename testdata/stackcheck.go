@@ -0,0 +1,26 @@
+package main
+
+// This program overflows the stack of a goroutine. It is built with
+// -stack-check, which reports the name of the goroutine.
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+func main() {
+	for _, stack := range debug.GoroutineStacks() {
+		println("goroutine:", stack.Name, stack.Used > 0 && stack.Used < stack.Size)
+	}
+	go overflow(0)
+	for {
+		runtime.Gosched()
+	}
+}
+
+// Recurse until the stack overflows. Every call switches to the scheduler,
+// which checks the stack before the overflow can overwrite other memory.
+func overflow(depth int) int {
+	runtime.Gosched()
+	return overflow(depth+1) + 1
+}
@@ -0,0 +1,24 @@
+package main
+
+// Test //go:wasmimport and //go:wasmexport functions that pass strings and
+// errors using the canonical ABI. See TestWasmCanonicalABI.
+
+import "errors"
+
+//go:wasmimport tester lookupTitle
+func lookupTitle(name string) (string, error)
+
+//go:wasmexport greet
+func greet(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("no name")
+	}
+	title, err := lookupTitle(name)
+	if err != nil {
+		return "", err
+	}
+	return "Hello, " + title + " " + name + "!", nil
+}
+
+func main() {
+}
@@ -50,7 +50,7 @@ func MakeGCStackSlots(mod llvm.Module) bool {
 		done := false
 		for bb := fn.FirstBasicBlock(); !bb.IsNil() && !done; bb = llvm.NextBasicBlock(bb) {
 			for call := bb.FirstInstruction(); !call.IsNil() && !done; call = llvm.NextInstruction(call) {
-				if call.IsACallInst().IsNil() {
+				if call.IsACallInst().IsNil() && call.IsAInvokeInst().IsNil() {
 					continue // only looking at calls
 				}
 				called := call.CalledValue()
@@ -100,6 +100,7 @@ func MakeGCStackSlots(mod llvm.Module) bool {
 	stackChainStart.SetInitializer(llvm.ConstNull(stackChainStartType))
 
 	// Iterate until runtime.trackPointer has no uses left.
+	stackObjects := make(map[llvm.Value]llvm.Value) // stack object of each function
 	for use := trackPointer.FirstUse(); !use.IsNil(); use = trackPointer.FirstUse() {
 		// Pick the first use of runtime.trackPointer.
 		call := use.User()
@@ -138,6 +139,15 @@ func MakeGCStackSlots(mod llvm.Module) bool {
 			}
 		}
 
+		// The result of an invoke can only be stored in the normal destination
+		// of the invoke. Make sure that block is only reached from the invoke,
+		// so that the store is dominated by it.
+		for _, call := range calls {
+			if ptr := call.Operand(0); !ptr.IsAInvokeInst().IsNil() {
+				splitInvokeNormalEdge(builder, ptr)
+			}
+		}
+
 		// Determine what to do with each call.
 		var pointers []llvm.Value
 		for _, call := range calls {
@@ -226,12 +236,20 @@ func MakeGCStackSlots(mod llvm.Module) bool {
 		}, "")
 		builder.CreateStore(parent, gep)
 		builder.CreateStore(stackObject, stackChainStart)
+		stackObjects[fn] = stackObject
 
 		// Do a store to the stack object after each new pointer that is created.
 		pointerStores := make(map[llvm.Value]struct{})
 		for i, ptr := range pointers {
 			// Insert the store after the pointer value is created.
 			insertionPoint := llvm.NextInstruction(ptr)
+			if !ptr.IsAInvokeInst().IsNil() {
+				// An invoke is a terminator, so insert the store at the start
+				// of the normal destination (the block where execution
+				// continues when the call didn't throw). This block has no
+				// other predecessors, see splitInvokeNormalEdge.
+				insertionPoint = ptr.Operand(ptr.OperandsCount() - 3).AsBasicBlock().FirstInstruction()
+			}
 			for !insertionPoint.IsAPHINode().IsNil() {
 				// PHI nodes are required to be at the start of the block.
 				// Insert after the last PHI node.
@@ -268,6 +286,34 @@ func MakeGCStackSlots(mod llvm.Module) bool {
 		}
 	}
 
+	// Panics on WebAssembly are exceptions, which may unwind past functions
+	// without popping their stack objects. Restore the stack chain where such
+	// an exception is caught.
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		var catchRets []llvm.Value
+		for bb := fn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+			for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+				if inst.InstructionOpcode() == llvm.CatchRet {
+					catchRets = append(catchRets, inst)
+				}
+			}
+		}
+		if len(catchRets) == 0 {
+			continue
+		}
+		stackChain, ok := stackObjects[fn]
+		if !ok {
+			// This function doesn't have a stack object, so restore the
+			// stack chain as it was when the function was entered.
+			builder.SetInsertPointBefore(fn.EntryBasicBlock().FirstInstruction())
+			stackChain = builder.CreateLoad(stackChainStartType, stackChainStart, "")
+		}
+		for _, catchRet := range catchRets {
+			builder.SetInsertPointBefore(catchRet)
+			builder.CreateStore(stackChain, stackChainStart)
+		}
+	}
+
 	return true
 }
 
@@ -280,7 +326,7 @@ func markParentFunctions(marked map[llvm.Value]struct{}, fn llvm.Value) {
 		fn := worklist[len(worklist)-1]
 		worklist = worklist[:len(worklist)-1]
 		for _, use := range getUses(fn) {
-			if use.IsACallInst().IsNil() || use.CalledValue() != fn {
+			if (use.IsACallInst().IsNil() && use.IsAInvokeInst().IsNil()) || use.CalledValue() != fn {
 				// Not the parent function.
 				continue
 			}
@@ -292,3 +338,52 @@ func markParentFunctions(marked map[llvm.Value]struct{}, fn llvm.Value) {
 		}
 	}
 }
+
+// splitInvokeNormalEdge makes sure the normal destination of the given invoke
+// instruction is only reached from the invoke, by inserting a new block on the
+// edge if the destination has other predecessors.
+func splitInvokeNormalEdge(builder llvm.Builder, invoke llvm.Value) {
+	destIndex := invoke.OperandsCount() - 3
+	dest := invoke.Operand(destIndex).AsBasicBlock()
+	numUses := 0
+	for use := dest.AsValue().FirstUse(); !use.IsNil(); use = use.NextUse() {
+		numUses++
+	}
+	if numUses == 1 {
+		// The invoke is the only predecessor.
+		return
+	}
+
+	// Insert a new block that jumps to the old destination.
+	oldBlock := invoke.InstructionParent()
+	newBlock := invoke.Type().Context().InsertBasicBlock(dest, "invoke.cont")
+	builder.SetInsertPointAtEnd(newBlock)
+	builder.CreateBr(dest)
+	invoke.SetOperand(destIndex, newBlock.AsValue())
+
+	// PHI nodes in the old destination now get their value from the new
+	// block. They have to be recreated, as incoming blocks can't be changed.
+	var phiNodes []llvm.Value
+	for inst := dest.FirstInstruction(); !inst.IsAPHINode().IsNil(); inst = llvm.NextInstruction(inst) {
+		phiNodes = append(phiNodes, inst)
+	}
+	for _, phi := range phiNodes {
+		builder.SetInsertPointBefore(phi)
+		newPhi := builder.CreatePHI(phi.Type(), "")
+		incomingCount := phi.IncomingCount()
+		incomingVals := make([]llvm.Value, incomingCount)
+		incomingBlocks := make([]llvm.BasicBlock, incomingCount)
+		for i := 0; i < incomingCount; i++ {
+			incomingVals[i] = phi.IncomingValue(i)
+			incomingBlocks[i] = phi.IncomingBlock(i)
+			if incomingBlocks[i] == oldBlock {
+				incomingBlocks[i] = newBlock
+			}
+		}
+		newPhi.AddIncoming(incomingVals, incomingBlocks)
+		phi.ReplaceAllUsesWith(newPhi)
+		name := phi.Name()
+		phi.EraseFromParentAsInstruction()
+		newPhi.SetName(name)
+	}
+}
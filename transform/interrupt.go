@@ -23,6 +23,10 @@ import (
 // is replaced with an 'unreachable' instruction.
 // This might seem like it causes extra overhead, but in fact inlining and const
 // propagation will eliminate most if not all of that.
+//
+// Functions that call interrupt handlers get a "tinygo-interrupt" attribute
+// with the names of these handlers, so that they can be found for stack size
+// analysis.
 func LowerInterrupts(mod llvm.Module) []error {
 	var errs []error
 
@@ -68,6 +72,8 @@ func LowerInterrupts(mod llvm.Module) []error {
 	// Discover interrupts. The runtime/interrupt.callHandlers call is a
 	// compiler intrinsic that is replaced with the handlers for the given
 	// function.
+	var interruptFuncs []llvm.Value
+	interruptFuncHandlers := map[llvm.Value][]string{}
 	for _, call := range getUses(mod.NamedFunction("runtime/interrupt.callHandlers")) {
 		if call.IsACallInst().IsNil() {
 			errs = append(errs, errorAt(call, "expected a call to runtime/interrupt.callHandlers?"))
@@ -87,6 +93,10 @@ func LowerInterrupts(mod llvm.Module) []error {
 			// Replace the callHandlers call with (possibly multiple) calls to
 			// these handlers.
 			builder.SetInsertPointBefore(call)
+			fn := call.InstructionParent().Parent()
+			if _, ok := interruptFuncHandlers[fn]; !ok {
+				interruptFuncs = append(interruptFuncs, fn)
+			}
 			for _, handler := range handlers {
 				initializer := handler.Initializer()
 				context := builder.CreateExtractValue(initializer, 0, "")
@@ -95,6 +105,7 @@ func LowerInterrupts(mod llvm.Module) []error {
 					num,
 					context,
 				}, "")
+				interruptFuncHandlers[fn] = append(interruptFuncHandlers[fn], strings.TrimSuffix(funcPtr.Name(), "$bound"))
 			}
 			call.EraseFromParentAsInstruction()
 		} else {
@@ -122,6 +133,12 @@ func LowerInterrupts(mod llvm.Module) []error {
 		}
 	}
 
+	// Mark the functions that call interrupt handlers.
+	for _, fn := range interruptFuncs {
+		attr := ctx.CreateStringAttribute("tinygo-interrupt", strings.Join(interruptFuncHandlers[fn], ", "))
+		fn.AddFunctionAttr(attr)
+	}
+
 	// Replace all ptrtoint uses of the interrupt handler globals with the real
 	// interrupt ID.
 	// This can now be safely done after interrupts have been lowered, doing it
@@ -4,11 +4,21 @@ import (
 	"tinygo.org/x/go-llvm"
 )
 
-// OptimizeMaps eliminates created but unused maps.
+// OptimizeMaps eliminates created but unused maps, and moves maps that are
+// never modified to read-only memory.
 //
-// In the future, this should statically allocate created but never modified
-// maps. This has not yet been implemented, however.
+// Maps that are created in package initializers (for example, lookup tables
+// written as map literals) are usually built by the interp package. This
+// results in a number of globals: one for the runtime.hashmap object and one
+// or more for the buckets. If the map is only ever read from, these globals
+// can be marked constant so that they end up in flash instead of RAM.
 func OptimizeMaps(mod llvm.Module) {
+	removeUnusedMaps(mod)
+	makeReadonlyMapsConstant(mod)
+}
+
+// removeUnusedMaps removes maps that are created but never read from.
+func removeUnusedMaps(mod llvm.Module) {
 	hashmapMake := mod.NamedFunction("runtime.hashmapMake")
 	if hashmapMake.IsNil() {
 		// nothing to optimize
@@ -45,3 +55,261 @@ func OptimizeMaps(mod llvm.Module) {
 		}
 	}
 }
+
+// Runtime functions that only read from the map that is passed as the first
+// parameter. They must never modify the map or its buckets, as the map may be
+// stored in read-only memory.
+var readonlyMapFunctions = []string{
+	"runtime.hashmapLen",
+	"runtime.hashmapGet",
+	"runtime.hashmapBinaryGet",
+	"runtime.hashmapStringGet",
+	"runtime.hashmapInterfaceGet",
+	"runtime.hashmapNext",
+	"runtime.hashmapBucketSize",
+	"runtime.hashmapBucketAddr",
+	"runtime.hashmapBucketAddrForHash",
+	"runtime.hashmapSlotKey",
+	"runtime.hashmapSlotValue",
+}
+
+// makeReadonlyMapsConstant marks runtime.hashmap globals (and their buckets)
+// constant when they are only used by runtime functions that read from the
+// map. These globals are created by the interp package when it runs package
+// initializers.
+func makeReadonlyMapsConstant(mod llvm.Module) {
+	readonlyFunctions := map[llvm.Value]struct{}{}
+	for _, name := range readonlyMapFunctions {
+		fn := mod.NamedFunction(name)
+		if !fn.IsNil() {
+			readonlyFunctions[fn] = struct{}{}
+		}
+	}
+	if len(readonlyFunctions) == 0 {
+		// nothing to optimize
+		return
+	}
+
+	targetData := llvm.NewTargetData(mod.DataLayout())
+	defer targetData.Dispose()
+	builder := mod.Context().NewBuilder()
+	defer builder.Dispose()
+	ptrSize := uint64(targetData.PointerSize())
+
+	// Find all globals that are used as a map in a read-only function call.
+	var maps []llvm.Value
+	seen := map[llvm.Value]struct{}{}
+	for fn := range readonlyFunctions {
+		for _, call := range getUses(fn) {
+			if call.IsACallInst().IsNil() || call.CalledValue() != fn {
+				continue
+			}
+			m := call.Operand(0)
+			if _, ok := seen[m]; ok {
+				continue
+			}
+			seen[m] = struct{}{}
+			if !isStaticGlobal(m) {
+				continue
+			}
+			maps = append(maps, m)
+		}
+	}
+
+	for _, m := range maps {
+		// Check that the map isn't used anywhere except in functions that
+		// only read from it.
+		if !isReadonlyMap(m, readonlyFunctions) {
+			continue
+		}
+
+		// Find all bucket globals of this map. They must only be referenced
+		// from the map itself (or from other buckets).
+		// The layout must match runtime.hashmap and runtime.hashmapBucket.
+		initializer := m.Initializer()
+		keySize, ok1 := readConstantUint(builder, targetData, initializer, ptrSize*3, ptrSize)
+		valueSize, ok2 := readConstantUint(builder, targetData, initializer, ptrSize*4, ptrSize)
+		bucketBits, ok3 := readConstantUint(builder, targetData, initializer, ptrSize*5, 1)
+		if !ok1 || !ok2 || !ok3 || bucketBits >= 32 {
+			continue
+		}
+		bucketHeaderSize := 8 + ptrSize // tophash and next pointer
+		bucketSize := bucketHeaderSize + keySize*8 + valueSize*8
+		buckets, ok := readConstantPointer(builder, targetData, initializer, 0)
+		if !ok || !isStaticGlobal(buckets) {
+			continue
+		}
+		globals := []llvm.Value{m, buckets}
+		valid := true
+		for i := uint64(0); i < 1<<bucketBits && valid; i++ {
+			// Walk the chain of overflow buckets.
+			bucket := buckets
+			offset := i * bucketSize
+			for {
+				next, ok := readConstantPointer(builder, targetData, bucket.Initializer(), offset+8)
+				if !ok || (!next.IsNull() && !isStaticGlobal(next)) {
+					valid = false
+					break
+				}
+				if next.IsNull() {
+					break
+				}
+				for _, global := range globals {
+					if global == next {
+						// Buckets can't be part of a chain twice.
+						valid = false
+					}
+				}
+				if !valid {
+					break
+				}
+				globals = append(globals, next)
+				bucket = next
+				offset = 0
+			}
+		}
+		for _, global := range globals[1:] {
+			for _, use := range getUses(global) {
+				if !use.IsAInstruction().IsNil() {
+					// Buckets should only be referenced from other globals.
+					valid = false
+				}
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		// The map is never modified, so it can be put in read-only memory.
+		for _, global := range globals {
+			global.SetGlobalConstant(true)
+		}
+	}
+}
+
+// isStaticGlobal returns whether the value is a non-constant global variable
+// that is defined in this module and cannot be accessed from outside it.
+func isStaticGlobal(value llvm.Value) bool {
+	if value.IsAGlobalVariable().IsNil() || value.IsDeclaration() || value.IsGlobalConstant() {
+		return false
+	}
+	switch value.Linkage() {
+	case llvm.InternalLinkage, llvm.PrivateLinkage:
+		return true
+	default:
+		return false
+	}
+}
+
+// isReadonlyMap returns whether the given map is only used as the first
+// parameter of the given read-only functions or from within these functions.
+func isReadonlyMap(m llvm.Value, readonlyFunctions map[llvm.Value]struct{}) bool {
+	for _, use := range getUses(m) {
+		if use.IsAInstruction().IsNil() {
+			// Used in a constant, for example the initializer of another
+			// global. We can't track these uses.
+			return false
+		}
+		if _, ok := readonlyFunctions[use.InstructionParent().Parent()]; ok {
+			// Used inside one of the read-only functions, for example after
+			// constant propagation.
+			continue
+		}
+		if use.IsACallInst().IsNil() {
+			return false
+		}
+		if _, ok := readonlyFunctions[use.CalledValue()]; !ok {
+			return false
+		}
+		for i := 1; i < use.OperandsCount()-1; i++ {
+			if use.Operand(i) == m {
+				// The map is passed as some other parameter.
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// readConstantPointer returns the pointer stored at the given byte offset in
+// the constant value. It returns false if there is no pointer at this offset.
+func readConstantPointer(builder llvm.Builder, targetData llvm.TargetData, value llvm.Value, offset uint64) (llvm.Value, bool) {
+	scalar, scalarOffset := constantAtOffset(builder, targetData, value, offset)
+	if scalar.IsNil() || scalarOffset != 0 {
+		return llvm.Value{}, false
+	}
+	switch scalar.Type().TypeKind() {
+	case llvm.PointerTypeKind:
+		return scalar, true
+	case llvm.IntegerTypeKind:
+		// A null pointer can be stored as (a number of) zero bytes.
+		for i := uint64(0); i < uint64(targetData.PointerSize()); i++ {
+			b, ok := readConstantUint(builder, targetData, value, offset+i, 1)
+			if !ok || b != 0 {
+				return llvm.Value{}, false
+			}
+		}
+		return llvm.ConstNull(llvm.PointerType(value.Type().Context().Int8Type(), 0)), true
+	default:
+		return llvm.Value{}, false
+	}
+}
+
+// readConstantUint reads an unsigned integer of the given size at the given
+// byte offset in the constant value. The integer may be split across multiple
+// constants, like the byte arrays created by the interp package.
+func readConstantUint(builder llvm.Builder, targetData llvm.TargetData, value llvm.Value, offset, size uint64) (uint64, bool) {
+	var result uint64
+	for i := uint64(0); i < size; i++ {
+		scalar, scalarOffset := constantAtOffset(builder, targetData, value, offset+i)
+		if scalar.IsNil() || scalar.Type().TypeKind() != llvm.IntegerTypeKind {
+			return 0, false
+		}
+		if scalar.IsUndef() {
+			scalar = llvm.ConstNull(scalar.Type())
+		}
+		if scalar.IsAConstantInt().IsNil() {
+			return 0, false
+		}
+		scalarSize := targetData.TypeStoreSize(scalar.Type())
+		shift := scalarOffset * 8
+		if targetData.ByteOrder() == llvm.BigEndian {
+			shift = (scalarSize - scalarOffset - 1) * 8
+		}
+		b := (scalar.ZExtValue() >> shift) & 0xff
+		if targetData.ByteOrder() == llvm.BigEndian {
+			result |= b << ((size - i - 1) * 8)
+		} else {
+			result |= b << (i * 8)
+		}
+	}
+	return result, true
+}
+
+// constantAtOffset returns the scalar constant (integer or pointer) that
+// contains the byte at the given offset, and the offset within that scalar.
+// It returns a nil value if the offset is in padding or outside the value.
+func constantAtOffset(builder llvm.Builder, targetData llvm.TargetData, value llvm.Value, offset uint64) (llvm.Value, uint64) {
+	typ := value.Type()
+	switch typ.TypeKind() {
+	case llvm.StructTypeKind:
+		for i, elementType := range typ.StructElementTypes() {
+			start := targetData.ElementOffset(typ, i)
+			if offset >= start && offset < start+targetData.TypeStoreSize(elementType) {
+				element := builder.CreateExtractValue(value, i, "")
+				return constantAtOffset(builder, targetData, element, offset-start)
+			}
+		}
+	case llvm.ArrayTypeKind:
+		elementSize := targetData.TypeAllocSize(typ.ElementType())
+		if index := offset / elementSize; index < uint64(typ.ArrayLength()) {
+			element := builder.CreateExtractValue(value, int(index), "")
+			return constantAtOffset(builder, targetData, element, offset%elementSize)
+		}
+	case llvm.IntegerTypeKind, llvm.PointerTypeKind:
+		if offset < targetData.TypeStoreSize(typ) {
+			return value, offset
+		}
+	}
+	return llvm.Value{}, 0
+}
@@ -64,6 +64,9 @@ func Optimize(mod llvm.Module, config *compileopts.Config) []error {
 		}
 
 		// Run TinyGo-specific optimization passes.
+		// Maps created by interp in package initializers are only known at
+		// this point, so OptimizeMaps is run again on the whole program.
+		OptimizeMaps(mod)
 		OptimizeStringToBytes(mod)
 		OptimizeReflectImplements(mod)
 		maxStackSize := config.MaxStackAlloc()
@@ -86,6 +89,19 @@ func Optimize(mod llvm.Module, config *compileopts.Config) []error {
 			return []error{fmt.Errorf("could not build pass pipeline: %w", err)}
 		}
 
+		// List all types that reflect may need to find at runtime. This is
+		// done after the previous passes removed unused types.
+		CreateReflectTypeTable(mod)
+
+		// Remove reflect call trampolines and method tables if they're not
+		// used, and remove the functions they referenced.
+		if OptimizeReflectCalls(mod) {
+			err = mod.RunPasses("globaldce", llvm.TargetMachine{}, po)
+			if err != nil {
+				return []error{fmt.Errorf("could not build pass pipeline: %w", err)}
+			}
+		}
+
 		// Run TinyGo-specific interprocedural optimizations.
 		OptimizeAllocs(mod, config.Options.PrintAllocs, maxStackSize, func(pos token.Position, msg string) {
 			fmt.Fprintln(os.Stderr, pos.String()+": "+msg)
@@ -103,6 +119,7 @@ func Optimize(mod llvm.Module, config *compileopts.Config) []error {
 		if len(errs) > 0 {
 			return errs
 		}
+		CreateReflectTypeTable(mod)
 
 		// Clean up some leftover symbols of the previous transformations.
 		po := llvm.NewPassBuilderOptions()
@@ -173,8 +173,135 @@ func OptimizeReflectImplements(mod llvm.Module) {
 		builder.SetInsertPointBefore(call)
 		implements := builder.CreateCall(typeAssertFunction.GlobalValueType(), typeAssertFunction, []llvm.Value{
 			call.Operand(0), // typecode to check
+			llvm.Undef(llvm.PointerType(mod.Context().Int8Type(), 0)), // context
 		}, "")
 		call.ReplaceAllUsesWith(implements)
 		call.EraseFromParentAsInstruction()
 	}
 }
+
+// OptimizeReflectCalls removes the call trampolines, method tables and
+// interface implements functions that are referenced from type codes if the
+// program never uses them. They are emitted for every function signature,
+// every type with exported methods and every interface type that ends up in a
+// type code, and they would otherwise keep all exported methods of those types
+// (and all type switches over interface types) alive.
+//
+// The trampolines are only read by internal/reflectlite.callFunc, the method
+// tables are only read by internal/reflectlite.methodTable and the implements
+// functions are only called by internal/reflectlite.implementedBy, so if these
+// functions are unused the trampolines, method tables and implements functions
+// can be replaced with null pointers. This pass needs to run after interface
+// lowering and a globaldce pass, because before that unused reflect methods are
+// still referenced from method sets. It returns whether anything was removed,
+// so the caller can run globaldce again to remove the functions that are no
+// longer referenced.
+func OptimizeReflectCalls(mod llvm.Module) bool {
+	removeCalls := !hasUses(mod.NamedFunction("internal/reflectlite.callFunc"))
+	removeMethods := !hasUses(mod.NamedFunction("internal/reflectlite.methodTable"))
+	removeImplements := !hasUses(mod.NamedFunction("internal/reflectlite.implementedBy"))
+
+	changed := false
+	if removeCalls {
+		for fn := mod.FirstFunction(); !fn.IsNil(); {
+			next := llvm.NextFunction(fn)
+			if strings.HasPrefix(fn.Name(), "reflect/types.call:") {
+				fn.ReplaceAllUsesWith(llvm.ConstNull(fn.Type()))
+				fn.EraseFromParentAsFunction()
+				changed = true
+			}
+			fn = next
+		}
+	}
+	if removeMethods {
+		for global := mod.FirstGlobal(); !global.IsNil(); {
+			next := llvm.NextGlobal(global)
+			if strings.HasPrefix(global.Name(), "reflect/types.methods:") {
+				global.ReplaceAllUsesWith(llvm.ConstNull(global.Type()))
+				global.EraseFromParentAsGlobal()
+				changed = true
+			}
+			global = next
+		}
+	}
+	if removeImplements {
+		builder := mod.Context().NewBuilder()
+		defer builder.Dispose()
+		for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+			if !strings.HasPrefix(global.Name(), "reflect/types.type:interface:") || global.IsDeclaration() {
+				continue
+			}
+			// The implements field is the 4th field of the type code, after
+			// the meta byte, number of methods and ptrTo.
+			initializer := global.Initializer()
+			implements := builder.CreateExtractValue(initializer, 3, "")
+			if implements.IsNull() {
+				// Interface without methods.
+				continue
+			}
+			fields := make([]llvm.Value, initializer.Type().StructElementTypesCount())
+			for i := range fields {
+				fields[i] = builder.CreateExtractValue(initializer, i, "")
+			}
+			fields[3] = llvm.ConstNull(implements.Type())
+			global.SetInitializer(mod.Context().ConstStruct(fields, false))
+			changed = true
+		}
+	}
+	return changed
+}
+
+// CreateReflectTypeTable defines the reflect/types.table global, if it is used
+// in the program. This table lists all array, chan, func, map, slice and struct
+// types in the program, so that internal/reflectlite can find an existing type
+// when constructing a type at runtime (for example, using reflect.SliceOf).
+//
+// This pass needs to run after interface lowering, because the table should
+// point to the final type codes (without method set).
+func CreateReflectTypeTable(mod llvm.Module) {
+	table := mod.NamedGlobal("reflect/types.table")
+	if table.IsNil() || !table.IsDeclaration() {
+		return
+	}
+
+	ctx := mod.Context()
+	targetData := llvm.NewTargetData(mod.DataLayout())
+	defer targetData.Dispose()
+	uintptrType := ctx.IntType(targetData.PointerSize() * 8)
+	ptrType := llvm.PointerType(ctx.Int8Type(), 0)
+
+	// Collect all type codes that may be constructed at runtime.
+	var typecodes []llvm.Value
+	for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		name := global.Name()
+		if !strings.HasPrefix(name, "reflect/types.type:") || global.IsDeclaration() {
+			continue
+		}
+		if global.GlobalValueType().TypeKind() != llvm.StructTypeKind {
+			// Struct field data, for example.
+			continue
+		}
+		name = strings.TrimPrefix(name, "reflect/types.type:")
+		for _, prefix := range []string{"array:", "chan:", "func:", "map:", "slice:", "struct:"} {
+			if strings.HasPrefix(name, prefix) {
+				typecodes = append(typecodes, global)
+				break
+			}
+		}
+	}
+
+	// Create the table and replace the declaration with it.
+	initializer := ctx.ConstStruct([]llvm.Value{
+		llvm.ConstInt(uintptrType, uint64(len(typecodes)), false),
+		llvm.ConstArray(ptrType, typecodes),
+	}, false)
+	newTable := llvm.AddGlobal(mod, initializer.Type(), "")
+	newTable.SetInitializer(initializer)
+	newTable.SetLinkage(llvm.InternalLinkage)
+	newTable.SetGlobalConstant(true)
+	newTable.SetAlignment(targetData.ABITypeAlignment(uintptrType))
+	table.ReplaceAllUsesWith(newTable)
+	name := table.Name()
+	table.EraseFromParentAsGlobal()
+	newTable.SetName(name)
+}
@@ -30,3 +30,19 @@ func TestOptimizeReflectImplements(t *testing.T) {
 		transform.OptimizeReflectImplements(mod)
 	})
 }
+
+func TestOptimizeReflectCalls(t *testing.T) {
+	t.Parallel()
+	testTransform(t, "testdata/reflect-calls", func(mod llvm.Module) {
+		// Run optimization pass.
+		transform.OptimizeReflectCalls(mod)
+	})
+}
+
+func TestCreateReflectTypeTable(t *testing.T) {
+	t.Parallel()
+	testTransform(t, "testdata/reflect-types", func(mod llvm.Module) {
+		// Run optimization pass.
+		transform.CreateReflectTypeTable(mod)
+	})
+}
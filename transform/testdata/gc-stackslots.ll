@@ -116,3 +116,33 @@ define void @allocAndSave(ptr %x) {
   store ptr %x, ptr @ptrGlobal
   ret void
 }
+
+declare i32 @__gxx_wasm_personality_v0(...)
+
+; The result of an invoke must be stored in the normal destination, which is
+; also reached from the loop. This edge must be split so that the store is only
+; done once.
+define void @invokeLoop() personality ptr @__gxx_wasm_personality_v0 {
+entry:
+  %ptr = invoke ptr @getPointer()
+          to label %loop unwind label %lpad
+
+loop:
+  %i = phi i32 [ 0, %entry ], [ %i.next, %loop ]
+  call void @runtime.trackPointer(ptr %ptr)
+  %other = call ptr @runtime.alloc(i32 4, ptr null)
+  call void @runtime.trackPointer(ptr %other)
+  %i.next = add i32 %i, 1
+  %done = icmp eq i32 %i.next, 10
+  br i1 %done, label %end, label %loop
+
+end:
+  ret void
+
+lpad:
+  %cs = catchswitch within none [label %catch] unwind to caller
+
+catch:
+  %cp = catchpad within %cs [ptr null]
+  catchret from %cp to label %end
+}
@@ -166,3 +166,43 @@ define void @allocAndSave(ptr %x) {
   store ptr %1, ptr @runtime.stackChainStart, align 4
   ret void
 }
+
+declare i32 @__gxx_wasm_personality_v0(...)
+
+define void @invokeLoop() personality ptr @__gxx_wasm_personality_v0 {
+entry:
+  %gc.stackobject = alloca { ptr, i32, ptr, ptr }, align 8
+  store { ptr, i32, ptr, ptr } { ptr null, i32 2, ptr null, ptr null }, ptr %gc.stackobject, align 4
+  %0 = load ptr, ptr @runtime.stackChainStart, align 4
+  %1 = getelementptr { ptr, i32, ptr, ptr }, ptr %gc.stackobject, i32 0, i32 0
+  store ptr %0, ptr %1, align 4
+  store ptr %gc.stackobject, ptr @runtime.stackChainStart, align 4
+  %ptr = invoke ptr @getPointer()
+          to label %invoke.cont unwind label %lpad
+
+invoke.cont:                                      ; preds = %entry
+  %2 = getelementptr { ptr, i32, ptr, ptr }, ptr %gc.stackobject, i32 0, i32 2
+  store ptr %ptr, ptr %2, align 4
+  br label %loop
+
+loop:                                             ; preds = %invoke.cont, %loop
+  %i = phi i32 [ 0, %invoke.cont ], [ %i.next, %loop ]
+  %other = call ptr @runtime.alloc(i32 4, ptr null)
+  %3 = getelementptr { ptr, i32, ptr, ptr }, ptr %gc.stackobject, i32 0, i32 3
+  store ptr %other, ptr %3, align 4
+  %i.next = add i32 %i, 1
+  %done = icmp eq i32 %i.next, 10
+  br i1 %done, label %end, label %loop
+
+end:                                              ; preds = %catch, %loop
+  store ptr %0, ptr @runtime.stackChainStart, align 4
+  ret void
+
+lpad:                                             ; preds = %entry
+  %cs = catchswitch within none [label %catch] unwind to caller
+
+catch:                                            ; preds = %lpad
+  %cp = catchpad within %cs [ptr null]
+  store ptr %gc.stackobject, ptr @runtime.stackChainStart, align 4
+  catchret from %cp to label %end
+}
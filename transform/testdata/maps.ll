@@ -3,6 +3,16 @@ target triple = "armv7m-none-eabi"
 
 @answer = constant [6 x i8] c"answer"
 
+; A map[string]int32 with a single key, as created by the interp package. It is
+; only read from, so it can be made constant together with its buckets.
+@readonlyMap = internal global { ptr, [24 x i8], ptr, [4 x i8], ptr } { ptr @readonlyMap.buckets, [24 x i8] c"\78\56\34\12\01\00\00\00\08\00\00\00\04\00\00\00\00\00\00\00\00\00\00\00", ptr @runtime.hashmapStringEqual, [4 x i8] zeroinitializer, ptr @runtime.hashmapStringPtrHash }
+@readonlyMap.buckets = internal global { [8 x i8], ptr, ptr, [92 x i8] } { [8 x i8] c"\A5\00\00\00\00\00\00\00", ptr @readonlyMap.overflow, ptr @answer, [92 x i8] c"\06\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\2A\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00" }
+@readonlyMap.overflow = internal global [108 x i8] zeroinitializer
+
+; The same map, but it is modified so must stay in RAM.
+@modifiedMap = internal global { ptr, [24 x i8], ptr, [4 x i8], ptr } { ptr @modifiedMap.buckets, [24 x i8] c"\78\56\34\12\01\00\00\00\08\00\00\00\04\00\00\00\00\00\00\00\00\00\00\00", ptr @runtime.hashmapStringEqual, [4 x i8] zeroinitializer, ptr @runtime.hashmapStringPtrHash }
+@modifiedMap.buckets = internal global { [8 x i8], ptr, ptr, [92 x i8] } { [8 x i8] c"\A5\00\00\00\00\00\00\00", ptr null, ptr @answer, [92 x i8] c"\06\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\2A\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00" }
+
 ; func(keySize, valueSize uint8, sizeHint uintptr) *runtime.hashmap
 declare nonnull ptr @runtime.hashmapMake(i8, i8, i32)
 
@@ -12,6 +22,10 @@ declare void @runtime.hashmapStringSet(ptr nocapture, ptr, i32, ptr nocapture re
 ; func(map[string]int, string, unsafe.Pointer)
 declare i1 @runtime.hashmapStringGet(ptr nocapture, ptr, i32, ptr nocapture)
 
+declare i1 @runtime.hashmapStringEqual(ptr, ptr, i32)
+
+declare i32 @runtime.hashmapStringPtrHash(ptr, i32, i32)
+
 define void @testUnused() {
     ; create the map
     %map = call ptr @runtime.hashmapMake(i8 4, i8 4, i32 0)
@@ -48,3 +62,20 @@ define ptr @testUsed() {
     %1 = call ptr @runtime.hashmapMake(i8 4, i8 4, i32 0)
     ret ptr %1
 }
+
+define i32 @testReadonlyGlobal() {
+    %hashmap.value = alloca i32
+    %commaOk = call i1 @runtime.hashmapStringGet(ptr @readonlyMap, ptr @answer, i32 6, ptr %hashmap.value)
+    %loadedValue = load i32, ptr %hashmap.value
+    ret i32 %loadedValue
+}
+
+define i32 @testModifiedGlobal() {
+    %hashmap.value = alloca i32
+    store i32 42, ptr %hashmap.value
+    call void @runtime.hashmapStringSet(ptr @modifiedMap, ptr @answer, i32 6, ptr %hashmap.value)
+    %hashmap.value2 = alloca i32
+    %commaOk = call i1 @runtime.hashmapStringGet(ptr @modifiedMap, ptr @answer, i32 6, ptr %hashmap.value2)
+    %loadedValue = load i32, ptr %hashmap.value2
+    ret i32 %loadedValue
+}
@@ -2,6 +2,11 @@ target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
 target triple = "armv7m-none-eabi"
 
 @answer = constant [6 x i8] c"answer"
+@readonlyMap = internal constant { ptr, [24 x i8], ptr, [4 x i8], ptr } { ptr @readonlyMap.buckets, [24 x i8] c"xV4\12\01\00\00\00\08\00\00\00\04\00\00\00\00\00\00\00\00\00\00\00", ptr @runtime.hashmapStringEqual, [4 x i8] zeroinitializer, ptr @runtime.hashmapStringPtrHash }
+@readonlyMap.buckets = internal constant { [8 x i8], ptr, ptr, [92 x i8] } { [8 x i8] c"\A5\00\00\00\00\00\00\00", ptr @readonlyMap.overflow, ptr @answer, [92 x i8] c"\06\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00*\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00" }
+@readonlyMap.overflow = internal constant [108 x i8] zeroinitializer
+@modifiedMap = internal global { ptr, [24 x i8], ptr, [4 x i8], ptr } { ptr @modifiedMap.buckets, [24 x i8] c"xV4\12\01\00\00\00\08\00\00\00\04\00\00\00\00\00\00\00\00\00\00\00", ptr @runtime.hashmapStringEqual, [4 x i8] zeroinitializer, ptr @runtime.hashmapStringPtrHash }
+@modifiedMap.buckets = internal global { [8 x i8], ptr, ptr, [92 x i8] } { [8 x i8] c"\A5\00\00\00\00\00\00\00", ptr null, ptr @answer, [92 x i8] c"\06\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00*\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00" }
 
 declare nonnull ptr @runtime.hashmapMake(i8, i8, i32)
 
@@ -9,6 +14,10 @@ declare void @runtime.hashmapStringSet(ptr nocapture, ptr, i32, ptr nocapture re
 
 declare i1 @runtime.hashmapStringGet(ptr nocapture, ptr, i32, ptr nocapture)
 
+declare i1 @runtime.hashmapStringEqual(ptr, ptr, i32)
+
+declare i32 @runtime.hashmapStringPtrHash(ptr, i32, i32)
+
 define void @testUnused() {
   ret void
 }
@@ -28,3 +37,20 @@ define ptr @testUsed() {
   %1 = call ptr @runtime.hashmapMake(i8 4, i8 4, i32 0)
   ret ptr %1
 }
+
+define i32 @testReadonlyGlobal() {
+  %hashmap.value = alloca i32, align 4
+  %commaOk = call i1 @runtime.hashmapStringGet(ptr @readonlyMap, ptr @answer, i32 6, ptr %hashmap.value)
+  %loadedValue = load i32, ptr %hashmap.value, align 4
+  ret i32 %loadedValue
+}
+
+define i32 @testModifiedGlobal() {
+  %hashmap.value = alloca i32, align 4
+  store i32 42, ptr %hashmap.value, align 4
+  call void @runtime.hashmapStringSet(ptr @modifiedMap, ptr @answer, i32 6, ptr %hashmap.value)
+  %hashmap.value2 = alloca i32, align 4
+  %commaOk = call i1 @runtime.hashmapStringGet(ptr @modifiedMap, ptr @answer, i32 6, ptr %hashmap.value2)
+  %loadedValue = load i32, ptr %hashmap.value2, align 4
+  ret i32 %loadedValue
+}
@@ -0,0 +1,53 @@
+target datalayout = "e-m:e-p:32:32-p270:32:32-p271:32:32-p272:64:64-f64:32:64-f80:32-n8:16:32-S128"
+target triple = "i686--linux"
+
+@"reflect/types.type:named:main.T" = internal constant { i8, i16, ptr, ptr, ptr, ptr, [7 x i8] } { i8 34, i16 1, ptr null, ptr null, ptr null, ptr null, [7 x i8] c"main.T\00" }, align 4
+@"reflect/types.method.name:Get" = internal constant [4 x i8] c"Get\00", align 1
+@"reflect/types.type:func:{}{basic:int}" = internal constant { i8, i16, ptr, { ptr, ptr }, i16, i1, [1 x ptr] } { i8 24, i16 0, ptr null, { ptr, ptr } { ptr null, ptr @"reflect/types.call:func:{}{basic:int}" }, i16 1, i1 false, [1 x ptr] zeroinitializer }, align 4
+@"reflect/types.type:interface:{Get:func:{}{basic:int}}" = internal constant { i8, i16, ptr, { ptr, ptr }, [1 x { ptr, ptr, ptr }] } { i8 84, i16 1, ptr null, { ptr, ptr } zeroinitializer, [1 x { ptr, ptr, ptr }] [{ ptr, ptr, ptr } { ptr @"reflect/types.method.name:Get", ptr @"reflect/types.type.pkgpath.empty", ptr @"reflect/types.type:func:{}{basic:int}" }] }, align 4
+@"reflect/types.type.pkgpath.empty" = internal constant [1 x i8] zeroinitializer, align 1
+
+define internal i32 @main.T.Get(i32 %t, ptr %context) {
+entry:
+  ret i32 %t
+}
+
+define internal i32 @main.T.Get$bound(ptr %context) {
+entry:
+  %t = ptrtoint ptr %context to i32
+  %ret = call i32 @main.T.Get(i32 %t, ptr undef)
+  ret i32 %ret
+}
+
+define internal void @"reflect/types.call:func:{}{basic:int}"(ptr %fn, ptr %args, ptr %results, ptr %context) {
+entry:
+  %fn.value = load { ptr, ptr }, ptr %fn, align 4
+  %fn.context = extractvalue { ptr, ptr } %fn.value, 0
+  %fn.funcptr = extractvalue { ptr, ptr } %fn.value, 1
+  %result = call i32 %fn.funcptr(ptr %fn.context)
+  store i32 %result, ptr %results, align 4
+  ret void
+}
+
+define internal i1 @"interface:{Get:func:{}{basic:int}}.$typeassert"(ptr %actualType, ptr %context) {
+entry:
+  %"named:main.T.icmp" = icmp eq ptr %actualType, @"reflect/types.type:named:main.T"
+  ret i1 %"named:main.T.icmp"
+}
+
+define internal void @"internal/reflectlite.callFunc"(ptr %t, ptr %fn, ptr %args, ptr %results, ptr %context) {
+entry:
+  %call = getelementptr inbounds { i8, i16, ptr, { ptr, ptr } }, ptr %t, i32 0, i32 3
+  %call.value = load { ptr, ptr }, ptr %call, align 4
+  %call.context = extractvalue { ptr, ptr } %call.value, 0
+  %call.funcptr = extractvalue { ptr, ptr } %call.value, 1
+  call void %call.funcptr(ptr %fn, ptr %args, ptr %results, ptr %call.context)
+  ret void
+}
+
+define void @main.main(ptr %fn, ptr %results, ptr %context) {
+entry:
+  call void @"internal/reflectlite.callFunc"(ptr @"reflect/types.type:func:{}{basic:int}", ptr %fn, ptr null, ptr %results, ptr undef)
+  %typecode = load i8, ptr @"reflect/types.type:named:main.T", align 4
+  ret void
+}
@@ -35,7 +35,7 @@ entry:
 }
 
 declare i1 @"reflect.Type.Implements$invoke"(ptr, ptr, ptr, ptr, ptr) #0
-declare i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr %0) #1
+declare i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr %0, ptr %1) #1
 
 attributes #0 = { "tinygo-invoke"="reflect/methods.Implements(reflect.Type) bool" "tinygo-methods"="reflect/methods.Align() int; reflect/methods.Implements(reflect.Type) bool" }
 attributes #1 = { "tinygo-methods"="reflect/methods.Error() string" }
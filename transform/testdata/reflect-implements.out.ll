@@ -10,7 +10,7 @@ target triple = "i686--linux"
 
 define i1 @main.isError(ptr %typ.typecode, ptr %typ.value, ptr %context) {
 entry:
-  %0 = call i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr %typ.value)
+  %0 = call i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr %typ.value, ptr undef)
   ret i1 %0
 }
 
@@ -22,7 +22,7 @@ entry:
 
 declare i1 @"reflect.Type.Implements$invoke"(ptr, ptr, ptr, ptr, ptr) #0
 
-declare i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr) #1
+declare i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(ptr, ptr) #1
 
 attributes #0 = { "tinygo-invoke"="reflect/methods.Implements(reflect.Type) bool" "tinygo-methods"="reflect/methods.Align() int; reflect/methods.Implements(reflect.Type) bool" }
 attributes #1 = { "tinygo-methods"="reflect/methods.Error() string" }
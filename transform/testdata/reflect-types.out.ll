@@ -0,0 +1,16 @@
+target datalayout = "e-m:e-p:32:32-p270:32:32-p271:32:32-p272:64:64-f64:32:64-f80:32-n8:16:32-S128"
+target triple = "i686--linux"
+
+@"reflect/types.type:basic:int" = linkonce_odr constant { i8, ptr } { i8 -62, ptr @"reflect/types.type:pointer:basic:int" }, align 4
+@"reflect/types.type:pointer:basic:int" = linkonce_odr constant { i8, i16, ptr } { i8 -43, i16 0, ptr @"reflect/types.type:basic:int" }, align 4
+@"reflect/types.type:slice:basic:int" = linkonce_odr constant { i8, i16, ptr, ptr } { i8 22, i16 0, ptr null, ptr @"reflect/types.type:basic:int" }, align 4
+@"reflect/types.type:named:main.T" = linkonce_odr constant { i8, i16, ptr, ptr, ptr, [7 x i8] } { i8 -30, i16 0, ptr null, ptr @"reflect/types.type:basic:int", ptr null, [7 x i8] c"main.T\00" }, align 4
+@"reflect/types.type:struct:{X:basic:int}" = linkonce_odr constant { i8, i16, ptr, ptr, i32, i16, [1 x { ptr, ptr }] } { i8 -38, i16 0, ptr null, ptr null, i32 4, i16 1, [1 x { ptr, ptr }] [{ ptr, ptr } { ptr @"reflect/types.type:basic:int", ptr @"reflect/types.type:struct:{X:basic:int}.X" }] }, align 4
+@"reflect/types.type:struct:{X:basic:int}.X" = internal unnamed_addr constant [4 x i8] c"\04\00X\00", align 1
+@"reflect/types.table" = internal constant { i32, [2 x ptr] } { i32 2, [2 x ptr] [ptr @"reflect/types.type:slice:basic:int", ptr @"reflect/types.type:struct:{X:basic:int}"] }, align 4
+
+define i32 @main.numTypes() {
+entry:
+  %numTypes = load i32, ptr @"reflect/types.table", align 4
+  ret i32 %numTypes
+}